@@ -2,8 +2,12 @@ package app
 
 import (
 	"go-monolite/internal/config"
+	"go-monolite/internal/infra/sender"
 	"go-monolite/internal/server"
 	"go-monolite/internal/store"
+	"go-monolite/module/archive"
+	"go-monolite/module/audit"
+	"go-monolite/module/notification"
 	"go-monolite/pkg/logger"
 	"time"
 )
@@ -20,9 +24,21 @@ func InitApp(config *config.Config, currentDir string) {
 	s := server.NewServer(config, postgresStore)
 	httpServer := s.StartServer()
 
+	dispatcher := notification.NewDispatcher(notification.NewRepository(postgresStore), sender.MustNew(config.Sender), config.Notification)
+	dispatcher.Start()
+
+	purger := archive.NewPurger(postgresStore, config.Archive)
+	purger.Start()
+
+	auditPurger := audit.NewPurger(postgresStore, config.Audit)
+	auditPurger.Start()
+
 	GracefulShutdown(
 		10*time.Second,
 		httpServer,
+		dispatcher,
+		purger,
+		auditPurger,
 		postgresStore,
 	)
 }
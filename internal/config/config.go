@@ -11,10 +11,32 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// Допустимая длина одноразового кода: короче — легко подобрать, длиннее — неудобно вводить
+const (
+	minCodeLength = 4
+	maxCodeLength = 10
+)
+
+// Окружения, в которых допустимы провайдеры отправки без реальной доставки (log, memory)
+const (
+	envLocal = "local"
+	envTest  = "test"
+)
+
 type Config struct {
-	Env        string `yaml:"env" env-default:"local"`
-	HTTPServer `yaml:"http_server"`
-	Db         `yaml:"db"`
+	Env          string `yaml:"env" env-default:"local"`
+	HTTPServer   `yaml:"http_server"`
+	Db           `yaml:"db"`
+	Auth         `yaml:"auth"`
+	RateLimit    `yaml:"rate_limit"`
+	City         `yaml:"city"`
+	Sender       `yaml:"sender"`
+	Notification `yaml:"notification"`
+	Cart         `yaml:"cart"`
+	Order        `yaml:"order"`
+	Favorite     `yaml:"favorite"`
+	Archive      `yaml:"archive"`
+	Audit        `yaml:"audit"`
 }
 
 type HTTPServer struct {
@@ -35,6 +57,100 @@ type Db struct {
 	Password     string `yaml:"password"`
 }
 
+type Auth struct {
+	JWTSecret       string `yaml:"jwt_secret"`
+	AccessTokenTTL  int    `yaml:"access_token_ttl" env-default:"900"`      // в секундах
+	RefreshTokenTTL int    `yaml:"refresh_token_ttl" env-default:"2592000"` // в секундах
+	CodeMaxAttempts int    `yaml:"code_max_attempts" env-default:"5"`
+	CodeLength      int    `yaml:"code_length" env-default:"6"`
+	CodeTTL         int    `yaml:"code_ttl" env-default:"300"` // в секундах
+	CodeSecret      string `yaml:"code_secret"`                // ключ HMAC для хранения кодов, по умолчанию JWTSecret
+
+	LoginMaxAttempts      int  `yaml:"login_max_attempts" env-default:"5"`         // неудачных попыток на логин за окно
+	LoginMaxAttemptsPerIP int  `yaml:"login_max_attempts_per_ip" env-default:"20"` // неудачных попыток с одного IP за окно
+	LoginLockWindow       int  `yaml:"login_lock_window" env-default:"900"`        // в секундах
+	RequireVerification   bool `yaml:"require_verification"`                       // вход по паролю только после подтверждения email/телефона
+
+	PasswordResetURL string `yaml:"password_reset_url"`                    // страница восстановления пароля, к ней добавляется контрольное слово
+	PasswordResetTTL int    `yaml:"password_reset_ttl" env-default:"3600"` // в секундах
+}
+
+// RateLimit — ограничения частоты запросов, окна в секундах
+type RateLimit struct {
+	Backend string `yaml:"backend" env-default:"memory"` // memory — в памяти процесса, postgres — общий для всех экземпляров
+
+	AuthRequests int `yaml:"auth_requests" env-default:"30"` // запросов к /api/auth с одного IP за окно
+	AuthWindow   int `yaml:"auth_window" env-default:"60"`
+
+	CodeRequests int `yaml:"code_requests" env-default:"10"` // отправок кодов и писем восстановления с одного IP за окно
+	CodeWindow   int `yaml:"code_window" env-default:"3600"`
+
+	APIRequests int `yaml:"api_requests" env-default:"600"` // запросов к /api от одного пользователя, ключа или IP за окно
+	APIWindow   int `yaml:"api_window" env-default:"60"`
+}
+
+type City struct {
+	DefaultSlug string `yaml:"default_slug" env-default:"moskva"` // город, если пользователь его не выбрал и координаты неизвестны
+}
+
+// Sender — доставка писем и SMS
+type Sender struct {
+	EmailProvider string `yaml:"email_provider" env-default:"log"` // smtp, log или memory
+	SmsProvider   string `yaml:"sms_provider" env-default:"log"`   // http, log или memory
+	LogFile       string `yaml:"log_file"`                         // для провайдера log: файл, куда дописываются сообщения
+	Timeout       int    `yaml:"timeout" env-default:"10"`         // в секундах
+
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     string `yaml:"smtp_port" env-default:"587"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+	SMTPFrom     string `yaml:"smtp_from"`
+	SMTPTLS      string `yaml:"smtp_tls" env-default:"starttls"` // none, starttls или tls
+
+	SmsURL   string `yaml:"sms_url"`
+	SmsToken string `yaml:"sms_token"`
+	SmsFrom  string `yaml:"sms_from"`
+}
+
+// Notification — доставка уведомлений из outbox, интервалы в секундах
+type Notification struct {
+	PollInterval int `yaml:"poll_interval" env-default:"2"` // как часто диспетчер проверяет очередь
+	BatchSize    int `yaml:"batch_size" env-default:"20"`   // сообщений за один проход
+	MaxAttempts  int `yaml:"max_attempts" env-default:"8"`  // после стольких неудач сообщение уходит в dead
+	RetryBase    int `yaml:"retry_base" env-default:"30"`   // пауза после первой неудачи, дальше удваивается
+	RetryMax     int `yaml:"retry_max" env-default:"3600"`  // предел паузы между попытками
+	Lease        int `yaml:"lease" env-default:"60"`        // на сколько сообщение закрепляется за экземпляром
+}
+
+// Cart — корзина, типы цен задаются UUID из справочника type_price
+type Cart struct {
+	DefaultPriceType string `yaml:"default_price_type"`          // для гостей и физических лиц, пустой — первый активный тип цен
+	LegalPriceType   string `yaml:"legal_price_type"`            // для юридических лиц, пустой — как для физических
+	MaxItems         int    `yaml:"max_items" env-default:"100"` // позиций в одной корзине
+}
+
+type Order struct {
+	URL string `yaml:"url"` // страница заказа для ссылок в уведомлениях, к ней добавляется номер заказа
+}
+
+// Favorite — избранное и сравнение товаров
+type Favorite struct {
+	MaxItems   int `yaml:"max_items" env-default:"500"`  // товаров в избранном
+	MaxCompare int `yaml:"max_compare" env-default:"10"` // товаров в сравнении
+}
+
+// Archive — очистка архива каталога: товаров, категорий, свойств, складов и типов цен
+type Archive struct {
+	RetentionDays int `yaml:"retention_days" env-default:"90"`   // сколько дней хранится удалённое, 0 — хранить всегда
+	PurgeInterval int `yaml:"purge_interval" env-default:"3600"` // как часто запускается очистка, в секундах
+}
+
+// Audit — срок хранения журнала изменений каталога
+type Audit struct {
+	RetentionDays int `yaml:"retention_days" env-default:"365"`  // сколько дней хранятся записи, 0 — хранить всегда
+	PurgeInterval int `yaml:"purge_interval" env-default:"3600"` // как часто запускается очистка, в секундах
+}
+
 func MustInit(configPath string) *Config {
 	if configPath == "" {
 		log.Fatal("CONFIG_PATH is not set")
@@ -45,7 +161,7 @@ func MustInit(configPath string) *Config {
 		log.Fatal("Error loading .env file")
 	}
 
-	return &Config{
+	cfg := &Config{
 		Env: MustGetEnv("ENV"),
 		HTTPServer: HTTPServer{
 			Address:     MustGetEnv("HTTP_SERVER_HOST") + ":" + MustGetEnv("HTTP_SERVER_PORT"),
@@ -63,7 +179,113 @@ func MustInit(configPath string) *Config {
 			User:         MustGetEnv("DB_USER"),
 			Password:     MustGetEnv("DB_PASSWORD"),
 		},
+		Auth: Auth{
+			JWTSecret:       MustGetEnv("AUTH_JWT_SECRET"),
+			AccessTokenTTL:  GetEnvAsInt("AUTH_ACCESS_TOKEN_TTL", 900),
+			RefreshTokenTTL: GetEnvAsInt("AUTH_REFRESH_TOKEN_TTL", 2592000),
+			CodeMaxAttempts: GetEnvAsInt("AUTH_CODE_MAX_ATTEMPTS", 5),
+			CodeLength:      GetEnvAsInt("AUTH_CODE_LENGTH", 6),
+			CodeTTL:         GetEnvAsInt("AUTH_CODE_TTL", 300),
+			CodeSecret:      GetEnv("AUTH_CODE_SECRET", MustGetEnv("AUTH_JWT_SECRET")),
+
+			LoginMaxAttempts:      GetEnvAsInt("AUTH_LOGIN_MAX_ATTEMPTS", 5),
+			LoginMaxAttemptsPerIP: GetEnvAsInt("AUTH_LOGIN_MAX_ATTEMPTS_PER_IP", 20),
+			LoginLockWindow:       GetEnvAsInt("AUTH_LOGIN_LOCK_WINDOW", 900),
+			RequireVerification:   GetEnvAsBool("AUTH_REQUIRE_VERIFICATION", false),
+
+			PasswordResetURL: GetEnv("AUTH_PASSWORD_RESET_URL", "http://localhost:8080/reset-password"),
+			PasswordResetTTL: GetEnvAsInt("AUTH_PASSWORD_RESET_TTL", 3600),
+		},
+		RateLimit: RateLimit{
+			Backend: GetEnv("RATE_LIMIT_BACKEND", "memory"),
+
+			AuthRequests: GetEnvAsInt("RATE_LIMIT_AUTH_REQUESTS", 30),
+			AuthWindow:   GetEnvAsInt("RATE_LIMIT_AUTH_WINDOW", 60),
+
+			CodeRequests: GetEnvAsInt("RATE_LIMIT_CODE_REQUESTS", 10),
+			CodeWindow:   GetEnvAsInt("RATE_LIMIT_CODE_WINDOW", 3600),
+
+			APIRequests: GetEnvAsInt("RATE_LIMIT_API_REQUESTS", 600),
+			APIWindow:   GetEnvAsInt("RATE_LIMIT_API_WINDOW", 60),
+		},
+		City: City{
+			DefaultSlug: GetEnv("CITY_DEFAULT_SLUG", "moskva"),
+		},
+		Sender: Sender{
+			EmailProvider: GetEnv("SENDER_EMAIL_PROVIDER", "log"),
+			SmsProvider:   GetEnv("SENDER_SMS_PROVIDER", "log"),
+			LogFile:       GetEnv("SENDER_LOG_FILE", ""),
+			Timeout:       GetEnvAsInt("SENDER_TIMEOUT", 10),
+
+			SMTPHost:     GetEnv("SMTP_HOST", ""),
+			SMTPPort:     GetEnv("SMTP_PORT", "587"),
+			SMTPUsername: GetEnv("SMTP_USERNAME", ""),
+			SMTPPassword: GetEnv("SMTP_PASSWORD", ""),
+			SMTPFrom:     GetEnv("SMTP_FROM", ""),
+			SMTPTLS:      GetEnv("SMTP_TLS", "starttls"),
+
+			SmsURL:   GetEnv("SMS_GATEWAY_URL", ""),
+			SmsToken: GetEnv("SMS_GATEWAY_TOKEN", ""),
+			SmsFrom:  GetEnv("SMS_GATEWAY_FROM", ""),
+		},
+		Notification: Notification{
+			PollInterval: GetEnvAsInt("NOTIFICATION_POLL_INTERVAL", 2),
+			BatchSize:    GetEnvAsInt("NOTIFICATION_BATCH_SIZE", 20),
+			MaxAttempts:  GetEnvAsInt("NOTIFICATION_MAX_ATTEMPTS", 8),
+			RetryBase:    GetEnvAsInt("NOTIFICATION_RETRY_BASE", 30),
+			RetryMax:     GetEnvAsInt("NOTIFICATION_RETRY_MAX", 3600),
+			Lease:        GetEnvAsInt("NOTIFICATION_LEASE", 60),
+		},
+		Cart: Cart{
+			DefaultPriceType: GetEnv("CART_DEFAULT_PRICE_TYPE", ""),
+			LegalPriceType:   GetEnv("CART_LEGAL_PRICE_TYPE", ""),
+			MaxItems:         GetEnvAsInt("CART_MAX_ITEMS", 100),
+		},
+		Order: Order{
+			URL: GetEnv("ORDER_URL", "http://localhost:8080/order"),
+		},
+		Favorite: Favorite{
+			MaxItems:   GetEnvAsInt("FAVORITE_MAX_ITEMS", 500),
+			MaxCompare: GetEnvAsInt("FAVORITE_MAX_COMPARE", 10),
+		},
+		Archive: Archive{
+			RetentionDays: GetEnvAsInt("ARCHIVE_RETENTION_DAYS", 90),
+			PurgeInterval: GetEnvAsInt("ARCHIVE_PURGE_INTERVAL", 3600),
+		},
+		Audit: Audit{
+			RetentionDays: GetEnvAsInt("AUDIT_RETENTION_DAYS", 365),
+			PurgeInterval: GetEnvAsInt("AUDIT_PURGE_INTERVAL", 3600),
+		},
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
 	}
+
+	return cfg
+}
+
+// Validate проверяет настройки, с которыми сервис не может работать корректно
+func (c *Config) Validate() error {
+	if c.Auth.CodeLength < minCodeLength || c.Auth.CodeLength > maxCodeLength {
+		return fmt.Errorf("AUTH_CODE_LENGTH must be between %d and %d, got %d", minCodeLength, maxCodeLength, c.Auth.CodeLength)
+	}
+
+	if c.Env != envLocal && c.Env != envTest {
+		if !deliveringProvider(c.Sender.EmailProvider) {
+			return fmt.Errorf("SENDER_EMAIL_PROVIDER must be a delivering provider in %q env, got %q", c.Env, c.Sender.EmailProvider)
+		}
+		if !deliveringProvider(c.Sender.SmsProvider) {
+			return fmt.Errorf("SENDER_SMS_PROVIDER must be a delivering provider in %q env, got %q", c.Env, c.Sender.SmsProvider)
+		}
+	}
+
+	return nil
+}
+
+// deliveringProvider — провайдер действительно доставляет сообщения, а не пишет их в лог или память
+func deliveringProvider(provider string) bool {
+	return provider != "" && provider != "log" && provider != "memory"
 }
 
 func Path(workDir string, envFile string) string {
@@ -90,6 +312,35 @@ func MustGetEnvAsInt(name string) int {
 	return -1
 }
 
+func GetEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func GetEnvAsInt(name string, defaultValue int) int {
+	valueStr := os.Getenv(name)
+	if valueStr == "" {
+		return defaultValue
+	}
+	if value, err := strconv.Atoi(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func GetEnvAsBool(name string, defaultValue bool) bool {
+	valueStr := os.Getenv(name)
+	if valueStr == "" {
+		return defaultValue
+	}
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 func GetConfigPathFromTest(envFile string) string {
 	projectRoot, err := findProjectRoot()
 	if err != nil {
@@ -1,12 +1,232 @@
 package sender
 
-type EmailClient struct {
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+const (
+	SMTPTLSNone     = "none"     // без шифрования, только для локальных серверов
+	SMTPTLSStartTLS = "starttls" // обычное соединение с переходом на TLS
+	SMTPTLSImplicit = "tls"      // TLS с момента подключения, обычно порт 465
+)
+
+const defaultSMTPTimeout = 10 * time.Second
+
+var ErrInvalidRecipient = errors.New("invalid recipient")
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	TLS      string
+	Timeout  time.Duration
+
+	TLSConfig *tls.Config // при nil проверяется сертификат для Host
+}
+
+// SMTPProvider отправляет письма через SMTP-сервер, на каждое письмо открывается отдельное соединение
+type SMTPProvider struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPProvider(cfg SMTPConfig) *SMTPProvider {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSMTPTimeout
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = SMTPTLSStartTLS
+	}
+	return &SMTPProvider{cfg: cfg}
 }
 
-func NewEmailClient() *EmailClient {
-	return &EmailClient{}
+func (p *SMTPProvider) SendEmail(ctx context.Context, to, subject, text, html string) error {
+	from, err := mail.ParseAddress(p.cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRecipient, err)
+	}
+
+	msg, err := buildMessage(from, rcpt, subject, text, html, IdempotencyKey(ctx))
+	if err != nil {
+		return err
+	}
+
+	client, err := p.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if p.cfg.Username != "" {
+		auth := smtp.PlainAuth("", p.cfg.Username, p.cfg.Password, p.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	if err := client.Rcpt(rcpt.Address); err != nil {
+		return fmt.Errorf("smtp rcpt to: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("smtp write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// dial подключается к серверу и при необходимости включает TLS
+func (p *SMTPProvider) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(p.cfg.Host, p.cfg.Port)
+	dialer := &net.Dialer{Timeout: p.cfg.Timeout}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	switch p.cfg.TLS {
+	case SMTPTLSImplicit:
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: p.tlsConfig()}).DialContext(ctx, "tcp", addr)
+	case SMTPTLSStartTLS, SMTPTLSNone:
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	default:
+		return nil, fmt.Errorf("unknown smtp tls mode: %q", p.cfg.TLS)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("smtp dial %s: %w", addr, err)
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(p.cfg.Timeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, p.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp handshake: %w", err)
+	}
+
+	if p.cfg.TLS == SMTPTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(p.tlsConfig()); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+func (p *SMTPProvider) tlsConfig() *tls.Config {
+	if p.cfg.TLSConfig != nil {
+		return p.cfg.TLSConfig
+	}
+	return &tls.Config{ServerName: p.cfg.Host, MinVersion: tls.VersionTLS12}
+}
+
+// buildMessage собирает письмо в UTF-8: text/plain или, если есть HTML, multipart/alternative с обеими версиями.
+// Message-ID строится из ключа идемпотентности, чтобы повторная отправка была тем же письмом
+func buildMessage(from, to *mail.Address, subject, text, html, key string) ([]byte, error) {
+	if strings.ContainsAny(subject, "\r\n") {
+		return nil, errors.New("subject must not contain line breaks")
+	}
+
+	var id []byte
+	if key != "" {
+		sum := sha256.Sum256([]byte(key))
+		id = sum[:16]
+	} else {
+		id = make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return nil, err
+		}
+	}
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if html == "" {
+		buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+		buf.WriteString("\r\n")
+		writeBase64(&buf, text)
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", html},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(w, part.body)
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
 }
 
-func (s *EmailClient) Send(email, mess string) error {
-	return nil
+// writeBase64 пишет тело в base64 строками по 76 символов
+func writeBase64(w io.Writer, body string) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		io.WriteString(w, encoded[:76]+"\r\n")
+		encoded = encoded[76:]
+	}
+	io.WriteString(w, encoded+"\r\n")
 }
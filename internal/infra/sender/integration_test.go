@@ -0,0 +1,260 @@
+package sender_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"go-monolite/internal/config"
+	"go-monolite/internal/infra/sender"
+	"go-monolite/pkg/logger"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStub — минимальный SMTP-сервер: принимает одно письмо за соединение и запоминает его
+type smtpStub struct {
+	listener net.Listener
+	mu       sync.Mutex
+	auth     string
+	from     string
+	to       []string
+	data     string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpStub{listener: l}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStub) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost ESMTP stub")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		s.mu.Lock()
+		switch cmd {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = line
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			s.from = line
+			reply("250 OK")
+		case "RCPT":
+			s.to = append(s.to, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					s.mu.Unlock()
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data = data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			s.mu.Unlock()
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestSenderIntegration(t *testing.T) {
+	logger.InitLogger("test", "")
+	ctx := context.Background()
+
+	t.Run("SMTP", func(t *testing.T) {
+		stub := newSMTPStub(t)
+		provider := sender.NewSMTPProvider(sender.SMTPConfig{
+			Host:     "127.0.0.1",
+			Port:     stub.port(),
+			Username: "user",
+			Password: "secret",
+			From:     "Shop <noreply@example.com>",
+			TLS:      sender.SMTPTLSNone,
+		})
+
+		err := provider.SendEmail(ctx, "client@example.com", "Код подтверждения", "Ваш код подтверждения: 123456", "")
+		require.NoError(t, err)
+
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+
+		credentials := base64.StdEncoding.EncodeToString([]byte("\x00user\x00secret"))
+		assert.Equal(t, "AUTH PLAIN "+credentials, stub.auth)
+		assert.Equal(t, "MAIL FROM:<noreply@example.com>", stub.from)
+		assert.Equal(t, []string{"RCPT TO:<client@example.com>"}, stub.to)
+
+		header, body, found := strings.Cut(stub.data, "\r\n\r\n")
+		require.True(t, found)
+		assert.Contains(t, header, "Content-Type: text/plain; charset=UTF-8")
+		assert.Contains(t, header, "Subject: "+mime.QEncoding.Encode("utf-8", "Код подтверждения"))
+
+		decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+		require.NoError(t, err)
+		assert.Equal(t, "Ваш код подтверждения: 123456", string(decoded))
+	})
+
+	t.Run("SMTP HTML", func(t *testing.T) {
+		stub := newSMTPStub(t)
+		provider := sender.NewSMTPProvider(sender.SMTPConfig{Host: "127.0.0.1", Port: stub.port(), From: "noreply@example.com", TLS: sender.SMTPTLSNone})
+
+		err := provider.SendEmail(ctx, "client@example.com", "Заказ", "Заказ принят", "<p>Заказ принят</p>")
+		require.NoError(t, err)
+
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+
+		msg, err := mail.ReadMessage(strings.NewReader(stub.data))
+		require.NoError(t, err)
+		mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, "multipart/alternative", mediaType)
+
+		var bodies []string
+		parts := multipart.NewReader(msg.Body, params["boundary"])
+		for {
+			part, err := parts.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+			require.NoError(t, err)
+			bodies = append(bodies, part.Header.Get("Content-Type")+" "+string(data))
+		}
+		assert.Equal(t, []string{
+			"text/plain; charset=UTF-8 Заказ принят",
+			"text/html; charset=UTF-8 <p>Заказ принят</p>",
+		}, bodies)
+	})
+
+	t.Run("SMTP Invalid Recipient", func(t *testing.T) {
+		provider := sender.NewSMTPProvider(sender.SMTPConfig{Host: "127.0.0.1", Port: "1", From: "noreply@example.com", TLS: sender.SMTPTLSNone})
+		err := provider.SendEmail(ctx, "client@example.com\r\nBcc: x@example.com", "Тема", "Текст", "")
+		assert.ErrorIs(t, err, sender.ErrInvalidRecipient)
+	})
+
+	t.Run("HTTP SMS", func(t *testing.T) {
+		var got map[string]string
+		var authorization, idempotencyKey string
+		gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			idempotencyKey = r.Header.Get("Idempotency-Key")
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer gateway.Close()
+
+		provider := sender.NewHTTPSmsProvider(sender.HTTPSmsConfig{URL: gateway.URL + "/send", Token: "token", From: "Shop"})
+		require.NoError(t, provider.SendSms(sender.WithIdempotencyKey(ctx, "otp:1"), "79990000001", "Код: 123456"))
+
+		assert.Equal(t, "Bearer token", authorization)
+		assert.Equal(t, "otp:1", idempotencyKey)
+		assert.Equal(t, map[string]string{"to": "79990000001", "text": "Код: 123456", "from": "Shop"}, got)
+	})
+
+	t.Run("HTTP SMS Gateway Error", func(t *testing.T) {
+		gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "balance is empty", http.StatusPaymentRequired)
+		}))
+		defer gateway.Close()
+
+		provider := sender.NewHTTPSmsProvider(sender.HTTPSmsConfig{URL: gateway.URL})
+		err := provider.SendSms(ctx, "79990000001", "Код: 123456")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "402")
+	})
+
+	t.Run("Log File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "messages.log")
+		provider := sender.NewLogProvider(path)
+		require.NoError(t, provider.SendEmail(ctx, "client@example.com", "Тема", "Текст", "<p>Текст</p>"))
+		require.NoError(t, provider.SendSms(ctx, "79990000001", "Код: 123456"))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		require.Len(t, lines, 2)
+
+		var msg sender.Message
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &msg))
+		assert.Equal(t, sender.ChannelSms, msg.Channel)
+		assert.Equal(t, "79990000001", msg.To)
+	})
+
+	t.Run("Memory From Config", func(t *testing.T) {
+		s, err := sender.NewFromConfig(config.Sender{EmailProvider: sender.ProviderMemory, SmsProvider: sender.ProviderMemory})
+		require.NoError(t, err)
+		sender.Memory.Reset()
+
+		require.NoError(t, s.Send(ctx, sender.Message{Channel: sender.ChannelEmail, To: "client@example.com", Subject: "Восстановление пароля", Body: "https://example.com/reset"}))
+		require.NoError(t, s.Send(ctx, sender.Message{Channel: sender.ChannelSms, To: "79990000001", Body: "Код: 123456"}))
+
+		msg, ok := sender.Memory.Last("client@example.com")
+		require.True(t, ok)
+		assert.Equal(t, sender.ChannelEmail, msg.Channel)
+		assert.Contains(t, msg.Body, "https://example.com/reset")
+		assert.Len(t, sender.Memory.Messages(), 2)
+	})
+
+	t.Run("Unknown Provider", func(t *testing.T) {
+		_, err := sender.NewFromConfig(config.Sender{EmailProvider: "pigeon", SmsProvider: sender.ProviderLog})
+		assert.Error(t, err)
+	})
+}
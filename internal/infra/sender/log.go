@@ -0,0 +1,56 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"go-monolite/pkg/logger"
+)
+
+// LogProvider ничего не отправляет: пишет сообщения в лог и, если задан файл, дописывает их туда строками JSON.
+// Подходит только для локальной разработки и тестов. Текст сообщения в лог не попадает: в нём бывают коды и ссылки
+// сброса пароля, его можно прочитать только из файла.
+type LogProvider struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogProvider(path string) *LogProvider {
+	return &LogProvider{path: path}
+}
+
+func (p *LogProvider) SendEmail(ctx context.Context, to, subject, text, html string) error {
+	return p.write(ctx, Message{Channel: ChannelEmail, To: to, Subject: subject, Body: text, HTML: html, SentAt: time.Now()})
+}
+
+func (p *LogProvider) SendSms(ctx context.Context, phone, text string) error {
+	return p.write(ctx, Message{Channel: ChannelSms, To: phone, Body: text, SentAt: time.Now()})
+}
+
+func (p *LogProvider) write(ctx context.Context, m Message) error {
+	logger.InfoCtx(ctx, "message sent", "channel", m.Channel, "to", m.To, "subject", m.Subject)
+
+	if p.path == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, err := os.OpenFile(p.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,72 @@
+package sender
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	ChannelEmail = "email"
+	ChannelSms   = "sms"
+)
+
+type Message struct {
+	Channel string    `json:"channel"`
+	To      string    `json:"to"`
+	Subject string    `json:"subject,omitempty"`
+	Body    string    `json:"body"`
+	HTML    string    `json:"html,omitempty"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// MemoryProvider сохраняет сообщения в памяти вместо отправки, для тестов
+type MemoryProvider struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{}
+}
+
+func (p *MemoryProvider) SendEmail(_ context.Context, to, subject, text, html string) error {
+	p.add(Message{Channel: ChannelEmail, To: to, Subject: subject, Body: text, HTML: html, SentAt: time.Now()})
+	return nil
+}
+
+func (p *MemoryProvider) SendSms(_ context.Context, phone, text string) error {
+	p.add(Message{Channel: ChannelSms, To: phone, Body: text, SentAt: time.Now()})
+	return nil
+}
+
+func (p *MemoryProvider) add(m Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, m)
+}
+
+// Messages возвращает копию всех сохранённых сообщений в порядке отправки
+func (p *MemoryProvider) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message(nil), p.messages...)
+}
+
+// Last возвращает последнее сообщение для получателя
+func (p *MemoryProvider) Last(to string) (Message, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := len(p.messages) - 1; i >= 0; i-- {
+		if p.messages[i].To == to {
+			return p.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+func (p *MemoryProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = nil
+}
@@ -1,21 +1,119 @@
 package sender
 
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-monolite/internal/config"
+	"go-monolite/pkg/logger"
+)
+
+const (
+	ProviderSMTP   = "smtp"
+	ProviderHTTP   = "http"
+	ProviderLog    = "log"
+	ProviderMemory = "memory"
+)
+
+// EmailProvider доставляет письма, html может быть пустым — тогда письмо только текстовое
+type EmailProvider interface {
+	SendEmail(ctx context.Context, to, subject, text, html string) error
+}
+
+// SmsProvider доставляет SMS
+type SmsProvider interface {
+	SendSms(ctx context.Context, phone, text string) error
+}
+
+// Memory — общий провайдер для режима memory, тесты читают из него отправленные сообщения
+var Memory = NewMemoryProvider()
+
 type Sender struct {
-	emailClient *EmailClient
-	smsClient   *SmsClient
+	email EmailProvider
+	sms   SmsProvider
 }
 
-func New() *Sender {
+func New(email EmailProvider, sms SmsProvider) *Sender {
 	return &Sender{
-		emailClient: NewEmailClient(),
-		smsClient:   NewSmsClient(),
+		email: email,
+		sms:   sms,
+	}
+}
+
+// NewFromConfig собирает отправителя из провайдеров, указанных в конфигурации
+func NewFromConfig(cfg config.Sender) (*Sender, error) {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+
+	var email EmailProvider
+	switch cfg.EmailProvider {
+	case ProviderSMTP:
+		email = NewSMTPProvider(SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			TLS:      cfg.SMTPTLS,
+			Timeout:  timeout,
+		})
+	case ProviderLog:
+		email = NewLogProvider(cfg.LogFile)
+	case ProviderMemory:
+		email = Memory
+	default:
+		return nil, fmt.Errorf("unknown email provider: %q", cfg.EmailProvider)
+	}
+
+	var sms SmsProvider
+	switch cfg.SmsProvider {
+	case ProviderHTTP:
+		sms = NewHTTPSmsProvider(HTTPSmsConfig{
+			URL:     cfg.SmsURL,
+			Token:   cfg.SmsToken,
+			From:    cfg.SmsFrom,
+			Timeout: timeout,
+		})
+	case ProviderLog:
+		sms = NewLogProvider(cfg.LogFile)
+	case ProviderMemory:
+		sms = Memory
+	default:
+		return nil, fmt.Errorf("unknown sms provider: %q", cfg.SmsProvider)
 	}
+
+	return New(email, sms), nil
 }
 
-func (s *Sender) SendEmailCode(email, code string) error {
-	return s.emailClient.Send(email, "Ваш код подтверждения: "+code)
+// MustNew как NewFromConfig, но завершает процесс при ошибке конфигурации
+func MustNew(cfg config.Sender) *Sender {
+	s, err := NewFromConfig(cfg)
+	if err != nil {
+		logger.Fatal(err, "failed to init sender")
+	}
+	return s
+}
+
+// Send доставляет сообщение через провайдер канала, для SMS тема и HTML не используются
+func (s *Sender) Send(ctx context.Context, m Message) error {
+	switch m.Channel {
+	case ChannelEmail:
+		return s.email.SendEmail(ctx, m.To, m.Subject, m.Body, m.HTML)
+	case ChannelSms:
+		return s.sms.SendSms(ctx, m.To, m.Body)
+	default:
+		return fmt.Errorf("unknown channel: %q", m.Channel)
+	}
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey передаёт провайдеру ключ сообщения, чтобы повторная отправка не дублировалась на его стороне
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
 }
 
-func (s *Sender) SendSmsCode(phone, code string) error {
-	return s.smsClient.Send(phone, "Код: "+code)
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
 }
@@ -1,11 +1,73 @@
 package sender
 
-type SmsClient struct{}
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
 
-func NewSmsClient() *SmsClient {
-	return &SmsClient{}
+	"go-monolite/pkg/httpclient"
+)
+
+const defaultSmsTimeout = 10 * time.Second
+
+type HTTPSmsConfig struct {
+	URL     string // адрес метода отправки шлюза
+	Token   string // передаётся в заголовке Authorization: Bearer
+	From    string // имя отправителя, если шлюз его поддерживает
+	Timeout time.Duration
+}
+
+// HTTPSmsProvider отправляет SMS через HTTP-шлюз: POST JSON {"to", "text", "from"}, успех — любой 2xx.
+// Ключ идемпотентности из контекста передаётся в заголовке Idempotency-Key
+type HTTPSmsProvider struct {
+	client *httpclient.HttpClient
+	cfg    HTTPSmsConfig
+}
+
+type smsRequest struct {
+	To   string `json:"to"`
+	Text string `json:"text"`
+	From string `json:"from,omitempty"`
 }
 
-func (s *SmsClient) Send(phone string, mess string) error {
+func NewHTTPSmsProvider(cfg HTTPSmsConfig) *HTTPSmsProvider {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSmsTimeout
+	}
+	return &HTTPSmsProvider{
+		client: httpclient.NewHttpClient(cfg.URL, cfg.Timeout),
+		cfg:    cfg,
+	}
+}
+
+func (p *HTTPSmsProvider) SendSms(ctx context.Context, phone, text string) error {
+	body, err := json.Marshal(smsRequest{To: phone, Text: text, From: p.cfg.From})
+	if err != nil {
+		return err
+	}
+
+	options := &httpclient.RequestOptions{Headers: map[string]string{"Accept": "application/json"}}
+	if p.cfg.Token != "" {
+		options.Headers["Authorization"] = "Bearer " + p.cfg.Token
+	}
+	if key := IdempotencyKey(ctx); key != "" {
+		options.Headers["Idempotency-Key"] = key
+	}
+
+	resp, err := p.client.Post(ctx, "", body, options)
+	if err != nil {
+		return fmt.Errorf("sms gateway: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms gateway: status %d: %s", resp.StatusCode, detail)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+
 	return nil
 }
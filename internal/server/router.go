@@ -1,18 +1,36 @@
 package server
 
 import (
+	"go-monolite/module/apikey"
+	"go-monolite/module/audit"
 	"go-monolite/module/auth"
+	"go-monolite/module/cart"
 	"go-monolite/module/category"
+	"go-monolite/module/city"
+	"go-monolite/module/company"
+	"go-monolite/module/customerfeed"
+	"go-monolite/module/favorite"
+	"go-monolite/module/notification"
+	"go-monolite/module/order"
 	"go-monolite/module/price"
 	"go-monolite/module/product"
+	"go-monolite/module/productlink"
+	"go-monolite/module/promotion"
 	"go-monolite/module/property"
+	"go-monolite/module/review"
 	"go-monolite/module/storage"
 	"go-monolite/module/user"
 	"go-monolite/pkg/logger"
+	middlewareAuth "go-monolite/pkg/middleware/auth"
 	"go-monolite/pkg/middleware/cors"
+	"go-monolite/pkg/middleware/locale"
 	middlewareLogger "go-monolite/pkg/middleware/logger"
+	"go-monolite/pkg/middleware/ratelimit"
 	"go-monolite/pkg/middleware/request_id"
 	"go-monolite/pkg/middleware/timemiddleware"
+	"go-monolite/pkg/token"
+	"net/http"
+	"time"
 
 	_ "go-monolite/docs"
 
@@ -25,20 +43,75 @@ func (s *Server) ConfigureRouting() {
 	s.router.Use(middlewareLogger.New(logger.GetZerologLogger()))
 	s.router.Use(timemiddleware.Handler)
 	s.router.Use(cors.Handler)
+	s.router.Use(locale.Handler)
 
 	// Swagger UI
 	s.router.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"),
 	))
 
+	// чтение каталога открыто, изменения — только с правом на запись у пользователя
+	// или нужным scope у ключа интеграции, статический токен администратора работает как суперпользователь
+	tokenManager := token.NewManager(s.config.Auth.JWTSecret, time.Duration(s.config.Auth.AccessTokenTTL)*time.Second)
+	contacts := auth.NewContactVerifier(s.store, s.config, tokenManager)
+	users := user.NewService(s.store, user.NewRepository(s.store), user.NewRolesRepository(s.store), nil, nil)
+	apiKeys := apikey.NewService(apikey.NewRepository(s.store))
+	authenticate := middlewareAuth.Authenticate(s.config.HTTPServer.BearerToken, tokenManager, users, apiKeys)
+	// личные разделы покупателя доступны только по токену пользователя, без статического токена и ключей интеграции
+	userAuth := middlewareAuth.UserAuth(tokenManager, users)
+
+	// анонимные запросы ограничиваются по IP, запись — по пользователю или ключу интеграции;
+	// коды считаются по IP отдельно для каждого маршрута, чтобы смена номера телефона не обходила дневной лимит
+	limits := s.config.RateLimit
+	limitStore := s.rateLimitStore()
+	apiWindow := ratelimit.Limit{Requests: limits.APIRequests, Window: time.Duration(limits.APIWindow) * time.Second}
+	ipLimit := ratelimit.New(limitStore, "api", apiWindow, ratelimit.ByIP)
+	apiLimit := ratelimit.New(limitStore, "api-identity", apiWindow, ratelimit.ByIdentity)
+	authLimit := ratelimit.New(limitStore, "auth", ratelimit.Limit{
+		Requests: limits.AuthRequests,
+		Window:   time.Duration(limits.AuthWindow) * time.Second,
+	}, ratelimit.ByIP)
+	codeLimit := ratelimit.New(limitStore, "code", ratelimit.Limit{
+		Requests: limits.CodeRequests,
+		Window:   time.Duration(limits.CodeWindow) * time.Second,
+	}, ratelimit.ForPaths(ratelimit.ByRoute(ratelimit.ByIP), "/sendCode", "/forgotPassword"))
+	write := func(permission string, apiKeyScopes ...string) func(http.Handler) http.Handler {
+		return middlewareAuth.WriteMethods(authenticate, apiLimit, middlewareAuth.RequirePermission(users, permission, apiKeyScopes...))
+	}
+
 	s.router.Route("/api", func(r chi.Router) {
-		r.Route("/product", product.NewHandler(s.store).Init)
-		r.Route("/category", category.NewHandler(s.store).Init)
-		r.Route("/property", property.NewHandler(s.store).Init)
-		r.Route("/storage", storage.NewHandler(s.store).Init)
-		r.Route("/price", price.NewHandler(s.store).Init)
-
-		r.Route("/auth", auth.NewHandler(s.store).Init)
-		r.Route("/user", user.NewHandler(s.store).Init)
+		r.Use(ipLimit)
+
+		r.With(write(user.PermissionCatalogWrite, apikey.ScopeCatalogWrite)).Route("/product", product.NewHandler(s.store).Init)
+		r.With(write(user.PermissionCatalogWrite, apikey.ScopeCatalogWrite, apikey.ScopeLinkUpsert)).Route("/product-link", productlink.NewHandler(s.store, s.config, userAuth).Init)
+		r.With(write(user.PermissionCatalogWrite, apikey.ScopeCatalogWrite)).Route("/category", category.NewHandler(s.store).Init)
+		r.With(write(user.PermissionExchangeWrite, apikey.ScopePropertyUpsert)).Route("/property", property.NewHandler(s.store).Init)
+		r.With(write(user.PermissionExchangeWrite, apikey.ScopeStorageUpsert)).Route("/storage", storage.NewHandler(s.store).Init)
+		r.With(write(user.PermissionExchangeWrite, apikey.ScopePriceUpsert)).Route("/price", price.NewHandler(s.store).Init)
+		r.With(write(user.PermissionCatalogWrite, apikey.ScopeCatalogWrite)).Route("/city", city.NewHandler(s.store, s.config).Init)
+
+		r.With(authLimit, codeLimit).Route("/auth", auth.NewHandler(s.store, s.config, tokenManager,
+			cart.NewLoginHook(s.store, s.config), favorite.NewLoginHook(s.store, s.config)).Init)
+		r.Route("/user", user.NewHandler(s.store, s.config, contacts, userAuth, authenticate).Init)
+		r.Route("/api-key", apikey.NewHandler(s.store, authenticate, users).Init)
+		r.Route("/company", company.NewHandler(s.store, authenticate, users).Init)
+		r.Route("/notification", notification.NewHandler(s.store, s.config, authenticate, users).Init)
+		r.Route("/cart", cart.NewHandler(s.store, s.config, userAuth).Init)
+		r.Route("/order", order.NewHandler(s.store, s.config, authenticate, users).Init)
+		r.Route("/user/me/orders", order.NewCustomerHandler(s.store, s.config, userAuth).Init)
+		r.Route("/exchange/customers", customerfeed.NewHandler(s.store, authenticate, users).Init)
+		r.Route("/promotion", promotion.NewHandler(s.store, s.config, userAuth, authenticate, users).Init)
+		r.Route("/favorite", favorite.NewHandler(s.store, s.config, userAuth).Init)
+		r.Route("/compare", favorite.NewCompareHandler(s.store, s.config, userAuth).Init)
+		r.Route("/review", review.NewHandler(s.store, authenticate, users).Init)
+		r.Route("/user/me/reviews", review.NewCustomerHandler(s.store, userAuth).Init)
+		r.Route("/audit", audit.NewHandler(s.store, authenticate, users).Init)
 	})
 }
+
+func (s *Server) rateLimitStore() ratelimit.Store {
+	if s.config.RateLimit.Backend == "postgres" {
+		return ratelimit.NewPostgresStore(s.store.Db)
+	}
+	return ratelimit.NewMemoryStore()
+}
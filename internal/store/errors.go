@@ -6,4 +6,5 @@ var (
 	ErrTimeoutExceeded   = errors.New("operation timed out")
 	ErrOperationCanceled = errors.New("operation was canceled")
 	ErrNotFound          = errors.New("not found")
+	ErrConflict          = errors.New("already exists")
 )
@@ -3,13 +3,17 @@ package store
 import (
 	"context"
 	"errors"
+	"fmt"
 	"go-monolite/internal/config"
 	"go-monolite/internal/store/postgres"
 	"go-monolite/pkg/logger"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+const uniqueViolationCode = "23505"
+
 type Store struct {
 	Db *sqlx.DB
 }
@@ -39,6 +43,12 @@ func ContextError(err error) error {
 	return err
 }
 
+// IsUniqueViolation — нарушение уникального индекса или ограничения
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode
+}
+
 type txKey struct{}
 
 func WithTx(ctx context.Context, tx *sqlx.Tx) context.Context {
@@ -51,3 +61,36 @@ func GetTx(ctx context.Context) *sqlx.Tx {
 	}
 	return nil
 }
+
+// Conn возвращает транзакцию из контекста, если она есть, иначе подключение к БД
+func (s *Store) Conn(ctx context.Context) sqlx.ExtContext {
+	if tx := GetTx(ctx); tx != nil {
+		return tx
+	}
+	return s.Db
+}
+
+// WithinTx выполняет fn в транзакции, вложенные вызовы используют уже открытую транзакцию
+func (s *Store) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if GetTx(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := s.Db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(WithTx(ctx, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			logger.ErrorCtx(ctx, rbErr, "failed to rollback transaction")
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
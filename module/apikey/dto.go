@@ -0,0 +1,36 @@
+package apikey
+
+import (
+	"go-monolite/pkg/validator"
+	"time"
+)
+
+// CreateRequest — DTO для POST /api-key/create
+type CreateRequest struct {
+	Name       string     `json:"name" validate:"required,max=100" example:"1C exchange"`
+	Scopes     []string   `json:"scopes" validate:"required,min=1,dive,oneof=catalog:read catalog:write property:upsert price:upsert storage:upsert link:upsert customers:export"`
+	AllowedIPs []string   `json:"allowed_ips,omitempty" validate:"omitempty,dive,ip|cidr"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+func (d *CreateRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+type APIKeyResponse struct {
+	ID         int64      `json:"id" example:"1"`
+	Name       string     `json:"name" example:"1C exchange"`
+	Prefix     string     `json:"prefix" example:"gm_a1b2c3d4"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateResponse — ключ целиком возвращается только при выпуске
+type CreateResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
@@ -0,0 +1,82 @@
+package apikey
+
+import (
+	"net"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// права ключей интеграций
+const (
+	ScopeCatalogRead     = "catalog:read"
+	ScopeCatalogWrite    = "catalog:write"
+	ScopePropertyUpsert  = "property:upsert"
+	ScopePriceUpsert     = "price:upsert"
+	ScopeStorageUpsert   = "storage:upsert"
+	ScopeLinkUpsert      = "link:upsert"
+	ScopeCustomersExport = "customers:export"
+)
+
+type APIKeyEnt struct {
+	ID         int64          `db:"id"`
+	Name       string         `db:"name"`
+	Prefix     string         `db:"prefix"`
+	KeyHash    string         `db:"key_hash"`
+	Scopes     pq.StringArray `db:"scopes"`
+	AllowedIPs pq.StringArray `db:"allowed_ips"`
+	ExpiresAt  *time.Time     `db:"expires_at"`
+	LastUsedAt *time.Time     `db:"last_used_at"`
+	RevokedAt  *time.Time     `db:"revoked_at"`
+	CreatedBy  *int64         `db:"created_by"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+}
+
+// IsActive — ключ не отозван и не истёк
+func (e *APIKeyEnt) IsActive(now time.Time) bool {
+	if e.RevokedAt != nil {
+		return false
+	}
+	return e.ExpiresAt == nil || now.Before(*e.ExpiresAt)
+}
+
+// AllowsIP проверяет IP по списку адресов и подсетей, пустой список разрешает любой IP
+func (e *APIKeyEnt) AllowsIP(ip string) bool {
+	if len(e.AllowedIPs) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, allowed := range e.AllowedIPs {
+		if _, network, err := net.ParseCIDR(allowed); err == nil {
+			if network.Contains(parsed) {
+				return true
+			}
+			continue
+		}
+		if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e APIKeyEnt) ToResponse() APIKeyResponse {
+	return APIKeyResponse{
+		ID:         e.ID,
+		Name:       e.Name,
+		Prefix:     e.Prefix,
+		Scopes:     e.Scopes,
+		AllowedIPs: e.AllowedIPs,
+		ExpiresAt:  e.ExpiresAt,
+		LastUsedAt: e.LastUsedAt,
+		RevokedAt:  e.RevokedAt,
+		CreatedAt:  e.CreatedAt,
+	}
+}
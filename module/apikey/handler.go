@@ -0,0 +1,138 @@
+package apikey
+
+import (
+	"errors"
+	"go-monolite/internal/store"
+	"go-monolite/module/user"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type Handler struct {
+	service     *Service
+	adminAuth   func(next http.Handler) http.Handler
+	requireRole func(next http.Handler) http.Handler
+}
+
+// NewHandler — authenticate и permissions собираются один раз в роутере сервера
+func NewHandler(store *store.Store, authenticate func(next http.Handler) http.Handler, permissions auth.PermissionChecker) *Handler {
+	return &Handler{
+		service:     NewService(NewRepository(store)),
+		adminAuth:   authenticate,
+		requireRole: auth.RequirePermission(permissions, user.PermissionAPIKeysManage),
+	}
+}
+
+func (h *Handler) Init(r chi.Router) {
+	r.Use(h.adminAuth, h.requireRole)
+
+	r.Get("/", h.GetList)
+	r.Post("/create", h.Create)
+	r.Delete("/revoke/{id}", h.Revoke)
+}
+
+// @Summary Get API keys
+// @Description Get all integration API keys without secrets
+// @Tags api-key
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} respond.SuccessResponse{data=[]APIKeyResponse}
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [get]
+func (h *Handler) GetList(w http.ResponseWriter, r *http.Request) {
+	resp, mess, err := h.service.GetList(r.Context())
+	if err != nil {
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Create API key
+// @Description Issue an integration API key, the key itself is returned only once
+// @Tags api-key
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param request body CreateRequest true "API key data"
+// @Success 201 {object} respond.SuccessResponse{data=CreateResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /create [post]
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request CreateRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.Create(r.Context(), request, identity.UserID)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		if errors.Is(err, ErrExpiresInPast) {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusCreated, mess, resp)
+}
+
+// @Summary Revoke API key
+// @Description Revoke an integration API key
+// @Tags api-key
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "API key ID"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /revoke/{id} [delete]
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный идентификатор ключа")
+		return
+	}
+
+	mess, err := h.service.Revoke(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, "")
+}
@@ -0,0 +1,96 @@
+package apikey_test
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/module/apikey"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	access := testinit.NewAuth(store, config)
+	handler := apikey.NewHandler(store, access.Authenticate, access.Users)
+	server := testinit.SetupTestServer(t, handler)
+	defer server.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	ctx := context.Background()
+	service := apikey.NewService(apikey.NewRepository(store))
+
+	send := func(t *testing.T, method, path, body, authorization string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	admin := "Bearer " + config.HTTPServer.BearerToken
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "/", "", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Create Validation Error", func(t *testing.T) {
+		resp := send(t, http.MethodPost, "/create", `{"name": "1C", "scopes": ["unknown"]}`, admin)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Create Verify Revoke", func(t *testing.T) {
+		resp := send(t, http.MethodPost, "/create", `{"name": "1C", "scopes": ["price:upsert"], "allowed_ips": ["10.0.0.0/8"]}`, admin)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+
+		var created apikey.CreateResponse
+		testinit.MarshalUnmarshal(t, response.Data, &created)
+		require.NotEmpty(t, created.Key)
+		assert.True(t, strings.HasPrefix(created.Key, created.Prefix))
+
+		id, scopes, err := service.VerifyAPIKey(ctx, created.Key, "10.1.2.3")
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, id)
+		assert.Equal(t, []string{apikey.ScopePriceUpsert}, scopes)
+
+		_, _, err = service.VerifyAPIKey(ctx, created.Key, "192.168.0.1")
+		assert.ErrorIs(t, err, auth.ErrAPIKeyInvalid)
+
+		// ключом интеграции нельзя управлять ключами
+		resp = send(t, http.MethodGet, "/", "", "ApiKey "+created.Key)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = send(t, http.MethodGet, "/", "", admin)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = send(t, http.MethodDelete, fmt.Sprintf("/revoke/%d", created.ID), "", admin)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = send(t, http.MethodDelete, fmt.Sprintf("/revoke/%d", created.ID), "", admin)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		_, _, err = service.VerifyAPIKey(ctx, created.Key, "10.1.2.3")
+		assert.ErrorIs(t, err, auth.ErrAPIKeyInvalid)
+	})
+}
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id BIGSERIAL PRIMARY KEY,
+  name VARCHAR(100) NOT NULL,
+  prefix VARCHAR(16) NOT NULL UNIQUE, -- открытая часть ключа, по ней ключ узнают в списке
+  key_hash VARCHAR(64) NOT NULL UNIQUE, -- sha256 от ключа целиком, сам ключ не хранится
+  scopes TEXT[] NOT NULL DEFAULT '{}',
+  allowed_ips TEXT[] NOT NULL DEFAULT '{}', -- IP или подсети, пустой список — без ограничений
+  expires_at TIMESTAMP,
+  last_used_at TIMESTAMP,
+  revoked_at TIMESTAMP,
+  created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO permissions (code, name) VALUES ('apikeys:manage', 'Управление ключами интеграций')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.code = 'admin' AND p.code = 'apikeys:manage'
+ON CONFLICT DO NOTHING;
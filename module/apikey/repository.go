@@ -0,0 +1,131 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-monolite/internal/store"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository struct {
+	store     *store.Store
+	tableName string
+}
+
+func NewRepository(store *store.Store) *Repository {
+	return &Repository{
+		store:     store,
+		tableName: "api_keys",
+	}
+}
+
+func (r *Repository) Create(ctx context.Context, e *APIKeyEnt) (int64, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			name, prefix, key_hash, scopes, allowed_ips, expires_at, created_by, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)
+		RETURNING id
+	`, r.tableName)
+
+	now := time.Now()
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query,
+		e.Name,
+		e.Prefix,
+		e.KeyHash,
+		e.Scopes,
+		e.AllowedIPs,
+		e.ExpiresAt,
+		e.CreatedBy,
+		e.CreatedAt,
+		e.UpdatedAt,
+	).Scan(&e.ID)
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	return e.ID, nil
+}
+
+func (r *Repository) GetList(ctx context.Context) ([]APIKeyEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT id, name, prefix, key_hash, scopes, allowed_ips, expires_at, last_used_at, revoked_at,
+			created_by, created_at, updated_at
+		FROM %s
+		ORDER BY id
+	`, r.tableName)
+
+	var list []APIKeyEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &list, query); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return list, nil
+}
+
+func (r *Repository) GetByHash(ctx context.Context, keyHash string) (*APIKeyEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT id, name, prefix, key_hash, scopes, allowed_ips, expires_at, last_used_at, revoked_at,
+			created_by, created_at, updated_at
+		FROM %s
+		WHERE key_hash = $1
+	`, r.tableName)
+
+	var e APIKeyEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, keyHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+// Revoke отзывает ключ, для отсутствующего или уже отозванного ключа — store.ErrNotFound
+func (r *Repository) Revoke(ctx context.Context, id int64) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET revoked_at = $2, updated_at = $2
+		WHERE id = $1 AND revoked_at IS NULL
+	`, r.tableName)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, id, time.Now())
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// TouchLastUsed обновляет время использования не чаще раза в минуту, чтобы не писать в БД на каждый запрос
+func (r *Repository) TouchLastUsed(ctx context.Context, id int64) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2 - INTERVAL '1 minute')
+	`, r.tableName)
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query, id, time.Now())
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/middleware/auth"
+	"strings"
+	"time"
+)
+
+const (
+	keyPrefix      = "gm_"
+	keyPrefixBytes = 5
+	keySecretBytes = 32
+)
+
+var ErrExpiresInPast = errors.New("expiration date is in the past")
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Create выпускает ключ, в ответе он возвращается один раз, в БД хранится только хэш
+func (s *Service) Create(ctx context.Context, req CreateRequest, createdBy int64) (*CreateResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, "срок действия ключа уже истёк", ErrExpiresInPast
+	}
+
+	prefix, key, err := generateKey()
+	if err != nil {
+		return nil, "произошла ошибка при создании ключа", err
+	}
+
+	e := &APIKeyEnt{
+		Name:       req.Name,
+		Prefix:     prefix,
+		KeyHash:    hashKey(key),
+		Scopes:     req.Scopes,
+		AllowedIPs: req.AllowedIPs,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if e.AllowedIPs == nil {
+		e.AllowedIPs = []string{}
+	}
+	if createdBy != 0 {
+		e.CreatedBy = &createdBy
+	}
+
+	if _, err := s.repo.Create(ctx, e); err != nil {
+		return nil, "произошла ошибка при создании ключа", err
+	}
+
+	return &CreateResponse{
+		APIKeyResponse: e.ToResponse(),
+		Key:            key,
+	}, "ключ создан, сохраните его — повторно он не показывается", nil
+}
+
+func (s *Service) GetList(ctx context.Context) ([]APIKeyResponse, string, error) {
+	list, err := s.repo.GetList(ctx)
+	if err != nil {
+		return nil, "произошла ошибка при получении ключей", err
+	}
+
+	return helper.ToResponse(list), "", nil
+}
+
+func (s *Service) Revoke(ctx context.Context, id int64) (string, error) {
+	if err := s.repo.Revoke(ctx, id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "ключ не найден или уже отозван", err
+		}
+		return "произошла ошибка при отзыве ключа", err
+	}
+
+	return "ключ отозван", nil
+}
+
+// VerifyAPIKey реализует auth.APIKeyVerifier
+func (s *Service) VerifyAPIKey(ctx context.Context, key, ip string) (int64, []string, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return 0, nil, auth.ErrAPIKeyInvalid
+	}
+
+	e, err := s.repo.GetByHash(ctx, hashKey(key))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return 0, nil, auth.ErrAPIKeyInvalid
+		}
+		return 0, nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	if !e.IsActive(time.Now()) || !e.AllowsIP(ip) {
+		return 0, nil, auth.ErrAPIKeyInvalid
+	}
+
+	// время использования не должно мешать самому запросу
+	if err := s.repo.TouchLastUsed(ctx, e.ID); err != nil {
+		logger.ErrorCtx(ctx, err, "failed to update api key last used", "api_key_id", e.ID)
+	}
+
+	return e.ID, e.Scopes, nil
+}
+
+// generateKey возвращает открытый префикс и ключ вида gm_<префикс>_<секрет>
+func generateKey() (prefix, key string, err error) {
+	prefixBuf := make([]byte, keyPrefixBytes)
+	secretBuf := make([]byte, keySecretBytes)
+	if _, err := rand.Read(prefixBuf); err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	if _, err := rand.Read(secretBuf); err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	prefix = keyPrefix + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(prefixBuf))
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBuf)
+	return prefix, key, nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
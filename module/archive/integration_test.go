@@ -0,0 +1,97 @@
+package archive_test
+
+import (
+	"context"
+	"go-monolite/internal/config"
+	"go-monolite/module/archive"
+	"go-monolite/pkg/testinit"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchivePurgeIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	ctx := context.Background()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	// срок хранения 90 дней: old пролежало в архиве дольше, recent — ещё нет
+	now := time.Now()
+	old, recent := now.Add(-100*24*time.Hour), now.Add(-10*24*time.Hour)
+
+	catalog, oldCategory, oldChild, busyCategory := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	gone, kept, parent, variant := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	brand, color := uuid.New(), uuid.New()
+	closedStorage, oldPrice := uuid.New(), uuid.New()
+	for _, q := range []struct {
+		query string
+		args  []any
+	}{
+		{`INSERT INTO categories (uuid, slug, name) VALUES ($1, 'catalog', 'Каталог')`, []any{catalog}},
+		{`INSERT INTO categories (uuid, slug, name, deleted_at) VALUES ($1, 'old', 'Старая', $2)`, []any{oldCategory, old}},
+		{`INSERT INTO categories (uuid, slug, name, parent_uuid, deleted_at) VALUES ($1, 'old-child', 'Старая дочерняя', $2, $3)`, []any{oldChild, oldCategory, old}},
+		{`INSERT INTO categories (uuid, slug, name, deleted_at) VALUES ($1, 'busy', 'С товаром', $2)`, []any{busyCategory, old}},
+		{`INSERT INTO property (uuid, slug, type, name, deleted_at) VALUES ($1, 'brand', 'Справочник', 'Бренд', $3), ($2, 'color', 'Справочник', 'Цвет', $3)`, []any{brand, color, old}},
+		{`INSERT INTO property_values (key, slug, value, property_uuid, deleted_at) VALUES ('acme', 'acme', 'Acme', $1, $3), ('red', 'red', 'Красный', $2, $3)`, []any{brand, color, old}},
+		{`INSERT INTO products (uuid, name, code, slug, category_uuid, deleted_at) VALUES ($1, 'Удалённый', 9501, 'gone', $2, $3)`, []any{gone, catalog, old}},
+		{`INSERT INTO products (uuid, name, code, slug, category_uuid, brand_uuid, deleted_at) VALUES ($1, 'Недавний', 9502, 'kept', $2, 'acme', $3)`, []any{kept, busyCategory, recent}},
+		{`INSERT INTO products (uuid, name, code, slug, category_uuid, deleted_at) VALUES ($1, 'Родитель', 9503, 'parent', $2, $3)`, []any{parent, catalog, old}},
+		{`INSERT INTO products (uuid, name, code, slug, category_uuid, parent_uuid, deleted_at) VALUES ($1, 'Вариант', 9504, 'variant', $2, $3, $4)`, []any{variant, catalog, parent, recent}},
+		{`INSERT INTO storage (uuid, name, deleted_at) VALUES ($1, 'Закрытый', $2)`, []any{closedStorage, old}},
+		{`INSERT INTO type_price (uuid, name, deleted_at) VALUES ($1, 'Старая', $2)`, []any{oldPrice, old}},
+	} {
+		_, err := store.Db.Exec(q.query, q.args...)
+		require.NoError(t, err)
+	}
+
+	exists := func(t *testing.T, table, column string, value any) bool {
+		t.Helper()
+		var found bool
+		err := store.Db.Get(&found, `SELECT EXISTS (SELECT 1 FROM `+table+` WHERE `+column+` = $1)`, value)
+		require.NoError(t, err)
+		return found
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		result, err := archive.NewPurger(store, config.Archive{RetentionDays: 0}).Purge(ctx, now)
+		require.NoError(t, err)
+		assert.Equal(t, archive.Result{}, *result)
+		assert.True(t, exists(t, "products", "uuid", gone))
+	})
+
+	purger := archive.NewPurger(store, config.Archive{RetentionDays: 90, PurgeInterval: 3600})
+
+	t.Run("Purge Expired", func(t *testing.T) {
+		result, err := purger.Purge(ctx, now)
+		require.NoError(t, err)
+
+		// родитель ждёт своего варианта, категория с товаром и бренд товара остаются
+		assert.Equal(t, archive.Result{Products: 1, Categories: 2, PropertyValues: 1, Properties: 1, Storages: 1, TypePrices: 1}, *result)
+		assert.False(t, exists(t, "products", "uuid", gone))
+		assert.True(t, exists(t, "products", "uuid", parent))
+		assert.False(t, exists(t, "categories", "uuid", oldChild))
+		assert.True(t, exists(t, "categories", "uuid", busyCategory))
+		assert.True(t, exists(t, "property_values", "key", "acme"))
+		assert.True(t, exists(t, "property", "uuid", brand))
+		assert.False(t, exists(t, "property", "uuid", color))
+		assert.True(t, exists(t, "categories", "uuid", catalog))
+	})
+
+	t.Run("Purge Later", func(t *testing.T) {
+		result, err := purger.Purge(ctx, now.Add(90*24*time.Hour))
+		require.NoError(t, err)
+
+		assert.Equal(t, archive.Result{Products: 3, Categories: 1, PropertyValues: 1, Properties: 1}, *result)
+		assert.False(t, exists(t, "products", "uuid", variant))
+		assert.False(t, exists(t, "categories", "uuid", busyCategory))
+		assert.False(t, exists(t, "property", "uuid", brand))
+		assert.True(t, exists(t, "categories", "uuid", catalog))
+	})
+}
@@ -0,0 +1,25 @@
+DROP INDEX IF EXISTS type_price_deleted_at_idx;
+
+ALTER TABLE type_price DROP COLUMN IF EXISTS deleted_at;
+
+DROP INDEX IF EXISTS storage_deleted_at_idx;
+
+ALTER TABLE storage DROP COLUMN IF EXISTS deleted_at;
+
+DROP INDEX IF EXISTS property_values_deleted_at_idx;
+DROP INDEX IF EXISTS property_deleted_at_idx;
+
+ALTER TABLE property_values DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE property DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_parent_uuid_fkey;
+ALTER TABLE categories ADD CONSTRAINT categories_parent_uuid_fkey
+  FOREIGN KEY (parent_uuid) REFERENCES categories(uuid) ON DELETE SET NULL;
+
+DROP INDEX IF EXISTS categories_deleted_at_idx;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS deleted_at;
+
+DROP INDEX IF EXISTS products_deleted_at_idx;
+
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,31 @@
+-- архив каталога одной миграцией: удалённые записи остаются с deleted_at, пока их не удалит задача очистки
+
+-- товары остаются для истории заказов и аналитики
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS products_deleted_at_idx ON products (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- удаление строки категории больше не должно молча отрывать дочерние категории,
+-- поэтому SET NULL заменён на RESTRICT: задача очистки удаляет только пустые категории
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS categories_deleted_at_idx ON categories (deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_parent_uuid_fkey;
+ALTER TABLE categories ADD CONSTRAINT categories_parent_uuid_fkey
+  FOREIGN KEY (parent_uuid) REFERENCES categories(uuid) ON DELETE RESTRICT;
+
+-- свойства, их значения, склады и типы цен обмен в режиме полной выгрузки архивирует, если их нет в выгрузке
+ALTER TABLE property ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE property_values ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS property_deleted_at_idx ON property (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS property_values_deleted_at_idx ON property_values (deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS storage_deleted_at_idx ON storage (deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE type_price ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS type_price_deleted_at_idx ON type_price (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,153 @@
+package archive
+
+import (
+	"context"
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/module/category"
+	"go-monolite/module/price"
+	"go-monolite/module/product"
+	"go-monolite/module/property"
+	"go-monolite/module/storage"
+	"go-monolite/pkg/logger"
+	"sync"
+	"time"
+)
+
+// Purger окончательно удаляет из каталога то, что пролежало в архиве дольше срока хранения.
+// Записи, на которые ещё ссылаются другие данные, остаются до следующего прохода
+type Purger struct {
+	products       *product.Repository
+	categories     *category.Repository
+	propertyValues *property.PropertyValuesRepository
+	properties     *property.PropertyRepository
+	storages       *storage.StorageRepository
+	typePrices     *price.TypePriceRepository
+
+	retention time.Duration
+	interval  time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Result — сколько записей удалено за проход
+type Result struct {
+	Products       int64
+	Categories     int64
+	PropertyValues int64
+	Properties     int64
+	Storages       int64
+	TypePrices     int64
+}
+
+func NewPurger(store *store.Store, cfg config.Archive) *Purger {
+	return &Purger{
+		products:       product.NewRepository(store),
+		categories:     category.NewRepository(store),
+		propertyValues: property.NewPropertyValuesRepository(store),
+		properties:     property.NewPropertyRepository(store),
+		storages:       storage.NewStorageRepository(store),
+		typePrices:     price.NewTypePriceRepository(store),
+		retention:      time.Duration(max(cfg.RetentionDays, 0)) * 24 * time.Hour,
+		interval:       time.Duration(max(cfg.PurgeInterval, 1)) * time.Second,
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+}
+
+// Start запускает очистку по расписанию, остановка — через Shutdown. Без срока хранения архив не очищается
+func (p *Purger) Start() {
+	if p.retention == 0 {
+		logger.Info("archive purge disabled")
+		close(p.done)
+		return
+	}
+
+	logger.Info("starting archive purger", "interval", p.interval.String(), "retention", p.retention.String())
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+
+			result, err := p.Purge(context.Background(), time.Now())
+			if err != nil {
+				logger.Error(err, "archive purge failed")
+				continue
+			}
+			logger.Info("archive purged",
+				"products", result.Products,
+				"categories", result.Categories,
+				"property_values", result.PropertyValues,
+				"properties", result.Properties,
+				"storages", result.Storages,
+				"type_prices", result.TypePrices,
+			)
+		}
+	}()
+}
+
+// Shutdown дожидается окончания текущего прохода
+func (p *Purger) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.stop) })
+
+	select {
+	case <-p.done:
+		logger.Info("archive purger stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Purge удаляет записи, перенесённые в архив раньше now минус срок хранения.
+// Сначала товары, потом категории и значения свойств, на которые они ссылались
+func (p *Purger) Purge(ctx context.Context, now time.Time) (*Result, error) {
+	result := &Result{}
+	if p.retention == 0 {
+		return result, nil
+	}
+	before := now.Add(-p.retention)
+
+	var err error
+	if result.Products, err = p.products.Purge(ctx, before); err != nil {
+		return nil, err
+	}
+
+	// категория удаляется после своих дочерних, ветка дерева очищается снизу вверх
+	for {
+		n, err := p.categories.Purge(ctx, before)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			break
+		}
+		result.Categories += n
+	}
+
+	if result.PropertyValues, err = p.propertyValues.Purge(ctx, before); err != nil {
+		return nil, err
+	}
+	if result.Properties, err = p.properties.Purge(ctx, before); err != nil {
+		return nil, err
+	}
+	if result.Storages, err = p.storages.Purge(ctx, before); err != nil {
+		return nil, err
+	}
+	if result.TypePrices, err = p.typePrices.Purge(ctx, before); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"encoding/json"
+	"go-monolite/pkg/validator"
+	"time"
+)
+
+// ListRequest — фильтр журнала по сущности, автору изменения и времени
+type ListRequest struct {
+	EntityType string `validate:"omitempty,oneof=category product type_price product_price storage product_storage property property_value"`
+	EntityID   string `validate:"omitempty,max=64"`
+	ActorType  string `validate:"omitempty,oneof=user api_key superuser system"`
+	ActorID    int64  `validate:"min=0"`
+	RequestID  string `validate:"omitempty,max=64"`
+	From       *time.Time
+	To         *time.Time
+	Limit      int `validate:"min=1,max=100"`
+	Offset     int `validate:"min=0"`
+}
+
+func (d *ListRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+type LogResponse struct {
+	ID         int64            `json:"id" example:"1"`
+	EntityType string           `json:"entity_type" example:"product"`
+	EntityID   string           `json:"entity_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Action     string           `json:"action" example:"update"`
+	ActorType  string           `json:"actor_type" example:"user"`
+	ActorID    *int64           `json:"actor_id,omitempty" example:"1"`
+	RequestID  *string          `json:"request_id,omitempty"`
+	Before     *json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After      *json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+type ListResponse struct {
+	Items []LogResponse `json:"items"`
+	Total int           `json:"total"`
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+const (
+	ActorUser      = "user"
+	ActorAPIKey    = "api_key"
+	ActorSuperuser = "superuser"
+	ActorSystem    = "system"
+)
+
+// Типы сущностей журнала. Цены и остатки товара пишутся под UUID товара, значения свойств — под ключом
+const (
+	EntityCategory       = "category"
+	EntityProduct        = "product"
+	EntityTypePrice      = "type_price"
+	EntityProductPrice   = "product_price"
+	EntityStorage        = "storage"
+	EntityProductStorage = "product_storage"
+	EntityProperty       = "property"
+	EntityPropertyValue  = "property_value"
+)
+
+type LogEnt struct {
+	ID         int64            `db:"id"`
+	EntityType string           `db:"entity_type"`
+	EntityID   string           `db:"entity_id"`
+	Action     string           `db:"action"`
+	ActorType  string           `db:"actor_type"`
+	ActorID    *int64           `db:"actor_id"`
+	RequestID  *string          `db:"request_id"`
+	Before     *json.RawMessage `db:"before"`
+	After      *json.RawMessage `db:"after"`
+	CreatedAt  time.Time        `db:"created_at"`
+}
+
+func (e LogEnt) ToResponse() LogResponse {
+	return LogResponse{
+		ID:         e.ID,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Action:     e.Action,
+		ActorType:  e.ActorType,
+		ActorID:    e.ActorID,
+		RequestID:  e.RequestID,
+		Before:     e.Before,
+		After:      e.After,
+		CreatedAt:  e.CreatedAt,
+	}
+}
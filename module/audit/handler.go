@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"errors"
+	"go-monolite/internal/store"
+	"go-monolite/module/user"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+const defaultListLimit = 50
+
+// Handler — просмотр журнала изменений каталога
+type Handler struct {
+	service     *Service
+	adminAuth   func(next http.Handler) http.Handler
+	requireRole func(next http.Handler) http.Handler
+}
+
+// NewHandler — authenticate и permissions собираются один раз в роутере сервера
+func NewHandler(store *store.Store, authenticate func(next http.Handler) http.Handler, permissions auth.PermissionChecker) *Handler {
+	return &Handler{
+		service:     NewService(NewRepository(store)),
+		adminAuth:   authenticate,
+		requireRole: auth.RequirePermission(permissions, user.PermissionAuditRead),
+	}
+}
+
+func (h *Handler) Init(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.adminAuth, h.requireRole)
+
+		r.Get("/", h.GetList)
+		r.Get("/{id}", h.Get)
+	})
+}
+
+// @Summary List audit log
+// @Description Get catalog changes, newest first. Price and stock changes are logged under the product UUID, property value changes under the value key
+// @Tags audit
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param entity_type query string false "Entity type: category, product, type_price, product_price, storage, product_storage, property, property_value"
+// @Param entity_id query string false "Entity UUID or property value key"
+// @Param actor_type query string false "Actor type: user, api_key, superuser, system"
+// @Param actor_id query int false "User or API key ID"
+// @Param request_id query string false "Request ID"
+// @Param from query string false "Period start, RFC3339"
+// @Param to query string false "Period end (exclusive), RFC3339"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [get]
+func (h *Handler) GetList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := ListRequest{
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+		ActorType:  query.Get("actor_type"),
+		RequestID:  query.Get("request_id"),
+	}
+
+	actorID, err := queryInt(r, "actor_id", 0)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный actor_id")
+		return
+	}
+	request.ActorID = int64(actorID)
+
+	if request.From, err = queryTime(r, "from"); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный from")
+		return
+	}
+	if request.To, err = queryTime(r, "to"); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный to")
+		return
+	}
+
+	if request.Limit, err = queryInt(r, "limit", defaultListLimit); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный limit")
+		return
+	}
+	if request.Offset, err = queryInt(r, "offset", 0); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный offset")
+		return
+	}
+
+	resp, mess, err := h.service.List(r.Context(), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Get audit log entry
+// @Description Get a single catalog change with its before and after fields
+// @Tags audit
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Entry ID"
+// @Success 200 {object} respond.SuccessResponse{data=LogResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный идентификатор записи")
+		return
+	}
+
+	resp, mess, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func queryTime(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, err error, mess string) {
+	var validationErrors validator.ValidationError
+	if errors.As(err, &validationErrors) {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrInvalidPeriod):
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, mess)
+	case errors.Is(err, store.ErrNotFound):
+		respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+	default:
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+	}
+}
@@ -0,0 +1,216 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-monolite/internal/config"
+	"go-monolite/module/audit"
+	"go-monolite/module/category"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	cfg := testinit.GetConfigs()
+	access := testinit.NewAuth(store, cfg)
+	server := testinit.SetupTestServer(t, audit.NewHandler(store, access.Authenticate, access.Users))
+	defer server.Close()
+	categories := testinit.SetupTestServer(t, category.NewHandler(store))
+	defer categories.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	ctx := context.Background()
+	recorder := audit.NewRecorder(store)
+
+	const parentUUID = "550e8400-e29b-41d4-a711-446655440501"
+	const childUUID = "550e8400-e29b-41d4-a712-446655440501"
+
+	send := func(t *testing.T, method, path, accessToken string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		require.NoError(t, err)
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	list := func(t *testing.T, query url.Values) audit.ListResponse {
+		t.Helper()
+		resp := send(t, http.MethodGet, "/?"+query.Encode(), cfg.HTTPServer.BearerToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		var l audit.ListResponse
+		testinit.MarshalUnmarshal(t, response.Data, &l)
+		return l
+	}
+
+	fields := func(t *testing.T, raw *json.RawMessage) map[string]any {
+		t.Helper()
+		if raw == nil {
+			return nil
+		}
+		var m map[string]any
+		require.NoError(t, json.Unmarshal(*raw, &m))
+		return m
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, send(t, http.MethodGet, "/", "").StatusCode)
+	})
+
+	t.Run("Category Writes", func(t *testing.T) {
+		body := fmt.Sprintf(`[
+			{"uuid": "%s", "name": "Инструмент", "active": "Y", "parent_uuid": null},
+			{"uuid": "%s", "name": "Дрели", "active": "Y", "parent_uuid": "%s"}
+		]`, parentUUID, childUUID, parentUUID)
+		resp := testinit.SendRequest(t, categories.URL+"/create", "POST", body)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		body = fmt.Sprintf(`{"uuid": "%s", "name": "Электроинструмент", "active": "Y", "parent_uuid": null}`, parentUUID)
+		resp = testinit.SendRequest(t, categories.URL+"/update/"+parentUUID, "PUT", body)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// повторное обновление без изменений в журнал не попадает
+		resp = testinit.SendRequest(t, categories.URL+"/update/"+parentUUID, "PUT", body)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, categories.URL+"/delete/"+childUUID, "DELETE", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = testinit.SendRequest(t, categories.URL+"/restore/"+childUUID, "POST", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		parent := list(t, url.Values{"entity_type": {audit.EntityCategory}, "entity_id": {parentUUID}})
+		require.Equal(t, 2, parent.Total)
+		updated, created := parent.Items[0], parent.Items[1]
+
+		assert.Equal(t, audit.ActionUpdate, updated.Action)
+		assert.Equal(t, audit.ActorSystem, updated.ActorType)
+		assert.Nil(t, updated.ActorID)
+		assert.Equal(t, "Инструмент", fields(t, updated.Before)["name"])
+		assert.Equal(t, "Электроинструмент", fields(t, updated.After)["name"])
+		assert.NotContains(t, fields(t, updated.After), "active")
+
+		assert.Equal(t, audit.ActionCreate, created.Action)
+		assert.Nil(t, created.Before)
+		assert.Equal(t, "Инструмент", fields(t, created.After)["name"])
+		assert.Equal(t, "Y", fields(t, created.After)["active"])
+
+		child := list(t, url.Values{"entity_id": {childUUID}})
+		require.Equal(t, 3, child.Total)
+		assert.Equal(t, audit.ActionRestore, child.Items[0].Action)
+		assert.Equal(t, audit.ActionDelete, child.Items[1].Action)
+		assert.Equal(t, "Дрели", fields(t, child.Items[1].Before)["name"])
+		assert.Nil(t, child.Items[1].After)
+		assert.Equal(t, audit.ActionCreate, child.Items[2].Action)
+	})
+
+	t.Run("Actors", func(t *testing.T) {
+		entry := audit.Updated(audit.EntityProduct, "550e8400-e29b-41d4-a713-446655440501",
+			map[string]any{"name": "Дрель", "code": 1}, map[string]any{"name": "Дрель ударная", "code": 1})
+
+		userCtx := auth.WithIdentity(ctx, auth.Identity{UserID: 42})
+		require.NoError(t, recorder.Record(userCtx, entry))
+		apiKeyCtx := auth.WithIdentity(ctx, auth.Identity{APIKeyID: 7, Scopes: []string{"catalog:write"}})
+		require.NoError(t, recorder.Record(apiKeyCtx, entry))
+		superuserCtx := auth.WithIdentity(ctx, auth.Identity{Superuser: true})
+		require.NoError(t, recorder.Record(superuserCtx, entry))
+
+		byUser := list(t, url.Values{"actor_type": {audit.ActorUser}, "actor_id": {"42"}})
+		require.Equal(t, 1, byUser.Total)
+		assert.Equal(t, map[string]any{"name": "Дрель"}, fields(t, byUser.Items[0].Before))
+		assert.Equal(t, map[string]any{"name": "Дрель ударная"}, fields(t, byUser.Items[0].After))
+
+		byKey := list(t, url.Values{"actor_type": {audit.ActorAPIKey}})
+		require.Equal(t, 1, byKey.Total)
+		require.NotNil(t, byKey.Items[0].ActorID)
+		assert.Equal(t, int64(7), *byKey.Items[0].ActorID)
+
+		assert.Equal(t, 1, list(t, url.Values{"actor_type": {audit.ActorSuperuser}}).Total)
+		assert.Equal(t, 3, list(t, url.Values{"entity_type": {audit.EntityProduct}}).Total)
+	})
+
+	t.Run("Time Range", func(t *testing.T) {
+		now := time.Now()
+		past := list(t, url.Values{"to": {now.Add(-time.Hour).Format(time.RFC3339)}})
+		assert.Equal(t, 0, past.Total)
+
+		all := list(t, url.Values{
+			"from":  {now.Add(-time.Hour).Format(time.RFC3339)},
+			"to":    {now.Add(time.Hour).Format(time.RFC3339)},
+			"limit": {"2"},
+		})
+		assert.Equal(t, 8, all.Total)
+		assert.Len(t, all.Items, 2)
+
+		resp := send(t, http.MethodGet, "/?from="+url.QueryEscape(now.Format(time.RFC3339))+"&to="+url.QueryEscape(now.Add(-time.Hour).Format(time.RFC3339)), cfg.HTTPServer.BearerToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, http.MethodGet, "/?from=yesterday", cfg.HTTPServer.BearerToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, http.MethodGet, "/?entity_type=order", cfg.HTTPServer.BearerToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Get Entry", func(t *testing.T) {
+		latest := list(t, url.Values{"limit": {"1"}}).Items[0]
+
+		resp := send(t, http.MethodGet, fmt.Sprintf("/%d", latest.ID), cfg.HTTPServer.BearerToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		var entry audit.LogResponse
+		testinit.MarshalUnmarshal(t, response.Data, &entry)
+		assert.Equal(t, latest.ID, entry.ID)
+		assert.Equal(t, latest.EntityID, entry.EntityID)
+
+		assert.Equal(t, http.StatusNotFound, send(t, http.MethodGet, "/999999", cfg.HTTPServer.BearerToken).StatusCode)
+	})
+
+	t.Run("Append Only", func(t *testing.T) {
+		_, err := store.Db.Exec(`UPDATE audit_log SET action = 'delete'`)
+		require.Error(t, err)
+		assert.True(t, strings.Contains(err.Error(), "append-only"))
+
+		_, err = store.Db.Exec(`DELETE FROM audit_log`)
+		require.Error(t, err)
+
+		assert.Equal(t, 8, list(t, url.Values{}).Total)
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		deleted, err := audit.NewPurger(store, config.Audit{RetentionDays: 0}).Purge(ctx, time.Now().Add(48*time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, deleted)
+
+		purger := audit.NewPurger(store, config.Audit{RetentionDays: 1, PurgeInterval: 3600})
+
+		deleted, err = purger.Purge(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, deleted)
+
+		deleted, err = purger.Purge(ctx, time.Now().Add(48*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(8), deleted)
+		assert.Equal(t, 0, list(t, url.Values{}).Total)
+	})
+}
@@ -0,0 +1,6 @@
+DELETE FROM permissions WHERE code = 'audit:read';
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
+
+DROP TABLE IF EXISTS audit_log;
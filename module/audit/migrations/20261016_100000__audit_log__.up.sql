@@ -0,0 +1,41 @@
+-- журнал изменений каталога: кто, когда и что поменял. Строки только добавляются,
+-- удаляет их только очистка по сроку хранения, выставив audit.purge внутри своей транзакции
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  entity_type VARCHAR(32) NOT NULL,
+  entity_id VARCHAR(64) NOT NULL, -- UUID сущности, для значений свойств — ключ
+  action VARCHAR(16) NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore')),
+  actor_type VARCHAR(16) NOT NULL CHECK (actor_type IN ('user', 'api_key', 'superuser', 'system')),
+  actor_id BIGINT, -- пользователь или ключ интеграции
+  request_id VARCHAR(64),
+  before JSONB, -- изменённые поля до записи, у созданной сущности пусто
+  after JSONB, -- изменённые поля после записи, у удалённой сущности пусто
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity_type, entity_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor_type, actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_log_created_idx ON audit_log (created_at);
+CREATE INDEX IF NOT EXISTS audit_log_request_idx ON audit_log (request_id) WHERE request_id IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' AND current_setting('audit.purge', true) = 'on' THEN
+    RETURN OLD;
+  END IF;
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only
+BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+INSERT INTO permissions (code, name) VALUES ('audit:read', 'Просмотр журнала изменений')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.code = 'admin' AND p.code = 'audit:read'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"context"
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/pkg/logger"
+	"sync"
+	"time"
+)
+
+// Purger удаляет из журнала записи старше срока хранения
+type Purger struct {
+	repo *Repository
+
+	retention time.Duration
+	interval  time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewPurger(store *store.Store, cfg config.Audit) *Purger {
+	return &Purger{
+		repo:      NewRepository(store),
+		retention: time.Duration(max(cfg.RetentionDays, 0)) * 24 * time.Hour,
+		interval:  time.Duration(max(cfg.PurgeInterval, 1)) * time.Second,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start запускает очистку по расписанию, остановка — через Shutdown. Без срока хранения журнал хранится бессрочно
+func (p *Purger) Start() {
+	if p.retention == 0 {
+		logger.Info("audit purge disabled")
+		close(p.done)
+		return
+	}
+
+	logger.Info("starting audit purger", "interval", p.interval.String(), "retention", p.retention.String())
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+
+			deleted, err := p.Purge(context.Background(), time.Now())
+			if err != nil {
+				logger.Error(err, "audit purge failed")
+				continue
+			}
+			logger.Info("audit log purged", "deleted", deleted)
+		}
+	}()
+}
+
+// Shutdown дожидается окончания текущего прохода
+func (p *Purger) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.stop) })
+
+	select {
+	case <-p.done:
+		logger.Info("audit purger stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Purge удаляет записи, сделанные раньше now минус срок хранения
+func (p *Purger) Purge(ctx context.Context, now time.Time) (int64, error) {
+	if p.retention == 0 {
+		return 0, nil
+	}
+	return p.repo.Purge(ctx, now.Add(-p.retention))
+}
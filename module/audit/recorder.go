@@ -0,0 +1,195 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-monolite/internal/store"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/middleware/request_id"
+	"reflect"
+	"time"
+)
+
+// ignoredFields — служебные поля записи, в журнал не попадают
+var ignoredFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// Entry — изменение одной сущности. Before и After — сама сущность до и после записи,
+// в журнал попадают только отличающиеся поля
+type Entry struct {
+	EntityType string
+	EntityID   string
+	Action     string
+	Before     any
+	After      any
+}
+
+func Created(entityType, entityID string, after any) Entry {
+	return Entry{EntityType: entityType, EntityID: entityID, Action: ActionCreate, After: after}
+}
+
+func Updated(entityType, entityID string, before, after any) Entry {
+	return Entry{EntityType: entityType, EntityID: entityID, Action: ActionUpdate, Before: before, After: after}
+}
+
+func Deleted(entityType, entityID string, before any) Entry {
+	return Entry{EntityType: entityType, EntityID: entityID, Action: ActionDelete, Before: before}
+}
+
+// Restored — сущность вернулась из архива, в журнал пишется целиком
+func Restored(entityType, entityID string, after any) Entry {
+	return Entry{EntityType: entityType, EntityID: entityID, Action: ActionRestore, After: after}
+}
+
+// Recorder пишет изменения в журнал от имени автора запроса
+type Recorder struct {
+	repo *Repository
+}
+
+func NewRecorder(store *store.Store) *Recorder {
+	return &Recorder{repo: NewRepository(store)}
+}
+
+// Record сохраняет изменения. Обновления без отличий пропускаются.
+// Вызванный внутри транзакции, журнал откатывается вместе с изменением
+func (r *Recorder) Record(ctx context.Context, entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	actorType, actorID := actor(ctx)
+	var requestID *string
+	if id := request_id.GetReqID(ctx); id != "" {
+		requestID = &id
+	}
+	now := time.Now()
+
+	rows := make([]LogEnt, 0, len(entries))
+	for _, e := range entries {
+		before, after, err := diff(e.Before, e.After)
+		if err != nil {
+			return fmt.Errorf("audit %s %s: %w", e.EntityType, e.EntityID, err)
+		}
+		if e.Action == ActionUpdate && before == nil && after == nil {
+			continue
+		}
+
+		rows = append(rows, LogEnt{
+			EntityType: e.EntityType,
+			EntityID:   e.EntityID,
+			Action:     e.Action,
+			ActorType:  actorType,
+			ActorID:    actorID,
+			RequestID:  requestID,
+			Before:     before,
+			After:      after,
+			CreatedAt:  now,
+		})
+	}
+
+	return r.repo.Insert(ctx, rows)
+}
+
+// actor — автор изменения: пользователь, ключ интеграции, администратор со статическим токеном
+// или сама система, если запрос пришёл не через HTTP
+func actor(ctx context.Context) (string, *int64) {
+	identity, ok := auth.CurrentUser(ctx)
+	switch {
+	case !ok:
+		return ActorSystem, nil
+	case identity.IsAPIKey():
+		return ActorAPIKey, &identity.APIKeyID
+	case identity.Superuser:
+		return ActorSuperuser, nil
+	case identity.UserID != 0:
+		return ActorUser, &identity.UserID
+	default:
+		return ActorSystem, nil
+	}
+}
+
+// diff оставляет у пары снимков только отличающиеся поля. Если одного из снимков нет, второй пишется целиком
+func diff(before, after any) (*json.RawMessage, *json.RawMessage, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if beforeFields != nil && afterFields != nil {
+		changedBefore, changedAfter := map[string]any{}, map[string]any{}
+		for key, value := range beforeFields {
+			if !reflect.DeepEqual(value, afterFields[key]) {
+				changedBefore[key] = value
+				changedAfter[key] = afterFields[key]
+			}
+		}
+		for key, value := range afterFields {
+			if _, seen := beforeFields[key]; !seen {
+				changedBefore[key] = nil
+				changedAfter[key] = value
+			}
+		}
+		if len(changedAfter) == 0 {
+			return nil, nil, nil
+		}
+		beforeFields, afterFields = changedBefore, changedAfter
+	}
+
+	beforeJSON, err := marshal(beforeFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterJSON, err := marshal(afterFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return beforeJSON, afterJSON, nil
+}
+
+// fields — снимок сущности как JSON-объект, nil для отсутствующего снимка
+func fields(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for key := range ignoredFields {
+		delete(m, key)
+	}
+
+	return m, nil
+}
+
+func marshal(m map[string]any) (*json.RawMessage, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := json.RawMessage(data)
+	return &raw, nil
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-monolite/internal/store"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const logColumns = `id, entity_type, entity_id, action, actor_type, actor_id, request_id, before, after, created_at`
+
+// insertChunk — записей в одном INSERT, чтобы не упереться в лимит параметров запроса
+const insertChunk = 1000
+
+type Repository struct {
+	store     *store.Store
+	tableName string
+}
+
+func NewRepository(store *store.Store) *Repository {
+	return &Repository{
+		store:     store,
+		tableName: "audit_log",
+	}
+}
+
+// Insert добавляет записи журнала. Внутри открытой транзакции запись откатится вместе с изменением
+func (r *Repository) Insert(ctx context.Context, entries []LogEnt) error {
+	for start := 0; start < len(entries); start += insertChunk {
+		chunk := entries[start:min(start+insertChunk, len(entries))]
+
+		values := make([]string, 0, len(chunk))
+		args := make([]any, 0, len(chunk)*9)
+		for _, e := range chunk {
+			n := len(args)
+			values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d::jsonb, $%d::jsonb, $%d)",
+				n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9))
+			args = append(args, e.EntityType, e.EntityID, e.Action, e.ActorType, e.ActorID, e.RequestID,
+				jsonText(e.Before), jsonText(e.After), e.CreatedAt)
+		}
+
+		query := fmt.Sprintf(`
+			INSERT INTO %s (entity_type, entity_id, action, actor_type, actor_id, request_id, before, after, created_at)
+			VALUES %s
+		`, r.tableName, strings.Join(values, ", "))
+
+		if _, err := r.store.Conn(ctx).ExecContext(ctx, query, args...); err != nil {
+			return store.ContextError(err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int64) (*LogEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, logColumns, r.tableName)
+
+	var e LogEnt
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+// List — записи журнала, сначала новые
+func (r *Repository) List(ctx context.Context, req ListRequest) ([]LogEnt, int, error) {
+	var (
+		where []string
+		args  []any
+	)
+	add := func(cond string, value any) {
+		args = append(args, value)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if req.EntityType != "" {
+		add("entity_type = $%d", req.EntityType)
+	}
+	if req.EntityID != "" {
+		add("entity_id = $%d", req.EntityID)
+	}
+	if req.ActorType != "" {
+		add("actor_type = $%d", req.ActorType)
+	}
+	if req.ActorID != 0 {
+		add("actor_id = $%d", req.ActorID)
+	}
+	if req.RequestID != "" {
+		add("request_id = $%d", req.RequestID)
+	}
+	if req.From != nil {
+		add("created_at >= $%d", *req.From)
+	}
+	if req.To != nil {
+		add("created_at < $%d", *req.To)
+	}
+
+	filter := ""
+	if len(where) > 0 {
+		filter = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s %s`, r.tableName, filter)
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &total, countQuery, args...); err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, logColumns, r.tableName, filter, len(args)+1, len(args)+2)
+
+	var entries []LogEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &entries, query, append(args, req.Limit, req.Offset)...); err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	return entries, total, nil
+}
+
+// Purge удаляет записи старше before. Триггер пропускает удаление только с выставленным audit.purge
+func (r *Repository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := r.store.WithinTx(ctx, func(ctx context.Context) error {
+		conn := r.store.Conn(ctx)
+		if _, err := conn.ExecContext(ctx, `SELECT set_config('audit.purge', 'on', true)`); err != nil {
+			return store.ContextError(err)
+		}
+
+		res, err := conn.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE created_at < $1`, r.tableName), before)
+		if err != nil {
+			return store.ContextError(err)
+		}
+		deleted, err = res.RowsAffected()
+		return err
+	})
+
+	return deleted, err
+}
+
+func jsonText(raw *json.RawMessage) *string {
+	if raw == nil {
+		return nil
+	}
+	text := string(*raw)
+	return &text
+}
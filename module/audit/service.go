@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"go-monolite/internal/store"
+	"go-monolite/pkg/helper"
+)
+
+// ErrInvalidPeriod — начало периода позже его конца
+var ErrInvalidPeriod = errors.New("invalid period")
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) List(ctx context.Context, req ListRequest) (*ListResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+	if req.From != nil && req.To != nil && req.From.After(*req.To) {
+		return nil, "начало периода позже его конца", ErrInvalidPeriod
+	}
+
+	entries, total, err := s.repo.List(ctx, req)
+	if err != nil {
+		return nil, "произошла ошибка при получении журнала изменений", err
+	}
+
+	return &ListResponse{Items: helper.ToResponse(entries), Total: total}, "", nil
+}
+
+func (s *Service) Get(ctx context.Context, id int64) (*LogResponse, string, error) {
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "запись журнала не найдена", err
+		}
+		return nil, "произошла ошибка при получении записи журнала", err
+	}
+
+	resp := e.ToResponse()
+	return &resp, "", nil
+}
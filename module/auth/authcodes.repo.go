@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"go-monolite/internal/store"
 	"time"
+
+	"github.com/jmoiron/sqlx"
 )
 
 type AuthCodeRepository struct {
@@ -49,11 +51,11 @@ func (r *AuthCodeRepository) CountCodesLast24Hours(ctx context.Context, email, p
 	return count, nil
 }
 
-func (r *AuthCodeRepository) GetActiveCode(ctx context.Context, email, phone string) (*AuthCode, error) {
+func (r *AuthCodeRepository) GetActiveCode(ctx context.Context, email, phone, codeType string) (*AuthCode, error) {
 	query := fmt.Sprintf(`
-		SELECT id, email, phone, code, expires_at, used, created_at
+		SELECT id, email, phone, code, expires_at, used, attempts, type, created_at
 		FROM %s
-		WHERE expires_at >= NOW() AND used = FALSE AND %%s
+		WHERE expires_at >= NOW() AND used = FALSE AND type = $2 AND %%s
 		ORDER BY created_at DESC
 		LIMIT 1
 	`, r.tableName)
@@ -68,11 +70,12 @@ func (r *AuthCodeRepository) GetActiveCode(ctx context.Context, email, phone str
 		condition = "phone = $1"
 		args = append(args, phone)
 	}
+	args = append(args, codeType)
 
 	finalQuery := fmt.Sprintf(query, condition)
 
 	var code AuthCode
-	err := r.store.Db.GetContext(ctx, &code, finalQuery, args...)
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &code, finalQuery, args...)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, store.ErrNotFound
@@ -83,17 +86,19 @@ func (r *AuthCodeRepository) GetActiveCode(ctx context.Context, email, phone str
 	return &code, nil
 }
 
-func (r *AuthCodeRepository) SaveCode(ctx context.Context, email, phone, code string, expiresAt time.Time) error {
+// SaveCode сохраняет хэш кода
+func (r *AuthCodeRepository) SaveCode(ctx context.Context, email, phone, codeType, codeHash string, expiresAt time.Time) error {
 	query := fmt.Sprintf(`
-		INSERT INTO %s (email, phone, code, expires_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO %s (email, phone, code, expires_at, type)
+		VALUES ($1, $2, $3, $4, $5)
 	`, r.tableName)
 
-	_, err := r.store.Db.ExecContext(ctx, query,
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query,
 		email,
 		phone,
-		code,
+		codeHash,
 		expiresAt,
+		codeType,
 	)
 	if err != nil {
 		return store.ContextError(err)
@@ -101,3 +106,88 @@ func (r *AuthCodeRepository) SaveCode(ctx context.Context, email, phone, code st
 
 	return nil
 }
+
+// InvalidateActive помечает использованными активные коды контакта с тем же назначением
+func (r *AuthCodeRepository) InvalidateActive(ctx context.Context, email, phone, codeType string) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET used = TRUE
+		WHERE used = FALSE AND type = $2 AND %%s
+	`, r.tableName)
+
+	condition := "phone = $1"
+	value := phone
+	if email != "" {
+		condition = "email = $1"
+		value = email
+	}
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, fmt.Sprintf(query, condition), value, codeType)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// SaveDelivery учитывает отправку, которая не является кодом входа (например, восстановление пароля),
+// запись сразу помечена использованной и участвует только в суточном лимите отправок
+func (r *AuthCodeRepository) SaveDelivery(ctx context.Context, email, phone, codeType string, expiresAt time.Time) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (email, phone, code, expires_at, used, type)
+		VALUES ($1, $2, '', $3, TRUE, $4)
+	`, r.tableName)
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query, email, phone, expiresAt, codeType)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// ReserveAttempt атомарно увеличивает счётчик попыток ввода кода,
+// если лимит уже исчерпан или код использован — возвращает store.ErrNotFound
+func (r *AuthCodeRepository) ReserveAttempt(ctx context.Context, id int, maxAttempts int) (int, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET attempts = attempts + 1
+		WHERE id = $1 AND used = FALSE AND attempts < $2
+		RETURNING attempts
+	`, r.tableName)
+
+	var attempts int
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query, id, maxAttempts).Scan(&attempts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, store.ErrNotFound
+		}
+		return 0, store.ContextError(err)
+	}
+
+	return attempts, nil
+}
+
+// MarkUsed помечает код использованным, повторная отметка возвращает store.ErrNotFound
+func (r *AuthCodeRepository) MarkUsed(ctx context.Context, id int) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET used = TRUE
+		WHERE id = $1 AND used = FALSE
+	`, r.tableName)
+
+	result, err := r.store.Conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if rows == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
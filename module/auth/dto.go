@@ -1,30 +1,134 @@
 package auth
 
+import (
+	"go-monolite/module/company"
+	"go-monolite/module/user"
+	"go-monolite/pkg/validator"
+)
+
+// SendCodeRequest — DTO для POST /auth/sendCode, по умолчанию код для входа.
+// contact_change — код на новый email/телефон для PATCH /user/me
 type SendCodeRequest struct {
-	Email string `json:"email,omitempty"` // обязательно email или phone
-	Phone string `json:"phone,omitempty"`
-	Type  string `json:"type,omitempty"`
+	Email string `json:"email,omitempty" validate:"required_without=Phone,omitempty,email"` // обязательно email или phone
+	Phone string `json:"phone,omitempty" validate:"required_without=Email,omitempty,phone"`
+	Type  string `json:"type,omitempty" validate:"omitempty,oneof=login registration contact_change"`
+}
+
+func (d *SendCodeRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+func (d *SendCodeRequest) CodeType() string {
+	return codeTypeOrDefault(d.Type)
+}
+
+// VerifyCodeRequest — DTO для POST /auth/verifyCode, тип должен совпадать с типом отправленного кода
+type VerifyCodeRequest struct {
+	Email string `json:"email,omitempty" validate:"required_without=Phone,omitempty,email"` // обязательно email или phone
+	Phone string `json:"phone,omitempty" validate:"required_without=Email,omitempty,phone"`
+	Code  string `json:"code" validate:"required,numeric"`
+	Type  string `json:"type,omitempty" validate:"omitempty,oneof=login registration"`
+}
+
+func (d *VerifyCodeRequest) CodeType() string {
+	return codeTypeOrDefault(d.Type)
 }
 
-// ------------------------------------------
-// RegistrationRequest — DTO для POST /auth/register
+func codeTypeOrDefault(codeType string) string {
+	if codeType == "" {
+		return CodeTypeLogin
+	}
+	return codeType
+}
+
+// RegistrationRequest — DTO для POST /auth/register. Юридическое лицо регистрирует компанию по ИНН
+// и становится её владельцем, в уже зарегистрированную компанию добавляет владелец
 type RegistrationRequest struct {
-	Email      *string `json:"email,omitempty"` // обязательно email или phone
-	Phone      *string `json:"phone,omitempty"`
-	Password   string  `json:"password" validate:"required,min=6"`
+	Email      string  `json:"email,omitempty" validate:"required_without=Phone,omitempty,email"` // обязательно email или phone
+	Phone      string  `json:"phone,omitempty" validate:"required_without=Email,omitempty,phone"`
+	Password   string  `json:"password" validate:"required,min=8,max=72,auth_password"`
 	Name       *string `json:"name,omitempty"`
 	LastName   *string `json:"last_name,omitempty"`
 	SecondName *string `json:"second_name,omitempty"`
 	CityID     *int64  `json:"city_id,omitempty"`
 	UserType   string  `json:"user_type" validate:"required,oneof=individual legal"`
-	INN        *string `json:"inn,omitempty"`
+
+	INN         *string `json:"inn,omitempty" validate:"required_if=UserType legal,excluded_unless=UserType legal,omitempty,inn"`
+	KPP         *string `json:"kpp,omitempty" validate:"excluded_unless=UserType legal,omitempty,kpp"`
+	CompanyName string  `json:"company_name,omitempty" validate:"required_if=UserType legal,excluded_unless=UserType legal,max=255"`
+}
+
+func (d *RegistrationRequest) Validate() error {
+	if err := validator.Validate(d); err != nil {
+		return err
+	}
+	if d.UserType == string(user.UserTypeLegal) {
+		companyReq := d.CompanyRequest()
+		return companyReq.Validate()
+	}
+	return nil
+}
+
+// CompanyRequest — данные компании юридического лица
+func (d *RegistrationRequest) CompanyRequest() company.CreateRequest {
+	req := company.CreateRequest{KPP: d.KPP, CompanyName: d.CompanyName}
+	if d.INN != nil {
+		req.INN = *d.INN
+	}
+	return req
 }
 
 // LoginRequest — DTO для POST /auth/login
 type LoginRequest struct {
-	Email    *string `json:"email,omitempty"`
-	Phone    *string `json:"phone,omitempty"`
-	Password string  `json:"password" validate:"required"`
+	Email    string `json:"email,omitempty" validate:"required_without=Phone,omitempty,email"` // обязательно email или phone
+	Phone    string `json:"phone,omitempty" validate:"required_without=Email,omitempty,phone"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+func (d *LoginRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+func (d *VerifyCodeRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// PasswordResetRequest — DTO для POST /auth/forgotPassword
+type PasswordResetRequest struct {
+	Email string `json:"email,omitempty" validate:"required_without=Phone,omitempty,email"` // обязательно email или phone
+	Phone string `json:"phone,omitempty" validate:"required_without=Email,omitempty,phone"`
+}
+
+func (d *PasswordResetRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// PasswordResetConfirmRequest — DTO для POST /auth/resetPassword
+type PasswordResetConfirmRequest struct {
+	Email     string `json:"email,omitempty" validate:"required_without=Phone,omitempty,email"` // обязательно email или phone
+	Phone     string `json:"phone,omitempty" validate:"required_without=Email,omitempty,phone"`
+	Checkword string `json:"checkword" validate:"required,max=64"`
+	Password  string `json:"password" validate:"required,min=8,max=72,auth_password"`
+}
+
+func (d *PasswordResetConfirmRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// RefreshRequest — DTO для POST /auth/refresh, /auth/logout, /auth/logoutAll
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func (d *RefreshRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// RegistrationResponse — Ответ на регистрацию, токены выдаются сразу, если подтверждение контакта не требуется
+type RegistrationResponse struct {
+	UserID               int64         `json:"user_id"`
+	VerificationRequired bool          `json:"verification_required"`
+	Tokens               *AuthResponse `json:"tokens,omitempty"`
 }
 
 // AuthResponse — Ответ на успешный логин/регистрацию
@@ -1,13 +1,38 @@
 package auth
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// назначение отправленного кода
+const (
+	CodeTypeLogin         = "login"
+	CodeTypeRegistration  = "registration"
+	CodeTypePasswordReset = "reset"
+	CodeTypeContactChange = "contact_change" // подтверждение нового email/телефона в профиле
+)
 
 type AuthCode struct {
 	ID        int       `db:"id"`
 	Email     string    `db:"email"`
 	Phone     string    `db:"phone"`
-	Code      string    `db:"code"`
+	Code      string    `db:"code"` // HMAC-SHA256 кода
 	ExpiresAt time.Time `db:"expires_at"`
 	Used      bool      `db:"used"`
+	Attempts  int       `db:"attempts"`
+	Type      string    `db:"type"`
 	CreatedAt time.Time `db:"created_at"`
-}
\ No newline at end of file
+}
+
+type UserTokenEnt struct {
+	ID           int64     `db:"id"`
+	RefreshToken string    `db:"refresh_token"` // sha256 от выданного токена
+	FamilyID     uuid.UUID `db:"family_id"`
+	UserID       int64     `db:"user_id"`
+	DeviceID     string    `db:"device_id"`
+	ExpiresAt    time.Time `db:"expires_at"`
+	CreatedAt    time.Time `db:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
@@ -0,0 +1,20 @@
+package auth
+
+import "errors"
+
+var (
+	ErrCodeNotFound       = errors.New("code not found or expired")
+	ErrInvalidCode        = errors.New("invalid code")
+	ErrTooManyAttempts    = errors.New("too many attempts")
+	ErrUserBlocked        = errors.New("user is blocked")
+	ErrDailyLimitExceeded = errors.New("maximum number of attempts reached, try again later")
+
+	ErrUserExists         = errors.New("user already exists")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrLoginLocked        = errors.New("too many failed login attempts")
+	ErrNotVerified        = errors.New("contact is not verified")
+	ErrInvalidCheckword   = errors.New("invalid or expired checkword")
+
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
@@ -1,36 +1,71 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"go-monolite/internal/config"
 	"go-monolite/internal/store"
+	"go-monolite/module/company"
+	"go-monolite/module/notification"
 	"go-monolite/module/user"
 	"go-monolite/pkg/helper"
 	"go-monolite/pkg/logger"
 	"go-monolite/pkg/respond"
+	"go-monolite/pkg/token"
 	"go-monolite/pkg/validator"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
 )
 
+const (
+	DeviceHeader    = "Device-Uid"
+	defaultDeviceID = "default"
+)
+
 type Handler struct {
 	service *Service
 }
 
-func NewHandler(store *store.Store) *Handler {
+// NewHandler — hooks выполняются после каждого успешного входа, регистрации и входа по коду,
+// tokenManager общий с проверкой токенов в роутере сервера
+func NewHandler(store *store.Store, cfg *config.Config, tokenManager *token.Manager, hooks ...LoginHook) *Handler {
+	service := newService(store, cfg, tokenManager)
+	service.OnLogin(hooks...)
+	return &Handler{service: service}
+}
+
+// NewContactVerifier — проверка кодов смены email/телефона для модуля user
+func NewContactVerifier(store *store.Store, cfg *config.Config, tokenManager *token.Manager) user.ContactVerifier {
+	return newService(store, cfg, tokenManager)
+}
+
+func newService(store *store.Store, cfg *config.Config, tokenManager *token.Manager) *Service {
 	userTokensRepo := NewUserTokensRepository(store)
 	codesRepo := NewAuthCodeRepository(store)
 	userRepo := user.NewRepository(store)
-	service := NewService(userTokensRepo, codesRepo, userRepo)
-	return &Handler{service: service}
+	rolesRepo := user.NewRolesRepository(store)
+	tokenService := NewTokenService(userTokensRepo, tokenManager, time.Duration(cfg.Auth.RefreshTokenTTL)*time.Second)
+	loginAttemptsRepo := NewLoginAttemptsRepository(store)
+	companyRepo := company.NewRepository(store)
+	notifications := notification.NewService(notification.NewRepository(store), notification.NewTemplates(notification.NewTemplateRepository(store)), cfg.Notification)
+	return NewService(store, userTokensRepo, codesRepo, loginAttemptsRepo, userRepo, rolesRepo, companyRepo, tokenService, notifications, cfg.Auth)
 }
 
 func (h *Handler) Init(r chi.Router) {
 	r.Post("/sendCode", h.SendCode)
-	// r.Post("/login", h.Login)
+	r.Post("/verifyCode", h.VerifyCode)
+	r.Post("/register", h.Register)
+	r.Post("/login", h.Login)
+	r.Post("/forgotPassword", h.ForgotPassword)
+	r.Post("/resetPassword", h.ResetPassword)
 	// r.Post("/againSendCode", h.AgainSendCode)
 
-	// r.Post("/logout", h.Logout)
-	// r.Post("/refresh", h.Refresh)
+	r.Post("/refresh", h.Refresh)
+	r.Post("/logout", h.Logout)
+	r.Post("/logoutAll", h.LogoutAll)
 }
 
 // принимает email/phone, отправляет код.
@@ -51,6 +86,10 @@ func (h *Handler) SendCode(w http.ResponseWriter, r *http.Request) {
 			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
 			return
 		}
+		if errors.Is(err, ErrDailyLimitExceeded) {
+			respond.ErrorHandler(w, r, http.StatusTooManyRequests, err, "превышен лимит отправок, попробуйте позже")
+			return
+		}
 		logger.ErrorCtx(r.Context(), err, mess)
 		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
 		return
@@ -59,116 +98,341 @@ func (h *Handler) SendCode(w http.ResponseWriter, r *http.Request) {
 	respond.SuccessHandler(w, r, http.StatusCreated, "", "")
 }
 
-// принимает email/phone + код, создаёт пользователя.
-// func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
-// 	body := respond.ParseBody(w, r)
-
-// 	var registrationRequests RegistrationRequest
-
-// 	mess, err := helper.Unmarshal(body, &registrationRequests)
-// 	if err != nil {
-// 		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
-// 		return
-// 	}
-
-// 	registrationResponse, mess, err := h.service.Register(r.Context(), registrationRequests)
-// 	if err != nil {
-// 		if validationErrors, ok := err.(validator.ValidationError); ok {
-// 			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
-// 			return
-// 		}
-// 		logger.ErrorCtx(r.Context(), err, mess)
-// 		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
-// 		return
-// 	}
-
-// 	respond.SuccessHandler(w, r, http.StatusCreated, mess, registrationResponse)
-// }
-
-// func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
-// 	body := respond.ParseBody(w, r)
-
-// 	var categoryRequests LoginRequest
-
-//		mess, err := helper.Unmarshal(body, &categoryRequests)
-//		if err != nil {
-//			respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
-//			return
-//		}
-//		respond.SuccessHandler(w, r, http.StatusCreated, mess, "")
-//	}
-//
-//	func (h *Handler) AgainSendCode(w http.ResponseWriter, r *http.Request) {
-//		return
-//	}
-//
-//	func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
-//		return
-//	}
+// @Summary Verify code
+// @Description Check the one-time code and log in, a new user is created if none exists
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param Device-Uid header string false "Device identifier"
+// @Param request body VerifyCodeRequest true "Email or phone with code"
+// @Success 200 {object} respond.SuccessResponse{data=AuthResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 429 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /verifyCode [post]
+func (h *Handler) VerifyCode(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request VerifyCodeRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.VerifyCode(r.Context(), request, deviceID(r))
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		switch {
+		case errors.Is(err, ErrCodeNotFound), errors.Is(err, ErrInvalidCode):
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, mess)
+			return
+		case errors.Is(err, ErrTooManyAttempts):
+			respond.ErrorHandler(w, r, http.StatusTooManyRequests, err, mess)
+			return
+		case errors.Is(err, ErrUserBlocked):
+			respond.ErrorHandler(w, r, http.StatusForbidden, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func deviceID(r *http.Request) string {
+	if id := r.Header.Get(DeviceHeader); id != "" {
+		return id
+	}
+	return defaultDeviceID
+}
+
+// @Summary Register
+// @Description Register a user by email or phone with a password
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param Device-Uid header string false "Device identifier"
+// @Param request body RegistrationRequest true "Registration data"
+// @Success 201 {object} respond.SuccessResponse{data=RegistrationResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /register [post]
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request RegistrationRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.Register(r.Context(), request, deviceID(r))
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		if errors.Is(err, ErrUserExists) || errors.Is(err, company.ErrCompanyExists) {
+			respond.ErrorHandler(w, r, http.StatusConflict, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusCreated, mess, resp)
+}
+
+// @Summary Login
+// @Description Log in by email or phone with a password
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param Device-Uid header string false "Device identifier"
+// @Param request body LoginRequest true "Credentials"
+// @Success 200 {object} respond.SuccessResponse{data=AuthResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 429 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /login [post]
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request LoginRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.Login(r.Context(), request, deviceID(r), clientIP(r))
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		switch {
+		case errors.Is(err, ErrInvalidCredentials):
+			respond.ErrorHandler(w, r, http.StatusUnauthorized, err, mess)
+			return
+		case errors.Is(err, ErrLoginLocked):
+			respond.ErrorHandler(w, r, http.StatusTooManyRequests, err, mess)
+			return
+		case errors.Is(err, ErrUserBlocked), errors.Is(err, ErrNotVerified):
+			respond.ErrorHandler(w, r, http.StatusForbidden, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Forgot password
+// @Description Send a password reset link to the email or a reset code to the phone
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body PasswordResetRequest true "Email or phone"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 429 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /forgotPassword [post]
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request PasswordResetRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	mess, err = h.service.ForgotPassword(r.Context(), request)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		if errors.Is(err, ErrDailyLimitExceeded) {
+			respond.ErrorHandler(w, r, http.StatusTooManyRequests, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, "")
+}
+
+// @Summary Reset password
+// @Description Set a new password by the checkword, all sessions of the user are ended
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body PasswordResetConfirmRequest true "Checkword and new password"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /resetPassword [post]
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request PasswordResetConfirmRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	mess, err = h.service.ResetPassword(r.Context(), request)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		if errors.Is(err, ErrInvalidCheckword) {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, "")
+}
+
+// @Summary Refresh tokens
+// @Description Rotate the refresh token of the device and issue a new access token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param Device-Uid header string false "Device identifier"
+// @Param request body RefreshRequest true "Refresh token"
+// @Success 200 {object} respond.SuccessResponse{data=AuthResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /refresh [post]
 func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
-	// ctx := r.Context()
-
-	// // 1. Извлечь refresh токен (например, из cookie)
-	// cookie, err := r.Cookie("refresh_token")
-	// if err != nil {
-	// 	http.Error(w, "unauthorized", http.StatusUnauthorized)
-	// 	return
-	// }
-	// refreshToken := cookie.Value
-
-	// // 2. Распарсить и проверить токен
-	// claims, err := token.ParseRefreshToken(refreshToken)
-	// if err != nil {
-	// 	http.Error(w, "invalid refresh token", http.StatusUnauthorized)
-	// 	return
-	// }
-
-	// // 3. Найти сессию в БД по user_id и device_id
-	// session, err := h.sessionRepo.FindByUserAndDevice(ctx, claims.UserID, claims.DeviceID)
-	// if err != nil || session.RefreshToken != refreshToken {
-	// 	http.Error(w, "invalid session", http.StatusUnauthorized)
-	// 	return
-	// }
-
-	// // 4. Проверка срока жизни и token_version
-	// if time.Now().After(session.ExpiresAt) || claims.TokenVersion != session.TokenVersion {
-	// 	http.Error(w, "token expired or invalid", http.StatusUnauthorized)
-	// 	return
-	// }
-
-	// // 5. Создать новый access + refresh токены
-	// newAccessToken, err := token.GenerateAccessToken(claims.UserID)
-	// if err != nil {
-	// 	http.Error(w, "failed to create access token", http.StatusInternalServerError)
-	// 	return
-	// }
-
-	// newRefreshToken, err := token.GenerateRefreshToken(claims.UserID, claims.DeviceID, claims.TokenVersion)
-	// if err != nil {
-	// 	http.Error(w, "failed to create refresh token", http.StatusInternalServerError)
-	// 	return
-	// }
-
-	// // 6. Обновить refresh токен в БД (если хранишь как opaque/hard-match)
-	// err = h.sessionRepo.UpdateRefreshToken(ctx, claims.UserID, claims.DeviceID, newRefreshToken)
-	// if err != nil {
-	// 	http.Error(w, "failed to update session", http.StatusInternalServerError)
-	// 	return
-	// }
-
-	// // 7. Установить новый refresh в cookie
-	// http.SetCookie(w, &http.Cookie{
-	// 	Name:     "refresh_token",
-	// 	Value:    newRefreshToken,
-	// 	HttpOnly: true,
-	// 	Path:     "/",
-	// 	MaxAge:   60 * 60 * 24 * 30, // 30 дней
-	// })
-
-	// // 8. Вернуть access токен
-	// resp := map[string]interface{}{
-	// 	"access_token": newAccessToken,
-	// 	"expires_in":   3600,
-	// }
-	// respond.SuccessHandler(w, r, http.StatusCreated, "", resp)
+	body := respond.ParseBody(w, r)
+
+	var request RefreshRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.Refresh(r.Context(), request, deviceID(r))
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		switch {
+		case errors.Is(err, ErrInvalidRefreshToken), errors.Is(err, ErrRefreshTokenReused):
+			respond.ErrorHandler(w, r, http.StatusUnauthorized, err, mess)
+			return
+		case errors.Is(err, ErrUserBlocked):
+			respond.ErrorHandler(w, r, http.StatusForbidden, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Logout
+// @Description End the session of the device the refresh token belongs to
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "Refresh token"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /logout [post]
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	h.logout(w, r, h.service.Logout)
+}
+
+// @Summary Logout from all devices
+// @Description End every session of the user the refresh token belongs to
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "Refresh token"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /logoutAll [post]
+func (h *Handler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	h.logout(w, r, h.service.LogoutAll)
+}
+
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request, fn func(context.Context, RefreshRequest) (string, error)) {
+	body := respond.ParseBody(w, r)
+
+	var request RefreshRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	mess, err = fn(r.Context(), request)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		if errors.Is(err, ErrInvalidRefreshToken) {
+			respond.ErrorHandler(w, r, http.StatusUnauthorized, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, "")
 }
@@ -0,0 +1,300 @@
+package auth_test
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/internal/infra/sender"
+	"go-monolite/module/auth"
+	"go-monolite/module/notification"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	access := testinit.NewAuth(store, config)
+	handler := auth.NewHandler(store, config, access.TokenManager)
+	server := testinit.SetupTestServer(t, handler)
+	defer server.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	const phone = "79990000001"
+
+	// коды доставляет диспетчер outbox, в тестовой конфигурации SMS сохраняются в sender.Memory
+	dispatcher := notification.NewDispatcher(notification.NewRepository(store), sender.MustNew(config.Sender), config.Notification)
+	lastCode := func(t *testing.T, phone string) string {
+		t.Helper()
+		_, err := dispatcher.Dispatch(context.Background())
+		require.NoError(t, err)
+		msg, ok := sender.Memory.Last(phone)
+		require.True(t, ok, "sms for %s was not sent", phone)
+		return msg.Body[strings.LastIndex(msg.Body, " ")+1:]
+	}
+
+	t.Run("Verify Code Validation Error", func(t *testing.T) {
+		resp := testinit.SendRequest(t, server.URL+"/verifyCode", "POST", `{"phone": "79990000001"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Verify Code Login", func(t *testing.T) {
+		resp := testinit.SendRequest(t, server.URL+"/sendCode", "POST", fmt.Sprintf(`{"phone": "%s"}`, phone))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		code := lastCode(t, phone)
+
+		resp = testinit.SendRequest(t, server.URL+"/verifyCode", "POST", fmt.Sprintf(`{"phone": "%s", "code": "%s"}`, phone, code))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+
+		var authResp auth.AuthResponse
+		testinit.MarshalUnmarshal(t, response.Data, &authResp)
+
+		assert.NotEmpty(t, authResp.AccessToken)
+		assert.NotEmpty(t, authResp.RefreshToken)
+		assert.Positive(t, authResp.ExpiresIn)
+
+		respReused := testinit.SendRequest(t, server.URL+"/verifyCode", "POST", fmt.Sprintf(`{"phone": "%s", "code": "%s"}`, phone, code))
+		assert.Equal(t, http.StatusBadRequest, respReused.StatusCode)
+	})
+
+	t.Run("Verify Code Lockout", func(t *testing.T) {
+		const lockPhone = "79990000002"
+
+		resp := testinit.SendRequest(t, server.URL+"/sendCode", "POST", fmt.Sprintf(`{"phone": "%s"}`, lockPhone))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		wrong := "000000"
+		require.NotEqual(t, wrong, lastCode(t, lockPhone))
+
+		body := fmt.Sprintf(`{"phone": "%s", "code": "%s"}`, lockPhone, wrong)
+		for i := 1; i < config.Auth.CodeMaxAttempts; i++ {
+			resp := testinit.SendRequest(t, server.URL+"/verifyCode", "POST", body)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		}
+
+		resp = testinit.SendRequest(t, server.URL+"/verifyCode", "POST", body)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	})
+
+	t.Run("Verify Code Type Mismatch", func(t *testing.T) {
+		const typePhone = "79990000005"
+
+		resp := testinit.SendRequest(t, server.URL+"/sendCode", "POST", fmt.Sprintf(`{"phone": "%s", "type": "registration"}`, typePhone))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		code := lastCode(t, typePhone)
+
+		// код регистрации нельзя использовать для входа
+		resp = testinit.SendRequest(t, server.URL+"/verifyCode", "POST", fmt.Sprintf(`{"phone": "%s", "code": "%s"}`, typePhone, code))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/verifyCode", "POST", fmt.Sprintf(`{"phone": "%s", "code": "%s", "type": "registration"}`, typePhone, code))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Refresh Rotation And Reuse", func(t *testing.T) {
+		const refreshPhone = "79990000003"
+
+		resp := testinit.SendRequest(t, server.URL+"/sendCode", "POST", fmt.Sprintf(`{"phone": "%s"}`, refreshPhone))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/verifyCode", "POST", fmt.Sprintf(`{"phone": "%s", "code": "%s"}`, refreshPhone, lastCode(t, refreshPhone)))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		decodeAuth := func(t *testing.T, resp *http.Response) auth.AuthResponse {
+			t.Helper()
+			var response respond.Response
+			testinit.DecodeJSON(t, resp.Body, &response)
+			var authResp auth.AuthResponse
+			testinit.MarshalUnmarshal(t, response.Data, &authResp)
+			return authResp
+		}
+
+		first := decodeAuth(t, resp)
+
+		resp = testinit.SendRequest(t, server.URL+"/refresh", "POST", fmt.Sprintf(`{"refresh_token": "%s"}`, first.RefreshToken))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		second := decodeAuth(t, resp)
+		assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+		rotations := func(t *testing.T) int {
+			t.Helper()
+			var count int
+			err := store.Db.Get(&count, `SELECT COUNT(*) FROM user_token_rotations r JOIN users u ON u.id = r.user_id WHERE u.phone = $1`, refreshPhone)
+			require.NoError(t, err)
+			return count
+		}
+		assert.Equal(t, 1, rotations(t))
+
+		// записи о токенах, истёкших по сроку жизни, удаляются при следующей ротации
+		_, err := store.Db.Exec(`
+			INSERT INTO user_token_rotations (token_hash, family_id, user_id, device_id, rotated_at)
+			SELECT 'expired', gen_random_uuid(), id, '', now() - $2 * interval '2 second' FROM users WHERE phone = $1
+		`, refreshPhone, config.Auth.RefreshTokenTTL)
+		require.NoError(t, err)
+
+		resp = testinit.SendRequest(t, server.URL+"/refresh", "POST", fmt.Sprintf(`{"refresh_token": "%s"}`, second.RefreshToken))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		third := decodeAuth(t, resp)
+		assert.Equal(t, 2, rotations(t))
+
+		// повторное использование старого токена отзывает всю цепочку вместе с записями о ротациях
+		resp = testinit.SendRequest(t, server.URL+"/refresh", "POST", fmt.Sprintf(`{"refresh_token": "%s"}`, first.RefreshToken))
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/refresh", "POST", fmt.Sprintf(`{"refresh_token": "%s"}`, third.RefreshToken))
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Zero(t, rotations(t))
+	})
+
+	t.Run("Logout", func(t *testing.T) {
+		resp := testinit.SendRequest(t, server.URL+"/sendCode", "POST", fmt.Sprintf(`{"phone": "%s"}`, phone))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/verifyCode", "POST", fmt.Sprintf(`{"phone": "%s", "code": "%s"}`, phone, lastCode(t, phone)))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		var authResp auth.AuthResponse
+		testinit.MarshalUnmarshal(t, response.Data, &authResp)
+
+		body := fmt.Sprintf(`{"refresh_token": "%s"}`, authResp.RefreshToken)
+
+		resp = testinit.SendRequest(t, server.URL+"/logout", "POST", body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/refresh", "POST", body)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Register And Login", func(t *testing.T) {
+		const email = "register@example.com"
+
+		resp := testinit.SendRequest(t, server.URL+"/register", "POST", fmt.Sprintf(`{"email": "%s", "password": "weak", "user_type": "individual"}`, email))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		body := fmt.Sprintf(`{"email": "%s", "password": "Secret123", "user_type": "individual"}`, email)
+
+		resp = testinit.SendRequest(t, server.URL+"/register", "POST", body)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/register", "POST", body)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/login", "POST", fmt.Sprintf(`{"email": "%s", "password": "Secret123"}`, email))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		var authResp auth.AuthResponse
+		testinit.MarshalUnmarshal(t, response.Data, &authResp)
+		assert.NotEmpty(t, authResp.AccessToken)
+
+		resp = testinit.SendRequest(t, server.URL+"/login", "POST", `{"email": "unknown@example.com", "password": "Secret123"}`)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Register Legal", func(t *testing.T) {
+		// юридическому лицу нужна компания
+		resp := testinit.SendRequest(t, server.URL+"/register", "POST", `{"email": "legal@example.com", "password": "Secret123", "user_type": "legal"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		body := `{"email": "legal@example.com", "password": "Secret123", "user_type": "legal",
+			"inn": "500100732259", "company_name": "ИП Тестов"}`
+		resp = testinit.SendRequest(t, server.URL+"/register", "POST", body)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		// в существующую компанию добавляет владелец
+		body = `{"email": "legal2@example.com", "password": "Secret123", "user_type": "legal",
+			"inn": "500100732259", "company_name": "ИП Тестов"}`
+		resp = testinit.SendRequest(t, server.URL+"/register", "POST", body)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		// неудачная регистрация не оставляет пользователя
+		resp = testinit.SendRequest(t, server.URL+"/login", "POST", `{"email": "legal2@example.com", "password": "Secret123"}`)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Login Lockout", func(t *testing.T) {
+		const email = "lockout@example.com"
+
+		resp := testinit.SendRequest(t, server.URL+"/register", "POST", fmt.Sprintf(`{"email": "%s", "password": "Secret123", "user_type": "individual"}`, email))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		wrong := fmt.Sprintf(`{"email": "%s", "password": "Wrong1234"}`, email)
+		for i := 0; i < config.Auth.LoginMaxAttempts; i++ {
+			resp := testinit.SendRequest(t, server.URL+"/login", "POST", wrong)
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+
+		// после блокировки не принимается даже верный пароль
+		resp = testinit.SendRequest(t, server.URL+"/login", "POST", fmt.Sprintf(`{"email": "%s", "password": "Secret123"}`, email))
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	})
+
+	t.Run("Password Reset", func(t *testing.T) {
+		const resetPhone = "79990000004"
+
+		resp := testinit.SendRequest(t, server.URL+"/register", "POST", fmt.Sprintf(`{"phone": "%s", "password": "Secret123", "user_type": "individual"}`, resetPhone))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/forgotPassword", "POST", fmt.Sprintf(`{"phone": "%s"}`, resetPhone))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		checkword := lastCode(t, resetPhone)
+		msg, _ := sender.Memory.Last(resetPhone)
+		assert.Contains(t, msg.Body, "восстановления пароля")
+
+		// чужая неверная попытка не отменяет восстановление
+		resp = testinit.SendRequest(t, server.URL+"/resetPassword", "POST", fmt.Sprintf(`{"phone": "%s", "checkword": "wrong", "password": "NewSecret123"}`, resetPhone))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/resetPassword", "POST", fmt.Sprintf(`{"phone": "%s", "checkword": "%s", "password": "NewSecret123"}`, resetPhone, checkword))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// контрольное слово одноразовое
+		resp = testinit.SendRequest(t, server.URL+"/resetPassword", "POST", fmt.Sprintf(`{"phone": "%s", "checkword": "%s", "password": "NewSecret123"}`, resetPhone, checkword))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/login", "POST", fmt.Sprintf(`{"phone": "%s", "password": "Secret123"}`, resetPhone))
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/login", "POST", fmt.Sprintf(`{"phone": "%s", "password": "NewSecret123"}`, resetPhone))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Password Reset Attempts Exhausted", func(t *testing.T) {
+		const resetPhone = "79990000005"
+
+		resp := testinit.SendRequest(t, server.URL+"/register", "POST", fmt.Sprintf(`{"phone": "%s", "password": "Secret123", "user_type": "individual"}`, resetPhone))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/forgotPassword", "POST", fmt.Sprintf(`{"phone": "%s"}`, resetPhone))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		checkword := lastCode(t, resetPhone)
+
+		for i := 0; i < config.Auth.CodeMaxAttempts; i++ {
+			resp = testinit.SendRequest(t, server.URL+"/resetPassword", "POST", fmt.Sprintf(`{"phone": "%s", "checkword": "wrong", "password": "NewSecret123"}`, resetPhone))
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		}
+
+		// после исчерпания попыток не принимается даже верное контрольное слово
+		resp = testinit.SendRequest(t, server.URL+"/resetPassword", "POST", fmt.Sprintf(`{"phone": "%s", "checkword": "%s", "password": "NewSecret123"}`, resetPhone, checkword))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
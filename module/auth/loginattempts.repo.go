@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/internal/store"
+	"time"
+)
+
+type LoginAttemptsRepository struct {
+	store     *store.Store
+	tableName string
+}
+
+func NewLoginAttemptsRepository(store *store.Store) *LoginAttemptsRepository {
+	return &LoginAttemptsRepository{
+		store:     store,
+		tableName: "login_attempts",
+	}
+}
+
+func (r *LoginAttemptsRepository) Save(ctx context.Context, identifier, ip string, success bool) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (identifier, ip, success, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, r.tableName)
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query, identifier, ip, success, time.Now())
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// CountFailed считает неудачные попытки по логину и по IP начиная с since
+func (r *LoginAttemptsRepository) CountFailed(ctx context.Context, identifier, ip string, since time.Time) (byIdentifier int, byIP int, err error) {
+	query := fmt.Sprintf(`
+		SELECT
+			COUNT(*) FILTER (WHERE identifier = $1) AS by_identifier,
+			COUNT(*) FILTER (WHERE ip = $2) AS by_ip
+		FROM %s
+		WHERE success = FALSE AND created_at >= $3 AND (identifier = $1 OR ip = $2)
+	`, r.tableName)
+
+	err = r.store.Conn(ctx).QueryRowxContext(ctx, query, identifier, ip, since).Scan(&byIdentifier, &byIP)
+	if err != nil {
+		return 0, 0, store.ContextError(err)
+	}
+
+	return byIdentifier, byIP, nil
+}
+
+// ResetFailed удаляет неудачные попытки по логину после успешного входа
+func (r *LoginAttemptsRepository) ResetFailed(ctx context.Context, identifier string) error {
+	query := fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE identifier = $1 AND success = FALSE
+	`, r.tableName)
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query, identifier)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
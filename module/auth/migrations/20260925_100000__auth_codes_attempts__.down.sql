@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_auth_codes_phone;
+DROP INDEX IF EXISTS idx_auth_codes_email;
+
+ALTER TABLE auth_codes DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,4 @@
+ALTER TABLE auth_codes ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0; -- количество неверных попыток ввода кода
+
+CREATE INDEX IF NOT EXISTS idx_auth_codes_email ON auth_codes (email, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_auth_codes_phone ON auth_codes (phone, created_at DESC);
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS user_token_rotations;
+
+DROP INDEX IF EXISTS user_tokens_family_id_idx;
+DROP INDEX IF EXISTS user_tokens_refresh_token_idx;
+
+ALTER TABLE user_tokens DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE user_tokens DROP COLUMN IF EXISTS family_id;
+
+-- хэши нельзя вернуть в исходные токены, пользователям придётся войти заново
+DELETE FROM user_tokens;
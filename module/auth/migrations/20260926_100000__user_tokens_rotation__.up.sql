@@ -0,0 +1,21 @@
+-- refresh токены храним только в виде sha256, уже выданные токены переводим в хэш
+UPDATE user_tokens SET refresh_token = encode(sha256(convert_to(refresh_token, 'UTF8')), 'hex');
+
+ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS family_id UUID NOT NULL DEFAULT gen_random_uuid(); -- цепочка ротаций одного входа
+ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_tokens_refresh_token_idx ON user_tokens (refresh_token);
+CREATE INDEX IF NOT EXISTS user_tokens_family_id_idx ON user_tokens (family_id);
+
+-- использованные (ротированные) refresh токены, повторное предъявление отзывает всю цепочку
+CREATE TABLE IF NOT EXISTS user_token_rotations (
+  id BIGSERIAL PRIMARY KEY,
+  token_hash VARCHAR(64) NOT NULL UNIQUE,
+  family_id UUID NOT NULL,
+  user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+  device_id VARCHAR(255) NOT NULL,
+  rotated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS user_token_rotations_family_id_idx ON user_token_rotations (family_id);
+CREATE INDEX IF NOT EXISTS user_token_rotations_rotated_at_idx ON user_token_rotations (rotated_at);
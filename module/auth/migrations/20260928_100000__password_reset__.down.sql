@@ -0,0 +1,6 @@
+DELETE FROM auth_codes WHERE type <> 'login';
+ALTER TABLE auth_codes DROP COLUMN IF EXISTS type;
+
+UPDATE users SET checkword = NULL;
+ALTER TABLE users DROP COLUMN IF EXISTS checkword_attempts;
+ALTER TABLE users DROP COLUMN IF EXISTS checkword_expires_at;
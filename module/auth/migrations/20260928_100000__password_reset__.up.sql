@@ -0,0 +1,6 @@
+-- checkword хранится как sha256, действует ограниченное время и только один раз
+ALTER TABLE users ADD COLUMN IF NOT EXISTS checkword_expires_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS checkword_attempts INT NOT NULL DEFAULT 0; -- неверные попытки ввода контрольного слова
+
+-- назначение отправки, отправки восстановления пароля учитываются в общем суточном лимите
+ALTER TABLE auth_codes ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'login';
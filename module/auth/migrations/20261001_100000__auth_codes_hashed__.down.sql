@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS auth_codes_type_idx;
+
+DELETE FROM auth_codes WHERE length(code) > 10;
+ALTER TABLE auth_codes ALTER COLUMN code TYPE VARCHAR(10);
@@ -0,0 +1,5 @@
+-- коды храним как HMAC-SHA256 (hex), уже выданные открытые коды больше не принимаются
+ALTER TABLE auth_codes ALTER COLUMN code TYPE VARCHAR(64);
+UPDATE auth_codes SET used = TRUE WHERE used = FALSE;
+
+CREATE INDEX IF NOT EXISTS auth_codes_type_idx ON auth_codes (type);
@@ -0,0 +1,10 @@
+-- счётчики ограничения запросов для нескольких экземпляров приложения (RATE_LIMIT_BACKEND=postgres)
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits (
+  key VARCHAR(255) NOT NULL,
+  window_start TIMESTAMP NOT NULL,
+  count INT NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP NOT NULL, -- после этого окно не учитывается и удаляется
+  PRIMARY KEY (key, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS rate_limits_expires_at_idx ON rate_limits (expires_at);
@@ -2,122 +2,736 @@ package auth
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"go-monolite/internal/infra/sender"
+	"go-monolite/internal/config"
 	"go-monolite/internal/store"
+	"go-monolite/module/company"
+	"go-monolite/module/notification"
 	"go-monolite/module/user"
 	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/password"
+	"net/url"
 	"time"
 )
 
-const maxDailyAttempts = 5
+const (
+	maxDailyAttempts        = 5
+	passwordResetCodeLength = 8
+)
+
+// LoginHook вызывается после успешного входа пользователя с устройства deviceID,
+// например чтобы перенести гостевую корзину. Ошибка хука не отменяет вход
+type LoginHook func(ctx context.Context, userID int64, deviceID string) error
 
 type Service struct {
-	userTokensRepo *UserTokensRepository
-	codeRepo       *AuthCodeRepository
-	userRepo       *user.Repository
-	sender         *sender.Sender
+	store             *store.Store
+	userTokensRepo    *UserTokensRepository
+	codeRepo          *AuthCodeRepository
+	loginAttemptsRepo *LoginAttemptsRepository
+	userRepo          *user.Repository
+	rolesRepo         *user.RolesRepository
+	companyRepo       *company.Repository
+	tokenService      *TokenService
+	notifications     *notification.Service
+	loginHooks        []LoginHook
+	cfg               config.Auth
 }
 
-func NewService(userTokensRepo *UserTokensRepository, codeRepo *AuthCodeRepository, userRepo *user.Repository) *Service {
-	sender := sender.New()
+func NewService(
+	store *store.Store,
+	userTokensRepo *UserTokensRepository,
+	codeRepo *AuthCodeRepository,
+	loginAttemptsRepo *LoginAttemptsRepository,
+	userRepo *user.Repository,
+	rolesRepo *user.RolesRepository,
+	companyRepo *company.Repository,
+	tokenService *TokenService,
+	notifications *notification.Service,
+	cfg config.Auth,
+) *Service {
 	return &Service{
-		userTokensRepo: userTokensRepo,
-		userRepo:       userRepo,
-		codeRepo:       codeRepo,
-		sender:         sender,
+		store:             store,
+		userTokensRepo:    userTokensRepo,
+		userRepo:          userRepo,
+		rolesRepo:         rolesRepo,
+		companyRepo:       companyRepo,
+		codeRepo:          codeRepo,
+		loginAttemptsRepo: loginAttemptsRepo,
+		tokenService:      tokenService,
+		notifications:     notifications,
+		cfg:               cfg,
 	}
 }
 
-func (s *Service) SendCode(ctx context.Context, req SendCodeRequest) error {
+// OnLogin добавляет хуки, выполняемые после входа
+func (s *Service) OnLogin(hooks ...LoginHook) {
+	s.loginHooks = append(s.loginHooks, hooks...)
+}
 
-	//  валидация
+// afterLogin выполняет хуки входа, ошибки только логируются: токены уже выданы
+func (s *Service) afterLogin(ctx context.Context, userID int64, deviceID string) {
+	for _, hook := range s.loginHooks {
+		if err := hook(ctx, userID, deviceID); err != nil {
+			logger.ErrorCtx(ctx, err, "login hook failed", "user_id", userID)
+		}
+	}
+}
+
+// SendCode ставит одноразовый код в очередь отправки, предыдущие активные коды того же назначения перестают действовать.
+// В БД хранится только ключевой хэш кода
+func (s *Service) SendCode(ctx context.Context, req SendCodeRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
 
-	// 2. Проверка лимита попыток
 	count, err := s.codeRepo.CountCodesLast24Hours(ctx, req.Email, req.Phone)
 	if err != nil {
 		return fmt.Errorf("failed to count sent codes: %w", err)
 	}
 	if count >= maxDailyAttempts {
-		return fmt.Errorf("maximum number of attempts reached, try again later")
+		return ErrDailyLimitExceeded
 	}
 
-	// 3. Повторно отправляем или создаём новый код
-	existingCode, err := s.codeRepo.GetActiveCode(ctx, req.Email, req.Phone)
-	if err != nil && !errors.Is(err, store.ErrNotFound) {
-		return fmt.Errorf("get active code error: %w", err)
+	code, err := helper.GenerateCode(s.cfg.CodeLength)
+	if err != nil {
+		return err
 	}
+	expiresAt := time.Now().Add(time.Duration(s.cfg.CodeTTL) * time.Second)
 
-	var code string
-	if existingCode != nil {
-		code = existingCode.Code
-	} else {
-		code = helper.GenerateCode(4)
-		expiry := time.Now().Add(5 * time.Minute)
-		if err := s.codeRepo.SaveCode(ctx, req.Email, req.Phone, code, expiry); err != nil {
+	// код сохраняется вместе с сообщением в outbox, доставляет его диспетчер уведомлений
+	return s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.codeRepo.InvalidateActive(ctx, req.Email, req.Phone, req.CodeType()); err != nil {
+			return fmt.Errorf("failed to invalidate codes: %w", err)
+		}
+		codeHash := s.hashCode(code)
+		if err := s.codeRepo.SaveCode(ctx, req.Email, req.Phone, req.CodeType(), codeHash, expiresAt); err != nil {
 			return fmt.Errorf("failed to save code: %w", err)
 		}
+		key := fmt.Sprintf("%s:%s:%d", req.CodeType(), codeHash, expiresAt.UnixNano())
+		return s.notifications.Notify(ctx, notification.CodeMessage(req.Email, req.Phone, code, key, expiresAt))
+	})
+}
+
+// VerifyCode проверяет последний активный код и выполняет вход,
+// если пользователя с таким email/телефоном ещё нет — создаёт его
+func (s *Service) VerifyCode(ctx context.Context, req VerifyCodeRequest, deviceID string) (*AuthResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	code, mess, err := s.checkCode(ctx, req.Email, req.Phone, req.CodeType(), req.Code)
+	if err != nil {
+		return nil, mess, err
+	}
+
+	var (
+		resp   *AuthResponse
+		userID int64
+	)
+	err = s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.codeRepo.MarkUsed(ctx, code.ID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return ErrCodeNotFound
+			}
+			return fmt.Errorf("failed to mark code used: %w", err)
+		}
+
+		u, err := s.findOrCreateUser(ctx, req.Email, req.Phone)
+		if err != nil {
+			return err
+		}
+		if u.Active != user.ActiveYes {
+			return ErrUserBlocked
+		}
+
+		// код пришёл на email/телефон — контакт подтверждён
+		if err := s.markContactVerified(ctx, u, req.Email); err != nil {
+			return err
+		}
+
+		userID = u.ID
+		resp, err = s.tokenService.Issue(ctx, u.ID, u.CurrentTokenVersion(), deviceID)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrCodeNotFound):
+			return nil, "код уже использован, запросите новый", err
+		case errors.Is(err, ErrUserBlocked):
+			return nil, "пользователь заблокирован", err
+		}
+		return nil, "произошла ошибка при входе", err
+	}
+
+	s.afterLogin(ctx, userID, deviceID)
+	return resp, "", nil
+}
+
+// Refresh ротирует refresh токен устройства. Повторное предъявление уже ротированного
+// токена означает его утечку — отзываем всю цепочку, к которой он относится
+func (s *Service) Refresh(ctx context.Context, req RefreshRequest, deviceID string) (*AuthResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	tokenHash := hashToken(req.RefreshToken)
+
+	var (
+		resp          *AuthResponse
+		reuseDetected bool
+	)
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		session, err := s.userTokensRepo.GetByTokenHashForUpdate(ctx, tokenHash)
+		if err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				return fmt.Errorf("failed to get session: %w", err)
+			}
+
+			familyID, err := s.userTokensRepo.GetRotatedFamily(ctx, tokenHash)
+			if err != nil {
+				if errors.Is(err, store.ErrNotFound) {
+					return ErrInvalidRefreshToken
+				}
+				return fmt.Errorf("failed to get rotation: %w", err)
+			}
+
+			reuseDetected = true
+			return s.userTokensRepo.DeleteByFamily(ctx, familyID)
+		}
+
+		if session.DeviceID != deviceID || time.Now().After(session.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		u, err := s.userRepo.GetByID(ctx, session.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if u.Active != user.ActiveYes {
+			return ErrUserBlocked
+		}
+
+		resp, err = s.tokenService.Rotate(ctx, session, u.CurrentTokenVersion())
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidRefreshToken):
+			return nil, "refresh токен недействителен", err
+		case errors.Is(err, ErrUserBlocked):
+			return nil, "пользователь заблокирован", err
+		}
+		return nil, "произошла ошибка при обновлении токена", err
+	}
+	if reuseDetected {
+		return nil, "refresh токен уже использован, требуется повторный вход", ErrRefreshTokenReused
+	}
+
+	return resp, "", nil
+}
+
+// Logout завершает сессию устройства, которому выдан refresh токен
+func (s *Service) Logout(ctx context.Context, req RefreshRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		session, err := s.userTokensRepo.GetByTokenHashForUpdate(ctx, hashToken(req.RefreshToken))
+		if err != nil {
+			return err
+		}
+		return s.userTokensRepo.DeleteByFamily(ctx, session.FamilyID)
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "refresh токен недействителен", ErrInvalidRefreshToken
+		}
+		return "произошла ошибка при выходе", err
+	}
+
+	return "успешно вышли", nil
+}
+
+// LogoutAll завершает все сессии пользователя на всех устройствах
+// и отзывает уже выданные access токены сменой token_version
+func (s *Service) LogoutAll(ctx context.Context, req RefreshRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		session, err := s.userTokensRepo.GetByTokenHashForUpdate(ctx, hashToken(req.RefreshToken))
+		if err != nil {
+			return err
+		}
+		if err := s.userTokensRepo.DeleteByUser(ctx, session.UserID); err != nil {
+			return err
+		}
+		return s.userRepo.IncrementTokenVersion(ctx, session.UserID)
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "refresh токен недействителен", ErrInvalidRefreshToken
+		}
+		return "произошла ошибка при выходе", err
+	}
+
+	return "успешно вышли со всех устройств", nil
+}
+
+// Register регистрирует пользователя по email или телефону с паролем. Если требуется
+// подтверждение контакта, токены не выдаются, а на контакт отправляется код
+func (s *Service) Register(ctx context.Context, req RegistrationRequest, deviceID string) (*RegistrationResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	passwordHash, err := password.Hash(req.Password)
+	if err != nil {
+		return nil, "произошла ошибка при регистрации", err
+	}
+
+	var resp RegistrationResponse
+	err = s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.ensureUserNotExists(ctx, req.Email, req.Phone); err != nil {
+			return err
+		}
+
+		tv := user.InitialTokenVersion
+		u := &user.UserEnt{
+			Email:        nullableString(req.Email),
+			Phone:        nullableString(req.Phone),
+			Name:         req.Name,
+			LastName:     req.LastName,
+			SecondName:   req.SecondName,
+			CityID:       req.CityID,
+			UserType:     user.UserTypeIndividual,
+			Active:       user.ActiveYes,
+			PasswordHash: passwordHash,
+			TokenVersion: &tv,
+		}
+		if err := s.createUser(ctx, u); err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				return ErrUserExists
+			}
+			return err
+		}
+		resp.UserID = u.ID
+
+		if req.UserType == string(user.UserTypeLegal) {
+			if err := s.createCompany(ctx, req.CompanyRequest(), u.ID); err != nil {
+				return err
+			}
+		}
+
+		if s.cfg.RequireVerification {
+			resp.VerificationRequired = true
+			return nil
+		}
+
+		resp.Tokens, err = s.tokenService.Issue(ctx, u.ID, u.CurrentTokenVersion(), deviceID)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserExists):
+			return nil, "пользователь с таким email или телефоном уже существует", err
+		case errors.Is(err, company.ErrCompanyExists):
+			return nil, "компания уже зарегистрирована, попросите её владельца добавить вас", err
+		}
+		return nil, "произошла ошибка при регистрации", err
+	}
+
+	if resp.VerificationRequired {
+		sendReq := SendCodeRequest{Email: req.Email, Type: CodeTypeRegistration}
+		if req.Email == "" {
+			sendReq.Phone = req.Phone
+		}
+		// пользователь уже создан, код можно запросить повторно через sendCode
+		if err := s.SendCode(ctx, sendReq); err != nil {
+			logger.ErrorCtx(ctx, err, "failed to send verification code", "user_id", resp.UserID)
+			return &resp, "пользователь зарегистрирован, запросите код подтверждения повторно", nil
+		}
+		return &resp, "пользователь зарегистрирован, код подтверждения отправлен", nil
+	}
+
+	s.afterLogin(ctx, resp.UserID, deviceID)
+	return &resp, "пользователь зарегистрирован", nil
+}
+
+// Login выполняет вход по паролю. Время ответа не зависит от существования пользователя,
+// неудачные попытки ограничиваются по логину и по IP
+func (s *Service) Login(ctx context.Context, req LoginRequest, deviceID, ip string) (*AuthResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	identifier := req.Email
+	if identifier == "" {
+		identifier = req.Phone
+	}
+
+	since := time.Now().Add(-time.Duration(s.cfg.LoginLockWindow) * time.Second)
+	failedByIdentifier, failedByIP, err := s.loginAttemptsRepo.CountFailed(ctx, identifier, ip, since)
+	if err != nil {
+		return nil, "произошла ошибка при входе", err
+	}
+	if failedByIdentifier >= s.cfg.LoginMaxAttempts || failedByIP >= s.cfg.LoginMaxAttemptsPerIP {
+		return nil, "слишком много неудачных попыток входа, попробуйте позже", ErrLoginLocked
+	}
+
+	u, err := s.findUser(ctx, req.Email, req.Phone)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, "произошла ошибка при входе", err
+	}
+
+	var passwordHash string
+	if u != nil {
+		passwordHash = u.PasswordHash
+	}
+	if !password.Compare(passwordHash, req.Password) {
+		if err := s.loginAttemptsRepo.Save(ctx, identifier, ip, false); err != nil {
+			return nil, "произошла ошибка при входе", err
+		}
+		return nil, "неверный логин или пароль", ErrInvalidCredentials
+	}
+
+	if u.Active != user.ActiveYes {
+		return nil, "пользователь заблокирован", ErrUserBlocked
+	}
+	if s.cfg.RequireVerification && !contactVerified(u, req.Email) {
+		return nil, "email или телефон не подтверждён, войдите по коду", ErrNotVerified
+	}
+
+	var resp *AuthResponse
+	err = s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.loginAttemptsRepo.ResetFailed(ctx, identifier); err != nil {
+			return err
+		}
+		if err := s.loginAttemptsRepo.Save(ctx, identifier, ip, true); err != nil {
+			return err
+		}
+
+		resp, err = s.tokenService.Issue(ctx, u.ID, u.CurrentTokenVersion(), deviceID)
+		return err
+	})
+	if err != nil {
+		return nil, "произошла ошибка при входе", err
+	}
+
+	s.afterLogin(ctx, u.ID, deviceID)
+	return resp, "", nil
+}
+
+// ForgotPassword отправляет контрольное слово для восстановления пароля: ссылку на email
+// или код по SMS. Ответ не зависит от существования пользователя
+func (s *Service) ForgotPassword(ctx context.Context, req PasswordResetRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+
+	const mess = "если пользователь существует, инструкция по восстановлению пароля отправлена"
+
+	count, err := s.codeRepo.CountCodesLast24Hours(ctx, req.Email, req.Phone)
+	if err != nil {
+		return "произошла ошибка при восстановлении пароля", err
+	}
+	if count >= maxDailyAttempts {
+		return "превышен лимит отправок, попробуйте позже", ErrDailyLimitExceeded
+	}
+
+	expiresAt := time.Now().Add(time.Duration(s.cfg.PasswordResetTTL) * time.Second)
+	if err := s.codeRepo.SaveDelivery(ctx, req.Email, req.Phone, CodeTypePasswordReset, expiresAt); err != nil {
+		return "произошла ошибка при восстановлении пароля", err
+	}
+
+	u, err := s.findUser(ctx, req.Email, req.Phone)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return mess, nil
+		}
+		return "произошла ошибка при восстановлении пароля", err
+	}
+	if u.Active != user.ActiveYes {
+		return mess, nil
 	}
 
-	// 4. Отправка
+	var checkword string
 	if req.Email != "" {
-		err = s.sender.SendEmailCode(req.Email, code)
+		checkword, err = generateOpaqueToken(refreshTokenBytes)
 	} else {
-		err = s.sender.SendSmsCode(req.Phone, code)
+		checkword, err = helper.GenerateCode(passwordResetCodeLength)
+	}
+	if err != nil {
+		return "произошла ошибка при восстановлении пароля", err
+	}
+
+	err = s.store.WithinTx(ctx, func(ctx context.Context) error {
+		checkwordHash := hashToken(checkword)
+		if err := s.userRepo.SetCheckword(ctx, u.ID, checkwordHash, expiresAt); err != nil {
+			return err
+		}
+		msg := notification.PasswordResetMessage(req.Email, req.Phone, s.passwordResetLink(req.Email, checkword), checkword, checkwordHash, expiresAt)
+		return s.notifications.Notify(ctx, msg)
+	})
+	if err != nil {
+		return "произошла ошибка при восстановлении пароля", err
+	}
+
+	return mess, nil
+}
+
+// ResetPassword меняет пароль по контрольному слову и завершает все сессии пользователя.
+// Контрольное слово одноразовое, после CodeMaxAttempts неверных попыток его нужно запросить заново
+func (s *Service) ResetPassword(ctx context.Context, req PasswordResetConfirmRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+
+	const invalidMess = "контрольное слово неверно или истекло, запросите восстановление заново"
+
+	u, err := s.findUser(ctx, req.Email, req.Phone)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return invalidMess, ErrInvalidCheckword
+		}
+		return "произошла ошибка при смене пароля", err
+	}
+	if u.Checkword == nil {
+		return invalidMess, ErrInvalidCheckword
+	}
+
+	attempts, err := s.userRepo.ReserveCheckwordAttempt(ctx, u.ID, s.cfg.CodeMaxAttempts)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return invalidMess, ErrInvalidCheckword
+		}
+		return "произошла ошибка при смене пароля", err
+	}
+
+	checkwordHash := hashToken(req.Checkword)
+	if subtle.ConstantTimeCompare([]byte(*u.Checkword), []byte(checkwordHash)) != 1 {
+		if attempts < s.cfg.CodeMaxAttempts {
+			return "неверное контрольное слово", ErrInvalidCheckword
+		}
+		// попытки исчерпаны — контрольное слово больше не действует
+		if err := s.userRepo.ClearCheckword(ctx, u.ID); err != nil {
+			return "произошла ошибка при смене пароля", err
+		}
+		return invalidMess, ErrInvalidCheckword
+	}
+
+	passwordHash, err := password.Hash(req.Password)
+	if err != nil {
+		return "произошла ошибка при смене пароля", err
+	}
+
+	err = s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.ResetPassword(ctx, u.ID, checkwordHash, passwordHash); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return ErrInvalidCheckword
+			}
+			return fmt.Errorf("failed to reset password: %w", err)
+		}
+		if err := s.userTokensRepo.DeleteByUser(ctx, u.ID); err != nil {
+			return err
+		}
+		// контрольное слово пришло на email/телефон — контакт подтверждён
+		if err := s.markContactVerified(ctx, u, req.Email); err != nil {
+			return err
+		}
+		identifier := req.Email
+		if identifier == "" {
+			identifier = req.Phone
+		}
+		return s.loginAttemptsRepo.ResetFailed(ctx, identifier)
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidCheckword) {
+			return invalidMess, err
+		}
+		return "произошла ошибка при смене пароля", err
+	}
+
+	return "пароль изменён, войдите заново", nil
+}
+
+// VerifyContactCode реализует user.ContactVerifier: проверяет код смены контакта и помечает его использованным
+func (s *Service) VerifyContactCode(ctx context.Context, email, phone, value string) error {
+	code, _, err := s.checkCode(ctx, email, phone, CodeTypeContactChange, value)
+	if err != nil {
+		if errors.Is(err, ErrCodeNotFound) || errors.Is(err, ErrInvalidCode) || errors.Is(err, ErrTooManyAttempts) {
+			return fmt.Errorf("%w: %w", user.ErrContactCodeInvalid, err)
+		}
+		return err
 	}
+
+	if err := s.codeRepo.MarkUsed(ctx, code.ID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("%w: %w", user.ErrContactCodeInvalid, ErrCodeNotFound)
+		}
+		return fmt.Errorf("failed to mark code used: %w", err)
+	}
+
+	return nil
+}
+
+// checkCode сверяет value с последним активным кодом назначения codeType и списывает попытку.
+// Использованным код не помечается — это делает вызывающий вместе с основным действием
+func (s *Service) checkCode(ctx context.Context, email, phone, codeType, value string) (*AuthCode, string, error) {
+	code, err := s.codeRepo.GetActiveCode(ctx, email, phone, codeType)
 	if err != nil {
-		return fmt.Errorf("failed to send code: %w", err)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "код не найден или истёк, запросите новый", ErrCodeNotFound
+		}
+		return nil, "произошла ошибка при получении кода", err
+	}
+
+	attempts, err := s.codeRepo.ReserveAttempt(ctx, code.ID, s.cfg.CodeMaxAttempts)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "превышено количество попыток, запросите новый код", ErrTooManyAttempts
+		}
+		return nil, "произошла ошибка при проверке кода", err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(code.Code), []byte(s.hashCode(value))) != 1 {
+		if attempts < s.cfg.CodeMaxAttempts {
+			return nil, "неверный код", ErrInvalidCode
+		}
+		// попытки исчерпаны — блокируем код, дальше только через отправку нового
+		if err := s.codeRepo.MarkUsed(ctx, code.ID); err != nil && !errors.Is(err, store.ErrNotFound) {
+			return nil, "произошла ошибка при проверке кода", err
+		}
+		return nil, "превышено количество попыток, запросите новый код", ErrTooManyAttempts
 	}
 
+	return code, "", nil
+}
+
+// hashCode — HMAC-SHA256 кода, без секрета коды из БД не восстановить перебором
+func (s *Service) hashCode(code string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.CodeSecret))
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Service) passwordResetLink(email, checkword string) string {
+	params := url.Values{}
+	params.Set("email", email)
+	params.Set("checkword", checkword)
+	return s.cfg.PasswordResetURL + "?" + params.Encode()
+}
+
+func (s *Service) findUser(ctx context.Context, email, phone string) (*user.UserEnt, error) {
+	if email != "" {
+		return s.userRepo.GetByEmail(ctx, email)
+	}
+	return s.userRepo.GetByPhone(ctx, phone)
+}
+
+func (s *Service) ensureUserNotExists(ctx context.Context, email, phone string) error {
+	if email != "" {
+		if _, err := s.userRepo.GetByEmail(ctx, email); err == nil {
+			return ErrUserExists
+		} else if !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+	}
+	if phone != "" {
+		if _, err := s.userRepo.GetByPhone(ctx, phone); err == nil {
+			return ErrUserExists
+		} else if !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+	}
 	return nil
 }
 
-// func (s *Service) Register(ctx context.Context, registrationResponse RegistrationRequest) (*AuthResponse, string, error) {
-
-// // 1. Проверка: есть ли активный код с таким email/телефоном и purpose = 'register'
-// codeValid, err := s.codeRepo.ValidateCode(ctx, req.Email, req.Phone, req.Code, "register")
-// if err != nil {
-// 	return nil, "", fmt.Errorf("failed to validate code: %w", err)
-// }
-// if !codeValid {
-// 	return nil, "", fmt.Errorf("invalid or expired verification code")
-// }
-
-// // 2. Проверка, не существует ли уже такой пользователь
-// exists, err := s.userRepo.ExistsByEmailOrPhone(ctx, req.Email, req.Phone)
-// if err != nil {
-// 	return nil, "", fmt.Errorf("failed to check user: %w", err)
-// }
-// if exists {
-// 	return nil, "", fmt.Errorf("user already exists")
-// }
-
-// // 3. Создание пользователя
-// user := &domain.User{
-// 	Email:  req.Email,
-// 	Phone:  req.Phone,
-// 	Name:   req.Name,
-// 	Active: "Y",
-// }
-// if err := s.userRepo.Create(ctx, user); err != nil {
-// 	return nil, "", fmt.Errorf("failed to create user: %w", err)
-// }
-
-// // 4. Генерация токенов
-// accessToken, err := s.tokenService.GenerateAccessToken(user.ID)
-// if err != nil {
-// 	return nil, "", fmt.Errorf("failed to create access token: %w", err)
-// }
-// refreshToken, err := s.tokenService.GenerateRefreshToken(user.ID, "", user.TokenVersion)
-// if err != nil {
-// 	return nil, "", fmt.Errorf("failed to create refresh token: %w", err)
-// }
-
-// return &AuthResponse{
-// 	UserID:      user.ID,
-// 	AccessToken: accessToken,
-// 	ExpiresIn:   3600,
-// }, refreshToken, nil
-
-// }
+func (s *Service) findOrCreateUser(ctx context.Context, email, phone string) (*user.UserEnt, error) {
+	u, err := s.findUser(ctx, email, phone)
+	if err == nil {
+		return u, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	tv := user.InitialTokenVersion
+	u = &user.UserEnt{
+		Email:        nullableString(email),
+		Phone:        nullableString(phone),
+		UserType:     user.UserTypeIndividual,
+		Active:       user.ActiveYes,
+		PasswordHash: "", // вход без пароля, пароль можно задать позже
+		TokenVersion: &tv,
+	}
+	if err := s.createUser(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// createUser создаёт пользователя с ролью покупателя
+func (s *Service) createUser(ctx context.Context, u *user.UserEnt) error {
+	if _, err := s.userRepo.Create(ctx, u); err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	if err := s.rolesRepo.AssignRole(ctx, u.ID, user.RoleCustomer); err != nil {
+		return fmt.Errorf("failed to assign role: %w", err)
+	}
+	return nil
+}
+
+// createCompany регистрирует компанию юридического лица, пользователь становится её владельцем
+func (s *Service) createCompany(ctx context.Context, req company.CreateRequest, userID int64) error {
+	e := &company.CompanyEnt{
+		INN:         req.INN,
+		KPP:         req.KPP,
+		CompanyName: req.CompanyName,
+	}
+	if _, err := s.companyRepo.Create(ctx, e); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			return company.ErrCompanyExists
+		}
+		return fmt.Errorf("failed to create company: %w", err)
+	}
+	return company.Attach(ctx, s.companyRepo, s.userRepo, e, userID, company.RoleOwner)
+}
+
+// markContactVerified отмечает подтверждённым контакт, на который пришёл код
+func (s *Service) markContactVerified(ctx context.Context, u *user.UserEnt, email string) error {
+	var err error
+	if email != "" {
+		err = s.userRepo.MarkEmailVerified(ctx, u.ID)
+	} else {
+		err = s.userRepo.MarkPhoneVerified(ctx, u.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark contact verified: %w", err)
+	}
+	return nil
+}
+
+// contactVerified — подтверждён ли контакт, по которому выполняется вход
+func contactVerified(u *user.UserEnt, email string) bool {
+	if email != "" {
+		return u.EmailVerifiedAt != nil
+	}
+	return u.PhoneVerifiedAt != nil
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"go-monolite/pkg/token"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const refreshTokenBytes = 32
+
+// TokenService выдаёт пару access/refresh токенов для устройства пользователя,
+// refresh токен непрозрачный и хранится в БД только в виде хэша
+type TokenService struct {
+	repo         *UserTokensRepository
+	tokenManager *token.Manager
+	refreshTTL   time.Duration
+}
+
+func NewTokenService(repo *UserTokensRepository, tokenManager *token.Manager, refreshTTL time.Duration) *TokenService {
+	return &TokenService{
+		repo:         repo,
+		tokenManager: tokenManager,
+		refreshTTL:   refreshTTL,
+	}
+}
+
+// Issue начинает новую цепочку refresh токенов для устройства, предыдущая сессия устройства заменяется
+func (s *TokenService) Issue(ctx context.Context, userID int64, tokenVersion, deviceID string) (*AuthResponse, error) {
+	refreshToken, err := generateOpaqueToken(refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Upsert(ctx, &UserTokenEnt{
+		RefreshToken: hashToken(refreshToken),
+		FamilyID:     uuid.New(),
+		UserID:       userID,
+		DeviceID:     deviceID,
+		ExpiresAt:    time.Now().Add(s.refreshTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	return s.buildResponse(userID, tokenVersion, deviceID, refreshToken)
+}
+
+// Rotate заменяет refresh токен сессии новым, старый запоминается для обнаружения повторного использования
+func (s *TokenService) Rotate(ctx context.Context, session *UserTokenEnt, tokenVersion string) (*AuthResponse, error) {
+	if err := s.repo.SaveRotation(ctx, session, time.Now().Add(-s.refreshTTL)); err != nil {
+		return nil, fmt.Errorf("failed to save rotation: %w", err)
+	}
+
+	refreshToken, err := generateOpaqueToken(refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	session.RefreshToken = hashToken(refreshToken)
+	session.ExpiresAt = time.Now().Add(s.refreshTTL)
+
+	if err := s.repo.UpdateToken(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to update refresh token: %w", err)
+	}
+
+	return s.buildResponse(session.UserID, tokenVersion, session.DeviceID, refreshToken)
+}
+
+func (s *TokenService) buildResponse(userID int64, tokenVersion, deviceID, refreshToken string) (*AuthResponse, error) {
+	accessToken, _, err := s.tokenManager.Generate(userID, tokenVersion, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.tokenManager.TTL().Seconds()),
+	}, nil
+}
+
+func generateOpaqueToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(t string) string {
+	sum := sha256.Sum256([]byte(t))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,17 +1,210 @@
 package auth
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"go-monolite/internal/store"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
 type UserTokensRepository struct {
-	store     *store.Store
-	tableName string
+	store              *store.Store
+	tableName          string
+	rotationsTableName string
 }
 
 func NewUserTokensRepository(store *store.Store) *UserTokensRepository {
 	return &UserTokensRepository{
-		store:     store,
-		tableName: "user_tokens",
+		store:              store,
+		tableName:          "user_tokens",
+		rotationsTableName: "user_token_rotations",
+	}
+}
+
+// Upsert сохраняет refresh токен устройства, один активный токен на пару (user_id, device_id)
+func (r *UserTokensRepository) Upsert(ctx context.Context, t *UserTokenEnt) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			refresh_token, family_id, user_id, device_id, expires_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+		ON CONFLICT (user_id, device_id) DO UPDATE SET
+			refresh_token = EXCLUDED.refresh_token,
+			family_id = EXCLUDED.family_id,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id
+	`, r.tableName)
+
+	now := time.Now()
+	t.CreatedAt = now
+	t.UpdatedAt = now
+
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query,
+		t.RefreshToken,
+		t.FamilyID,
+		t.UserID,
+		t.DeviceID,
+		t.ExpiresAt,
+		t.CreatedAt,
+		t.UpdatedAt,
+	).Scan(&t.ID)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// GetByTokenHashForUpdate возвращает сессию по хэшу refresh токена и блокирует строку до конца транзакции
+func (r *UserTokensRepository) GetByTokenHashForUpdate(ctx context.Context, tokenHash string) (*UserTokenEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT id, refresh_token, family_id, user_id, device_id, expires_at, created_at, updated_at
+		FROM %s
+		WHERE refresh_token = $1
+		FOR UPDATE
+	`, r.tableName)
+
+	var t UserTokenEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &t, query, tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &t, nil
+}
+
+func (r *UserTokensRepository) UpdateToken(ctx context.Context, t *UserTokenEnt) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET refresh_token = $1, expires_at = $2, updated_at = $3
+		WHERE id = $4
+	`, r.tableName)
+
+	t.UpdatedAt = time.Now()
+
+	result, err := r.store.Conn(ctx).ExecContext(ctx, query,
+		t.RefreshToken,
+		t.ExpiresAt,
+		t.UpdatedAt,
+		t.ID,
+	)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if rows == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserTokensRepository) DeleteByID(ctx context.Context, id int64) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, r.tableName)
+
+	result, err := r.store.Conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if rows == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteByFamily завершает цепочку ротаций вместе с её использованными токенами
+func (r *UserTokensRepository) DeleteByFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE family_id = $1`, r.tableName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, familyID); err != nil {
+		return store.ContextError(err)
+	}
+
+	query = fmt.Sprintf(`DELETE FROM %s WHERE family_id = $1`, r.rotationsTableName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, familyID); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+func (r *UserTokensRepository) DeleteByUser(ctx context.Context, userID int64) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, r.tableName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, userID); err != nil {
+		return store.ContextError(err)
+	}
+
+	query = fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, r.rotationsTableName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, userID); err != nil {
+		return store.ContextError(err)
 	}
+
+	return nil
+}
+
+// SaveRotation запоминает уже использованный refresh токен для обнаружения повторного предъявления.
+// Токены, ротированные раньше expiredBefore, к этому времени истекли сами, их записи удаляются
+func (r *UserTokensRepository) SaveRotation(ctx context.Context, t *UserTokenEnt, expiredBefore time.Time) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (token_hash, family_id, user_id, device_id, rotated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (token_hash) DO NOTHING
+	`, r.rotationsTableName)
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query,
+		t.RefreshToken,
+		t.FamilyID,
+		t.UserID,
+		t.DeviceID,
+		time.Now(),
+	)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	query = fmt.Sprintf(`DELETE FROM %s WHERE rotated_at < $1`, r.rotationsTableName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, expiredBefore); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// GetRotatedFamily возвращает цепочку, в которой токен уже был ротирован
+func (r *UserTokensRepository) GetRotatedFamily(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	query := fmt.Sprintf(`SELECT family_id FROM %s WHERE token_hash = $1`, r.rotationsTableName)
+
+	var familyID uuid.UUID
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &familyID, query, tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, store.ErrNotFound
+		}
+		return uuid.Nil, store.ContextError(err)
+	}
+
+	return familyID, nil
 }
@@ -0,0 +1,52 @@
+package cart
+
+import (
+	"go-monolite/pkg/validator"
+
+	"github.com/google/uuid"
+)
+
+// AddItemRequest — DTO для POST /cart/items, количество прибавляется к уже лежащему в корзине
+type AddItemRequest struct {
+	ProductUUID uuid.UUID `json:"product_uuid" validate:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Quantity    int       `json:"quantity" validate:"required,gt=0,max=100000" example:"2"`
+}
+
+func (d *AddItemRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// UpdateItemRequest — DTO для PUT /cart/items/{productUuid}, задаёт количество позиции
+type UpdateItemRequest struct {
+	Quantity int `json:"quantity" validate:"required,gt=0,max=100000" example:"4"`
+}
+
+func (d *UpdateItemRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+type CartResponse struct {
+	Items         []ItemResponse `json:"items"`
+	TotalQuantity int            `json:"total_quantity" example:"3"` // количество доступных к заказу единиц
+	Total         float64        `json:"total" example:"1520.5"`     // сумма доступных к заказу позиций
+	Ready         bool           `json:"ready"`                      // все позиции можно заказать
+	PriceChanged  bool           `json:"price_changed"`              // цены изменились с прошлого просмотра
+
+	PriceTypeUUID *uuid.UUID `json:"price_type_uuid,omitempty"`
+}
+
+type ItemResponse struct {
+	ProductUUID   uuid.UUID `json:"product_uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name          string    `json:"name" example:"Перфоратор"`
+	Slug          string    `json:"slug" example:"perforator"`
+	Code          int       `json:"code" example:"1001"`
+	Article       *string   `json:"article,omitempty"`
+	Unit          *string   `json:"unit,omitempty" example:"шт"`
+	Step          int       `json:"step" example:"1"`
+	Quantity      int       `json:"quantity" example:"2"`
+	Price         *float64  `json:"price,omitempty" example:"760.25"`
+	PreviousPrice *float64  `json:"previous_price,omitempty" example:"700"` // цена при прошлом просмотре, если изменилась
+	Sum           float64   `json:"sum" example:"1520.5"`
+	Stock         int       `json:"stock" example:"10"`
+	Status        string    `json:"status" example:"ok"` // ok, not_enough_stock, out_of_stock, no_price или unavailable
+}
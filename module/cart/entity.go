@@ -0,0 +1,122 @@
+package cart
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// состояние позиции корзины при пересчёте
+const (
+	StatusOK             = "ok"
+	StatusNotEnoughStock = "not_enough_stock" // остатка меньше, чем в корзине
+	StatusOutOfStock     = "out_of_stock"
+	StatusNoPrice        = "no_price"    // нет цены для типа цен покупателя
+	StatusUnavailable    = "unavailable" // товар выключен или удалён из каталога
+)
+
+// Owner — владелец корзины: пользователь, а без входа — устройство из заголовка Device-Uid
+type Owner struct {
+	UserID   int64
+	DeviceID string
+}
+
+func (o Owner) IsGuest() bool {
+	return o.UserID == 0
+}
+
+type CartEnt struct {
+	ID        int64     `db:"id"`
+	UserID    *int64    `db:"user_id"`
+	DeviceID  *string   `db:"device_id"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type ItemEnt struct {
+	CartID      int64     `db:"cart_id"`
+	ProductUUID uuid.UUID `db:"product_uuid"`
+	Quantity    int       `db:"quantity"`
+	Price       *float64  `db:"price"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// LineEnt — позиция корзины вместе с текущими данными товара, ценой и остатком.
+// Поля товара пустые, если его нет в каталоге
+type LineEnt struct {
+	ProductUUID uuid.UUID `db:"product_uuid"`
+	Quantity    int       `db:"quantity"`
+	SavedPrice  *float64  `db:"saved_price"`
+
+	Name    *string  `db:"name"`
+	Slug    *string  `db:"slug"`
+	Code    *int     `db:"code"`
+	Article *string  `db:"article"`
+	Unit    *string  `db:"unit"`
+	Step    *int     `db:"step"`
+	Active  *string  `db:"active"`
+	Price   *float64 `db:"price"`
+	Stock   int      `db:"stock"`
+}
+
+// StepSize — кратность количества товара
+func (e LineEnt) StepSize() int {
+	if e.Step == nil {
+		return 1
+	}
+	return *e.Step
+}
+
+func (e LineEnt) Status() string {
+	switch {
+	case e.Active == nil || *e.Active != "Y":
+		return StatusUnavailable
+	case e.Price == nil:
+		return StatusNoPrice
+	case e.Stock <= 0:
+		return StatusOutOfStock
+	case e.Stock < e.Quantity:
+		return StatusNotEnoughStock
+	}
+	return StatusOK
+}
+
+// PriceChanged — цена изменилась с прошлого просмотра корзины
+func (e LineEnt) PriceChanged() bool {
+	return e.SavedPrice != nil && e.Price != nil && *e.SavedPrice != *e.Price
+}
+
+func (e LineEnt) ToResponse() ItemResponse {
+	resp := ItemResponse{
+		ProductUUID: e.ProductUUID,
+		Article:     e.Article,
+		Unit:        e.Unit,
+		Step:        e.StepSize(),
+		Quantity:    e.Quantity,
+		Price:       e.Price,
+		Stock:       e.Stock,
+		Status:      e.Status(),
+	}
+	if e.Name != nil {
+		resp.Name = *e.Name
+	}
+	if e.Slug != nil {
+		resp.Slug = *e.Slug
+	}
+	if e.Code != nil {
+		resp.Code = *e.Code
+	}
+	if e.PriceChanged() {
+		resp.PreviousPrice = e.SavedPrice
+	}
+	if e.Price != nil {
+		resp.Sum = roundMoney(*e.Price * float64(e.Quantity))
+	}
+	return resp
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
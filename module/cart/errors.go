@@ -0,0 +1,13 @@
+package cart
+
+import "errors"
+
+var (
+	ErrOwnerRequired    = errors.New("device id or user is required")
+	ErrProductNotFound  = errors.New("product not found")
+	ErrItemNotFound     = errors.New("cart item not found")
+	ErrNoPrice          = errors.New("product has no price")
+	ErrNotEnoughStock   = errors.New("not enough stock")
+	ErrTooManyItems     = errors.New("too many items in cart")
+	ErrInvalidPriceType = errors.New("invalid price type in config")
+)
@@ -0,0 +1,230 @@
+package cart
+
+import (
+	"errors"
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/module/auth"
+	"go-monolite/module/user"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	middlewareAuth "go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	service  *Service
+	userAuth func(next http.Handler) http.Handler
+}
+
+// NewHandler — userAuth (middlewareAuth.UserAuth) собирается один раз в роутере сервера
+func NewHandler(store *store.Store, cfg *config.Config, userAuth func(next http.Handler) http.Handler) *Handler {
+	return &Handler{
+		service:  newService(store, cfg),
+		userAuth: userAuth,
+	}
+}
+
+// NewLoginHook — перенос гостевой корзины в корзину пользователя при входе, подключается к модулю auth
+func NewLoginHook(store *store.Store, cfg *config.Config) auth.LoginHook {
+	return newService(store, cfg).MergeGuest
+}
+
+func newService(store *store.Store, cfg *config.Config) *Service {
+	return NewService(store, NewRepository(store), user.NewRepository(store), cfg.Cart)
+}
+
+func (h *Handler) Init(r chi.Router) {
+	r.Use(middlewareAuth.Optional(h.userAuth))
+
+	r.Get("/", h.Get)
+	r.Delete("/", h.Clear)
+	r.Post("/items", h.AddItem)
+	r.Put("/items/{productUuid}", h.UpdateItem)
+	r.Delete("/items/{productUuid}", h.RemoveItem)
+}
+
+// @Summary Get cart
+// @Description Get the cart recalculated with current prices and stock. Without authorization the guest cart of the Device-Uid device is returned
+// @Tags cart
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest cart"
+// @Success 200 {object} respond.SuccessResponse{data=CartResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	resp, mess, err := h.service.Get(r.Context(), owner(r))
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Add cart item
+// @Description Add a product to the cart, the quantity is added to the one already in the cart and must be a multiple of the product step
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest cart"
+// @Param request body AddItemRequest true "Product and quantity"
+// @Success 200 {object} respond.SuccessResponse{data=CartResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /items [post]
+func (h *Handler) AddItem(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request AddItemRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.AddItem(r.Context(), owner(r), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "товар добавлен в корзину", resp)
+}
+
+// @Summary Update cart item
+// @Description Set the quantity of a product in the cart
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest cart"
+// @Param productUuid path string true "Product UUID"
+// @Param request body UpdateItemRequest true "Quantity"
+// @Success 200 {object} respond.SuccessResponse{data=CartResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /items/{productUuid} [put]
+func (h *Handler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	productUUID, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	body := respond.ParseBody(w, r)
+
+	var request UpdateItemRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.UpdateItem(r.Context(), owner(r), productUUID, request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Remove cart item
+// @Description Remove a product from the cart
+// @Tags cart
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest cart"
+// @Param productUuid path string true "Product UUID"
+// @Success 200 {object} respond.SuccessResponse{data=CartResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /items/{productUuid} [delete]
+func (h *Handler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	productUUID, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	resp, mess, err := h.service.RemoveItem(r.Context(), owner(r), productUUID)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "товар удалён из корзины", resp)
+}
+
+// @Summary Clear cart
+// @Description Remove all products from the cart
+// @Tags cart
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest cart"
+// @Success 200 {object} respond.SuccessResponse{data=CartResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [delete]
+func (h *Handler) Clear(w http.ResponseWriter, r *http.Request) {
+	resp, mess, err := h.service.Clear(r.Context(), owner(r))
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "корзина очищена", resp)
+}
+
+// owner — вошедший пользователь, без авторизации — устройство из заголовка Device-Uid
+func owner(r *http.Request) Owner {
+	identity, _ := middlewareAuth.CurrentUser(r.Context())
+	return Owner{UserID: identity.UserID, DeviceID: r.Header.Get(auth.DeviceHeader)}
+}
+
+func pathUUID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "productUuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный UUID товара")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, err error, mess string) {
+	if validationErrors, ok := err.(validator.ValidationError); ok {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrOwnerRequired):
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, mess)
+	case errors.Is(err, ErrProductNotFound), errors.Is(err, ErrItemNotFound):
+		respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+	case errors.Is(err, ErrNoPrice), errors.Is(err, ErrNotEnoughStock), errors.Is(err, ErrTooManyItems):
+		respond.ErrorHandler(w, r, http.StatusConflict, err, mess)
+	default:
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+	}
+}
@@ -0,0 +1,222 @@
+package cart_test
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/module/auth"
+	"go-monolite/module/cart"
+	"go-monolite/module/user"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"go-monolite/pkg/token"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCartIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	access := testinit.NewAuth(store, config)
+	handler := cart.NewHandler(store, config, access.UserAuth)
+	server := testinit.SetupTestServer(t, handler)
+	defer server.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	ctx := context.Background()
+	tokenManager := token.NewManager(config.Auth.JWTSecret, time.Minute)
+
+	// каталог: дрель продаётся по одной, саморезы — упаковками по 5, у кабеля нет цены
+	categoryUUID, typePriceUUID, storageUUID := uuid.New(), uuid.New(), uuid.New()
+	drill, screws, cable := uuid.New(), uuid.New(), uuid.New()
+	for _, query := range []string{
+		fmt.Sprintf(`INSERT INTO categories (uuid, slug, name) VALUES ('%s', 'tools', 'Инструмент')`, categoryUUID),
+		fmt.Sprintf(`INSERT INTO type_price (uuid, name) VALUES ('%s', 'Розничная')`, typePriceUUID),
+		fmt.Sprintf(`INSERT INTO storage (uuid, name) VALUES ('%s', 'Основной')`, storageUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Дрель', 9001, 'drill', '%s')`, drill, categoryUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, step, category_uuid) VALUES ('%s', 'Саморезы', 9002, 'screws', 5, '%s')`, screws, categoryUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Кабель', 9003, 'cable', '%s')`, cable, categoryUUID),
+		fmt.Sprintf(`INSERT INTO product_prices (product_uuid, type_price_uuid, price) VALUES ('%s', '%s', 2500), ('%s', '%s', 1.5)`, drill, typePriceUUID, screws, typePriceUUID),
+		fmt.Sprintf(`INSERT INTO product_storages (product_uuid, storage_uuid, quantity) VALUES ('%s', '%s', 3), ('%s', '%s', 100), ('%s', '%s', 10)`, drill, storageUUID, screws, storageUUID, cable, storageUUID),
+	} {
+		_, err := store.Db.Exec(query)
+		require.NoError(t, err)
+	}
+
+	send := func(t *testing.T, method, path, body, deviceID, accessToken string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if deviceID != "" {
+			req.Header.Set(auth.DeviceHeader, deviceID)
+		}
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	decode := func(t *testing.T, resp *http.Response) cart.CartResponse {
+		t.Helper()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		var c cart.CartResponse
+		testinit.MarshalUnmarshal(t, response.Data, &c)
+		return c
+	}
+
+	item := func(t *testing.T, c cart.CartResponse, productUUID uuid.UUID) cart.ItemResponse {
+		t.Helper()
+		for _, i := range c.Items {
+			if i.ProductUUID == productUUID {
+				return i
+			}
+		}
+		require.Failf(t, "item not found", "product %s", productUUID)
+		return cart.ItemResponse{}
+	}
+
+	const guest = "guest-device"
+
+	t.Run("Device Required", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "/", "", "", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Empty Cart", func(t *testing.T) {
+		c := decode(t, send(t, http.MethodGet, "/", "", guest, ""))
+		assert.Empty(t, c.Items)
+		assert.Zero(t, c.Total)
+		require.NotNil(t, c.PriceTypeUUID)
+		assert.Equal(t, typePriceUUID, *c.PriceTypeUUID)
+	})
+
+	t.Run("Add Item Validation", func(t *testing.T) {
+		resp := send(t, http.MethodPost, "/items", fmt.Sprintf(`{"product_uuid": "%s", "quantity": 0}`, drill), guest, "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		// саморезы продаются упаковками по 5
+		resp = send(t, http.MethodPost, "/items", fmt.Sprintf(`{"product_uuid": "%s", "quantity": 7}`, screws), guest, "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, http.MethodPost, "/items", fmt.Sprintf(`{"product_uuid": "%s", "quantity": 4}`, drill), guest, "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = send(t, http.MethodPost, "/items", fmt.Sprintf(`{"product_uuid": "%s", "quantity": 1}`, cable), guest, "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = send(t, http.MethodPost, "/items", fmt.Sprintf(`{"product_uuid": "%s", "quantity": 1}`, uuid.New()), guest, "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Add Item", func(t *testing.T) {
+		decode(t, send(t, http.MethodPost, "/items", fmt.Sprintf(`{"product_uuid": "%s", "quantity": 1}`, drill), guest, ""))
+		decode(t, send(t, http.MethodPost, "/items", fmt.Sprintf(`{"product_uuid": "%s", "quantity": 1}`, drill), guest, ""))
+		c := decode(t, send(t, http.MethodPost, "/items", fmt.Sprintf(`{"product_uuid": "%s", "quantity": 10}`, screws), guest, ""))
+
+		require.Len(t, c.Items, 2)
+		assert.Equal(t, 2, item(t, c, drill).Quantity)
+		assert.Equal(t, 5, item(t, c, screws).Step)
+		assert.Equal(t, 15.0, item(t, c, screws).Sum)
+		assert.Equal(t, 5015.0, c.Total)
+		assert.Equal(t, 12, c.TotalQuantity)
+		assert.True(t, c.Ready)
+
+		// в сумме с уже лежащими в корзине больше остатка
+		resp := send(t, http.MethodPost, "/items", fmt.Sprintf(`{"product_uuid": "%s", "quantity": 2}`, drill), guest, "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Recalculated On Price And Stock Change", func(t *testing.T) {
+		_, err := store.Db.Exec(`UPDATE product_prices SET price = 2700 WHERE product_uuid = $1`, drill)
+		require.NoError(t, err)
+		_, err = store.Db.Exec(`UPDATE product_storages SET quantity = 5 WHERE product_uuid = $1`, screws)
+		require.NoError(t, err)
+
+		c := decode(t, send(t, http.MethodGet, "/", "", guest, ""))
+		assert.True(t, c.PriceChanged)
+		drillItem := item(t, c, drill)
+		require.NotNil(t, drillItem.PreviousPrice)
+		assert.Equal(t, 2500.0, *drillItem.PreviousPrice)
+		assert.Equal(t, 5400.0, drillItem.Sum)
+		assert.Equal(t, cart.StatusNotEnoughStock, item(t, c, screws).Status)
+		assert.False(t, c.Ready)
+		assert.Equal(t, 5400.0, c.Total, "unavailable lines are not counted")
+
+		// изменение цены показывается один раз
+		c = decode(t, send(t, http.MethodGet, "/", "", guest, ""))
+		assert.False(t, c.PriceChanged)
+		assert.Nil(t, item(t, c, drill).PreviousPrice)
+	})
+
+	t.Run("Update And Remove Item", func(t *testing.T) {
+		path := fmt.Sprintf("/items/%s", screws)
+		resp := send(t, http.MethodPut, path, `{"quantity": 6}`, guest, "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		c := decode(t, send(t, http.MethodPut, path, `{"quantity": 5}`, guest, ""))
+		assert.Equal(t, cart.StatusOK, item(t, c, screws).Status)
+		assert.True(t, c.Ready)
+
+		resp = send(t, http.MethodPut, fmt.Sprintf("/items/%s", cable), `{"quantity": 1}`, guest, "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		c = decode(t, send(t, http.MethodDelete, path, "", guest, ""))
+		require.Len(t, c.Items, 1)
+
+		resp = send(t, http.MethodDelete, path, "", guest, "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Merge On Login", func(t *testing.T) {
+		phone := "79990000030"
+		tv := user.InitialTokenVersion
+		userID, err := user.NewRepository(store).Create(ctx, &user.UserEnt{
+			Phone:        &phone,
+			UserType:     user.UserTypeIndividual,
+			Active:       user.ActiveYes,
+			TokenVersion: &tv,
+		})
+		require.NoError(t, err)
+		accessToken, _, err := tokenManager.Generate(userID, tv, guest)
+		require.NoError(t, err)
+
+		// у пользователя уже есть дрель в корзине с другого устройства
+		decode(t, send(t, http.MethodPost, "/items", fmt.Sprintf(`{"product_uuid": "%s", "quantity": 1}`, drill), "", accessToken))
+		decode(t, send(t, http.MethodPost, "/items", fmt.Sprintf(`{"product_uuid": "%s", "quantity": 5}`, screws), guest, ""))
+
+		require.NoError(t, cart.NewLoginHook(store, config)(ctx, userID, guest))
+
+		c := decode(t, send(t, http.MethodGet, "/", "", guest, accessToken))
+		require.Len(t, c.Items, 2)
+		assert.Equal(t, 3, item(t, c, drill).Quantity)
+		assert.Equal(t, 5, item(t, c, screws).Quantity)
+
+		// гостевая корзина перенесена
+		c = decode(t, send(t, http.MethodGet, "/", "", guest, ""))
+		assert.Empty(t, c.Items)
+
+		c = decode(t, send(t, http.MethodDelete, "/", "", "", accessToken))
+		assert.Empty(t, c.Items)
+	})
+
+	t.Run("Invalid Token", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "/", "", guest, "invalid")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
@@ -0,0 +1,21 @@
+-- корзина принадлежит либо пользователю, либо гостевому устройству (заголовок Device-Uid)
+CREATE TABLE IF NOT EXISTS carts (
+  id BIGSERIAL PRIMARY KEY,
+  user_id INT UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+  device_id VARCHAR(255) UNIQUE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CHECK ((user_id IS NULL) <> (device_id IS NULL))
+);
+
+-- price — цена на момент последнего просмотра корзины, по ней покупатель видит изменение цены.
+-- Нет связи REFERENCES products(uuid): товар может пропасть из каталога после обмена, позиция остаётся недоступной
+CREATE TABLE IF NOT EXISTS cart_items (
+  cart_id BIGINT NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+  product_uuid UUID NOT NULL,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  price NUMERIC(12, 2),
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (cart_id, product_uuid)
+);
@@ -0,0 +1,248 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	cartColumns = `id, user_id, device_id, created_at, updated_at`
+	itemColumns = `cart_id, product_uuid, quantity, price, created_at, updated_at`
+
+	// lineColumns — данные товара, его цена для типа цен $2 и остаток на активных складах,
+	// товар из архива недоступен как выключенный. %[1]s — выражение с UUID товара
+	lineColumns = `
+		p.name, p.slug, p.code, p.article, p.unit, p.step, CASE WHEN p.deleted_at IS NULL THEN p.active ELSE 'N' END AS active,
+		(SELECT pp.price FROM product_prices pp
+			WHERE pp.product_uuid = %[1]s AND pp.type_price_uuid = $2 AND pp.active = 'Y'
+			ORDER BY pp.updated_at DESC LIMIT 1) AS price,
+		COALESCE((SELECT SUM(ps.quantity) FROM product_storages ps
+			JOIN storage s ON s.uuid = ps.storage_uuid AND s.active = 'Y' AND s.deleted_at IS NULL
+			WHERE ps.product_uuid = %[1]s AND ps.active = 'Y'), 0) AS stock`
+)
+
+type Repository struct {
+	store     *store.Store
+	tableName string
+	itemsName string
+}
+
+func NewRepository(store *store.Store) *Repository {
+	return &Repository{
+		store:     store,
+		tableName: "carts",
+		itemsName: "cart_items",
+	}
+}
+
+// Get возвращает корзину владельца
+func (r *Repository) Get(ctx context.Context, owner Owner) (*CartEnt, error) {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s = $1`, cartColumns, r.tableName, column)
+
+	var e CartEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+// GetOrCreate возвращает корзину владельца, создавая её при необходимости.
+// Строка корзины блокируется до конца транзакции, так что изменения одной корзины выполняются по очереди
+func (r *Repository) GetOrCreate(ctx context.Context, owner Owner) (*CartEnt, error) {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (%[2]s, created_at, updated_at) VALUES ($1, $2, $2)
+		ON CONFLICT (%[2]s) DO UPDATE SET updated_at = EXCLUDED.updated_at
+		RETURNING %[3]s
+	`, r.tableName, column, cartColumns)
+
+	var e CartEnt
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, value, time.Now()); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, r.tableName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, id); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetItem(ctx context.Context, cartID int64, productUUID uuid.UUID) (*ItemEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE cart_id = $1 AND product_uuid = $2`, itemColumns, r.itemsName)
+
+	var e ItemEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, cartID, productUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+func (r *Repository) CountItems(ctx context.Context, cartID int64) (int, error) {
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE cart_id = $1`, r.itemsName)
+
+	var count int
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &count, query, cartID); err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	return count, nil
+}
+
+// SaveItem добавляет позицию или заменяет её количество и цену
+func (r *Repository) SaveItem(ctx context.Context, e *ItemEnt) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (cart_id, product_uuid, quantity, price, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (cart_id, product_uuid) DO UPDATE SET
+			quantity = EXCLUDED.quantity,
+			price = EXCLUDED.price,
+			updated_at = EXCLUDED.updated_at
+	`, r.itemsName)
+
+	e.UpdatedAt = time.Now()
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query, e.CartID, e.ProductUUID, e.Quantity, e.Price, e.UpdatedAt)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// UpdatePrice запоминает цену, которую покупатель увидел в корзине
+func (r *Repository) UpdatePrice(ctx context.Context, cartID int64, productUUID uuid.UUID, price *float64) error {
+	query := fmt.Sprintf(`UPDATE %s SET price = $3 WHERE cart_id = $1 AND product_uuid = $2`, r.itemsName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, cartID, productUUID, price); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteItem(ctx context.Context, cartID int64, productUUID uuid.UUID) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE cart_id = $1 AND product_uuid = $2`, r.itemsName)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, cartID, productUUID)
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) Clear(ctx context.Context, cartID int64) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE cart_id = $1`, r.itemsName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, cartID); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// Merge переносит позиции корзины fromID в toID, количество одинаковых товаров складывается
+func (r *Repository) Merge(ctx context.Context, fromID, toID int64) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (cart_id, product_uuid, quantity, price, created_at, updated_at)
+		SELECT $2::bigint, product_uuid, quantity, price, created_at, $3::timestamp FROM %[1]s WHERE cart_id = $1
+		ON CONFLICT (cart_id, product_uuid) DO UPDATE SET
+			quantity = %[1]s.quantity + EXCLUDED.quantity,
+			updated_at = EXCLUDED.updated_at
+	`, r.itemsName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, fromID, toID, time.Now()); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// Lines возвращает позиции корзины с текущими ценами типа priceType и остатками
+func (r *Repository) Lines(ctx context.Context, cartID int64, priceType *uuid.UUID) ([]LineEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT i.product_uuid, i.quantity, i.price AS saved_price, %s
+		FROM %s i
+		LEFT JOIN products p ON p.uuid = i.product_uuid
+		WHERE i.cart_id = $1
+		ORDER BY i.created_at, i.product_uuid
+	`, fmt.Sprintf(lineColumns, "i.product_uuid"), r.itemsName)
+
+	var lines []LineEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &lines, query, cartID, priceType); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return lines, nil
+}
+
+// Product возвращает товар с ценой типа priceType и остатком, как он попал бы в корзину
+func (r *Repository) Product(ctx context.Context, productUUID uuid.UUID, priceType *uuid.UUID) (*LineEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT p.uuid AS product_uuid, 0 AS quantity, NULL::numeric AS saved_price, %s
+		FROM products p
+		WHERE p.uuid = $1
+	`, fmt.Sprintf(lineColumns, "p.uuid"))
+
+	var e LineEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, productUUID, priceType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+// FirstPriceType — первый активный тип цен не из архива, если тип цен не задан в конфигурации
+func (r *Repository) FirstPriceType(ctx context.Context) (*uuid.UUID, error) {
+	query := `SELECT uuid FROM type_price WHERE active = 'Y' AND deleted_at IS NULL ORDER BY id LIMIT 1`
+
+	var id uuid.UUID
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &id, query)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return &id, nil
+}
+
+func ownerColumn(owner Owner) (string, any) {
+	if owner.IsGuest() {
+		return "device_id", owner.DeviceID
+	}
+	return "user_id", owner.UserID
+}
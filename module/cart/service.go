@@ -0,0 +1,329 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/module/user"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/validator"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	store    *store.Store
+	repo     *Repository
+	userRepo *user.Repository
+	cfg      config.Cart
+}
+
+func NewService(store *store.Store, repo *Repository, userRepo *user.Repository, cfg config.Cart) *Service {
+	return &Service{
+		store:    store,
+		repo:     repo,
+		userRepo: userRepo,
+		cfg:      cfg,
+	}
+}
+
+// Get пересчитывает корзину по текущим ценам и остаткам. Цены, которые увидел покупатель,
+// запоминаются: изменение цены показывается один раз
+func (s *Service) Get(ctx context.Context, owner Owner) (*CartResponse, string, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, "укажите заголовок Device-Uid или войдите", err
+	}
+
+	priceType, err := s.PriceType(ctx, owner)
+	if err != nil {
+		return nil, "произошла ошибка при получении корзины", err
+	}
+
+	e, err := s.repo.Get(ctx, owner)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return &CartResponse{Items: []ItemResponse{}, PriceTypeUUID: priceType}, "", nil
+		}
+		return nil, "произошла ошибка при получении корзины", err
+	}
+
+	lines, err := s.repo.Lines(ctx, e.ID, priceType)
+	if err != nil {
+		return nil, "произошла ошибка при получении корзины", err
+	}
+
+	for _, line := range lines {
+		if line.Price == nil || (line.SavedPrice != nil && *line.SavedPrice == *line.Price) {
+			continue
+		}
+		if err := s.repo.UpdatePrice(ctx, e.ID, line.ProductUUID, line.Price); err != nil {
+			return nil, "произошла ошибка при получении корзины", err
+		}
+	}
+
+	return newCartResponse(lines, priceType), "", nil
+}
+
+// AddItem добавляет товар в корзину, количество прибавляется к уже лежащему в корзине
+func (s *Service) AddItem(ctx context.Context, owner Owner, req AddItemRequest) (*CartResponse, string, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, "укажите заголовок Device-Uid или войдите", err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		e, err := s.repo.GetOrCreate(ctx, owner)
+		if err != nil {
+			return err
+		}
+
+		quantity := req.Quantity
+		item, err := s.repo.GetItem(ctx, e.ID, req.ProductUUID)
+		switch {
+		case err == nil:
+			quantity += item.Quantity
+		case !errors.Is(err, store.ErrNotFound):
+			return err
+		case s.cfg.MaxItems > 0:
+			count, err := s.repo.CountItems(ctx, e.ID)
+			if err != nil {
+				return err
+			}
+			if count >= s.cfg.MaxItems {
+				return ErrTooManyItems
+			}
+		}
+
+		return s.saveItem(ctx, owner, e.ID, req.ProductUUID, quantity)
+	})
+	if err != nil {
+		return nil, itemMessage(err, "произошла ошибка при добавлении товара в корзину"), err
+	}
+
+	return s.Get(ctx, owner)
+}
+
+// UpdateItem задаёт количество товара в корзине
+func (s *Service) UpdateItem(ctx context.Context, owner Owner, productUUID uuid.UUID, req UpdateItemRequest) (*CartResponse, string, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, "укажите заголовок Device-Uid или войдите", err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		e, err := s.repo.GetOrCreate(ctx, owner)
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.repo.GetItem(ctx, e.ID, productUUID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return ErrItemNotFound
+			}
+			return err
+		}
+
+		return s.saveItem(ctx, owner, e.ID, productUUID, req.Quantity)
+	})
+	if err != nil {
+		return nil, itemMessage(err, "произошла ошибка при изменении количества"), err
+	}
+
+	return s.Get(ctx, owner)
+}
+
+// RemoveItem удаляет товар из корзины
+func (s *Service) RemoveItem(ctx context.Context, owner Owner, productUUID uuid.UUID) (*CartResponse, string, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, "укажите заголовок Device-Uid или войдите", err
+	}
+
+	e, err := s.repo.Get(ctx, owner)
+	if err == nil {
+		err = s.repo.DeleteItem(ctx, e.ID, productUUID)
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "товара нет в корзине", ErrItemNotFound
+		}
+		return nil, "произошла ошибка при удалении товара из корзины", err
+	}
+
+	return s.Get(ctx, owner)
+}
+
+// Clear удаляет все товары из корзины
+func (s *Service) Clear(ctx context.Context, owner Owner) (*CartResponse, string, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, "укажите заголовок Device-Uid или войдите", err
+	}
+
+	e, err := s.repo.Get(ctx, owner)
+	if err == nil {
+		err = s.repo.Clear(ctx, e.ID)
+	}
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, "произошла ошибка при очистке корзины", err
+	}
+
+	return s.Get(ctx, owner)
+}
+
+// MergeGuest переносит гостевую корзину устройства в корзину пользователя после входа.
+// Количество одинаковых товаров складывается, недостаток остатка покажет пересчёт корзины
+func (s *Service) MergeGuest(ctx context.Context, userID int64, deviceID string) error {
+	if deviceID == "" {
+		return nil
+	}
+
+	return s.store.WithinTx(ctx, func(ctx context.Context) error {
+		guest, err := s.repo.Get(ctx, Owner{DeviceID: deviceID})
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		e, err := s.repo.GetOrCreate(ctx, Owner{UserID: userID})
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Merge(ctx, guest.ID, e.ID); err != nil {
+			return fmt.Errorf("failed to merge guest cart: %w", err)
+		}
+
+		return s.repo.Delete(ctx, guest.ID)
+	})
+}
+
+// saveItem проверяет товар и сохраняет позицию с текущей ценой
+func (s *Service) saveItem(ctx context.Context, owner Owner, cartID int64, productUUID uuid.UUID, quantity int) error {
+	priceType, err := s.PriceType(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	product, err := s.Line(ctx, priceType, productUUID, quantity)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.SaveItem(ctx, &ItemEnt{
+		CartID:      cartID,
+		ProductUUID: productUUID,
+		Quantity:    quantity,
+		Price:       product.Price,
+	})
+}
+
+// Line проверяет, что товар можно заказать в количестве quantity: товар активен, у него есть цена типа priceType,
+// количество кратно шагу и не больше остатка. Возвращает товар с текущей ценой и остатком
+func (s *Service) Line(ctx context.Context, priceType *uuid.UUID, productUUID uuid.UUID, quantity int) (*LineEnt, error) {
+	product, err := s.repo.Product(ctx, productUUID, priceType)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	if step := product.StepSize(); quantity%step != 0 {
+		return nil, validator.ValidationError{
+			Err:    validator.ErrorValidation,
+			Fields: map[string]string{"quantity": fmt.Sprintf("Количество должно быть кратно %d", step)},
+		}
+	}
+
+	product.Quantity = quantity
+	switch product.Status() {
+	case StatusUnavailable:
+		return nil, ErrProductNotFound
+	case StatusNoPrice:
+		return nil, ErrNoPrice
+	case StatusOutOfStock, StatusNotEnoughStock:
+		return nil, ErrNotEnoughStock
+	}
+
+	return product, nil
+}
+
+// PriceType выбирает тип цен покупателя: для юридических лиц — LegalPriceType,
+// для остальных — DefaultPriceType, если тип не задан — первый активный тип цен
+func (s *Service) PriceType(ctx context.Context, owner Owner) (*uuid.UUID, error) {
+	configured := s.cfg.DefaultPriceType
+	if !owner.IsGuest() && s.cfg.LegalPriceType != "" {
+		u, err := s.userRepo.GetByID(ctx, owner.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if u.UserType == user.UserTypeLegal {
+			configured = s.cfg.LegalPriceType
+		}
+	}
+
+	if configured != "" {
+		id, err := uuid.Parse(configured)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPriceType, err)
+		}
+		return &id, nil
+	}
+
+	id, err := s.repo.FirstPriceType(ctx)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	return id, err
+}
+
+func newCartResponse(lines []LineEnt, priceType *uuid.UUID) *CartResponse {
+	resp := &CartResponse{
+		Items:         helper.ToResponse(lines),
+		Ready:         len(lines) > 0,
+		PriceTypeUUID: priceType,
+	}
+	for i, line := range lines {
+		if line.PriceChanged() {
+			resp.PriceChanged = true
+		}
+		if resp.Items[i].Status != StatusOK {
+			resp.Ready = false
+			continue
+		}
+		resp.TotalQuantity += line.Quantity
+		resp.Total += resp.Items[i].Sum
+	}
+	resp.Total = roundMoney(resp.Total)
+
+	return resp
+}
+
+func checkOwner(owner Owner) error {
+	if owner.IsGuest() && owner.DeviceID == "" {
+		return ErrOwnerRequired
+	}
+	return nil
+}
+
+func itemMessage(err error, fallback string) string {
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		return "товар не найден"
+	case errors.Is(err, ErrItemNotFound):
+		return "товара нет в корзине"
+	case errors.Is(err, ErrNoPrice):
+		return "у товара нет цены"
+	case errors.Is(err, ErrNotEnoughStock):
+		return "недостаточно товара на складе"
+	case errors.Is(err, ErrTooManyItems):
+		return "в корзине слишком много товаров"
+	}
+	return fallback
+}
@@ -22,6 +22,7 @@ type CategoryEnt struct {
 	Active     string     `db:"active"`
 	CreatedAt  time.Time  `db:"created_at"`
 	UpdatedAt  time.Time  `db:"updated_at"`
+	DeletedAt  *time.Time `db:"deleted_at"` // не пустой — категория в архиве
 }
 
 func (e CategoryEnt) PatchDto(request *CategoryRequest) CategoryEnt {
@@ -0,0 +1,8 @@
+package category
+
+import "errors"
+
+var (
+	ErrNotEmpty       = errors.New("category has children or products")
+	ErrParentArchived = errors.New("parent category is archived")
+)
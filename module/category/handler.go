@@ -3,6 +3,7 @@ package category
 import (
 	"errors"
 	"go-monolite/internal/store"
+	"go-monolite/module/audit"
 
 	"go-monolite/pkg/helper"
 	"go-monolite/pkg/logger"
@@ -19,7 +20,7 @@ type Handler struct {
 
 func NewHandler(store *store.Store) *Handler {
 	repo := NewRepository(store)
-	service := NewService(repo)
+	service := NewService(store, repo, audit.NewRecorder(store))
 	return &Handler{service: service}
 }
 
@@ -28,6 +29,7 @@ func (h *Handler) Init(r chi.Router) {
 	r.Post("/create", h.Create)
 	r.Put("/update/{uuid}", h.Update)
 	r.Delete("/delete/{uuid}", h.Delete)
+	r.Post("/restore/{uuid}", h.Restore)
 	r.Get("/tree", h.GetTree)
 	r.Get("/tree/{uuid}", h.GetTree)
 }
@@ -156,13 +158,14 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Delete category
-// @Description Delete a category by UUID
+// @Description Move a category to the archive. A category with subcategories or products outside the archive can't be deleted. Archived categories are purged after the retention period
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param uuid path string true "Category UUID"
 // @Success 200 {object} respond.SuccessResponse
 // @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
 // @Failure 500 {object} respond.ErrorResponse
 // @Router /delete/{uuid} [delete]
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -180,6 +183,10 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 			respond.ErrorHandler(w, r, http.StatusNotFound, nil, mess)
 			return
 		}
+		if errors.Is(err, ErrNotEmpty) {
+			respond.ErrorHandler(w, r, http.StatusConflict, err, mess)
+			return
+		}
 		logger.ErrorCtx(r.Context(), err, mess)
 		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
 		return
@@ -188,6 +195,45 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	respond.SuccessHandler(w, r, http.StatusOK, mess)
 }
 
+// @Summary Restore category
+// @Description Restore a category from the archive. The parent category must be restored first
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Category UUID"
+// @Success 200 {object} respond.SuccessResponse{data=CategoryResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /restore/{uuid} [post]
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	uuidStr := chi.URLParam(r, "uuid")
+
+	_, err := validator.ParseUUID(uuidStr)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	category, mess, err := h.service.Restore(r.Context(), uuidStr)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respond.ErrorHandler(w, r, http.StatusNotFound, nil, mess)
+			return
+		}
+		if errors.Is(err, ErrParentArchived) {
+			respond.ErrorHandler(w, r, http.StatusConflict, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "категория восстановлена", category)
+}
+
 // @Summary Get category tree
 // @Description Get category tree (optionally from a specific UUID node)
 // @Tags categories
@@ -27,6 +27,7 @@ func TestCategoryIntegration(t *testing.T) {
 	})
 
 	const targetUUID = "550e8400-e29b-41d4-a711-446655440002"
+	const childUUID = "550e8400-e29b-41d4-a712-446655440002"
 
 	validJSON := `[
 		{
@@ -112,11 +113,46 @@ func TestCategoryIntegration(t *testing.T) {
 	})
 
 	t.Run("Delete Category", func(t *testing.T) {
+		// категория с подкатегориями не удаляется, чтобы не оставить их без родителя
 		resp := testinit.SendRequest(t, server.URL+"/delete/"+targetUUID, "DELETE", "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/delete/"+childUUID, "DELETE", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/delete/"+targetUUID, "DELETE", "")
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
 		respNotFound := testinit.SendRequest(t, server.URL+"/delete/"+targetUUID, "DELETE", "")
 		assert.Equal(t, http.StatusNotFound, respNotFound.StatusCode)
+
+		respNotFound = testinit.SendRequest(t, server.URL+"/"+targetUUID, "GET", "")
+		assert.Equal(t, http.StatusNotFound, respNotFound.StatusCode)
+	})
+
+	t.Run("Restore Category", func(t *testing.T) {
+		// подкатегория возвращается только после родителя
+		resp := testinit.SendRequest(t, server.URL+"/restore/"+childUUID, "POST", "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/restore/"+targetUUID, "POST", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+
+		var restored category.CategoryResponse
+		testinit.MarshalUnmarshal(t, response.Data, &restored)
+		assert.Equal(t, makeCategoryResp(1, targetUUID, "Категория a обновленная", "N"), restored)
+
+		resp = testinit.SendRequest(t, server.URL+"/restore/"+targetUUID, "POST", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/restore/"+childUUID, "POST", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = testinit.SendRequest(t, server.URL+"/"+childUUID, "GET", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
 	})
 }
 
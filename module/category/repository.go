@@ -60,7 +60,7 @@ func (r *Repository) CreateBatch(ctx context.Context, categories []CategoryEnt)
 
 	query += strings.Join(valueStrings, ", ")
 
-	_, err := r.store.Db.ExecContext(ctx, query, args...)
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query, args...)
 	if err != nil {
 		return store.ContextError(err)
 	}
@@ -83,7 +83,7 @@ func (r *Repository) Create(ctx context.Context, c *CategoryEnt) (*uint, error)
 	c.UpdatedAt = now
 
 	var id uint
-	err := r.store.Db.QueryRowxContext(ctx, query,
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query,
 		c.UUID,
 		c.Name,
 		c.Slug,
@@ -101,15 +101,16 @@ func (r *Repository) Create(ctx context.Context, c *CategoryEnt) (*uint, error)
 	return &id, nil
 }
 
+// GetByUUID — категория не из архива
 func (r *Repository) GetByUUID(ctx context.Context, uuid string) (*CategoryEnt, error) {
 	query := fmt.Sprintf(`
-		SELECT id, uuid, name, slug, active, parent_uuid, created_at, updated_at
+		SELECT id, uuid, name, slug, active, parent_uuid, created_at, updated_at, deleted_at
 		FROM %s
-		WHERE uuid = $1
+		WHERE uuid = $1 AND deleted_at IS NULL
 	`, r.tableName)
 
 	var category CategoryEnt
-	err := r.store.Db.GetContext(ctx, &category, query, uuid)
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &category, query, uuid)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, store.ErrNotFound
@@ -120,13 +121,14 @@ func (r *Repository) GetByUUID(ctx context.Context, uuid string) (*CategoryEnt,
 	return &category, nil
 }
 
+// GetByUUIDs — категории вместе с архивными, чтобы при создании не вставлять повторно категорию из архива
 func (r *Repository) GetByUUIDs(ctx context.Context, uuids []string) ([]CategoryEnt, error) {
 	if len(uuids) == 0 {
 		return nil, nil
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, uuid, name, slug, active, parent_uuid, created_at, updated_at
+		SELECT id, uuid, name, slug, active, parent_uuid, created_at, updated_at, deleted_at
 		FROM %s
 		WHERE uuid IN (?)
 	`, r.tableName)
@@ -139,7 +141,7 @@ func (r *Repository) GetByUUIDs(ctx context.Context, uuids []string) ([]Category
 	query = r.store.Db.Rebind(query)
 
 	var categories []CategoryEnt
-	err = r.store.Db.SelectContext(ctx, &categories, query, args...)
+	err = sqlx.SelectContext(ctx, r.store.Conn(ctx), &categories, query, args...)
 	if err != nil {
 		return nil, store.ContextError(err)
 	}
@@ -151,12 +153,12 @@ func (r *Repository) Update(ctx context.Context, c *CategoryEnt) error {
 	query := fmt.Sprintf(`
 		UPDATE %s
 		SET name = $1, slug = $2, active = $3, parent_uuid = $4, updated_at = $5
-		WHERE uuid = $6
+		WHERE uuid = $6 AND deleted_at IS NULL
 	`, r.tableName)
 
 	c.UpdatedAt = time.Now()
 
-	result, err := r.store.Db.ExecContext(ctx, query,
+	result, err := r.store.Conn(ctx).ExecContext(ctx, query,
 		c.Name,
 		c.Slug,
 		c.Active,
@@ -179,10 +181,11 @@ func (r *Repository) Update(ctx context.Context, c *CategoryEnt) error {
 	return nil
 }
 
+// Delete переносит категорию в архив, окончательно её удаляет задача очистки
 func (r *Repository) Delete(ctx context.Context, uuid string) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE uuid = $1`, r.tableName)
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = $2 WHERE uuid = $1 AND deleted_at IS NULL`, r.tableName)
 
-	result, err := r.store.Db.ExecContext(ctx, query, uuid)
+	result, err := r.store.Conn(ctx).ExecContext(ctx, query, uuid, time.Now())
 	if err != nil {
 		return store.ContextError(err)
 	}
@@ -198,6 +201,85 @@ func (r *Repository) Delete(ctx context.Context, uuid string) error {
 	return nil
 }
 
+// GetArchived — категория из архива
+func (r *Repository) GetArchived(ctx context.Context, uuid string) (*CategoryEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT id, uuid, name, slug, active, parent_uuid, created_at, updated_at, deleted_at
+		FROM %s
+		WHERE uuid = $1 AND deleted_at IS NOT NULL
+	`, r.tableName)
+
+	var category CategoryEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &category, query, uuid)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &category, nil
+}
+
+// Restore возвращает категорию из архива
+func (r *Repository) Restore(ctx context.Context, uuid string) error {
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = NULL, updated_at = $2 WHERE uuid = $1 AND deleted_at IS NOT NULL`, r.tableName)
+
+	result, err := r.store.Conn(ctx).ExecContext(ctx, query, uuid, time.Now())
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if rows == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// HasContent — в категории есть дочерние категории или товары не из архива
+func (r *Repository) HasContent(ctx context.Context, uuid string) (bool, error) {
+	query := fmt.Sprintf(`
+		SELECT EXISTS (SELECT 1 FROM %s WHERE parent_uuid = $1 AND deleted_at IS NULL)
+			OR EXISTS (SELECT 1 FROM products WHERE category_uuid = $1 AND deleted_at IS NULL)
+	`, r.tableName)
+
+	var exists bool
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &exists, query, uuid); err != nil {
+		return false, store.ContextError(err)
+	}
+
+	return exists, nil
+}
+
+// Purge окончательно удаляет категории, которые лежат в архиве с момента before.
+// Категория удаляется, только когда на неё не ссылаются ни дочерние категории, ни товары, ни фильтры,
+// поэтому ветка дерева очищается снизу вверх за несколько проходов
+func (r *Repository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %[1]s c
+		WHERE c.deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM %[1]s ch WHERE ch.parent_uuid = c.uuid)
+			AND NOT EXISTS (SELECT 1 FROM products p WHERE p.category_uuid = c.uuid)
+			AND NOT EXISTS (SELECT 1 FROM filter f WHERE f.category_uuid = c.uuid)
+	`, r.tableName)
+
+	result, err := r.store.Conn(ctx).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+	return rows, nil
+}
+
 func (r *Repository) GetTree(ctx context.Context, rootUUID string) ([]*CategoryTree, error) {
 	baseQuery := `
 		WITH RECURSIVE descendants AS (
@@ -212,7 +294,7 @@ func (r *Repository) GetTree(ctx context.Context, rootUUID string) ([]*CategoryT
 				updated_at,
 				1 AS level
 		FROM %[1]s
-		WHERE %[2]s
+		WHERE %[2]s AND deleted_at IS NULL
 
 			UNION ALL
 
@@ -228,6 +310,7 @@ func (r *Repository) GetTree(ctx context.Context, rootUUID string) ([]*CategoryT
 				d.level + 1
 			FROM categories c
 			INNER JOIN descendants d ON c.parent_uuid = d.uuid
+			WHERE c.deleted_at IS NULL
 		)
 		SELECT *
 		FROM descendants
@@ -247,7 +330,7 @@ func (r *Repository) GetTree(ctx context.Context, rootUUID string) ([]*CategoryT
 	}
 
 	var categories []*CategoryTree
-	if err := r.store.Db.SelectContext(ctx, &categories, query, args...); err != nil {
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &categories, query, args...); err != nil {
 		return nil, store.ContextError(err)
 	}
 
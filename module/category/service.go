@@ -4,15 +4,18 @@ import (
 	"context"
 	"errors"
 	"go-monolite/internal/store"
+	"go-monolite/module/audit"
 	"go-monolite/pkg/helper"
 )
 
 type Service struct {
-	repo *Repository
+	store *store.Store
+	repo  *Repository
+	audit *audit.Recorder
 }
 
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+func NewService(store *store.Store, repo *Repository, recorder *audit.Recorder) *Service {
+	return &Service{store: store, repo: repo, audit: recorder}
 }
 
 func (s *Service) Create(ctx context.Context, reqs []CategoryRequest) ([]CategoryResponse, string, error) {
@@ -39,19 +42,29 @@ func (s *Service) Create(ctx context.Context, reqs []CategoryRequest) ([]Categor
 		return nil, "нет элементов для вставки", nil
 	}
 
-	err = s.repo.CreateBatch(ctx, toInsert)
-	if err != nil {
-		return nil, "произошла ошибка при создания категорий", err
-	}
+	var response []CategoryResponse
+	err = s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateBatch(ctx, toInsert); err != nil {
+			return err
+		}
 
-	insertedUUIDs := helper.CollectKeys(toInsert)
+		categories, err := s.repo.GetByUUIDs(ctx, helper.CollectKeys(toInsert))
+		if err != nil {
+			return err
+		}
+		response = helper.ToResponse(categories)
 
-	categories, err := s.repo.GetByUUIDs(ctx, insertedUUIDs)
+		entries := make([]audit.Entry, 0, len(response))
+		for _, category := range response {
+			entries = append(entries, audit.Created(audit.EntityCategory, category.UUID.String(), category))
+		}
+		return s.audit.Record(ctx, entries...)
+	})
 	if err != nil {
-		return nil, "произошла ошибка при возвращении категорий", err
+		return nil, "произошла ошибка при создания категорий", err
 	}
 
-	return helper.ToResponse(categories), "категории успешно создались", nil
+	return response, "категории успешно создались", nil
 }
 
 func (s *Service) Update(ctx context.Context, req *CategoryRequest) (*CategoryResponse, string, error) {
@@ -65,30 +78,50 @@ func (s *Service) Update(ctx context.Context, req *CategoryRequest) (*CategoryRe
 
 	updated := existing.PatchDto(req)
 
-	err = s.repo.Update(ctx, &updated)
-	if err != nil {
-		return nil, "произошла ошибка при обновлении категорий", err
-	}
+	var response CategoryResponse
+	err = s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, &updated); err != nil {
+			return err
+		}
 
-	afterUpdate, err := s.repo.GetByUUID(ctx, req.UUID.String())
+		afterUpdate, err := s.repo.GetByUUID(ctx, req.UUID.String())
+		if err != nil {
+			return err
+		}
+		response = afterUpdate.ToResponse()
+
+		return s.audit.Record(ctx, audit.Updated(audit.EntityCategory, req.UUID.String(), existing.ToResponse(), response))
+	})
 	if err != nil {
-		return nil, "произошла ошибка при возвращении категорий", err
+		return nil, "произошла ошибка при обновлении категорий", err
 	}
 
-	response := afterUpdate.ToResponse()
-
 	return &response, "", nil
 }
 
 func (s *Service) Delete(ctx context.Context, uuid string) (string, error) {
-	_, err := s.repo.GetByUUID(ctx, uuid)
+	existing, err := s.repo.GetByUUID(ctx, uuid)
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
 			return "категория не найдена", store.ErrNotFound
 		}
 		return "произошла ошибка при получении категории", err
 	}
-	err = s.repo.Delete(ctx, uuid)
+
+	hasContent, err := s.repo.HasContent(ctx, uuid)
+	if err != nil {
+		return "произошла ошибка при получении категории", err
+	}
+	if hasContent {
+		return "в категории есть подкатегории или товары", ErrNotEmpty
+	}
+
+	err = s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, uuid); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Deleted(audit.EntityCategory, uuid, existing.ToResponse()))
+	})
 	if err != nil {
 		return "произошла ошибка при удалении категорий", err
 	}
@@ -96,6 +129,50 @@ func (s *Service) Delete(ctx context.Context, uuid string) (string, error) {
 	return "успешно удалили", nil
 }
 
+// Restore возвращает категорию из архива, если её родитель не в архиве
+func (s *Service) Restore(ctx context.Context, uuid string) (*CategoryResponse, string, error) {
+	archived, err := s.repo.GetArchived(ctx, uuid)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "категория в архиве не найдена", store.ErrNotFound
+		}
+		return nil, "произошла ошибка при получении категории", err
+	}
+
+	if archived.ParentUUID != nil {
+		_, err := s.repo.GetByUUID(ctx, archived.ParentUUID.String())
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "родительская категория в архиве, сначала восстановите её", ErrParentArchived
+		}
+		if err != nil {
+			return nil, "произошла ошибка при получении категории", err
+		}
+	}
+
+	var resp CategoryResponse
+	err = s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Restore(ctx, uuid); err != nil {
+			return err
+		}
+
+		category, err := s.repo.GetByUUID(ctx, uuid)
+		if err != nil {
+			return err
+		}
+		resp = category.ToResponse()
+
+		return s.audit.Record(ctx, audit.Restored(audit.EntityCategory, uuid, resp))
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "категория в архиве не найдена", store.ErrNotFound
+		}
+		return nil, "произошла ошибка при восстановлении категории", err
+	}
+
+	return &resp, "", nil
+}
+
 func (s *Service) GetByUUID(ctx context.Context, uuid string) (*CategoryResponse, string, error) {
 	category, err := s.repo.GetByUUID(ctx, uuid)
 	if err != nil {
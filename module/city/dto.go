@@ -0,0 +1,56 @@
+package city
+
+import "go-monolite/pkg/validator"
+
+// ImportItem — город для POST /city/import, slug по умолчанию строится из названия транслитерацией
+type ImportItem struct {
+	Name   string   `json:"name" validate:"required,max=255" example:"Москва"`
+	Slug   string   `json:"slug,omitempty" validate:"omitempty,max=255" example:"moskva"`
+	Region *string  `json:"region,omitempty" validate:"omitempty,max=255" example:"Московская область"`
+	Lat    *float64 `json:"lat,omitempty" validate:"omitempty,gte=-90,lte=90" example:"55.7558"`
+	Lon    *float64 `json:"lon,omitempty" validate:"omitempty,gte=-180,lte=180" example:"37.6173"`
+}
+
+// ImportRequest — DTO для POST /city/import, города с существующим slug обновляются
+type ImportRequest struct {
+	Items []ImportItem `json:"items" validate:"required,min=1,max=10000,dive"`
+}
+
+func (d *ImportRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// SearchRequest — фильтр GET /city
+type SearchRequest struct {
+	Query string `validate:"max=100"`
+	Limit int    `validate:"min=1,max=100"`
+}
+
+func (d *SearchRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// NearestRequest — точка для GET /city/nearest
+type NearestRequest struct {
+	Lat   float64 `validate:"gte=-90,lte=90"`
+	Lon   float64 `validate:"gte=-180,lte=180"`
+	Limit int     `validate:"min=1,max=20"`
+}
+
+func (d *NearestRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+type CityResponse struct {
+	ID         int64    `json:"id" example:"1"`
+	Name       string   `json:"name" example:"Москва"`
+	Slug       string   `json:"slug" example:"moskva"`
+	Region     *string  `json:"region,omitempty" example:"Московская область"`
+	Lat        *float64 `json:"lat,omitempty" example:"55.7558"`
+	Lon        *float64 `json:"lon,omitempty" example:"37.6173"`
+	DistanceKm *float64 `json:"distance_km,omitempty" example:"12.5"`
+}
+
+type ImportResponse struct {
+	Imported int `json:"imported" example:"100"`
+}
@@ -0,0 +1,37 @@
+package city
+
+import "time"
+
+type CityEnt struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name"`
+	Slug      string    `db:"slug"`
+	Region    *string   `db:"region"`
+	Lat       *float64  `db:"lat"`
+	Lon       *float64  `db:"lon"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (e CityEnt) ToResponse() CityResponse {
+	return CityResponse{
+		ID:     e.ID,
+		Name:   e.Name,
+		Slug:   e.Slug,
+		Region: e.Region,
+		Lat:    e.Lat,
+		Lon:    e.Lon,
+	}
+}
+
+// CityDistanceEnt — город с расстоянием до заданной точки в километрах
+type CityDistanceEnt struct {
+	CityEnt
+	Distance float64 `db:"distance"`
+}
+
+func (e CityDistanceEnt) ToResponse() CityResponse {
+	resp := e.CityEnt.ToResponse()
+	resp.DistanceKm = &e.Distance
+	return resp
+}
@@ -0,0 +1,8 @@
+package city
+
+import "errors"
+
+var (
+	ErrInvalidImport = errors.New("invalid import data")
+	ErrPointRequired = errors.New("lat and lon are required")
+)
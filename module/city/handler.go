@@ -0,0 +1,237 @@
+package city
+
+import (
+	"errors"
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+const (
+	defaultSearchLimit  = 20
+	defaultNearestLimit = 1
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(store *store.Store, cfg *config.Config) *Handler {
+	return &Handler{
+		service: NewService(store, NewRepository(store), cfg.City.DefaultSlug),
+	}
+}
+
+func (h *Handler) Init(r chi.Router) {
+	r.Get("/", h.Search)
+	r.Get("/nearest", h.Nearest)
+	r.Get("/default", h.Default)
+	r.Get("/{id}", h.Get)
+	r.Post("/import", h.Import)
+}
+
+// @Summary Search cities
+// @Description Search cities by name prefix, case and ё/е insensitive, latin queries are matched by transliteration
+// @Tags city
+// @Produce json
+// @Param q query string false "Name prefix"
+// @Param limit query int false "Result size, 20 by default, at most 100"
+// @Success 200 {object} respond.SuccessResponse{data=[]CityResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [get]
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	request := SearchRequest{Query: r.URL.Query().Get("q")}
+
+	var err error
+	if request.Limit, err = queryInt(r, "limit", defaultSearchLimit); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный limit")
+		return
+	}
+
+	resp, mess, err := h.service.Search(r.Context(), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Nearest cities
+// @Description Get cities nearest to a point
+// @Tags city
+// @Produce json
+// @Param lat query number true "Latitude"
+// @Param lon query number true "Longitude"
+// @Param limit query int false "Result size, 1 by default, at most 20"
+// @Success 200 {object} respond.SuccessResponse{data=[]CityResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /nearest [get]
+func (h *Handler) Nearest(w http.ResponseWriter, r *http.Request) {
+	lat, lon, err := queryPoint(r)
+	if err == nil && lat == nil {
+		err = ErrPointRequired
+	}
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректные координаты")
+		return
+	}
+
+	request := NearestRequest{Lat: *lat, Lon: *lon}
+	if request.Limit, err = queryInt(r, "limit", defaultNearestLimit); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный limit")
+		return
+	}
+
+	resp, mess, err := h.service.Nearest(r.Context(), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Default city
+// @Description Resolve the city for an anonymous visitor: the nearest to the coordinates if given, otherwise the default one
+// @Tags city
+// @Produce json
+// @Param lat query number false "Latitude"
+// @Param lon query number false "Longitude"
+// @Success 200 {object} respond.SuccessResponse{data=CityResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /default [get]
+func (h *Handler) Default(w http.ResponseWriter, r *http.Request) {
+	lat, lon, err := queryPoint(r)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректные координаты")
+		return
+	}
+
+	resp, mess, err := h.service.ResolveDefault(r.Context(), nil, lat, lon)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Get city
+// @Description Get a city by ID
+// @Tags city
+// @Produce json
+// @Param id path int true "City ID"
+// @Success 200 {object} respond.SuccessResponse{data=CityResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный идентификатор города")
+		return
+	}
+
+	resp, mess, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Import cities
+// @Description Bulk import cities from JSON ({"items": [...]}) or CSV (Content-Type text/csv, header name,slug,region,lat,lon), cities with an existing slug are updated
+// @Tags city
+// @Accept json
+// @Accept text/csv
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param request body ImportRequest true "Cities"
+// @Success 200 {object} respond.SuccessResponse{data=ImportResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /import [post]
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request ImportRequest
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		var err error
+		if request, err = ParseCSV(body); err != nil {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный CSV")
+			return
+		}
+	} else {
+		mess, err := helper.Unmarshal(body, &request)
+		if err != nil {
+			respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+			return
+		}
+	}
+
+	resp, mess, err := h.service.Import(r.Context(), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, resp)
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, err error, mess string) {
+	if validationErrors, ok := err.(validator.ValidationError); ok {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+		return
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+		return
+	}
+	logger.ErrorCtx(r.Context(), err, mess)
+	respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// queryPoint читает необязательные координаты, передавать нужно обе
+func queryPoint(r *http.Request) (*float64, *float64, error) {
+	latStr, lonStr := r.URL.Query().Get("lat"), r.URL.Query().Get("lon")
+	if latStr == "" && lonStr == "" {
+		return nil, nil, nil
+	}
+
+	lat, err := strconv.ParseFloat(latStr, 64)
+	if err != nil {
+		return nil, nil, err
+	}
+	lon, err := strconv.ParseFloat(lonStr, 64)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &lat, &lon, nil
+}
@@ -0,0 +1,76 @@
+package city
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseCSV читает города из CSV с заголовком, обязательна колонка name, остальные: slug, region, lat, lon
+func ParseCSV(data []byte) (ImportRequest, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return ImportRequest{}, fmt.Errorf("%w: failed to read header: %w", ErrInvalidImport, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return ImportRequest{}, fmt.Errorf("%w: column name is required", ErrInvalidImport)
+	}
+
+	var req ImportRequest
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ImportRequest{}, fmt.Errorf("%w: line %d: %w", ErrInvalidImport, line, err)
+		}
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		item := ImportItem{
+			Name: value("name"),
+			Slug: value("slug"),
+		}
+		if region := value("region"); region != "" {
+			item.Region = &region
+		}
+		if item.Lat, err = parseCoordinate(value("lat")); err != nil {
+			return ImportRequest{}, fmt.Errorf("%w: line %d: lat: %w", ErrInvalidImport, line, err)
+		}
+		if item.Lon, err = parseCoordinate(value("lon")); err != nil {
+			return ImportRequest{}, fmt.Errorf("%w: line %d: lon: %w", ErrInvalidImport, line, err)
+		}
+
+		req.Items = append(req.Items, item)
+	}
+
+	return req, nil
+}
+
+func parseCoordinate(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
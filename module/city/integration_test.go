@@ -0,0 +1,134 @@
+package city_test
+
+import (
+	"go-monolite/module/city"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCityIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	handler := city.NewHandler(store, config)
+	server := testinit.SetupTestServer(t, handler)
+	defer server.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	send := func(t *testing.T, method, path, contentType, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	decodeList := func(t *testing.T, resp *http.Response) []city.CityResponse {
+		t.Helper()
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+
+		var list []city.CityResponse
+		testinit.MarshalUnmarshal(t, response.Data, &list)
+		return list
+	}
+
+	t.Run("Import Validation Error", func(t *testing.T) {
+		resp := send(t, http.MethodPost, "/import", "application/json", `{"items": [{"name": "Москва", "lat": 100}]}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, http.MethodPost, "/import", "text/csv", "title\nМосква\n")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		resp := send(t, http.MethodPost, "/import", "application/json", `{"items": [
+			{"name": "Москва", "region": "Москва", "lat": 55.7558, "lon": 37.6173},
+			{"name": "Орёл", "region": "Орловская область", "lat": 52.9703, "lon": 36.0635}
+		]}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = send(t, http.MethodPost, "/import", "text/csv; charset=utf-8",
+			"name,slug,region,lat,lon\nСанкт-Петербург,,Санкт-Петербург,59.9386,30.3141\nМосква,moskva,Москва,55.7558,37.6173\n")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+
+		var imported city.ImportResponse
+		testinit.MarshalUnmarshal(t, response.Data, &imported)
+		assert.Equal(t, 2, imported.Imported)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		cases := map[string]string{
+			"/?q=моск":   "moskva",
+			"/?q=ОРЕЛ":   "orel",
+			"/?q=orel":   "orel",
+			"/?q=sankt":  "sankt-peterburg",
+			"/?q=Санкт ": "sankt-peterburg",
+		}
+		for path, slug := range cases {
+			resp := send(t, http.MethodGet, path, "", "")
+			require.Equal(t, http.StatusOK, resp.StatusCode, path)
+
+			list := decodeList(t, resp)
+			require.Len(t, list, 1, path)
+			assert.Equal(t, slug, list[0].Slug, path)
+		}
+
+		resp := send(t, http.MethodGet, "/?limit=1000", "", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Nearest", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "/nearest", "", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		// Тула ближе к Москве, чем к Орлу
+		resp = send(t, http.MethodGet, "/nearest?lat=54.1931&lon=37.6173&limit=2", "", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		list := decodeList(t, resp)
+		require.Len(t, list, 2)
+		assert.Equal(t, "moskva", list[0].Slug)
+		require.NotNil(t, list[0].DistanceKm)
+		assert.InDelta(t, 174, *list[0].DistanceKm, 5)
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "/default", "", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+
+		var c city.CityResponse
+		testinit.MarshalUnmarshal(t, response.Data, &c)
+		assert.Equal(t, config.City.DefaultSlug, c.Slug)
+
+		resp = send(t, http.MethodGet, "/default?lat=59.9&lon=30.3", "", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		testinit.DecodeJSON(t, resp.Body, &response)
+		testinit.MarshalUnmarshal(t, response.Data, &c)
+		assert.Equal(t, "sankt-peterburg", c.Slug)
+	})
+
+	t.Run("Get Not Found", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "/999999", "", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS cities_slug_pattern_idx;
+DROP INDEX IF EXISTS cities_search_name_idx;
@@ -0,0 +1,3 @@
+-- поиск по началу названия без учёта регистра и ё, латиницей — по slug
+CREATE INDEX IF NOT EXISTS cities_search_name_idx ON cities (replace(lower(name), 'ё', 'е') text_pattern_ops);
+CREATE INDEX IF NOT EXISTS cities_slug_pattern_idx ON cities (slug text_pattern_ops);
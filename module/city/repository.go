@@ -0,0 +1,144 @@
+package city
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-monolite/internal/store"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const earthRadiusKm = 6371
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type Repository struct {
+	store     *store.Store
+	tableName string
+}
+
+func NewRepository(store *store.Store) *Repository {
+	return &Repository{
+		store:     store,
+		tableName: "cities",
+	}
+}
+
+// Upsert создаёт город или обновляет город с тем же slug
+func (r *Repository) Upsert(ctx context.Context, e *CityEnt) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (name, slug, region, lat, lon, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (slug) DO UPDATE SET
+			name = EXCLUDED.name,
+			region = EXCLUDED.region,
+			lat = EXCLUDED.lat,
+			lon = EXCLUDED.lon,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id
+	`, r.tableName)
+
+	now := time.Now()
+	e.UpdatedAt = now
+
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query,
+		e.Name,
+		e.Slug,
+		e.Region,
+		e.Lat,
+		e.Lon,
+		now,
+	).Scan(&e.ID)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int64) (*CityEnt, error) {
+	return r.getBy(ctx, "id", id)
+}
+
+func (r *Repository) GetBySlug(ctx context.Context, slug string) (*CityEnt, error) {
+	return r.getBy(ctx, "slug", slug)
+}
+
+func (r *Repository) Exists(ctx context.Context, id int64) (bool, error) {
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1)`, r.tableName)
+
+	var exists bool
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &exists, query, id); err != nil {
+		return false, store.ContextError(err)
+	}
+
+	return exists, nil
+}
+
+// Search ищет города по началу нормализованного названия или slug
+func (r *Repository) Search(ctx context.Context, name, slug string, limit int) ([]CityEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT id, name, slug, region, lat, lon, created_at, updated_at
+		FROM %s
+		WHERE replace(lower(name), 'ё', 'е') LIKE $1 OR slug LIKE $2
+		ORDER BY name, id
+		LIMIT $3
+	`, r.tableName)
+
+	var list []CityEnt
+	err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &list, query,
+		likeEscaper.Replace(name)+"%",
+		likeEscaper.Replace(slug)+"%",
+		limit,
+	)
+	if err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return list, nil
+}
+
+// Nearest возвращает ближайшие к точке города с координатами, расстояние считается по формуле гаверсинусов.
+// Для почти противоположных точек погрешность даёт под корнем больше 1, LEAST не даёт asin упасть
+func (r *Repository) Nearest(ctx context.Context, lat, lon float64, limit int) ([]CityDistanceEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT id, name, slug, region, lat, lon, created_at, updated_at,
+			%d * 2 * asin(LEAST(1, sqrt(
+				power(sin(radians(lat - $1) / 2), 2) +
+				cos(radians($1)) * cos(radians(lat)) * power(sin(radians(lon - $2) / 2), 2)
+			))) AS distance
+		FROM %s
+		WHERE lat IS NOT NULL AND lon IS NOT NULL
+		ORDER BY distance, id
+		LIMIT $3
+	`, earthRadiusKm, r.tableName)
+
+	var list []CityDistanceEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &list, query, lat, lon, limit); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return list, nil
+}
+
+func (r *Repository) getBy(ctx context.Context, column string, value any) (*CityEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT id, name, slug, region, lat, lon, created_at, updated_at
+		FROM %s
+		WHERE %s = $1
+	`, r.tableName, column)
+
+	var e CityEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
@@ -0,0 +1,168 @@
+package city
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/validator"
+	"strings"
+
+	"github.com/gosimple/slug"
+)
+
+type Service struct {
+	store       *store.Store
+	repo        *Repository
+	defaultSlug string
+}
+
+// NewService — defaultSlug задаёт город, который выбирается, когда больше ничего о пользователе не известно
+func NewService(store *store.Store, repo *Repository, defaultSlug string) *Service {
+	return &Service{
+		store:       store,
+		repo:        repo,
+		defaultSlug: defaultSlug,
+	}
+}
+
+// Import загружает справочник городов, существующие по slug города обновляются
+func (s *Service) Import(ctx context.Context, req ImportRequest) (*ImportResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	cities := make([]*CityEnt, 0, len(req.Items))
+	for i, item := range req.Items {
+		citySlug := item.Slug
+		if citySlug == "" {
+			citySlug = makeSlug(item.Name)
+		}
+		if citySlug == "" {
+			return nil, "", validator.ValidationError{
+				Err:    validator.ErrorValidation,
+				Fields: map[string]string{fmt.Sprintf("items[%d].slug", i): "Поле slug не удалось построить из названия"},
+			}
+		}
+
+		cities = append(cities, &CityEnt{
+			Name:   item.Name,
+			Slug:   citySlug,
+			Region: item.Region,
+			Lat:    item.Lat,
+			Lon:    item.Lon,
+		})
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		for _, e := range cities {
+			if err := s.repo.Upsert(ctx, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "произошла ошибка при импорте городов", err
+	}
+
+	return &ImportResponse{Imported: len(cities)}, "города загружены", nil
+}
+
+// Search ищет города по началу названия без учёта регистра и ё/е, латинский запрос ищется по slug
+func (s *Service) Search(ctx context.Context, req SearchRequest) ([]CityResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	list, err := s.repo.Search(ctx, normalizeName(req.Query), makeSlug(req.Query), req.Limit)
+	if err != nil {
+		return nil, "произошла ошибка при поиске городов", err
+	}
+
+	return helper.ToResponse(list), "", nil
+}
+
+// Nearest возвращает ближайшие к точке города
+func (s *Service) Nearest(ctx context.Context, req NearestRequest) ([]CityResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	list, err := s.repo.Nearest(ctx, req.Lat, req.Lon, req.Limit)
+	if err != nil {
+		return nil, "произошла ошибка при поиске городов", err
+	}
+
+	return helper.ToResponse(list), "", nil
+}
+
+func (s *Service) Get(ctx context.Context, id int64) (*CityResponse, string, error) {
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "город не найден", err
+		}
+		return nil, "произошла ошибка при получении города", err
+	}
+
+	resp := e.ToResponse()
+	return &resp, "", nil
+}
+
+// ResolveDefault выбирает город пользователя: сохранённый в профиле, иначе ближайший
+// к координатам, иначе город по умолчанию
+func (s *Service) ResolveDefault(ctx context.Context, cityID *int64, lat, lon *float64) (*CityResponse, string, error) {
+	if cityID != nil {
+		e, err := s.repo.GetByID(ctx, *cityID)
+		if err == nil {
+			resp := e.ToResponse()
+			return &resp, "", nil
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return nil, "произошла ошибка при получении города", err
+		}
+	}
+
+	if lat != nil && lon != nil {
+		list, mess, err := s.Nearest(ctx, NearestRequest{Lat: *lat, Lon: *lon, Limit: 1})
+		if err != nil {
+			return nil, mess, err
+		}
+		if len(list) > 0 {
+			return &list[0], "", nil
+		}
+	}
+
+	e, err := s.repo.GetBySlug(ctx, s.defaultSlug)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "город не найден", err
+		}
+		return nil, "произошла ошибка при получении города", err
+	}
+
+	resp := e.ToResponse()
+	return &resp, "", nil
+}
+
+// GetByID — поиск города для других модулей
+func (s *Service) GetByID(ctx context.Context, id int64) (*CityEnt, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Exists — есть ли город в справочнике
+func (s *Service) Exists(ctx context.Context, id int64) (bool, error) {
+	return s.repo.Exists(ctx, id)
+}
+
+// normalizeName — название для поиска: нижний регистр, ё заменена на е
+func normalizeName(s string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "ё", "е")
+}
+
+// makeSlug — slug из названия, ё читается как е, как и в поиске по названию: Орёл → orel
+func makeSlug(name string) string {
+	return slug.Make(normalizeName(name))
+}
@@ -0,0 +1,67 @@
+package company
+
+import (
+	"go-monolite/pkg/validator"
+	"time"
+)
+
+// CreateRequest — DTO для POST /company/create, КПП обязателен для организаций (ИНН из 10 цифр)
+type CreateRequest struct {
+	INN         string  `json:"inn" validate:"required,inn" example:"7707083893"`
+	KPP         *string `json:"kpp,omitempty" validate:"omitempty,kpp" example:"773601001"`
+	CompanyName string  `json:"company_name" validate:"required,max=255" example:"ПАО Сбербанк"`
+}
+
+func (d *CreateRequest) Validate() error {
+	if err := validator.Validate(d); err != nil {
+		return err
+	}
+	if len(d.INN) == 10 && d.KPP == nil {
+		return kppRequiredError()
+	}
+	return nil
+}
+
+// UpdateRequest — DTO для PUT /company/{id}, ИНН не меняется
+type UpdateRequest struct {
+	KPP         *string `json:"kpp,omitempty" validate:"omitempty,kpp"`
+	CompanyName *string `json:"company_name,omitempty" validate:"omitempty,min=1,max=255"`
+}
+
+func (d *UpdateRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// AddUserRequest — DTO для POST /company/{id}/users
+type AddUserRequest struct {
+	UserID int64  `json:"user_id" validate:"required,gt=0"`
+	Role   string `json:"role" validate:"required,oneof=owner buyer"`
+}
+
+func (d *AddUserRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+type CompanyResponse struct {
+	ID          int64     `json:"id" example:"1"`
+	INN         string    `json:"inn" example:"7707083893"`
+	KPP         *string   `json:"kpp,omitempty" example:"773601001"`
+	CompanyName string    `json:"company_name" example:"ПАО Сбербанк"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Role string `json:"role,omitempty" example:"owner"` // роль текущего пользователя в компании
+}
+
+type CompanyUserResponse struct {
+	UserID    int64     `json:"user_id" example:"1"`
+	Role      string    `json:"role" example:"buyer"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func kppRequiredError() error {
+	return validator.ValidationError{
+		Err:    validator.ErrorValidation,
+		Fields: map[string]string{"kpp": "Поле kpp обязательно для организации"},
+	}
+}
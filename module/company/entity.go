@@ -0,0 +1,44 @@
+package company
+
+import "time"
+
+// роли пользователя в компании
+const (
+	RoleOwner = "owner" // управляет компанией и её сотрудниками
+	RoleBuyer = "buyer" // оформляет заказы от имени компании
+)
+
+type CompanyEnt struct {
+	ID          int64     `db:"id"`
+	INN         string    `db:"inn"`
+	KPP         *string   `db:"kpp"`
+	CompanyName string    `db:"company_name"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+func (e CompanyEnt) ToResponse() CompanyResponse {
+	return CompanyResponse{
+		ID:          e.ID,
+		INN:         e.INN,
+		KPP:         e.KPP,
+		CompanyName: e.CompanyName,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+type CompanyUserEnt struct {
+	CompanyID int64     `db:"company_id"`
+	UserID    int64     `db:"user_id"`
+	Role      string    `db:"role"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (e CompanyUserEnt) ToResponse() CompanyUserResponse {
+	return CompanyUserResponse{
+		UserID:    e.UserID,
+		Role:      e.Role,
+		CreatedAt: e.CreatedAt,
+	}
+}
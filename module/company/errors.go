@@ -0,0 +1,11 @@
+package company
+
+import "errors"
+
+var (
+	ErrForbidden        = errors.New("not a company owner")
+	ErrUserInCompany    = errors.New("user already belongs to a company")
+	ErrLastOwner        = errors.New("company must have at least one owner")
+	ErrCompanyExists    = errors.New("company already exists")
+	ErrUserNotInCompany = errors.New("user does not belong to a company")
+)
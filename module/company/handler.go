@@ -0,0 +1,307 @@
+package company
+
+import (
+	"errors"
+	"go-monolite/internal/store"
+	"go-monolite/module/user"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type Handler struct {
+	service  *Service
+	userAuth func(next http.Handler) http.Handler
+}
+
+// NewHandler — authenticate и permissions собираются один раз в роутере сервера
+func NewHandler(store *store.Store, authenticate func(next http.Handler) http.Handler, permissions auth.PermissionChecker) *Handler {
+	return &Handler{
+		service:  NewService(store, NewRepository(store), user.NewRepository(store), permissions),
+		userAuth: authenticate,
+	}
+}
+
+func (h *Handler) Init(r chi.Router) {
+	r.Use(h.userAuth)
+
+	r.Post("/create", h.Create)
+	r.Get("/my", h.My)
+	r.Get("/{id}", h.Get)
+	r.Put("/{id}", h.Update)
+	r.Get("/{id}/users", h.GetUsers)
+	r.Post("/{id}/users", h.AddUser)
+	r.Delete("/{id}/users/{userId}", h.RemoveUser)
+}
+
+// @Summary Create company
+// @Description Create a company, the current user becomes its owner
+// @Tags company
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param request body CreateRequest true "Company data"
+// @Success 201 {object} respond.SuccessResponse{data=CompanyResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /create [post]
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request CreateRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.Create(r.Context(), request, identity)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusCreated, mess, resp)
+}
+
+// @Summary Current user company
+// @Description Get the company of the authenticated user with the user's role
+// @Tags company
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} respond.SuccessResponse{data=CompanyResponse}
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /my [get]
+func (h *Handler) My(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.My(r.Context(), identity.UserID)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Get company
+// @Description Get a company, available to its users and administrators
+// @Tags company
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Company ID"
+// @Success 200 {object} respond.SuccessResponse{data=CompanyResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.Get(r.Context(), id, identity)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Update company
+// @Description Update company details, available to owners
+// @Tags company
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Company ID"
+// @Param request body UpdateRequest true "Company data"
+// @Success 200 {object} respond.SuccessResponse{data=CompanyResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id} [put]
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	body := respond.ParseBody(w, r)
+
+	var request UpdateRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.Update(r.Context(), id, request, identity)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, resp)
+}
+
+// @Summary Get company users
+// @Description Get users of a company with their roles
+// @Tags company
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Company ID"
+// @Success 200 {object} respond.SuccessResponse{data=[]CompanyUserResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id}/users [get]
+func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.GetUsers(r.Context(), id, identity)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Add company user
+// @Description Add a user to a company as owner or buyer, available to owners
+// @Tags company
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Company ID"
+// @Param request body AddUserRequest true "User and role"
+// @Success 201 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id}/users [post]
+func (h *Handler) AddUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	body := respond.ParseBody(w, r)
+
+	var request AddUserRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	mess, err = h.service.AddUser(r.Context(), id, request, identity)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusCreated, mess, "")
+}
+
+// @Summary Remove company user
+// @Description Remove a user from a company, available to owners, users can leave by themselves
+// @Tags company
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Company ID"
+// @Param userId path int true "User ID"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id}/users/{userId} [delete]
+func (h *Handler) RemoveUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := pathID(w, r, "userId")
+	if !ok {
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	mess, err := h.service.RemoveUser(r.Context(), id, userID, identity)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, "")
+}
+
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный идентификатор")
+		return 0, false
+	}
+	return id, true
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, err error, mess string) {
+	if validationErrors, ok := err.(validator.ValidationError); ok {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrForbidden):
+		respond.ErrorHandler(w, r, http.StatusForbidden, err, mess)
+	case errors.Is(err, store.ErrNotFound), errors.Is(err, ErrUserNotInCompany):
+		respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+	case errors.Is(err, ErrCompanyExists), errors.Is(err, ErrUserInCompany), errors.Is(err, ErrLastOwner):
+		respond.ErrorHandler(w, r, http.StatusConflict, err, mess)
+	default:
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+	}
+}
@@ -0,0 +1,177 @@
+package company_test
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/module/apikey"
+	"go-monolite/module/company"
+	"go-monolite/module/user"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"go-monolite/pkg/token"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompanyIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	access := testinit.NewAuth(store, config)
+	handler := company.NewHandler(store, access.Authenticate, access.Users)
+	server := testinit.SetupTestServer(t, handler)
+	defer server.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	ctx := context.Background()
+	userRepo := user.NewRepository(store)
+	tokenManager := token.NewManager(config.Auth.JWTSecret, time.Minute)
+
+	createUser := func(t *testing.T, phone string) (int64, string) {
+		t.Helper()
+		tv := user.InitialTokenVersion
+		id, err := userRepo.Create(ctx, &user.UserEnt{
+			Phone:        &phone,
+			UserType:     user.UserTypeIndividual,
+			Active:       user.ActiveYes,
+			TokenVersion: &tv,
+		})
+		require.NoError(t, err)
+
+		accessToken, _, err := tokenManager.Generate(id, tv, "default")
+		require.NoError(t, err)
+		return id, accessToken
+	}
+
+	send := func(t *testing.T, method, path, body, accessToken string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	ownerID, ownerToken := createUser(t, "79990000020")
+	buyerID, buyerToken := createUser(t, "79990000021")
+
+	var companyID int64
+
+	t.Run("Create Validation Error", func(t *testing.T) {
+		// неверная контрольная цифра ИНН
+		resp := send(t, http.MethodPost, "/create", `{"inn": "7707083894", "kpp": "773601001", "company_name": "Test"}`, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		// у организации КПП обязателен
+		resp = send(t, http.MethodPost, "/create", `{"inn": "7707083893", "company_name": "Test"}`, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, http.MethodPost, "/create", `{"inn": "7707083893", "kpp": "7736", "company_name": "Test"}`, ownerToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Create By API Key", func(t *testing.T) {
+		key, _, err := apikey.NewService(apikey.NewRepository(store)).Create(ctx, apikey.CreateRequest{
+			Name:   "1C exchange",
+			Scopes: []string{apikey.ScopeCatalogWrite},
+		}, 0)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/create", strings.NewReader(`{"inn": "7707083893", "kpp": "773601001", "company_name": "Test"}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "ApiKey "+key.Key)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		body := `{"inn": "7707083893", "kpp": "773601001", "company_name": "Test"}`
+		resp := send(t, http.MethodPost, "/create", body, ownerToken)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+
+		var created company.CompanyResponse
+		testinit.MarshalUnmarshal(t, response.Data, &created)
+		assert.Equal(t, company.RoleOwner, created.Role)
+		companyID = created.ID
+
+		u, err := userRepo.GetByID(ctx, ownerID)
+		require.NoError(t, err)
+		assert.Equal(t, user.UserTypeLegal, u.UserType)
+		require.NotNil(t, u.INN)
+		assert.Equal(t, "7707083893", *u.INN)
+
+		resp = send(t, http.MethodPost, "/create", body, buyerToken)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Add User", func(t *testing.T) {
+		path := fmt.Sprintf("/%d/users", companyID)
+		body := fmt.Sprintf(`{"user_id": %d, "role": "buyer"}`, buyerID)
+
+		resp := send(t, http.MethodPost, path, body, buyerToken)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = send(t, http.MethodPost, path, body, ownerToken)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = send(t, http.MethodPost, path, body, ownerToken)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = send(t, http.MethodGet, "/my", "", buyerToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+
+		var my company.CompanyResponse
+		testinit.MarshalUnmarshal(t, response.Data, &my)
+		assert.Equal(t, companyID, my.ID)
+		assert.Equal(t, company.RoleBuyer, my.Role)
+
+		// покупатель не меняет реквизиты компании
+		resp = send(t, http.MethodPut, fmt.Sprintf("/%d", companyID), `{"company_name": "Other"}`, buyerToken)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = send(t, http.MethodPut, fmt.Sprintf("/%d", companyID), `{"company_name": "Other"}`, ownerToken)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Remove User", func(t *testing.T) {
+		resp := send(t, http.MethodDelete, fmt.Sprintf("/%d/users/%d", companyID, ownerID), "", ownerToken)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		// сотрудник может выйти из компании сам
+		resp = send(t, http.MethodDelete, fmt.Sprintf("/%d/users/%d", companyID, buyerID), "", buyerToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		u, err := userRepo.GetByID(ctx, buyerID)
+		require.NoError(t, err)
+		assert.Equal(t, user.UserTypeIndividual, u.UserType)
+		assert.Nil(t, u.INN)
+
+		resp = send(t, http.MethodGet, fmt.Sprintf("/%d", companyID), "", buyerToken)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = send(t, http.MethodGet, fmt.Sprintf("/%d/users", companyID), "", config.HTTPServer.BearerToken)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
@@ -0,0 +1,16 @@
+-- сотрудники компании, пользователь состоит не больше чем в одной компании,
+-- users.inn повторяет ИНН его компании
+CREATE TABLE IF NOT EXISTS company_users (
+  company_id INT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+  user_id INT NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+  role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'buyer')),
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (company_id, user_id)
+);
+
+-- юридические лица уже привязаны к компании через users.inn, их считаем владельцами
+INSERT INTO company_users (company_id, user_id, role)
+SELECT c.id, u.id, 'owner' FROM users u
+JOIN companies c ON c.inn = u.inn
+WHERE u.user_type = 'legal'
+ON CONFLICT DO NOTHING;
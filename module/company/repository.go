@@ -0,0 +1,195 @@
+package company
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-monolite/internal/store"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository struct {
+	store      *store.Store
+	tableName  string
+	usersTable string
+}
+
+func NewRepository(store *store.Store) *Repository {
+	return &Repository{
+		store:      store,
+		tableName:  "companies",
+		usersTable: "company_users",
+	}
+}
+
+// Create создаёт компанию, если компания с таким ИНН уже есть — store.ErrConflict
+func (r *Repository) Create(ctx context.Context, e *CompanyEnt) (int64, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (inn, kpp, company_name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, r.tableName)
+
+	now := time.Now()
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query,
+		e.INN,
+		e.KPP,
+		e.CompanyName,
+		e.CreatedAt,
+		e.UpdatedAt,
+	).Scan(&e.ID)
+	if err != nil {
+		if store.IsUniqueViolation(err) {
+			return 0, store.ErrConflict
+		}
+		return 0, store.ContextError(err)
+	}
+
+	return e.ID, nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int64) (*CompanyEnt, error) {
+	return r.getBy(ctx, "id", id)
+}
+
+func (r *Repository) GetByINN(ctx context.Context, inn string) (*CompanyEnt, error) {
+	return r.getBy(ctx, "inn", inn)
+}
+
+func (r *Repository) Update(ctx context.Context, e *CompanyEnt) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET kpp = $2, company_name = $3, updated_at = $4
+		WHERE id = $1
+	`, r.tableName)
+
+	e.UpdatedAt = time.Now()
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, e.ID, e.KPP, e.CompanyName, e.UpdatedAt)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// AddUser добавляет пользователя в компанию, если он уже состоит в компании — store.ErrConflict
+func (r *Repository) AddUser(ctx context.Context, companyID, userID int64, role string) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (company_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, r.usersTable)
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query, companyID, userID, role, time.Now())
+	if err != nil {
+		if store.IsUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) RemoveUser(ctx context.Context, companyID, userID int64) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE company_id = $1 AND user_id = $2`, r.usersTable)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, companyID, userID)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetMembership возвращает компанию пользователя, store.ErrNotFound — пользователь не состоит в компании
+func (r *Repository) GetMembership(ctx context.Context, userID int64) (*CompanyUserEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT company_id, user_id, role, created_at
+		FROM %s
+		WHERE user_id = $1
+	`, r.usersTable)
+
+	var e CompanyUserEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+func (r *Repository) GetUsers(ctx context.Context, companyID int64) ([]CompanyUserEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT company_id, user_id, role, created_at
+		FROM %s
+		WHERE company_id = $1
+		ORDER BY created_at, user_id
+	`, r.usersTable)
+
+	var list []CompanyUserEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &list, query, companyID); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return list, nil
+}
+
+// CountOwnersForUpdate считает владельцев компании, блокируя их записи до конца транзакции
+func (r *Repository) CountOwnersForUpdate(ctx context.Context, companyID int64) (int, error) {
+	query := fmt.Sprintf(`
+		SELECT user_id FROM %s
+		WHERE company_id = $1 AND role = $2
+		FOR UPDATE
+	`, r.usersTable)
+
+	var owners []int64
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &owners, query, companyID, RoleOwner); err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	return len(owners), nil
+}
+
+func (r *Repository) getBy(ctx context.Context, column string, value any) (*CompanyEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT id, inn, kpp, company_name, created_at, updated_at
+		FROM %s
+		WHERE %s = $1
+	`, r.tableName, column)
+
+	var e CompanyEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
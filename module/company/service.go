@@ -0,0 +1,294 @@
+package company
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"go-monolite/module/user"
+	"go-monolite/pkg/middleware/auth"
+)
+
+type Service struct {
+	store       *store.Store
+	repo        *Repository
+	userRepo    *user.Repository
+	permissions auth.PermissionChecker
+}
+
+func NewService(store *store.Store, repo *Repository, userRepo *user.Repository, permissions auth.PermissionChecker) *Service {
+	return &Service{
+		store:       store,
+		repo:        repo,
+		userRepo:    userRepo,
+		permissions: permissions,
+	}
+}
+
+// Create создаёт компанию, пользователь становится её владельцем.
+// Суперпользователь создаёт компанию без сотрудников, ключам интеграции создание недоступно
+func (s *Service) Create(ctx context.Context, req CreateRequest, identity auth.Identity) (*CompanyResponse, string, error) {
+	if identity.UserID == 0 && !identity.Superuser {
+		return nil, "создать компанию может только пользователь", ErrForbidden
+	}
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	e := &CompanyEnt{
+		INN:         req.INN,
+		KPP:         req.KPP,
+		CompanyName: req.CompanyName,
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.repo.Create(ctx, e); err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				return ErrCompanyExists
+			}
+			return err
+		}
+		if identity.UserID == 0 {
+			return nil
+		}
+		return Attach(ctx, s.repo, s.userRepo, e, identity.UserID, RoleOwner)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrCompanyExists):
+			return nil, "компания с таким ИНН уже зарегистрирована", err
+		case errors.Is(err, ErrUserInCompany):
+			return nil, "вы уже состоите в компании", err
+		}
+		return nil, "произошла ошибка при создании компании", err
+	}
+
+	resp := e.ToResponse()
+	if identity.UserID != 0 {
+		resp.Role = RoleOwner
+	}
+	return &resp, "компания создана", nil
+}
+
+// My возвращает компанию текущего пользователя
+func (s *Service) My(ctx context.Context, userID int64) (*CompanyResponse, string, error) {
+	membership, err := s.repo.GetMembership(ctx, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "вы не состоите в компании", ErrUserNotInCompany
+		}
+		return nil, "произошла ошибка при получении компании", err
+	}
+
+	e, err := s.repo.GetByID(ctx, membership.CompanyID)
+	if err != nil {
+		return nil, "произошла ошибка при получении компании", err
+	}
+
+	resp := e.ToResponse()
+	resp.Role = membership.Role
+	return &resp, "", nil
+}
+
+func (s *Service) Get(ctx context.Context, id int64, identity auth.Identity) (*CompanyResponse, string, error) {
+	role, err := s.access(ctx, id, identity, false)
+	if err != nil {
+		return nil, accessMessage(err, "нет доступа к компании"), err
+	}
+
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "компания не найдена", err
+		}
+		return nil, "произошла ошибка при получении компании", err
+	}
+
+	resp := e.ToResponse()
+	resp.Role = role
+	return &resp, "", nil
+}
+
+// Update меняет реквизиты компании, доступно владельцу
+func (s *Service) Update(ctx context.Context, id int64, req UpdateRequest, identity auth.Identity) (*CompanyResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	role, err := s.access(ctx, id, identity, true)
+	if err != nil {
+		return nil, accessMessage(err, "изменять компанию может только владелец"), err
+	}
+
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "компания не найдена", err
+		}
+		return nil, "произошла ошибка при получении компании", err
+	}
+
+	if req.KPP != nil {
+		e.KPP = req.KPP
+	}
+	if req.CompanyName != nil {
+		e.CompanyName = *req.CompanyName
+	}
+
+	if err := s.repo.Update(ctx, e); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "компания не найдена", err
+		}
+		return nil, "произошла ошибка при изменении компании", err
+	}
+
+	resp := e.ToResponse()
+	resp.Role = role
+	return &resp, "компания изменена", nil
+}
+
+func (s *Service) GetUsers(ctx context.Context, id int64, identity auth.Identity) ([]CompanyUserResponse, string, error) {
+	if _, err := s.access(ctx, id, identity, false); err != nil {
+		return nil, accessMessage(err, "нет доступа к компании"), err
+	}
+
+	list, err := s.repo.GetUsers(ctx, id)
+	if err != nil {
+		return nil, "произошла ошибка при получении сотрудников", err
+	}
+
+	resp := make([]CompanyUserResponse, 0, len(list))
+	for _, e := range list {
+		resp = append(resp, e.ToResponse())
+	}
+	return resp, "", nil
+}
+
+// AddUser добавляет сотрудника, доступно владельцу. Пользователь становится юридическим лицом компании
+func (s *Service) AddUser(ctx context.Context, id int64, req AddUserRequest, identity auth.Identity) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+
+	if _, err := s.access(ctx, id, identity, true); err != nil {
+		return accessMessage(err, "добавлять сотрудников может только владелец"), err
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		e, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := s.userRepo.GetByID(ctx, req.UserID); err != nil {
+			return err
+		}
+		return Attach(ctx, s.repo, s.userRepo, e, req.UserID, req.Role)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return "компания или пользователь не найдены", err
+		case errors.Is(err, ErrUserInCompany):
+			return "пользователь уже состоит в компании", err
+		}
+		return "произошла ошибка при добавлении сотрудника", err
+	}
+
+	return "сотрудник добавлен", nil
+}
+
+// RemoveUser исключает сотрудника, доступно владельцу, сотрудник может выйти из компании сам.
+// Последнего владельца исключить нельзя
+func (s *Service) RemoveUser(ctx context.Context, id, userID int64, identity auth.Identity) (string, error) {
+	if identity.UserID != userID {
+		if _, err := s.access(ctx, id, identity, true); err != nil {
+			return accessMessage(err, "исключать сотрудников может только владелец"), err
+		}
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		membership, err := s.repo.GetMembership(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if membership.CompanyID != id {
+			return store.ErrNotFound
+		}
+
+		if membership.Role == RoleOwner {
+			owners, err := s.repo.CountOwnersForUpdate(ctx, id)
+			if err != nil {
+				return err
+			}
+			if owners <= 1 {
+				return ErrLastOwner
+			}
+		}
+
+		if err := s.repo.RemoveUser(ctx, id, userID); err != nil {
+			return err
+		}
+		return s.userRepo.SetCompany(ctx, userID, nil, user.UserTypeIndividual)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return "сотрудник не найден", err
+		case errors.Is(err, ErrLastOwner):
+			return "нельзя исключить последнего владельца компании", err
+		}
+		return "произошла ошибка при исключении сотрудника", err
+	}
+
+	return "сотрудник исключён", nil
+}
+
+// Attach добавляет пользователя в компанию и переводит его в юридические лица с ИНН компании.
+// Используется и при регистрации юридического лица, вызывается внутри транзакции
+func Attach(ctx context.Context, repo *Repository, userRepo *user.Repository, e *CompanyEnt, userID int64, role string) error {
+	if err := repo.AddUser(ctx, e.ID, userID, role); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			return ErrUserInCompany
+		}
+		return fmt.Errorf("failed to add company user: %w", err)
+	}
+	if err := userRepo.SetCompany(ctx, userID, &e.INN, user.UserTypeLegal); err != nil {
+		return fmt.Errorf("failed to set user company: %w", err)
+	}
+	return nil
+}
+
+// access возвращает роль пользователя в компании. Суперпользователь и пользователи с правом
+// users:manage имеют доступ к любой компании, для остальных нужно членство, а при owner — роль владельца
+func (s *Service) access(ctx context.Context, companyID int64, identity auth.Identity, owner bool) (string, error) {
+	if identity.Superuser {
+		return "", nil
+	}
+	if identity.UserID == 0 {
+		return "", ErrForbidden
+	}
+
+	membership, err := s.repo.GetMembership(ctx, identity.UserID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return "", err
+	}
+	if membership != nil && membership.CompanyID == companyID && (!owner || membership.Role == RoleOwner) {
+		return membership.Role, nil
+	}
+
+	allowed, err := s.permissions.HasPermission(ctx, identity.UserID, user.PermissionUsersManage)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", ErrForbidden
+	}
+	return "", nil
+}
+
+func accessMessage(err error, forbidden string) string {
+	if errors.Is(err, ErrForbidden) {
+		return forbidden
+	}
+	return "произошла ошибка при проверке доступа"
+}
@@ -0,0 +1,125 @@
+package customerfeed
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+const commerceMLVersion = "2.10"
+
+// документ CommerceML с разделом Контрагенты
+type commerceInfo struct {
+	XMLName        xml.Name       `xml:"КоммерческаяИнформация"`
+	SchemaVersion  string         `xml:"ВерсияСхемы,attr"`
+	CreatedAt      string         `xml:"ДатаФормирования,attr"`
+	Counterparties []counterparty `xml:"Контрагенты>Контрагент"`
+}
+
+type counterparty struct {
+	ID         string      `xml:"Ид"`
+	Version    int64       `xml:"НомерВерсии"`
+	Name       string      `xml:"Наименование"`
+	Legal      *legalInfo  `xml:"РеквизитыЮрЛица,omitempty"`
+	Person     *personInfo `xml:"РеквизитыФизЛица,omitempty"`
+	Contacts   *contacts   `xml:"Контакты,omitempty"`
+	Requisites *requisites `xml:"ЗначенияРеквизитов,omitempty"`
+}
+
+type legalInfo struct {
+	OfficialName string `xml:"ОфициальноеНаименование"`
+	INN          string `xml:"ИНН"`
+	KPP          string `xml:"КПП,omitempty"`
+}
+
+type personInfo struct {
+	FullName   string `xml:"ПолноеНаименование"`
+	LastName   string `xml:"Фамилия,omitempty"`
+	FirstName  string `xml:"Имя,omitempty"`
+	SecondName string `xml:"Отчество,omitempty"`
+}
+
+// обёртки вместо путей a>b, чтобы пустые списки не попадали в документ
+type contacts struct {
+	Items []contact `xml:"Контакт"`
+}
+
+type requisites struct {
+	Items []requisite `xml:"ЗначениеРеквизита"`
+}
+
+type contact struct {
+	Type  string `xml:"Тип"`
+	Value string `xml:"Значение"`
+}
+
+type requisite struct {
+	Name  string `xml:"Наименование"`
+	Value string `xml:"Значение"`
+}
+
+// CommerceML — выгрузка в формате обмена 1С. Сотрудник компании ссылается на неё реквизитом ИдОрганизации,
+// код 1С, если он уже получен, передаётся реквизитом Код
+func CommerceML(items []CounterpartyResponse, now time.Time) ([]byte, error) {
+	doc := commerceInfo{
+		SchemaVersion:  commerceMLVersion,
+		CreatedAt:      now.Format("2006-01-02T15:04:05"),
+		Counterparties: make([]counterparty, 0, len(items)),
+	}
+
+	for _, item := range items {
+		c := counterparty{
+			ID:      item.ExternalID,
+			Version: item.Version,
+			Name:    item.Name,
+		}
+
+		var cs contacts
+		var rs requisites
+		if item.Type == EntityCompany {
+			c.Legal = &legalInfo{OfficialName: item.Name, INN: value(item.INN), KPP: value(item.KPP)}
+		} else {
+			c.Person = &personInfo{
+				FullName:   item.Name,
+				LastName:   value(item.LastName),
+				FirstName:  value(item.FirstName),
+				SecondName: value(item.SecondName),
+			}
+			if item.Email != nil {
+				cs.Items = append(cs.Items, contact{Type: "Электронная почта", Value: *item.Email})
+			}
+			if item.Phone != nil {
+				cs.Items = append(cs.Items, contact{Type: "Телефон мобильный", Value: *item.Phone})
+			}
+			rs.Items = append(rs.Items, requisite{Name: "Активен", Value: strconv.FormatBool(item.Active)})
+			if item.CompanyID != nil {
+				rs.Items = append(rs.Items, requisite{Name: "ИдОрганизации", Value: ExternalID(EntityCompany, *item.CompanyID)})
+			}
+		}
+
+		if item.Code != nil {
+			rs.Items = append(rs.Items, requisite{Name: "Код", Value: *item.Code})
+		}
+		if len(cs.Items) > 0 {
+			c.Contacts = &cs
+		}
+		if len(rs.Items) > 0 {
+			c.Requisites = &rs
+		}
+
+		doc.Counterparties = append(doc.Counterparties, c)
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
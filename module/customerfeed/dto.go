@@ -0,0 +1,94 @@
+package customerfeed
+
+import (
+	"go-monolite/pkg/validator"
+	"time"
+)
+
+// FeedRequest — параметры GET /exchange/customers
+type FeedRequest struct {
+	Since  int64  `validate:"min=0"`
+	Limit  int    `validate:"min=1,max=1000"`
+	Format string `validate:"oneof=json commerceml"`
+}
+
+func (d *FeedRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// AckRequest — DTO для POST /exchange/customers/ack
+type AckRequest struct {
+	Items []AckItem `json:"items" validate:"required,min=1,max=1000,dive"`
+}
+
+type AckItem struct {
+	Type    string `json:"type" validate:"required,oneof=user company" example:"user"`
+	ID      int64  `json:"id" validate:"required,gt=0" example:"12"`
+	Version int64  `json:"version" validate:"required,gt=0" example:"345"` // версия из выгрузки
+}
+
+func (d *AckRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// CodesRequest — DTO для POST /exchange/customers/codes
+type CodesRequest struct {
+	Items []CodeItem `json:"items" validate:"required,min=1,max=1000,dive"`
+}
+
+type CodeItem struct {
+	Type string `json:"type" validate:"required,oneof=user company" example:"company"`
+	ID   int64  `json:"id" validate:"required,gt=0" example:"3"`
+	Code string `json:"code" validate:"required,max=100" example:"00-000123"` // код контрагента в 1С
+}
+
+func (d *CodesRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// CounterpartyResponse — покупатель или компания, поля зависят от типа
+type CounterpartyResponse struct {
+	Type       string  `json:"type" example:"user"`
+	ID         int64   `json:"id" example:"12"`
+	ExternalID string  `json:"external_id" example:"user-12"`
+	Version    int64   `json:"version" example:"345"`
+	Code       *string `json:"code,omitempty" example:"00-000123"`
+	Name       string  `json:"name" example:"Петров Иван"`
+
+	Email      *string `json:"email,omitempty"`
+	Phone      *string `json:"phone,omitempty"`
+	FirstName  *string `json:"first_name,omitempty"`
+	LastName   *string `json:"last_name,omitempty"`
+	SecondName *string `json:"second_name,omitempty"`
+	UserType   string  `json:"user_type,omitempty" example:"legal"`
+
+	INN *string `json:"inn,omitempty" example:"7701234567"`
+	KPP *string `json:"kpp,omitempty"`
+
+	Active      bool    `json:"active"`
+	CompanyID   *int64  `json:"company_id,omitempty"`
+	CompanyRole *string `json:"company_role,omitempty" example:"owner"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type FeedResponse struct {
+	Items   []CounterpartyResponse `json:"items"`
+	Cursor  int64                  `json:"cursor" example:"345"` // since для следующего запроса
+	HasMore bool                   `json:"has_more"`
+}
+
+type AckResponse struct {
+	Acknowledged int `json:"acknowledged" example:"2"`
+}
+
+type CodesResponse struct {
+	Updated  int         `json:"updated" example:"2"`
+	NotFound []EntityRef `json:"not_found"`
+}
+
+type EntityRef struct {
+	Type string `json:"type" example:"user"`
+	ID   int64  `json:"id" example:"12"`
+}
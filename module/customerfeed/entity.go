@@ -0,0 +1,114 @@
+package customerfeed
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// типы записей выгрузки
+const (
+	EntityUser    = "user"
+	EntityCompany = "company"
+)
+
+// форматы выгрузки
+const (
+	FormatJSON       = "json"
+	FormatCommerceML = "commerceml"
+)
+
+// UserEnt — покупатель в выгрузке вместе с компанией, в которой он состоит
+type UserEnt struct {
+	ID          int64     `db:"id"`
+	Version     int64     `db:"exchange_version"`
+	Code        *string   `db:"exchange_code"`
+	Email       *string   `db:"email"`
+	Phone       *string   `db:"phone"`
+	Name        *string   `db:"name"`
+	LastName    *string   `db:"last_name"`
+	SecondName  *string   `db:"second_name"`
+	UserType    string    `db:"user_type"`
+	INN         *string   `db:"inn"`
+	Active      string    `db:"active"`
+	CompanyID   *int64    `db:"company_id"`
+	CompanyRole *string   `db:"company_role"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+func (e UserEnt) ToResponse() CounterpartyResponse {
+	return CounterpartyResponse{
+		Type:        EntityUser,
+		ID:          e.ID,
+		ExternalID:  ExternalID(EntityUser, e.ID),
+		Version:     e.Version,
+		Code:        e.Code,
+		Name:        e.fullName(),
+		Email:       e.Email,
+		Phone:       e.Phone,
+		FirstName:   e.Name,
+		LastName:    e.LastName,
+		SecondName:  e.SecondName,
+		UserType:    e.UserType,
+		INN:         e.INN,
+		Active:      e.Active == "Y",
+		CompanyID:   e.CompanyID,
+		CompanyRole: e.CompanyRole,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+// fullName — ФИО, а без него email или телефон, чтобы у контрагента в 1С всегда было наименование
+func (e UserEnt) fullName() string {
+	var parts []string
+	for _, p := range []*string{e.LastName, e.Name, e.SecondName} {
+		if p != nil && *p != "" {
+			parts = append(parts, *p)
+		}
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, " ")
+	}
+	if e.Email != nil && *e.Email != "" {
+		return *e.Email
+	}
+	if e.Phone != nil {
+		return *e.Phone
+	}
+	return ExternalID(EntityUser, e.ID)
+}
+
+type CompanyEnt struct {
+	ID          int64     `db:"id"`
+	Version     int64     `db:"exchange_version"`
+	Code        *string   `db:"exchange_code"`
+	INN         string    `db:"inn"`
+	KPP         *string   `db:"kpp"`
+	CompanyName string    `db:"company_name"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+func (e CompanyEnt) ToResponse() CounterpartyResponse {
+	inn := e.INN
+	return CounterpartyResponse{
+		Type:       EntityCompany,
+		ID:         e.ID,
+		ExternalID: ExternalID(EntityCompany, e.ID),
+		Version:    e.Version,
+		Code:       e.Code,
+		Name:       e.CompanyName,
+		INN:        &inn,
+		KPP:        e.KPP,
+		Active:     true,
+		CreatedAt:  e.CreatedAt,
+		UpdatedAt:  e.UpdatedAt,
+	}
+}
+
+// ExternalID — идентификатор контрагента сайта в 1С, например user-12 или company-3
+func ExternalID(entity string, id int64) string {
+	return fmt.Sprintf("%s-%d", entity, id)
+}
@@ -0,0 +1,191 @@
+package customerfeed
+
+import (
+	"errors"
+	"go-monolite/internal/store"
+	"go-monolite/module/apikey"
+	"go-monolite/module/user"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+const (
+	defaultFeedLimit = 100
+
+	// заголовки выгрузки в CommerceML, в JSON то же самое приходит в теле ответа
+	CursorHeader  = "X-Feed-Cursor"
+	HasMoreHeader = "X-Feed-Has-More"
+)
+
+// Handler — выгрузка покупателей в 1С, доступна пользователям с правом customers:export
+// и ключам интеграций со scope customers:export
+type Handler struct {
+	service      *Service
+	authenticate func(next http.Handler) http.Handler
+	requireRole  func(next http.Handler) http.Handler
+}
+
+// NewHandler — authenticate и permissions собираются один раз в роутере сервера
+func NewHandler(store *store.Store, authenticate func(next http.Handler) http.Handler, permissions auth.PermissionChecker) *Handler {
+	return &Handler{
+		service:      NewService(store, NewRepository(store)),
+		authenticate: authenticate,
+		requireRole:  auth.RequirePermission(permissions, user.PermissionCustomersExport, apikey.ScopeCustomersExport),
+	}
+}
+
+func (h *Handler) Init(r chi.Router) {
+	r.Use(h.authenticate, h.requireRole)
+
+	r.Get("/", h.Feed)
+	r.Post("/ack", h.Ack)
+	r.Post("/codes", h.SetCodes)
+}
+
+// @Summary Customer feed
+// @Description New and changed customers and companies with a version greater than since, excluding versions already acknowledged. Pass the returned cursor as since of the next request; records of transactions still in progress are held back so the cursor never skips them. since=0 returns everything 1C has not acknowledged yet and should be polled periodically together with acknowledgements
+// @Tags exchange
+// @Produce json,xml
+// @Param Authorization header string true "Bearer access token or integration key"
+// @Param since query int false "Cursor from the previous response"
+// @Param limit query int false "Page size, 100 by default, at most 1000"
+// @Param format query string false "json (default) or commerceml"
+// @Success 200 {object} respond.SuccessResponse{data=FeedResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [get]
+func (h *Handler) Feed(w http.ResponseWriter, r *http.Request) {
+	request := FeedRequest{Format: r.URL.Query().Get("format")}
+	if request.Format == "" {
+		request.Format = FormatJSON
+	}
+
+	var err error
+	if request.Since, err = strconv.ParseInt(valueOr(r.URL.Query().Get("since"), "0"), 10, 64); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный since")
+		return
+	}
+	if request.Limit, err = strconv.Atoi(valueOr(r.URL.Query().Get("limit"), strconv.Itoa(defaultFeedLimit))); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный limit")
+		return
+	}
+
+	resp, mess, err := h.service.Feed(r.Context(), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	if request.Format == FormatJSON {
+		respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+		return
+	}
+
+	body, err := CommerceML(resp.Items, time.Now())
+	if err != nil {
+		respondError(w, r, err, "произошла ошибка при выгрузке покупателей")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set(CursorHeader, strconv.FormatInt(resp.Cursor, 10))
+	w.Header().Set(HasMoreHeader, strconv.FormatBool(resp.HasMore))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		logger.ErrorCtx(r.Context(), err, "failed to write customer feed")
+	}
+}
+
+// @Summary Acknowledge customer feed
+// @Description Record versions 1C has consumed. A record is returned by the feed again only after its next change
+// @Tags exchange
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token or integration key"
+// @Param request body AckRequest true "Consumed records"
+// @Success 200 {object} respond.SuccessResponse{data=AckResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /ack [post]
+func (h *Handler) Ack(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request AckRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.Ack(r.Context(), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Set 1C counterparty codes
+// @Description Store 1C counterparty codes against site customers and companies. Unknown records are listed in not_found, a code already used by another counterparty rejects the request
+// @Tags exchange
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token or integration key"
+// @Param request body CodesRequest true "1C codes"
+// @Success 200 {object} respond.SuccessResponse{data=CodesResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /codes [post]
+func (h *Handler) SetCodes(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request CodesRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.SetCodes(r.Context(), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+func valueOr(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, err error, mess string) {
+	var validationErrors validator.ValidationError
+	if errors.As(err, &validationErrors) {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+		return
+	}
+
+	logger.ErrorCtx(r.Context(), err, mess)
+	respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+}
@@ -0,0 +1,239 @@
+package customerfeed_test
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/module/company"
+	"go-monolite/module/customerfeed"
+	"go-monolite/module/user"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomerFeedIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	access := testinit.NewAuth(store, config)
+	handler := customerfeed.NewHandler(store, access.Authenticate, access.Users)
+	server := testinit.SetupTestServer(t, handler)
+	defer server.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	ctx := context.Background()
+	users := user.NewRepository(store)
+	companies := company.NewRepository(store)
+
+	createUser := func(t *testing.T, phone string, userType user.UserType, inn *string) int64 {
+		t.Helper()
+		tv := user.InitialTokenVersion
+		id, err := users.Create(ctx, &user.UserEnt{
+			Phone:        &phone,
+			UserType:     userType,
+			INN:          inn,
+			Active:       user.ActiveYes,
+			TokenVersion: &tv,
+		})
+		require.NoError(t, err)
+		return id
+	}
+
+	kpp := "770101001"
+	companyID, err := companies.Create(ctx, &company.CompanyEnt{INN: "7701234567", KPP: &kpp, CompanyName: "ООО Ромашка"})
+	require.NoError(t, err)
+	inn := "7701234567"
+	legalID := createUser(t, "79990000201", user.UserTypeLegal, &inn)
+	require.NoError(t, companies.AddUser(ctx, companyID, legalID, company.RoleOwner))
+	customerID := createUser(t, "79990000202", user.UserTypeIndividual, nil)
+
+	// сотрудники магазина в 1С не выгружаются
+	staffID := createUser(t, "79990000203", user.UserTypeIndividual, nil)
+	for _, query := range []string{
+		`INSERT INTO roles (code, name) VALUES ('admin', 'Администратор') ON CONFLICT (code) DO NOTHING`,
+		fmt.Sprintf(`INSERT INTO user_roles (user_id, role_id) SELECT %d, id FROM roles WHERE code = 'admin'`, staffID),
+	} {
+		_, err := store.Db.Exec(query)
+		require.NoError(t, err)
+	}
+
+	send := func(t *testing.T, method, path, body, accessToken string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	decode := func(t *testing.T, resp *http.Response, v any) {
+		t.Helper()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		testinit.MarshalUnmarshal(t, response.Data, v)
+	}
+
+	feed := func(t *testing.T, query string) customerfeed.FeedResponse {
+		t.Helper()
+		var f customerfeed.FeedResponse
+		decode(t, send(t, http.MethodGet, "/"+query, "", config.HTTPServer.BearerToken), &f)
+		return f
+	}
+
+	find := func(f customerfeed.FeedResponse, entity string, id int64) (customerfeed.CounterpartyResponse, bool) {
+		for _, item := range f.Items {
+			if item.Type == entity && item.ID == id {
+				return item, true
+			}
+		}
+		return customerfeed.CounterpartyResponse{}, false
+	}
+
+	ack := func(t *testing.T, items []customerfeed.CounterpartyResponse) {
+		t.Helper()
+		var parts []string
+		for _, item := range items {
+			parts = append(parts, fmt.Sprintf(`{"type": "%s", "id": %d, "version": %d}`, item.Type, item.ID, item.Version))
+		}
+		var resp customerfeed.AckResponse
+		decode(t, send(t, http.MethodPost, "/ack", `{"items": [`+strings.Join(parts, ",")+`]}`, config.HTTPServer.BearerToken), &resp)
+		assert.Equal(t, len(items), resp.Acknowledged)
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "/", "", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Feed", func(t *testing.T) {
+		f := feed(t, "")
+		require.Len(t, f.Items, 3)
+		assert.False(t, f.HasMore)
+		assert.Equal(t, f.Items[2].Version, f.Cursor)
+
+		legal, ok := find(f, customerfeed.EntityUser, legalID)
+		require.True(t, ok)
+		require.NotNil(t, legal.CompanyID)
+		assert.Equal(t, companyID, *legal.CompanyID)
+
+		c, ok := find(f, customerfeed.EntityCompany, companyID)
+		require.True(t, ok)
+		assert.Equal(t, "ООО Ромашка", c.Name)
+		assert.Equal(t, "company-"+fmt.Sprint(companyID), c.ExternalID)
+
+		_, ok = find(f, customerfeed.EntityUser, staffID)
+		assert.False(t, ok)
+
+		// постранично с курсором
+		page := feed(t, "?limit=2")
+		require.Len(t, page.Items, 2)
+		assert.True(t, page.HasMore)
+		next := feed(t, fmt.Sprintf("?limit=2&since=%d", page.Cursor))
+		require.Len(t, next.Items, 1)
+		assert.False(t, next.HasMore)
+		assert.Equal(t, f.Items[2].ID, next.Items[0].ID)
+
+		resp := send(t, http.MethodGet, "/?format=csv", "", config.HTTPServer.BearerToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("CommerceML", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "/?format=commerceml", "", config.HTTPServer.BearerToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "application/xml")
+		assert.NotEmpty(t, resp.Header.Get(customerfeed.CursorHeader))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "<КоммерческаяИнформация")
+		assert.Contains(t, string(body), "<ИНН>7701234567</ИНН>")
+		assert.Contains(t, string(body), fmt.Sprintf("<Значение>company-%d</Значение>", companyID))
+	})
+
+	t.Run("Ack", func(t *testing.T) {
+		ack(t, feed(t, "").Items)
+		assert.Empty(t, feed(t, "").Items)
+
+		// смена данных профиля возвращает покупателя в выгрузку, служебные поля — нет
+		_, err := store.Db.Exec(fmt.Sprintf(`UPDATE users SET token_version = '2' WHERE id = %d`, customerID))
+		require.NoError(t, err)
+		assert.Empty(t, feed(t, "").Items)
+
+		_, err = store.Db.Exec(fmt.Sprintf(`UPDATE users SET name = 'Иван' WHERE id = %d`, customerID))
+		require.NoError(t, err)
+		f := feed(t, "")
+		require.Len(t, f.Items, 1)
+		assert.Equal(t, customerID, f.Items[0].ID)
+		assert.Equal(t, "Иван", f.Items[0].Name)
+		ack(t, f.Items)
+	})
+
+	t.Run("Codes", func(t *testing.T) {
+		var resp customerfeed.CodesResponse
+		body := fmt.Sprintf(`{"items": [{"type": "company", "id": %d, "code": "00-000123"}, {"type": "user", "id": %d, "code": "00-000124"}, {"type": "user", "id": 999999, "code": "00-000125"}]}`, companyID, legalID)
+		decode(t, send(t, http.MethodPost, "/codes", body, config.HTTPServer.BearerToken), &resp)
+		assert.Equal(t, 2, resp.Updated)
+		require.Len(t, resp.NotFound, 1)
+		assert.Equal(t, int64(999999), resp.NotFound[0].ID)
+
+		// коды из 1С не выгружаются обратно
+		assert.Empty(t, feed(t, "").Items)
+
+		body = fmt.Sprintf(`{"items": [{"type": "user", "id": %d, "code": "00-000124"}]}`, customerID)
+		r := send(t, http.MethodPost, "/codes", body, config.HTTPServer.BearerToken)
+		assert.Equal(t, http.StatusBadRequest, r.StatusCode)
+
+		_, err := store.Db.Exec(fmt.Sprintf(`UPDATE companies SET company_name = 'ООО Ромашка-2' WHERE id = %d`, companyID))
+		require.NoError(t, err)
+		f := feed(t, "")
+		require.Len(t, f.Items, 1)
+		require.NotNil(t, f.Items[0].Code)
+		assert.Equal(t, "00-000123", *f.Items[0].Code)
+	})
+
+	t.Run("Late Commit", func(t *testing.T) {
+		f := feed(t, "")
+		ack(t, f.Items)
+		since := f.Cursor
+
+		// покупатель получает версию раньше компании, но его транзакция заканчивается позже
+		tx, err := store.Db.BeginTxx(ctx, nil)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		_, err = tx.Exec(fmt.Sprintf(`UPDATE users SET name = 'Пётр' WHERE id = %d`, customerID))
+		require.NoError(t, err)
+
+		_, err = store.Db.Exec(fmt.Sprintf(`UPDATE companies SET company_name = 'ООО Ромашка-3' WHERE id = %d`, companyID))
+		require.NoError(t, err)
+
+		// курсор не проходит версию незавершённой транзакции
+		f = feed(t, fmt.Sprintf("?since=%d", since))
+		assert.Empty(t, f.Items)
+		assert.Equal(t, since, f.Cursor)
+
+		require.NoError(t, tx.Commit())
+		f = feed(t, fmt.Sprintf("?since=%d", since))
+		require.Len(t, f.Items, 2)
+		assert.Equal(t, customerfeed.EntityUser, f.Items[0].Type)
+		assert.Equal(t, customerID, f.Items[0].ID)
+		assert.Equal(t, "Пётр", f.Items[0].Name)
+		assert.Equal(t, customerfeed.EntityCompany, f.Items[1].Type)
+		assert.Less(t, f.Items[0].Version, f.Items[1].Version)
+	})
+}
@@ -0,0 +1,15 @@
+DELETE FROM permissions WHERE code = 'customers:export';
+
+DROP TABLE IF EXISTS exchange_acks;
+
+DROP TRIGGER IF EXISTS company_users_exchange_version ON company_users;
+DROP TRIGGER IF EXISTS companies_exchange_version ON companies;
+DROP TRIGGER IF EXISTS users_exchange_version ON users;
+DROP FUNCTION IF EXISTS company_users_exchange_version();
+DROP FUNCTION IF EXISTS companies_exchange_version();
+DROP FUNCTION IF EXISTS users_exchange_version();
+
+ALTER TABLE companies DROP COLUMN IF EXISTS exchange_code, DROP COLUMN IF EXISTS exchange_xid, DROP COLUMN IF EXISTS exchange_version;
+ALTER TABLE users DROP COLUMN IF EXISTS exchange_code, DROP COLUMN IF EXISTS exchange_xid, DROP COLUMN IF EXISTS exchange_version;
+
+DROP SEQUENCE IF EXISTS exchange_version_seq;
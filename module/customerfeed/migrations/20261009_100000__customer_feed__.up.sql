@@ -0,0 +1,87 @@
+-- выгрузка покупателей в 1С: версия записи растёт при изменении данных, которые видит 1С,
+-- поэтому запись кода из 1С или смена пароля не выгружают покупателя повторно.
+-- Версия берётся из последовательности при записи, а не при коммите, поэтому рядом хранится транзакция,
+-- выдавшая версию: выгрузка не отдаёт записи транзакций новее самой старой незавершённой
+CREATE SEQUENCE IF NOT EXISTS exchange_version_seq;
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS exchange_version BIGINT NOT NULL DEFAULT nextval('exchange_version_seq'),
+  ADD COLUMN IF NOT EXISTS exchange_xid XID8 NOT NULL DEFAULT pg_current_xact_id(),
+  ADD COLUMN IF NOT EXISTS exchange_code VARCHAR(100); -- код контрагента в 1С
+
+ALTER TABLE companies
+  ADD COLUMN IF NOT EXISTS exchange_version BIGINT NOT NULL DEFAULT nextval('exchange_version_seq'),
+  ADD COLUMN IF NOT EXISTS exchange_xid XID8 NOT NULL DEFAULT pg_current_xact_id(),
+  ADD COLUMN IF NOT EXISTS exchange_code VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS users_exchange_version_idx ON users (exchange_version);
+CREATE INDEX IF NOT EXISTS companies_exchange_version_idx ON companies (exchange_version);
+CREATE UNIQUE INDEX IF NOT EXISTS users_exchange_code_idx ON users (exchange_code) WHERE exchange_code IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS companies_exchange_code_idx ON companies (exchange_code) WHERE exchange_code IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION users_exchange_version() RETURNS trigger AS $$
+BEGIN
+  IF (NEW.email, NEW.phone, NEW.name, NEW.last_name, NEW.second_name, NEW.city_id, NEW.user_type, NEW.inn, NEW.active)
+    IS DISTINCT FROM (OLD.email, OLD.phone, OLD.name, OLD.last_name, OLD.second_name, OLD.city_id, OLD.user_type, OLD.inn, OLD.active) THEN
+    NEW.exchange_version := nextval('exchange_version_seq');
+  END IF;
+  -- версию поднимает и триггер company_users
+  IF NEW.exchange_version IS DISTINCT FROM OLD.exchange_version THEN
+    NEW.exchange_xid := pg_current_xact_id();
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_exchange_version BEFORE UPDATE ON users
+FOR EACH ROW EXECUTE FUNCTION users_exchange_version();
+
+CREATE OR REPLACE FUNCTION companies_exchange_version() RETURNS trigger AS $$
+BEGIN
+  IF (NEW.inn, NEW.kpp, NEW.company_name) IS DISTINCT FROM (OLD.inn, OLD.kpp, OLD.company_name) THEN
+    NEW.exchange_version := nextval('exchange_version_seq');
+    NEW.exchange_xid := pg_current_xact_id();
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER companies_exchange_version BEFORE UPDATE ON companies
+FOR EACH ROW EXECUTE FUNCTION companies_exchange_version();
+
+-- покупатель выгружается вместе с компанией, в которой состоит
+CREATE OR REPLACE FUNCTION company_users_exchange_version() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP IN ('UPDATE', 'DELETE') THEN
+    UPDATE users SET exchange_version = nextval('exchange_version_seq') WHERE id = OLD.user_id;
+  END IF;
+  IF TG_OP IN ('INSERT', 'UPDATE') THEN
+    UPDATE users SET exchange_version = nextval('exchange_version_seq') WHERE id = NEW.user_id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER company_users_exchange_version AFTER INSERT OR UPDATE OR DELETE ON company_users
+FOR EACH ROW EXECUTE FUNCTION company_users_exchange_version();
+
+-- версии, которые 1С подтвердила; запись снова попадает в выгрузку, когда её версия вырастет
+CREATE TABLE IF NOT EXISTS exchange_acks (
+  entity VARCHAR(20) NOT NULL CHECK (entity IN ('user', 'company')),
+  entity_id INT NOT NULL,
+  version BIGINT NOT NULL,
+  acked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (entity, entity_id)
+);
+
+INSERT INTO permissions (code, name) VALUES ('customers:export', 'Выгрузка покупателей в 1С')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON (r.code, p.code) IN (
+  ('admin', 'customers:export'),
+  ('exchange', 'customers:export')
+)
+ON CONFLICT DO NOTHING;
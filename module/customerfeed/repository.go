@@ -0,0 +1,119 @@
+package customerfeed
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/internal/store"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository struct {
+	store     *store.Store
+	tableName string
+}
+
+func NewRepository(store *store.Store) *Repository {
+	return &Repository{
+		store:     store,
+		tableName: "exchange_acks",
+	}
+}
+
+// Users — покупатели с версией больше since, которые 1С ещё не подтвердила.
+// Сотрудники магазина (пользователи с ролями кроме customer) не выгружаются.
+// Записи транзакций не старше самой старой незавершённой откладываются до её окончания:
+// она могла взять меньшую версию, и курсор не должен её обогнать
+func (r *Repository) Users(ctx context.Context, since int64, limit int) ([]UserEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT u.id, u.exchange_version, u.exchange_code, u.email, u.phone, u.name, u.last_name, u.second_name,
+			u.user_type, u.inn, u.active, cu.company_id, cu.role AS company_role, u.created_at, u.updated_at
+		FROM users u
+		LEFT JOIN company_users cu ON cu.user_id = u.id
+		LEFT JOIN %s a ON a.entity = $1 AND a.entity_id = u.id
+		WHERE u.exchange_version > $2
+			AND u.exchange_xid < pg_snapshot_xmin(pg_current_snapshot())
+			AND (a.version IS NULL OR a.version < u.exchange_version)
+			AND NOT EXISTS (
+				SELECT 1 FROM user_roles ur
+				JOIN roles ro ON ro.id = ur.role_id
+				WHERE ur.user_id = u.id AND ro.code <> 'customer'
+			)
+		ORDER BY u.exchange_version
+		LIMIT $3
+	`, r.tableName)
+
+	var users []UserEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &users, query, EntityUser, since, limit); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return users, nil
+}
+
+// Companies — компании с версией больше since, которые 1С ещё не подтвердила,
+// записи незавершённых транзакций откладываются так же, как в Users
+func (r *Repository) Companies(ctx context.Context, since int64, limit int) ([]CompanyEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT c.id, c.exchange_version, c.exchange_code, c.inn, c.kpp, c.company_name, c.created_at, c.updated_at
+		FROM companies c
+		LEFT JOIN %s a ON a.entity = $1 AND a.entity_id = c.id
+		WHERE c.exchange_version > $2
+			AND c.exchange_xid < pg_snapshot_xmin(pg_current_snapshot())
+			AND (a.version IS NULL OR a.version < c.exchange_version)
+		ORDER BY c.exchange_version
+		LIMIT $3
+	`, r.tableName)
+
+	var companies []CompanyEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &companies, query, EntityCompany, since, limit); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return companies, nil
+}
+
+// Ack запоминает версию, которую загрузила 1С, более старая версия не затирает новую
+func (r *Repository) Ack(ctx context.Context, entity string, id, version int64) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (entity, entity_id, version, acked_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (entity, entity_id) DO UPDATE
+		SET version = GREATEST(%s.version, EXCLUDED.version), acked_at = EXCLUDED.acked_at
+	`, r.tableName, r.tableName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, entity, id, version); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// SetCode сохраняет код контрагента из 1С. Версия записи не меняется, повторно покупатель не выгружается.
+// Если код уже у другого контрагента — store.ErrConflict
+func (r *Repository) SetCode(ctx context.Context, entity string, id int64, code string) error {
+	table := "users"
+	if entity == EntityCompany {
+		table = "companies"
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET exchange_code = $2 WHERE id = $1`, table)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, id, code)
+	if err != nil {
+		if store.IsUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		return store.ContextError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
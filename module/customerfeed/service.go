@@ -0,0 +1,115 @@
+package customerfeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/validator"
+	"sort"
+)
+
+type Service struct {
+	store *store.Store
+	repo  *Repository
+}
+
+func NewService(store *store.Store, repo *Repository) *Service {
+	return &Service{
+		store: store,
+		repo:  repo,
+	}
+}
+
+// Feed — новые и изменённые покупатели и компании в порядке версий.
+// Подтверждённые версии не возвращаются, поэтому с since=0 1С получает всё, что ещё не загрузила.
+// Пока идёт транзакция, изменившая покупателя, курсор не проходит дальше её версии,
+// а полную сверку даёт периодический запрос с since=0 и подтверждением загруженного
+func (s *Service) Feed(ctx context.Context, req FeedRequest) (*FeedResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	// по одной лишней записи, чтобы понять, есть ли следующая страница
+	users, err := s.repo.Users(ctx, req.Since, req.Limit+1)
+	if err != nil {
+		return nil, "произошла ошибка при выгрузке покупателей", err
+	}
+	companies, err := s.repo.Companies(ctx, req.Since, req.Limit+1)
+	if err != nil {
+		return nil, "произошла ошибка при выгрузке покупателей", err
+	}
+
+	items := append(helper.ToResponse(users), helper.ToResponse(companies)...)
+	sort.Slice(items, func(i, j int) bool { return items[i].Version < items[j].Version })
+
+	resp := &FeedResponse{
+		Items:  items,
+		Cursor: req.Since,
+	}
+	if len(items) > req.Limit {
+		resp.Items = items[:req.Limit]
+		resp.HasMore = true
+	}
+	if len(resp.Items) > 0 {
+		resp.Cursor = resp.Items[len(resp.Items)-1].Version
+	}
+
+	return resp, "", nil
+}
+
+// Ack — 1С подтверждает загруженные версии, запись вернётся в выгрузку только после следующего изменения
+func (s *Service) Ack(ctx context.Context, req AckRequest) (*AckResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		for _, item := range req.Items {
+			if err := s.repo.Ack(ctx, item.Type, item.ID, item.Version); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "произошла ошибка при подтверждении выгрузки", err
+	}
+
+	return &AckResponse{Acknowledged: len(req.Items)}, "", nil
+}
+
+// SetCodes сохраняет коды контрагентов из 1С. Неизвестные записи возвращаются в not_found,
+// занятый код отклоняет весь запрос
+func (s *Service) SetCodes(ctx context.Context, req CodesRequest) (*CodesResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	resp := &CodesResponse{NotFound: []EntityRef{}}
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		for i, item := range req.Items {
+			err := s.repo.SetCode(ctx, item.Type, item.ID, item.Code)
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				resp.NotFound = append(resp.NotFound, EntityRef{Type: item.Type, ID: item.ID})
+			case errors.Is(err, store.ErrConflict):
+				return validator.ValidationError{
+					Err:    validator.ErrorValidation,
+					Fields: map[string]string{fmt.Sprintf("items[%d].code", i): "Код уже присвоен другому контрагенту"},
+				}
+			case err != nil:
+				return err
+			default:
+				resp.Updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "произошла ошибка при сохранении кодов контрагентов", err
+	}
+
+	return resp, "", nil
+}
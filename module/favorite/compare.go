@@ -0,0 +1,157 @@
+package favorite
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-monolite/module/property"
+	"sort"
+	"strings"
+)
+
+// productProperties разбирает products.property — объект {ключ свойства: значение}.
+// Ключ — UUID или slug свойства из справочника property, значение — ключ из property_values,
+// строка, число, логическое значение или список. Другой формат считается пустым
+func productProperties(raw json.RawMessage) map[string]any {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var result map[string]any
+	if err := decoder.Decode(&result); err != nil {
+		return nil
+	}
+	return result
+}
+
+// lookupKeys — ключи свойств и строковые значения, которые нужно найти в справочниках
+func lookupKeys(products []map[string]any) (propertyKeys, valueKeys []string) {
+	seenProperties, seenValues := map[string]bool{}, map[string]bool{}
+	addValue := func(v any) {
+		if s, ok := v.(string); ok && !seenValues[s] {
+			seenValues[s] = true
+			valueKeys = append(valueKeys, s)
+		}
+	}
+
+	for _, props := range products {
+		for key, v := range props {
+			if !seenProperties[key] {
+				seenProperties[key] = true
+				propertyKeys = append(propertyKeys, key)
+			}
+			if list, ok := v.([]any); ok {
+				for _, item := range list {
+					addValue(item)
+				}
+				continue
+			}
+			addValue(v)
+		}
+	}
+
+	return propertyKeys, valueKeys
+}
+
+// compareTable собирает строки сравнения: объединение свойств всех товаров, значения в порядке товаров.
+// Ключи одного свойства справочника (UUID и slug) попадают в одну строку, значения справочных свойств
+// ищутся только среди property_values этого свойства
+func compareTable(products []map[string]any, properties []property.PropertyEnt, values []property.PropertyValueEnt) []PropertyRow {
+	byKey := make(map[string]*property.PropertyEnt, len(properties)*2)
+	for i := range properties {
+		p := &properties[i]
+		byKey[p.UUID.String()] = p
+		byKey[p.Slug] = p
+	}
+	valueNames := make(map[string]map[string]string, len(properties))
+	for _, v := range values {
+		id := v.PropertyUUID.String()
+		if valueNames[id] == nil {
+			valueNames[id] = map[string]string{}
+		}
+		valueNames[id][v.Key] = v.Value
+	}
+
+	rows := map[string]*PropertyRow{}
+	for i, props := range products {
+		for key, v := range props {
+			id, row := key, &PropertyRow{Key: key, Name: key}
+			if p, found := byKey[key]; found {
+				propertyUUID := p.UUID
+				id, row = propertyUUID.String(), &PropertyRow{PropertyUUID: &propertyUUID, Key: p.Slug, Name: p.Name}
+			}
+
+			display, ok := formatValue(v, valueNames[id])
+			if !ok {
+				continue
+			}
+			if existing, found := rows[id]; found {
+				row = existing
+			} else {
+				row.Values = make([]*string, len(products))
+				rows[id] = row
+			}
+			row.Values[i] = &display
+		}
+	}
+
+	result := make([]PropertyRow, 0, len(rows))
+	for _, row := range rows {
+		row.Different = different(row.Values)
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Key < result[j].Key
+	})
+
+	return result
+}
+
+// formatValue — значение свойства для показа, false — значения нет
+func formatValue(v any, valueNames map[string]string) (string, bool) {
+	switch value := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		if name, ok := valueNames[value]; ok {
+			return name, true
+		}
+		return value, value != ""
+	case json.Number:
+		return value.String(), true
+	case bool:
+		if value {
+			return "Да", true
+		}
+		return "Нет", true
+	case []any:
+		parts := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := formatValue(item, valueNames); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", "), len(parts) > 0
+	default:
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+		return string(raw), true
+	}
+}
+
+// different — значения у товаров отличаются, отсутствие значения тоже считается отличием
+func different(values []*string) bool {
+	for _, v := range values[1:] {
+		if (v == nil) != (values[0] == nil) || (v != nil && *v != *values[0]) {
+			return true
+		}
+	}
+	return false
+}
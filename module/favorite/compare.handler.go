@@ -0,0 +1,143 @@
+package favorite
+
+import (
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	middlewareAuth "go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+// CompareHandler — сравнение товаров
+type CompareHandler struct {
+	service  *Service
+	userAuth func(next http.Handler) http.Handler
+}
+
+func NewCompareHandler(store *store.Store, cfg *config.Config, userAuth func(next http.Handler) http.Handler) *CompareHandler {
+	return &CompareHandler{
+		service:  newService(store, cfg),
+		userAuth: middlewareAuth.Optional(userAuth),
+	}
+}
+
+func (h *CompareHandler) Init(r chi.Router) {
+	r.Use(h.userAuth)
+
+	r.Get("/", h.Get)
+	r.Delete("/", h.Clear)
+	r.Put("/{productUuid}", h.Add)
+	r.Delete("/{productUuid}", h.Remove)
+}
+
+// @Summary Compare products
+// @Description Get the comparison table: products as columns and the union of their properties as rows. Property values follow the order of products, null means the product has no such property, different marks rows whose values differ
+// @Tags compare
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest list"
+// @Param different query bool false "Only properties with differing values"
+// @Success 200 {object} respond.SuccessResponse{data=CompareResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [get]
+func (h *CompareHandler) Get(w http.ResponseWriter, r *http.Request) {
+	var onlyDifferent bool
+	if value := r.URL.Query().Get("different"); value != "" {
+		var err error
+		if onlyDifferent, err = strconv.ParseBool(value); err != nil {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный параметр different")
+			return
+		}
+	}
+
+	resp, mess, err := h.service.Compare(r.Context(), owner(r), onlyDifferent)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Add to comparison
+// @Description Add an active product to the comparison, adding it again changes nothing
+// @Tags compare
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest list"
+// @Param productUuid path string true "Product UUID"
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{productUuid} [put]
+func (h *CompareHandler) Add(w http.ResponseWriter, r *http.Request) {
+	productUUID, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	resp, mess, err := h.service.Add(r.Context(), owner(r), ListCompare, productUUID)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "товар добавлен к сравнению", resp)
+}
+
+// @Summary Remove from comparison
+// @Description Remove a product from the comparison
+// @Tags compare
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest list"
+// @Param productUuid path string true "Product UUID"
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{productUuid} [delete]
+func (h *CompareHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	productUUID, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	resp, mess, err := h.service.Remove(r.Context(), owner(r), ListCompare, productUUID)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "товар удалён из сравнения", resp)
+}
+
+// @Summary Clear comparison
+// @Description Remove all products from the comparison
+// @Tags compare
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest list"
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [delete]
+func (h *CompareHandler) Clear(w http.ResponseWriter, r *http.Request) {
+	resp, mess, err := h.service.Clear(r.Context(), owner(r), ListCompare)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "сравнение очищено", resp)
+}
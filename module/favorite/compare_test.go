@@ -0,0 +1,95 @@
+package favorite
+
+import (
+	"encoding/json"
+	"go-monolite/module/property"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func str(s string) *string {
+	return &s
+}
+
+func TestCompareTable(t *testing.T) {
+	colorUUID, weightUUID, materialUUID := uuid.New(), uuid.New(), uuid.New()
+	properties := []property.PropertyEnt{
+		{UUID: colorUUID, Slug: "color", Name: "Цвет"},
+		{UUID: weightUUID, Slug: "weight", Name: "Вес"},
+		{UUID: materialUUID, Slug: "material", Name: "Материал"},
+	}
+	values := []property.PropertyValueEnt{
+		{PropertyUUID: colorUUID, Key: "red", Value: "Красный"},
+		{PropertyUUID: colorUUID, Key: "blue", Value: "Синий"},
+		{PropertyUUID: materialUUID, Key: "red", Value: "Красное дерево"},
+	}
+
+	// цвет первого товара записан по UUID свойства, второго — по slug; finish нет в справочнике
+	products := []map[string]any{
+		productProperties(json.RawMessage(`{"` + colorUUID.String() + `": "red", "finish": "red", "material": "red", "wireless": true}`)),
+		productProperties(json.RawMessage(`{"color": "blue", "weight": 2.5, "material": "red", "wireless": true, "empty": ""}`)),
+	}
+
+	rows := compareTable(products, properties, values)
+
+	assert.Equal(t, []PropertyRow{
+		{Key: "finish", Name: "finish", Values: []*string{str("red"), nil}, Different: true},
+		{Key: "wireless", Name: "wireless", Values: []*string{str("Да"), str("Да")}},
+		{PropertyUUID: &weightUUID, Key: "weight", Name: "Вес", Values: []*string{nil, str("2.5")}, Different: true},
+		{PropertyUUID: &materialUUID, Key: "material", Name: "Материал", Values: []*string{str("Красное дерево"), str("Красное дерево")}},
+		{PropertyUUID: &colorUUID, Key: "color", Name: "Цвет", Values: []*string{str("Красный"), str("Синий")}, Different: true},
+	}, rows)
+}
+
+func TestFormatValue(t *testing.T) {
+	names := map[string]string{"red": "Красный"}
+
+	tests := []struct {
+		name  string
+		value any
+		want  string
+		ok    bool
+	}{
+		{name: "nil", value: nil},
+		{name: "empty string", value: ""},
+		{name: "property value", value: "red", want: "Красный", ok: true},
+		{name: "plain string", value: "matte", want: "matte", ok: true},
+		{name: "number", value: json.Number("2.5"), want: "2.5", ok: true},
+		{name: "true", value: true, want: "Да", ok: true},
+		{name: "false", value: false, want: "Нет", ok: true},
+		{name: "list", value: []any{"red", "", nil, json.Number("2"), true}, want: "Красный, 2, Да", ok: true},
+		{name: "list without values", value: []any{nil, ""}},
+		{name: "object", value: map[string]any{"a": json.Number("1")}, want: `{"a":1}`, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := formatValue(tt.value, names)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDifferent(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []*string
+		want   bool
+	}{
+		{name: "single product", values: []*string{str("a")}},
+		{name: "same values", values: []*string{str("a"), str("a"), str("a")}},
+		{name: "other value", values: []*string{str("a"), str("a"), str("b")}, want: true},
+		{name: "missing in first", values: []*string{nil, str("a")}, want: true},
+		{name: "missing in last", values: []*string{str("a"), nil}, want: true},
+		{name: "missing everywhere", values: []*string{nil, nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, different(tt.values))
+		})
+	}
+}
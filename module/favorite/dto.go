@@ -0,0 +1,40 @@
+package favorite
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ItemResponse struct {
+	ProductUUID uuid.UUID `json:"product_uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name        string    `json:"name" example:"Дрель"`
+	Slug        string    `json:"slug" example:"drel"`
+	Code        int       `json:"code" example:"1001"`
+	Article     *string   `json:"article,omitempty"`
+	Unit        *string   `json:"unit,omitempty" example:"шт"`
+	Price       *float64  `json:"price,omitempty" example:"2500"`
+	Available   bool      `json:"available"` // товар включён и у него есть цена
+	AddedAt     time.Time `json:"added_at"`
+}
+
+type ListResponse struct {
+	Items []ItemResponse `json:"items"`
+	Total int            `json:"total" example:"3"`
+	Limit int            `json:"limit" example:"500"` // сколько товаров можно добавить в список, 0 — без ограничения
+}
+
+// CompareResponse — таблица сравнения: столбцы — товары, строки — свойства.
+// Значения строки идут в порядке товаров, null — у товара нет свойства
+type CompareResponse struct {
+	Products   []ItemResponse `json:"products"`
+	Properties []PropertyRow  `json:"properties"`
+}
+
+type PropertyRow struct {
+	PropertyUUID *uuid.UUID `json:"property_uuid,omitempty"` // пустой, если свойства нет в справочнике
+	Key          string     `json:"key" example:"color"`     // ключ свойства в products.property
+	Name         string     `json:"name" example:"Цвет"`
+	Values       []*string  `json:"values"`
+	Different    bool       `json:"different"` // значения у товаров отличаются
+}
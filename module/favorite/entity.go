@@ -0,0 +1,66 @@
+package favorite
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// списки товаров
+const (
+	ListFavorites = "favorites"
+	ListCompare   = "compare"
+)
+
+// Owner — владелец списков: пользователь, а без входа — устройство из заголовка Device-Uid
+type Owner struct {
+	UserID   int64
+	DeviceID string
+}
+
+func (o Owner) IsGuest() bool {
+	return o.UserID == 0
+}
+
+// ItemEnt — товар из списка вместе с текущими данными каталога.
+// Поля товара пустые, если его нет в каталоге
+type ItemEnt struct {
+	ProductUUID uuid.UUID `db:"product_uuid"`
+	AddedAt     time.Time `db:"created_at"`
+
+	Name     *string         `db:"name"`
+	Slug     *string         `db:"slug"`
+	Code     *int            `db:"code"`
+	Article  *string         `db:"article"`
+	Unit     *string         `db:"unit"`
+	Active   *string         `db:"active"`
+	Price    *float64        `db:"price"`
+	Property json.RawMessage `db:"property"`
+}
+
+// Available — товар есть в каталоге, включён и у него есть цена
+func (e ItemEnt) Available() bool {
+	return e.Active != nil && *e.Active == "Y" && e.Price != nil
+}
+
+func (e ItemEnt) ToResponse() ItemResponse {
+	resp := ItemResponse{
+		ProductUUID: e.ProductUUID,
+		Article:     e.Article,
+		Unit:        e.Unit,
+		Price:       e.Price,
+		Available:   e.Available(),
+		AddedAt:     e.AddedAt,
+	}
+	if e.Name != nil {
+		resp.Name = *e.Name
+	}
+	if e.Slug != nil {
+		resp.Slug = *e.Slug
+	}
+	if e.Code != nil {
+		resp.Code = *e.Code
+	}
+	return resp
+}
@@ -0,0 +1,10 @@
+package favorite
+
+import "errors"
+
+var (
+	ErrOwnerRequired   = errors.New("device id or user is required")
+	ErrProductNotFound = errors.New("product not found")
+	ErrItemNotFound    = errors.New("product is not in list")
+	ErrTooManyItems    = errors.New("too many products in list")
+)
@@ -0,0 +1,184 @@
+package favorite
+
+import (
+	"errors"
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/module/auth"
+	"go-monolite/module/cart"
+	"go-monolite/module/user"
+	"go-monolite/pkg/logger"
+	middlewareAuth "go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// Handler — избранное покупателя
+type Handler struct {
+	service  *Service
+	userAuth func(next http.Handler) http.Handler
+}
+
+// NewHandler — userAuth (middlewareAuth.UserAuth) собирается один раз в роутере сервера, гости проходят без него
+func NewHandler(store *store.Store, cfg *config.Config, userAuth func(next http.Handler) http.Handler) *Handler {
+	return &Handler{
+		service:  newService(store, cfg),
+		userAuth: middlewareAuth.Optional(userAuth),
+	}
+}
+
+// NewLoginHook — перенос гостевых избранного и сравнения пользователю при входе, подключается к модулю auth
+func NewLoginHook(store *store.Store, cfg *config.Config) auth.LoginHook {
+	return newService(store, cfg).MergeGuest
+}
+
+func newService(store *store.Store, cfg *config.Config) *Service {
+	carts := cart.NewService(store, cart.NewRepository(store), user.NewRepository(store), cfg.Cart)
+	return NewService(store, NewRepository(store), carts, cfg.Favorite)
+}
+
+func (h *Handler) Init(r chi.Router) {
+	r.Use(h.userAuth)
+
+	r.Get("/", h.Get)
+	r.Delete("/", h.Clear)
+	r.Put("/{productUuid}", h.Add)
+	r.Delete("/{productUuid}", h.Remove)
+}
+
+// @Summary Get favourites
+// @Description Get favourite products with the customer's prices, last added first. Without authorization the guest list of the Device-Uid device is returned
+// @Tags favorite
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest list"
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	resp, mess, err := h.service.List(r.Context(), owner(r), ListFavorites)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Add to favourites
+// @Description Add an active product to favourites, adding it again changes nothing
+// @Tags favorite
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest list"
+// @Param productUuid path string true "Product UUID"
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{productUuid} [put]
+func (h *Handler) Add(w http.ResponseWriter, r *http.Request) {
+	productUUID, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	resp, mess, err := h.service.Add(r.Context(), owner(r), ListFavorites, productUUID)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "товар добавлен в избранное", resp)
+}
+
+// @Summary Remove from favourites
+// @Description Remove a product from favourites
+// @Tags favorite
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest list"
+// @Param productUuid path string true "Product UUID"
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{productUuid} [delete]
+func (h *Handler) Remove(w http.ResponseWriter, r *http.Request) {
+	productUUID, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	resp, mess, err := h.service.Remove(r.Context(), owner(r), ListFavorites, productUUID)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "товар удалён из избранного", resp)
+}
+
+// @Summary Clear favourites
+// @Description Remove all products from favourites
+// @Tags favorite
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID for a guest list"
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [delete]
+func (h *Handler) Clear(w http.ResponseWriter, r *http.Request) {
+	resp, mess, err := h.service.Clear(r.Context(), owner(r), ListFavorites)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "избранное очищено", resp)
+}
+
+// owner — вошедший пользователь, без авторизации — устройство из заголовка Device-Uid
+func owner(r *http.Request) Owner {
+	identity, _ := middlewareAuth.CurrentUser(r.Context())
+	return Owner{UserID: identity.UserID, DeviceID: r.Header.Get(auth.DeviceHeader)}
+}
+
+func pathUUID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "productUuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный UUID товара")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, err error, mess string) {
+	if validationErrors, ok := err.(validator.ValidationError); ok {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrOwnerRequired):
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, mess)
+	case errors.Is(err, ErrProductNotFound), errors.Is(err, ErrItemNotFound):
+		respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+	case errors.Is(err, ErrTooManyItems):
+		respond.ErrorHandler(w, r, http.StatusConflict, err, mess)
+	default:
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+	}
+}
@@ -0,0 +1,224 @@
+package favorite_test
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/module/auth"
+	"go-monolite/module/favorite"
+	"go-monolite/module/user"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"go-monolite/pkg/token"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFavoriteIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	config.Favorite.MaxCompare = 2
+	access := testinit.NewAuth(store, config)
+	favorites := testinit.SetupTestServer(t, favorite.NewHandler(store, config, access.UserAuth))
+	defer favorites.Close()
+	compare := testinit.SetupTestServer(t, favorite.NewCompareHandler(store, config, access.UserAuth))
+	defer compare.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	ctx := context.Background()
+	tokenManager := token.NewManager(config.Auth.JWTSecret, time.Minute)
+
+	// свойства дрелей: цвет — справочный, мощность — по slug, аккумулятор — без справочника
+	categoryUUID, typePriceUUID, colorUUID, powerUUID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	drill, cordless, hammer, archived := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	for _, query := range []string{
+		fmt.Sprintf(`INSERT INTO categories (uuid, slug, name) VALUES ('%s', 'tools', 'Инструмент')`, categoryUUID),
+		fmt.Sprintf(`INSERT INTO type_price (uuid, name) VALUES ('%s', 'Розничная')`, typePriceUUID),
+		fmt.Sprintf(`INSERT INTO property (uuid, slug, type, name) VALUES ('%s', 'color', 'Справочник', 'Цвет'), ('%s', 'power', 'Число', 'Мощность')`, colorUUID, powerUUID),
+		fmt.Sprintf(`INSERT INTO property_values (key, slug, value, property_uuid) VALUES ('red', 'krasnyj', 'Красный', '%s'), ('blue', 'sinij', 'Синий', '%s')`, colorUUID, colorUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid, property) VALUES ('%s', 'Дрель', 9201, 'drill', '%s', '{"%s": "red", "power": 800, "cordless": true}')`, drill, categoryUUID, colorUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid, property) VALUES ('%s', 'Дрель аккумуляторная', 9202, 'cordless-drill', '%s', '{"color": "blue", "power": 800}')`, cordless, categoryUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Молоток', 9203, 'hammer', '%s')`, hammer, categoryUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid, active) VALUES ('%s', 'Архивная дрель', 9204, 'archived', '%s', 'N')`, archived, categoryUUID),
+		fmt.Sprintf(`INSERT INTO product_prices (product_uuid, type_price_uuid, price) VALUES ('%s', '%s', 2500), ('%s', '%s', 4000)`, drill, typePriceUUID, cordless, typePriceUUID),
+	} {
+		_, err := store.Db.Exec(query)
+		require.NoError(t, err)
+	}
+
+	send := func(t *testing.T, server *httptest.Server, method, path, deviceID, accessToken string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		require.NoError(t, err)
+		if deviceID != "" {
+			req.Header.Set(auth.DeviceHeader, deviceID)
+		}
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	decode := func(t *testing.T, resp *http.Response, v any) {
+		t.Helper()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		testinit.MarshalUnmarshal(t, response.Data, v)
+	}
+
+	list := func(t *testing.T, server *httptest.Server, method, path, deviceID, accessToken string) favorite.ListResponse {
+		t.Helper()
+		var l favorite.ListResponse
+		decode(t, send(t, server, method, path, deviceID, accessToken), &l)
+		return l
+	}
+
+	const guest = "favorite-device"
+
+	t.Run("Owner Required", func(t *testing.T) {
+		resp := send(t, favorites, http.MethodGet, "/", "", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, favorites, http.MethodGet, "/", guest, "invalid")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Favorites", func(t *testing.T) {
+		l := list(t, favorites, http.MethodPut, "/"+drill.String(), guest, "")
+		require.Len(t, l.Items, 1)
+		assert.Equal(t, "Дрель", l.Items[0].Name)
+		require.NotNil(t, l.Items[0].Price)
+		assert.Equal(t, 2500.0, *l.Items[0].Price)
+		assert.True(t, l.Items[0].Available)
+
+		// повторное добавление ничего не меняет, последний добавленный — первый
+		list(t, favorites, http.MethodPut, "/"+drill.String(), guest, "")
+		l = list(t, favorites, http.MethodPut, "/"+hammer.String(), guest, "")
+		require.Len(t, l.Items, 2)
+		assert.Equal(t, 2, l.Total)
+		assert.Equal(t, hammer, l.Items[0].ProductUUID)
+		assert.False(t, l.Items[0].Available)
+
+		resp := send(t, favorites, http.MethodPut, "/"+archived.String(), guest, "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp = send(t, favorites, http.MethodPut, "/"+uuid.New().String(), guest, "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp = send(t, favorites, http.MethodPut, "/not-a-uuid", guest, "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		l = list(t, favorites, http.MethodDelete, "/"+hammer.String(), guest, "")
+		require.Len(t, l.Items, 1)
+		resp = send(t, favorites, http.MethodDelete, "/"+hammer.String(), guest, "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		// избранное и сравнение — разные списки
+		var c favorite.CompareResponse
+		decode(t, send(t, compare, http.MethodGet, "/", guest, ""), &c)
+		assert.Empty(t, c.Products)
+	})
+
+	t.Run("Compare", func(t *testing.T) {
+		const device = "compare-device"
+		list(t, compare, http.MethodPut, "/"+drill.String(), device, "")
+		l := list(t, compare, http.MethodPut, "/"+cordless.String(), device, "")
+		assert.Equal(t, 2, l.Limit)
+
+		resp := send(t, compare, http.MethodPut, "/"+hammer.String(), device, "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		var c favorite.CompareResponse
+		decode(t, send(t, compare, http.MethodGet, "/", device, ""), &c)
+		require.Len(t, c.Products, 2)
+		assert.Equal(t, cordless, c.Products[0].ProductUUID)
+		require.Len(t, c.Properties, 3)
+
+		value := func(v *string) string {
+			if v == nil {
+				return "<nil>"
+			}
+			return *v
+		}
+
+		// ключ без справочника показывается как есть
+		assert.Equal(t, "cordless", c.Properties[0].Name)
+		assert.Nil(t, c.Properties[0].PropertyUUID)
+		assert.Equal(t, "<nil>", value(c.Properties[0].Values[0]))
+		assert.Equal(t, "Да", value(c.Properties[0].Values[1]))
+		assert.True(t, c.Properties[0].Different)
+
+		assert.Equal(t, "Мощность", c.Properties[1].Name)
+		assert.Equal(t, "800", value(c.Properties[1].Values[0]))
+		assert.False(t, c.Properties[1].Different)
+
+		// UUID и slug одного свойства — одна строка, значения берутся из property_values
+		assert.Equal(t, "Цвет", c.Properties[2].Name)
+		require.NotNil(t, c.Properties[2].PropertyUUID)
+		assert.Equal(t, colorUUID, *c.Properties[2].PropertyUUID)
+		assert.Equal(t, "Синий", value(c.Properties[2].Values[0]))
+		assert.Equal(t, "Красный", value(c.Properties[2].Values[1]))
+		assert.True(t, c.Properties[2].Different)
+
+		decode(t, send(t, compare, http.MethodGet, "/?different=true", device, ""), &c)
+		require.Len(t, c.Properties, 2)
+		assert.Equal(t, "cordless", c.Properties[0].Name)
+		assert.Equal(t, "Цвет", c.Properties[1].Name)
+
+		resp = send(t, compare, http.MethodGet, "/?different=maybe", device, "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		l = list(t, compare, http.MethodDelete, "/", device, "")
+		assert.Empty(t, l.Items)
+	})
+
+	t.Run("Merge On Login", func(t *testing.T) {
+		phone := "79990000301"
+		tv := user.InitialTokenVersion
+		userID, err := user.NewRepository(store).Create(ctx, &user.UserEnt{
+			Phone:        &phone,
+			UserType:     user.UserTypeIndividual,
+			Active:       user.ActiveYes,
+			TokenVersion: &tv,
+		})
+		require.NoError(t, err)
+		accessToken, _, err := tokenManager.Generate(userID, tv, guest)
+		require.NoError(t, err)
+
+		// у пользователя уже есть дрель и два товара в сравнении с другого устройства
+		list(t, favorites, http.MethodPut, "/"+drill.String(), "", accessToken)
+		list(t, favorites, http.MethodPut, "/"+cordless.String(), guest, "")
+		list(t, compare, http.MethodPut, "/"+drill.String(), "", accessToken)
+		list(t, compare, http.MethodPut, "/"+cordless.String(), "", accessToken)
+		list(t, compare, http.MethodPut, "/"+hammer.String(), guest, "")
+
+		require.NoError(t, favorite.NewLoginHook(store, config)(ctx, userID, guest))
+
+		l := list(t, favorites, http.MethodGet, "/", guest, accessToken)
+		require.Len(t, l.Items, 2)
+
+		// сравнение переполнилось — остались добавленные последними
+		l = list(t, compare, http.MethodGet, "/", "", accessToken)
+		require.Len(t, l.Items, 2)
+		assert.Equal(t, hammer, l.Items[0].ProductUUID)
+		assert.Equal(t, cordless, l.Items[1].ProductUUID)
+
+		// гостевые списки перенесены
+		l = list(t, favorites, http.MethodGet, "/", guest, "")
+		assert.Empty(t, l.Items)
+		l = list(t, compare, http.MethodGet, "/", guest, "")
+		assert.Empty(t, l.Items)
+	})
+}
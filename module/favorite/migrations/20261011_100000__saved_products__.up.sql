@@ -0,0 +1,14 @@
+-- избранное и сравнение товаров, list — какой это список. Владелец — пользователь либо гостевое устройство
+-- (заголовок Device-Uid), гостевые списки переносятся пользователю при входе.
+-- Нет связи REFERENCES products(uuid): товар может пропасть из каталога после обмена
+CREATE TABLE IF NOT EXISTS saved_products (
+  id BIGSERIAL PRIMARY KEY,
+  list VARCHAR(16) NOT NULL CHECK (list IN ('favorites', 'compare')),
+  user_id INT REFERENCES users(id) ON DELETE CASCADE,
+  device_id VARCHAR(255),
+  product_uuid UUID NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CHECK ((user_id IS NULL) <> (device_id IS NULL)),
+  UNIQUE (list, user_id, product_uuid),
+  UNIQUE (list, device_id, product_uuid)
+);
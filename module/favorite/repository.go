@@ -0,0 +1,186 @@
+package favorite
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/internal/store"
+	"go-monolite/module/property"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	store     *store.Store
+	tableName string
+}
+
+func NewRepository(store *store.Store) *Repository {
+	return &Repository{
+		store:     store,
+		tableName: "saved_products",
+	}
+}
+
+// Items — товары списка с ценой типа цен priceType, сначала добавленные последними.
+// Товар из архива показывается выключенным
+func (r *Repository) Items(ctx context.Context, owner Owner, list string, priceType *uuid.UUID) ([]ItemEnt, error) {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
+		SELECT sp.product_uuid, sp.created_at,
+			p.name, p.slug, p.code, p.article, p.unit, CASE WHEN p.deleted_at IS NULL THEN p.active ELSE 'N' END AS active, p.property,
+			(SELECT pp.price FROM product_prices pp
+				WHERE pp.product_uuid = sp.product_uuid AND pp.type_price_uuid = $3 AND pp.active = 'Y'
+				ORDER BY pp.updated_at DESC LIMIT 1) AS price
+		FROM %s sp
+		LEFT JOIN products p ON p.uuid = sp.product_uuid
+		WHERE sp.list = $1 AND sp.%s = $2
+		ORDER BY sp.created_at DESC, sp.id DESC
+	`, r.tableName, column)
+
+	var items []ItemEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &items, query, list, value, priceType); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return items, nil
+}
+
+func (r *Repository) Count(ctx context.Context, owner Owner, list string) (int, error) {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE list = $1 AND %s = $2`, r.tableName, column)
+
+	var count int
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &count, query, list, value); err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	return count, nil
+}
+
+// Add добавляет товар в список, возвращает false, если он там уже есть
+func (r *Repository) Add(ctx context.Context, owner Owner, list string, productUUID uuid.UUID) (bool, error) {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (list, %[2]s, product_uuid, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (list, %[2]s, product_uuid) DO NOTHING
+	`, r.tableName, column)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, list, value, productUUID, time.Now())
+	if err != nil {
+		return false, store.ContextError(err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, store.ContextError(err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *Repository) Remove(ctx context.Context, owner Owner, list string, productUUID uuid.UUID) error {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`DELETE FROM %s WHERE list = $1 AND %s = $2 AND product_uuid = $3`, r.tableName, column)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, list, value, productUUID)
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrItemNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) Clear(ctx context.Context, owner Owner, list string) error {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`DELETE FROM %s WHERE list = $1 AND %s = $2`, r.tableName, column)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, list, value); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// Merge переносит списки устройства пользователю, товары, которые у него уже есть, пропускаются
+func (r *Repository) Merge(ctx context.Context, deviceID string, userID int64) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (list, user_id, product_uuid, created_at)
+		SELECT list, $2, product_uuid, created_at FROM %[1]s WHERE device_id = $1
+		ON CONFLICT (list, user_id, product_uuid) DO NOTHING
+	`, r.tableName)
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, deviceID, userID); err != nil {
+		return store.ContextError(err)
+	}
+
+	query = fmt.Sprintf(`DELETE FROM %s WHERE device_id = $1`, r.tableName)
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, deviceID); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// Trim оставляет в списке limit товаров, добавленных последними
+func (r *Repository) Trim(ctx context.Context, owner Owner, list string, limit int) error {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
+		DELETE FROM %[1]s WHERE id IN (
+			SELECT id FROM %[1]s WHERE list = $1 AND %[2]s = $2
+			ORDER BY created_at DESC, id DESC
+			OFFSET $3
+		)
+	`, r.tableName, column)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, list, value, limit); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// ProductActive — товар есть в каталоге, не в архиве и включён
+func (r *Repository) ProductActive(ctx context.Context, productUUID uuid.UUID) (bool, error) {
+	var active bool
+	query := `SELECT EXISTS (SELECT 1 FROM products WHERE uuid = $1 AND active = 'Y' AND deleted_at IS NULL)`
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &active, query, productUUID); err != nil {
+		return false, store.ContextError(err)
+	}
+
+	return active, nil
+}
+
+// Properties — свойства справочника не из архива по UUID или slug
+func (r *Repository) Properties(ctx context.Context, keys []string) ([]property.PropertyEnt, error) {
+	query := `SELECT id, uuid, slug, type, name FROM property WHERE (uuid::text = ANY($1) OR slug = ANY($1)) AND deleted_at IS NULL`
+
+	var properties []property.PropertyEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &properties, query, pq.StringArray(keys)); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return properties, nil
+}
+
+// PropertyValues — значения справочных свойств не из архива по ключам
+func (r *Repository) PropertyValues(ctx context.Context, keys []string) ([]property.PropertyValueEnt, error) {
+	query := `SELECT id, key, slug, property_uuid, value FROM property_values WHERE key = ANY($1) AND deleted_at IS NULL`
+
+	var values []property.PropertyValueEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &values, query, pq.StringArray(keys)); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return values, nil
+}
+
+func ownerColumn(owner Owner) (string, any) {
+	if owner.IsGuest() {
+		return "device_id", owner.DeviceID
+	}
+	return "user_id", owner.UserID
+}
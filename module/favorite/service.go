@@ -0,0 +1,203 @@
+package favorite
+
+import (
+	"context"
+	"errors"
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/module/cart"
+	"go-monolite/pkg/helper"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	store *store.Store
+	repo  *Repository
+	carts *cart.Service
+	cfg   config.Favorite
+}
+
+func NewService(store *store.Store, repo *Repository, carts *cart.Service, cfg config.Favorite) *Service {
+	return &Service{
+		store: store,
+		repo:  repo,
+		carts: carts,
+		cfg:   cfg,
+	}
+}
+
+// List — товары списка по ценам покупателя
+func (s *Service) List(ctx context.Context, owner Owner, list string) (*ListResponse, string, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, "укажите заголовок Device-Uid или войдите", err
+	}
+
+	items, err := s.items(ctx, owner, list)
+	if err != nil {
+		return nil, "произошла ошибка при получении списка", err
+	}
+
+	return &ListResponse{Items: helper.ToResponse(items), Total: len(items), Limit: s.limit(list)}, "", nil
+}
+
+// Add добавляет включённый товар в список, повторное добавление ничего не меняет
+func (s *Service) Add(ctx context.Context, owner Owner, list string, productUUID uuid.UUID) (*ListResponse, string, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, "укажите заголовок Device-Uid или войдите", err
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		active, err := s.repo.ProductActive(ctx, productUUID)
+		if err != nil {
+			return err
+		}
+		if !active {
+			return ErrProductNotFound
+		}
+
+		added, err := s.repo.Add(ctx, owner, list, productUUID)
+		if err != nil || !added {
+			return err
+		}
+
+		if limit := s.limit(list); limit > 0 {
+			count, err := s.repo.Count(ctx, owner, list)
+			if err != nil {
+				return err
+			}
+			if count > limit {
+				return ErrTooManyItems
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, itemMessage(err, "произошла ошибка при добавлении товара"), err
+	}
+
+	return s.List(ctx, owner, list)
+}
+
+func (s *Service) Remove(ctx context.Context, owner Owner, list string, productUUID uuid.UUID) (*ListResponse, string, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, "укажите заголовок Device-Uid или войдите", err
+	}
+
+	if err := s.repo.Remove(ctx, owner, list, productUUID); err != nil {
+		return nil, itemMessage(err, "произошла ошибка при удалении товара"), err
+	}
+
+	return s.List(ctx, owner, list)
+}
+
+func (s *Service) Clear(ctx context.Context, owner Owner, list string) (*ListResponse, string, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, "укажите заголовок Device-Uid или войдите", err
+	}
+
+	if err := s.repo.Clear(ctx, owner, list); err != nil {
+		return nil, "произошла ошибка при очистке списка", err
+	}
+
+	return s.List(ctx, owner, list)
+}
+
+// Compare — таблица сравнения товаров, onlyDifferent оставляет свойства с отличающимися значениями
+func (s *Service) Compare(ctx context.Context, owner Owner, onlyDifferent bool) (*CompareResponse, string, error) {
+	if err := checkOwner(owner); err != nil {
+		return nil, "укажите заголовок Device-Uid или войдите", err
+	}
+
+	items, err := s.items(ctx, owner, ListCompare)
+	if err != nil {
+		return nil, "произошла ошибка при сравнении товаров", err
+	}
+
+	products := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		products = append(products, productProperties(item.Property))
+	}
+
+	propertyKeys, valueKeys := lookupKeys(products)
+	properties, err := s.repo.Properties(ctx, propertyKeys)
+	if err != nil {
+		return nil, "произошла ошибка при сравнении товаров", err
+	}
+	values, err := s.repo.PropertyValues(ctx, valueKeys)
+	if err != nil {
+		return nil, "произошла ошибка при сравнении товаров", err
+	}
+
+	rows := compareTable(products, properties, values)
+	if onlyDifferent {
+		filtered := rows[:0]
+		for _, row := range rows {
+			if row.Different {
+				filtered = append(filtered, row)
+			}
+		}
+		rows = filtered
+	}
+
+	return &CompareResponse{Products: helper.ToResponse(items), Properties: rows}, "", nil
+}
+
+// MergeGuest переносит избранное и сравнение устройства пользователю после входа.
+// Если списки переполнились, остаются товары, добавленные последними
+func (s *Service) MergeGuest(ctx context.Context, userID int64, deviceID string) error {
+	if deviceID == "" {
+		return nil
+	}
+
+	return s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Merge(ctx, deviceID, userID); err != nil {
+			return err
+		}
+
+		owner := Owner{UserID: userID}
+		for _, list := range []string{ListFavorites, ListCompare} {
+			if limit := s.limit(list); limit > 0 {
+				if err := s.repo.Trim(ctx, owner, list, limit); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) items(ctx context.Context, owner Owner, list string) ([]ItemEnt, error) {
+	priceType, err := s.carts.PriceType(ctx, cart.Owner{UserID: owner.UserID, DeviceID: owner.DeviceID})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Items(ctx, owner, list, priceType)
+}
+
+func (s *Service) limit(list string) int {
+	if list == ListCompare {
+		return s.cfg.MaxCompare
+	}
+	return s.cfg.MaxItems
+}
+
+func checkOwner(owner Owner) error {
+	if owner.IsGuest() && owner.DeviceID == "" {
+		return ErrOwnerRequired
+	}
+	return nil
+}
+
+func itemMessage(err error, fallback string) string {
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		return "товар не найден"
+	case errors.Is(err, ErrItemNotFound):
+		return "товара нет в списке"
+	case errors.Is(err, ErrTooManyItems):
+		return "в списке слишком много товаров"
+	}
+	return fallback
+}
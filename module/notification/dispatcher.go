@@ -0,0 +1,159 @@
+package notification
+
+import (
+	"context"
+	"go-monolite/internal/config"
+	"go-monolite/internal/infra/sender"
+	"go-monolite/pkg/logger"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Dispatcher доставляет сообщения из outbox в фоне. Несколько экземпляров приложения могут работать одновременно:
+// сообщение закрепляется за одним из них на время Lease. Доставка «хотя бы один раз», дубли гасит ключ идемпотентности
+type Dispatcher struct {
+	repo   *Repository
+	sender *sender.Sender
+
+	pollInterval time.Duration
+	batchSize    int
+	retryBase    time.Duration
+	retryMax     time.Duration
+	lease        time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewDispatcher(repo *Repository, sender *sender.Sender, cfg config.Notification) *Dispatcher {
+	return &Dispatcher{
+		repo:         repo,
+		sender:       sender,
+		pollInterval: time.Duration(max(cfg.PollInterval, 1)) * time.Second,
+		batchSize:    max(cfg.BatchSize, 1),
+		retryBase:    time.Duration(max(cfg.RetryBase, 1)) * time.Second,
+		retryMax:     time.Duration(max(cfg.RetryMax, 1)) * time.Second,
+		lease:        time.Duration(max(cfg.Lease, 1)) * time.Second,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start запускает цикл доставки, остановка — через Shutdown
+func (d *Dispatcher) Start() {
+	logger.Info("starting notification dispatcher", "interval", d.pollInterval.String())
+
+	go func() {
+		defer close(d.done)
+
+		ticker := time.NewTicker(d.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+			}
+
+			// пока очередь полная, выбираем её без паузы
+			for {
+				n, err := d.Dispatch(context.Background())
+				if err != nil {
+					logger.Error(err, "notification dispatch failed")
+				}
+				if err != nil || n < d.batchSize || d.stopped() {
+					break
+				}
+			}
+		}
+	}()
+}
+
+// Shutdown дожидается окончания текущей пачки. Сообщения, которые не успели обработать, останутся закреплёнными
+// до истечения Lease и будут взяты повторно
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.stopOnce.Do(func() { close(d.stop) })
+
+	select {
+	case <-d.done:
+		logger.Info("notification dispatcher stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dispatch обрабатывает одну пачку сообщений и возвращает их количество
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	now := time.Now()
+	messages, err := d.repo.Claim(ctx, d.batchSize, now, now.Add(d.lease))
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range messages {
+		d.deliver(ctx, &messages[i])
+	}
+
+	return len(messages), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, m *MessageEnt) {
+	log := []any{"notification_id", m.ID, "channel", m.Channel, "attempt", m.Attempts}
+
+	if m.Expired(time.Now()) {
+		if err := d.repo.MarkDead(ctx, m.ID, ErrExpired.Error(), time.Now()); err != nil {
+			logger.Error(err, "failed to mark notification dead", log...)
+		}
+		return
+	}
+
+	sendCtx, cancel := context.WithTimeout(sender.WithIdempotencyKey(ctx, m.IdempotencyKey), d.lease)
+	sendErr := d.sender.Send(sendCtx, sender.Message{Channel: m.Channel, To: m.Recipient, Subject: m.Subject, Body: m.Body, HTML: m.HTMLBody})
+	cancel()
+
+	now := time.Now()
+	if sendErr == nil {
+		if err := d.repo.MarkSent(ctx, m.ID, now); err != nil {
+			logger.Error(err, "failed to mark notification sent", log...)
+		}
+		return
+	}
+
+	if m.Attempts >= m.MaxAttempts {
+		logger.Error(sendErr, "notification dead-lettered", log...)
+		if err := d.repo.MarkDead(ctx, m.ID, sendErr.Error(), now); err != nil {
+			logger.Error(err, "failed to mark notification dead", log...)
+		}
+		return
+	}
+
+	logger.Warn(sendErr, "notification delivery failed, will retry", log...)
+	if err := d.repo.MarkRetry(ctx, m.ID, sendErr.Error(), now.Add(d.backoff(m.Attempts)), now); err != nil {
+		logger.Error(err, "failed to reschedule notification", log...)
+	}
+}
+
+// backoff — экспоненциальная пауза после attempt неудачных попыток с разбросом до 10%,
+// чтобы сообщения, упавшие вместе, не повторялись одновременно
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.retryBase
+	for i := 1; i < attempt && delay < d.retryMax; i++ {
+		delay *= 2
+	}
+	delay = min(delay, d.retryMax)
+
+	return delay + rand.N(delay/10+1)
+}
+
+func (d *Dispatcher) stopped() bool {
+	select {
+	case <-d.stop:
+		return true
+	default:
+		return false
+	}
+}
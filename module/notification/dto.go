@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"encoding/json"
+	"go-monolite/pkg/validator"
+	"time"
+)
+
+// ListRequest — фильтр GET /notification
+type ListRequest struct {
+	Status    string `validate:"omitempty,oneof=pending sent dead"`
+	Channel   string `validate:"omitempty,oneof=email sms"`
+	Recipient string `validate:"max=255"`
+	Limit     int    `validate:"min=1,max=100"`
+	Offset    int    `validate:"min=0"`
+}
+
+func (d *ListRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// MessageResponse — сообщение outbox, текст сообщений с кодами не возвращается
+type MessageResponse struct {
+	ID             int64      `json:"id"`
+	IdempotencyKey string     `json:"idempotency_key"`
+	Channel        string     `json:"channel"`
+	Recipient      string     `json:"recipient"`
+	Subject        string     `json:"subject,omitempty"`
+	Body           string     `json:"body,omitempty"`
+	HTML           string     `json:"html,omitempty"`
+	Template       string     `json:"template,omitempty"`
+	Locale         string     `json:"locale,omitempty"`
+	Sensitive      bool       `json:"sensitive"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	MaxAttempts    int        `json:"max_attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ListResponse — DTO для GET /notification
+type ListResponse struct {
+	Items []MessageResponse `json:"items"`
+	Total int               `json:"total"`
+}
+
+// TemplateRequest — DTO для PUT /notification/template/{name}/{locale}, поля — тексты Go-шаблонов
+type TemplateRequest struct {
+	Subject string `json:"subject" validate:"required,max=1000"`
+	Text    string `json:"text" validate:"required,max=20000"`
+	HTML    string `json:"html" validate:"max=100000"`
+	Sms     string `json:"sms" validate:"max=1000"`
+}
+
+func (d *TemplateRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+func (d *TemplateRequest) Source() TemplateSource {
+	return TemplateSource{Subject: d.Subject, Text: d.Text, HTML: d.HTML, Sms: d.Sms}
+}
+
+// PreviewRequest — DTO для POST /notification/template/{name}/preview.
+// Data дополняет пример данных шаблона, Template позволяет посмотреть черновик до сохранения
+type PreviewRequest struct {
+	Locale   string           `json:"locale" validate:"omitempty,oneof=ru en"`
+	Data     json.RawMessage  `json:"data" swaggertype:"object"`
+	Template *TemplateRequest `json:"template"`
+}
+
+func (d *PreviewRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// TemplateResponse — действующие тексты шаблона
+type TemplateResponse struct {
+	Name       string     `json:"name"`
+	Locale     string     `json:"locale"`
+	Subject    string     `json:"subject"`
+	Text       string     `json:"text"`
+	HTML       string     `json:"html,omitempty"`
+	Sms        string     `json:"sms,omitempty"`
+	Overridden bool       `json:"overridden"` // тексты изменены администратором
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// TemplateInfoResponse — элемент списка шаблонов
+type TemplateInfoResponse struct {
+	Name       string     `json:"name"`
+	Locale     string     `json:"locale"`
+	Overridden bool       `json:"overridden"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
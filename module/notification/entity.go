@@ -0,0 +1,108 @@
+package notification
+
+import (
+	"time"
+)
+
+// статусы сообщений outbox: pending ждёт отправки или повтора, dead — попытки исчерпаны или сообщение истекло
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusDead    = "dead"
+)
+
+// Message — сообщение для постановки в outbox
+type Message struct {
+	Key       string // ключ идемпотентности, одно и то же событие ставится в очередь один раз
+	Channel   string // sender.ChannelEmail или sender.ChannelSms
+	Recipient string
+	Subject   string
+	Body      string
+	HTML      string
+	Template  string // шаблон и язык, по которым построен текст, для разбора в админке
+	Locale    string
+	Sensitive bool       // содержит код или ссылку: не показывается в админке и стирается после отправки
+	ExpiresAt *time.Time // после этого времени отправка не имеет смысла
+}
+
+// Notification — уведомление по шаблону, текст строится на языке из контекста запроса
+type Notification struct {
+	Key       string
+	Template  string
+	Channel   string
+	Recipient string
+	Data      any
+	Sensitive bool
+	ExpiresAt *time.Time
+}
+
+type MessageEnt struct {
+	ID             int64      `db:"id"`
+	IdempotencyKey string     `db:"idempotency_key"`
+	Channel        string     `db:"channel"`
+	Recipient      string     `db:"recipient"`
+	Subject        string     `db:"subject"`
+	Body           string     `db:"body"`
+	HTMLBody       string     `db:"html_body"`
+	Template       string     `db:"template"`
+	Locale         string     `db:"locale"`
+	Sensitive      bool       `db:"sensitive"`
+	Status         string     `db:"status"`
+	Attempts       int        `db:"attempts"`
+	MaxAttempts    int        `db:"max_attempts"`
+	NextAttemptAt  time.Time  `db:"next_attempt_at"`
+	LockedUntil    *time.Time `db:"locked_until"`
+	ExpiresAt      *time.Time `db:"expires_at"`
+	LastError      *string    `db:"last_error"`
+	SentAt         *time.Time `db:"sent_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+}
+
+func (e MessageEnt) ToResponse() MessageResponse {
+	resp := MessageResponse{
+		ID:             e.ID,
+		IdempotencyKey: e.IdempotencyKey,
+		Channel:        e.Channel,
+		Recipient:      e.Recipient,
+		Subject:        e.Subject,
+		Template:       e.Template,
+		Locale:         e.Locale,
+		Sensitive:      e.Sensitive,
+		Status:         e.Status,
+		Attempts:       e.Attempts,
+		MaxAttempts:    e.MaxAttempts,
+		NextAttemptAt:  e.NextAttemptAt,
+		ExpiresAt:      e.ExpiresAt,
+		LastError:      e.LastError,
+		SentAt:         e.SentAt,
+		CreatedAt:      e.CreatedAt,
+		UpdatedAt:      e.UpdatedAt,
+	}
+	if !e.Sensitive {
+		resp.Body = e.Body
+		resp.HTML = e.HTMLBody
+	}
+	return resp
+}
+
+// Expired — сообщение устарело и отправлять его уже не нужно
+func (e *MessageEnt) Expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
+
+// TemplateEnt — шаблон, изменённый администратором
+type TemplateEnt struct {
+	Name      string    `db:"name"`
+	Locale    string    `db:"locale"`
+	Subject   string    `db:"subject"`
+	Text      string    `db:"text_body"`
+	HTML      string    `db:"html_body"`
+	Sms       string    `db:"sms_body"`
+	UpdatedBy *int64    `db:"updated_by"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (e *TemplateEnt) Source() TemplateSource {
+	return TemplateSource{Subject: e.Subject, Text: e.Text, HTML: e.HTML, Sms: e.Sms}
+}
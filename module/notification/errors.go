@@ -0,0 +1,12 @@
+package notification
+
+import "errors"
+
+var (
+	ErrNotRetryable = errors.New("only dead messages can be retried")
+	ErrExpired      = errors.New("message expired")
+	ErrSensitive    = errors.New("sensitive message text is wiped")
+
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrInvalidTemplate  = errors.New("invalid template")
+)
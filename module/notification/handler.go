@@ -0,0 +1,337 @@
+package notification
+
+import (
+	"errors"
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/module/user"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+const defaultListLimit = 50
+
+type Handler struct {
+	service     *Service
+	adminAuth   func(next http.Handler) http.Handler
+	requireRole func(next http.Handler) http.Handler
+}
+
+// NewHandler — authenticate и permissions собираются один раз в роутере сервера
+func NewHandler(store *store.Store, cfg *config.Config, authenticate func(next http.Handler) http.Handler, permissions auth.PermissionChecker) *Handler {
+	return &Handler{
+		service:     NewService(NewRepository(store), NewTemplates(NewTemplateRepository(store)), cfg.Notification),
+		adminAuth:   authenticate,
+		requireRole: auth.RequirePermission(permissions, user.PermissionNotificationsManage),
+	}
+}
+
+func (h *Handler) Init(r chi.Router) {
+	r.Use(h.adminAuth, h.requireRole)
+
+	r.Get("/", h.GetList)
+	r.Get("/{id}", h.Get)
+	r.Post("/{id}/retry", h.Retry)
+
+	r.Get("/template", h.GetTemplates)
+	r.Get("/template/{name}/{locale}", h.GetTemplate)
+	r.Put("/template/{name}/{locale}", h.SaveTemplate)
+	r.Delete("/template/{name}/{locale}", h.ResetTemplate)
+	r.Post("/template/{name}/preview", h.Preview)
+}
+
+// @Summary Get notifications
+// @Description Inspect the notification outbox, texts of messages with codes are not returned
+// @Tags notification
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param status query string false "Filter by status (pending, sent or dead)"
+// @Param channel query string false "Filter by channel (email or sms)"
+// @Param recipient query string false "Filter by recipient email or phone"
+// @Param limit query int false "Page size, 50 by default, at most 100"
+// @Param offset query int false "Offset"
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [get]
+func (h *Handler) GetList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := ListRequest{
+		Status:    query.Get("status"),
+		Channel:   query.Get("channel"),
+		Recipient: query.Get("recipient"),
+	}
+
+	var err error
+	if request.Limit, err = queryInt(r, "limit", defaultListLimit); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный limit")
+		return
+	}
+	if request.Offset, err = queryInt(r, "offset", 0); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный offset")
+		return
+	}
+
+	resp, mess, err := h.service.List(r.Context(), request)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Get notification
+// @Description Get an outbox message with its delivery state and last error
+// @Tags notification
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Notification ID"
+// @Success 200 {object} respond.SuccessResponse{data=MessageResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный идентификатор уведомления")
+		return
+	}
+
+	resp, mess, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Retry notification
+// @Description Put a dead-lettered message back into the queue with a fresh attempt counter. Messages with codes cannot be retried: their text is wiped once they are dead-lettered
+// @Tags notification
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Notification ID"
+// @Success 200 {object} respond.SuccessResponse{data=MessageResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id}/retry [post]
+func (h *Handler) Retry(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный идентификатор уведомления")
+		return
+	}
+
+	resp, mess, err := h.service.Retry(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+		case errors.Is(err, ErrNotRetryable), errors.Is(err, ErrExpired), errors.Is(err, ErrSensitive):
+			respond.ErrorHandler(w, r, http.StatusConflict, err, mess)
+		default:
+			logger.ErrorCtx(r.Context(), err, mess)
+			respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		}
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, resp)
+}
+
+// @Summary Get notification templates
+// @Description List templates for every locale, marking those overridden by an administrator
+// @Tags notification
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} respond.SuccessResponse{data=[]TemplateInfoResponse}
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /template [get]
+func (h *Handler) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	resp, mess, err := h.service.GetTemplates(r.Context())
+	if err != nil {
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Get notification template
+// @Description Get the effective template texts: the administrator override or the built-in version
+// @Tags notification
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param name path string true "Template name (otp, password_reset, back_in_stock, order_status)"
+// @Param locale path string true "Locale (ru or en)"
+// @Success 200 {object} respond.SuccessResponse{data=TemplateResponse}
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /template/{name}/{locale} [get]
+func (h *Handler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	resp, mess, err := h.service.GetTemplate(r.Context(), chi.URLParam(r, "name"), chi.URLParam(r, "locale"))
+	if err != nil {
+		h.templateError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Override notification template
+// @Description Replace the built-in template with Go template texts, the template is checked on sample data before saving
+// @Tags notification
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param name path string true "Template name (otp, password_reset, back_in_stock, order_status)"
+// @Param locale path string true "Locale (ru or en)"
+// @Param request body TemplateRequest true "Template texts"
+// @Success 200 {object} respond.SuccessResponse{data=TemplateResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /template/{name}/{locale} [put]
+func (h *Handler) SaveTemplate(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request TemplateRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, mess)
+		return
+	}
+
+	var updatedBy *int64
+	if identity, ok := auth.CurrentUser(r.Context()); ok && identity.UserID != 0 {
+		updatedBy = &identity.UserID
+	}
+
+	resp, mess, err := h.service.SaveTemplate(r.Context(), chi.URLParam(r, "name"), chi.URLParam(r, "locale"), request, updatedBy)
+	if err != nil {
+		h.templateError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, resp)
+}
+
+// @Summary Reset notification template
+// @Description Remove the administrator override, the built-in template is used again
+// @Tags notification
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param name path string true "Template name"
+// @Param locale path string true "Locale (ru or en)"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /template/{name}/{locale} [delete]
+func (h *Handler) ResetTemplate(w http.ResponseWriter, r *http.Request) {
+	mess, err := h.service.ResetTemplate(r.Context(), chi.URLParam(r, "name"), chi.URLParam(r, "locale"))
+	if err != nil {
+		h.templateError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, "")
+}
+
+// @Summary Preview notification template
+// @Description Render the effective template or an unsaved draft on sample data, data fields override the sample
+// @Tags notification
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param name path string true "Template name (otp, password_reset, back_in_stock, order_status)"
+// @Param request body PreviewRequest false "Locale, data and draft texts"
+// @Success 200 {object} respond.SuccessResponse{data=Rendered}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /template/{name}/preview [post]
+func (h *Handler) Preview(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	// без тела — предпросмотр действующего шаблона на примере данных
+	var request PreviewRequest
+	if len(body) > 0 {
+		if mess, err := helper.Unmarshal(body, &request); err != nil {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, mess)
+			return
+		}
+	}
+
+	resp, mess, err := h.service.Preview(r.Context(), chi.URLParam(r, "name"), request)
+	if err != nil {
+		h.templateError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+func (h *Handler) templateError(w http.ResponseWriter, r *http.Request, err error, mess string) {
+	if validationErrors, ok := err.(validator.ValidationError); ok {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrInvalidTemplate):
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, mess)
+	case errors.Is(err, ErrTemplateNotFound), errors.Is(err, store.ErrNotFound):
+		respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+	default:
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+	}
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,282 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-monolite/internal/infra/sender"
+	"go-monolite/module/notification"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenProvider имитирует недоступный шлюз
+type brokenProvider struct{}
+
+func (brokenProvider) SendEmail(context.Context, string, string, string, string) error {
+	return errors.New("smtp: connection refused")
+}
+
+func (brokenProvider) SendSms(context.Context, string, string) error {
+	return errors.New("sms gateway: status 503")
+}
+
+func TestNotificationIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	access := testinit.NewAuth(store, config)
+	handler := notification.NewHandler(store, config, access.Authenticate, access.Users)
+	server := testinit.SetupTestServer(t, handler)
+	defer server.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	ctx := context.Background()
+	repo := notification.NewRepository(store)
+	templates := notification.NewTemplates(notification.NewTemplateRepository(store))
+	service := notification.NewService(repo, templates, config.Notification)
+	memory := sender.New(sender.Memory, sender.Memory)
+	broken := sender.New(brokenProvider{}, brokenProvider{})
+
+	send := func(t *testing.T, method, path string, body ...string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(strings.Join(body, "")))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+config.HTTPServer.BearerToken)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	find := func(t *testing.T, recipient string) notification.MessageResponse {
+		t.Helper()
+		resp := send(t, http.MethodGet, "/?recipient="+recipient)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		var list notification.ListResponse
+		testinit.MarshalUnmarshal(t, response.Data, &list)
+		require.Len(t, list.Items, 1)
+		return list.Items[0]
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		resp := testinit.SendRequest(t, server.URL+"/", "GET", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Rolled Back With Transaction", func(t *testing.T) {
+		err := store.WithinTx(ctx, func(ctx context.Context) error {
+			require.NoError(t, service.Enqueue(ctx, notification.Message{
+				Key: "rollback", Channel: sender.ChannelSms, Recipient: "79990000010", Body: "text",
+			}))
+			return errors.New("business change failed")
+		})
+		require.Error(t, err)
+
+		messages, total, err := repo.List(ctx, notification.ListRequest{Recipient: "79990000010", Limit: 10})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, messages)
+	})
+
+	t.Run("Idempotent Delivery", func(t *testing.T) {
+		const phone = "79990000011"
+		expiresAt := time.Now().Add(time.Minute)
+		msg := notification.CodeMessage("", phone, "123456", "idempotent", expiresAt)
+		require.NoError(t, service.Notify(ctx, msg))
+		require.NoError(t, service.Notify(ctx, msg))
+
+		dispatcher := notification.NewDispatcher(repo, memory, config.Notification)
+		_, err := dispatcher.Dispatch(ctx)
+		require.NoError(t, err)
+
+		count := 0
+		for _, m := range sender.Memory.Messages() {
+			if m.To == phone {
+				count++
+				assert.Equal(t, "Код: 123456", m.Body)
+			}
+		}
+		assert.Equal(t, 1, count)
+
+		item := find(t, phone)
+		assert.Equal(t, notification.StatusSent, item.Status)
+		assert.Equal(t, 1, item.Attempts)
+		assert.Empty(t, item.Body, "code must not be exposed")
+	})
+
+	t.Run("Retry Then Dead Letter", func(t *testing.T) {
+		const email = "retry@example.com"
+		cfg := config.Notification
+		cfg.MaxAttempts = 2
+		cfg.RetryBase = 60
+		require.NoError(t, notification.NewService(repo, templates, cfg).Enqueue(ctx, notification.Message{
+			Key: "retry", Channel: sender.ChannelEmail, Recipient: email, Subject: "Заказ", Body: "Заказ принят",
+		}))
+
+		dispatcher := notification.NewDispatcher(repo, broken, cfg)
+		_, err := dispatcher.Dispatch(ctx)
+		require.NoError(t, err)
+
+		item := find(t, email)
+		assert.Equal(t, notification.StatusPending, item.Status)
+		require.NotNil(t, item.LastError)
+		assert.Contains(t, *item.LastError, "connection refused")
+		assert.True(t, item.NextAttemptAt.After(time.Now().Add(50*time.Second)), "retry must be delayed")
+
+		// не дожидаемся паузы: сдвигаем повтор на сейчас
+		_, err = store.Db.Exec(`UPDATE notification_outbox SET next_attempt_at = next_attempt_at - interval '1 hour' WHERE id = $1`, item.ID)
+		require.NoError(t, err)
+		_, err = dispatcher.Dispatch(ctx)
+		require.NoError(t, err)
+
+		item = find(t, email)
+		assert.Equal(t, notification.StatusDead, item.Status)
+		assert.Equal(t, 2, item.Attempts)
+		assert.Equal(t, "Заказ принят", item.Body)
+
+		resp := send(t, http.MethodPost, fmt.Sprintf("/%d/retry", item.ID))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		_, err = notification.NewDispatcher(repo, memory, cfg).Dispatch(ctx)
+		require.NoError(t, err)
+
+		item = find(t, email)
+		assert.Equal(t, notification.StatusSent, item.Status)
+		m, ok := sender.Memory.Last(email)
+		require.True(t, ok)
+		assert.Equal(t, "Заказ", m.Subject)
+
+		resp = send(t, http.MethodPost, fmt.Sprintf("/%d/retry", item.ID))
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Expired Not Sent", func(t *testing.T) {
+		const phone = "79990000012"
+		require.NoError(t, service.Notify(ctx, notification.CodeMessage("", phone, "654321", "expired", time.Now().Add(-time.Second))))
+
+		_, err := notification.NewDispatcher(repo, memory, config.Notification).Dispatch(ctx)
+		require.NoError(t, err)
+
+		_, sent := sender.Memory.Last(phone)
+		assert.False(t, sent)
+
+		item := find(t, phone)
+		assert.Equal(t, notification.StatusDead, item.Status)
+
+		resp := send(t, http.MethodPost, fmt.Sprintf("/%d/retry", item.ID))
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Dead Sensitive Wiped", func(t *testing.T) {
+		const phone = "79990000013"
+		cfg := config.Notification
+		cfg.MaxAttempts = 1
+		require.NoError(t, notification.NewService(repo, templates, cfg).Notify(ctx,
+			notification.CodeMessage("", phone, "111222", "dead-sensitive", time.Now().Add(time.Hour))))
+
+		_, err := notification.NewDispatcher(repo, broken, cfg).Dispatch(ctx)
+		require.NoError(t, err)
+
+		item := find(t, phone)
+		require.Equal(t, notification.StatusDead, item.Status)
+
+		e, err := repo.GetByID(ctx, item.ID)
+		require.NoError(t, err)
+		assert.True(t, e.Sensitive)
+		assert.Empty(t, e.Body, "code must not be kept in the outbox")
+		assert.Empty(t, e.HTMLBody)
+
+		resp := send(t, http.MethodPost, fmt.Sprintf("/%d/retry", item.ID))
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	preview := func(t *testing.T, name, body string) notification.Rendered {
+		t.Helper()
+		resp := send(t, http.MethodPost, "/template/"+name+"/preview", body)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		var rendered notification.Rendered
+		testinit.MarshalUnmarshal(t, response.Data, &rendered)
+		return rendered
+	}
+
+	t.Run("Template Preview", func(t *testing.T) {
+		rendered := preview(t, notification.TemplateOTP, "")
+		assert.Equal(t, "Код подтверждения", rendered.Subject)
+		assert.Contains(t, rendered.Text, "123456")
+		assert.Contains(t, rendered.HTML, "<p")
+		assert.Equal(t, "Код: 123456", rendered.Sms)
+
+		rendered = preview(t, notification.TemplateOrderStatus, `{"locale": "en", "data": {"order_number": "B-42", "status_name": "Shipped"}}`)
+		assert.Equal(t, "Order B-42: Shipped", rendered.Subject)
+
+		// данные в HTML экранируются
+		rendered = preview(t, notification.TemplateBackInStock, `{"data": {"product_name": "<script>"}}`)
+		assert.NotContains(t, rendered.HTML, "<script>")
+		assert.Contains(t, rendered.Text, "<script>")
+
+		resp := send(t, http.MethodPost, "/template/unknown/preview")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Template Override", func(t *testing.T) {
+		resp := send(t, http.MethodPut, "/template/otp/ru", `{"subject": "Код", "text": "Код {{.Unknown}}"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, http.MethodPut, "/template/otp/ru", `{"subject": "Код", "text": "Код {{.Code"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, http.MethodPut, "/template/otp/de", `{"subject": "Code", "text": "Code {{.Code}}"}`)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = send(t, http.MethodPut, "/template/otp/ru",
+			`{"subject": "Вход в магазин", "text": "Ваш код {{.Code}}", "html": "<b>{{.Code}}</b>", "sms": "Магазин: {{.Code}}"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		const email = "template@example.com"
+		require.NoError(t, service.Notify(ctx, notification.CodeMessage(email, "", "777777", "override", time.Now().Add(time.Minute))))
+		_, err := notification.NewDispatcher(repo, memory, config.Notification).Dispatch(ctx)
+		require.NoError(t, err)
+
+		m, ok := sender.Memory.Last(email)
+		require.True(t, ok)
+		assert.Equal(t, "Вход в магазин", m.Subject)
+		assert.Equal(t, "Ваш код 777777", m.Body)
+		assert.Equal(t, "<b>777777</b>", m.HTML)
+
+		// английская версия не изменялась
+		assert.Equal(t, "Verification code", preview(t, notification.TemplateOTP, `{"locale": "en"}`).Subject)
+
+		resp = send(t, http.MethodDelete, "/template/otp/ru")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "Код подтверждения", preview(t, notification.TemplateOTP, "").Subject)
+
+		resp = send(t, http.MethodDelete, "/template/otp/ru")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "/999999")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = send(t, http.MethodGet, "/?status=unknown")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,59 @@
+package notification
+
+import (
+	"go-monolite/internal/infra/sender"
+	"math"
+	"time"
+)
+
+// CodeMessage — одноразовый код подтверждения на email или телефон, key отличает эту отправку от других
+func CodeMessage(email, phone, code, key string, expiresAt time.Time) Notification {
+	channel, recipient := contact(email, phone)
+	return Notification{
+		Key:       "auth-code:" + key,
+		Template:  TemplateOTP,
+		Channel:   channel,
+		Recipient: recipient,
+		Data:      OTPData{Code: code, TTLMinutes: minutesUntil(expiresAt)},
+		Sensitive: true,
+		ExpiresAt: &expiresAt,
+	}
+}
+
+// PasswordResetMessage — ссылка восстановления пароля на email или контрольное слово в SMS
+func PasswordResetMessage(email, phone, link, checkword, key string, expiresAt time.Time) Notification {
+	channel, recipient := contact(email, phone)
+	return Notification{
+		Key:       "password-reset:" + key,
+		Template:  TemplatePasswordReset,
+		Channel:   channel,
+		Recipient: recipient,
+		Data:      PasswordResetData{Link: link, Checkword: checkword, TTLMinutes: minutesUntil(expiresAt)},
+		Sensitive: true,
+		ExpiresAt: &expiresAt,
+	}
+}
+
+// OrderStatusMessage — смена статуса заказа, key отличает эту смену от других
+func OrderStatusMessage(email, phone string, data OrderStatusData, key string) Notification {
+	channel, recipient := contact(email, phone)
+	return Notification{
+		Key:       "order-status:" + key,
+		Template:  TemplateOrderStatus,
+		Channel:   channel,
+		Recipient: recipient,
+		Data:      data,
+	}
+}
+
+// contact — письмо, если указан email, иначе SMS на телефон
+func contact(email, phone string) (string, string) {
+	if email != "" {
+		return sender.ChannelEmail, email
+	}
+	return sender.ChannelSms, phone
+}
+
+func minutesUntil(t time.Time) int {
+	return int(math.Ceil(time.Until(t).Minutes()))
+}
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE code = 'notifications:manage';
+
+DROP TABLE IF EXISTS notification_outbox;
@@ -0,0 +1,31 @@
+-- исходящие уведомления: пишутся в одной транзакции с бизнес-изменением, доставляются фоновым диспетчером
+CREATE TABLE IF NOT EXISTS notification_outbox (
+  id BIGSERIAL PRIMARY KEY,
+  idempotency_key VARCHAR(255) NOT NULL UNIQUE, -- повторная постановка того же сообщения игнорируется
+  channel VARCHAR(10) NOT NULL CHECK (channel IN ('email', 'sms')),
+  recipient VARCHAR(255) NOT NULL,
+  subject VARCHAR(255) NOT NULL DEFAULT '',
+  body TEXT NOT NULL,
+  sensitive BOOLEAN NOT NULL DEFAULT FALSE, -- коды и ссылки: текст не показывается в админке и стирается после отправки
+  status VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'dead')),
+  attempts INT NOT NULL DEFAULT 0,
+  max_attempts INT NOT NULL,
+  next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  locked_until TIMESTAMP, -- сообщение взято диспетчером, после этого времени его может взять другой экземпляр
+  expires_at TIMESTAMP, -- после этого времени сообщение не отправляется, например истёкший код
+  last_error TEXT,
+  sent_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS notification_outbox_pending_idx ON notification_outbox (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS notification_outbox_status_idx ON notification_outbox (status, created_at DESC);
+
+INSERT INTO permissions (code, name) VALUES ('notifications:manage', 'Управление уведомлениями')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.code = 'admin' AND p.code = 'notifications:manage'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+ALTER TABLE notification_outbox DROP COLUMN IF EXISTS locale;
+ALTER TABLE notification_outbox DROP COLUMN IF EXISTS template;
+ALTER TABLE notification_outbox DROP COLUMN IF EXISTS html_body;
+
+DROP TABLE IF EXISTS notification_templates;
@@ -0,0 +1,16 @@
+-- тексты шаблонов, изменённые администратором; без записи используется шаблон, встроенный в приложение
+CREATE TABLE IF NOT EXISTS notification_templates (
+  name VARCHAR(50) NOT NULL,
+  locale VARCHAR(5) NOT NULL,
+  subject TEXT NOT NULL,
+  text_body TEXT NOT NULL,
+  html_body TEXT NOT NULL DEFAULT '', -- пустой — письмо только текстовое
+  sms_body TEXT NOT NULL DEFAULT '', -- пустой — в SMS уходит текстовая версия
+  updated_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (name, locale)
+);
+
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS html_body TEXT NOT NULL DEFAULT '';
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS template VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS locale VARCHAR(5) NOT NULL DEFAULT '';
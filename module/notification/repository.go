@@ -0,0 +1,232 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const messageColumns = `id, idempotency_key, channel, recipient, subject, body, html_body, template, locale, sensitive, status, attempts, max_attempts,
+	next_attempt_at, locked_until, expires_at, last_error, sent_at, created_at, updated_at`
+
+type Repository struct {
+	store     *store.Store
+	tableName string
+}
+
+func NewRepository(store *store.Store) *Repository {
+	return &Repository{
+		store:     store,
+		tableName: "notification_outbox",
+	}
+}
+
+// Enqueue добавляет сообщение, если сообщения с таким ключом ещё нет. Выполняется в транзакции из контекста
+func (r *Repository) Enqueue(ctx context.Context, e *MessageEnt) (bool, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			idempotency_key, channel, recipient, subject, body, html_body, template, locale, sensitive, status, max_attempts,
+			next_attempt_at, expires_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+		)
+		ON CONFLICT (idempotency_key) DO NOTHING
+		RETURNING id
+	`, r.tableName)
+
+	now := time.Now()
+	e.Status = StatusPending
+	e.NextAttemptAt = now
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query,
+		e.IdempotencyKey,
+		e.Channel,
+		e.Recipient,
+		e.Subject,
+		e.Body,
+		e.HTMLBody,
+		e.Template,
+		e.Locale,
+		e.Sensitive,
+		e.Status,
+		e.MaxAttempts,
+		e.NextAttemptAt,
+		e.ExpiresAt,
+		e.CreatedAt,
+		e.UpdatedAt,
+	).Scan(&e.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, store.ContextError(err)
+	}
+
+	return true, nil
+}
+
+// Claim закрепляет за вызывающим до limit готовых к отправке сообщений до времени lockedUntil и увеличивает счётчик попыток.
+// Сообщения, закреплённые другим экземпляром, пропускаются
+func (r *Repository) Claim(ctx context.Context, limit int, now, lockedUntil time.Time) ([]MessageEnt, error) {
+	query := fmt.Sprintf(`
+		UPDATE %[1]s
+		SET attempts = attempts + 1, locked_until = $3, updated_at = $1
+		WHERE id IN (
+			SELECT id FROM %[1]s
+			WHERE status = '%[2]s' AND next_attempt_at <= $1 AND (locked_until IS NULL OR locked_until <= $1)
+			ORDER BY next_attempt_at, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %[3]s
+	`, r.tableName, StatusPending, messageColumns)
+
+	var messages []MessageEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &messages, query, now, limit, lockedUntil); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return messages, nil
+}
+
+// MarkSent отмечает сообщение отправленным, текст сообщений с кодами стирается
+func (r *Repository) MarkSent(ctx context.Context, id int64, now time.Time) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET status = $2, sent_at = $3, updated_at = $3, locked_until = NULL, last_error = NULL,
+			body = CASE WHEN sensitive THEN '' ELSE body END,
+			html_body = CASE WHEN sensitive THEN '' ELSE html_body END
+		WHERE id = $1
+	`, r.tableName)
+
+	return r.exec(ctx, query, id, StatusSent, now)
+}
+
+// MarkRetry возвращает сообщение в очередь с повтором не раньше nextAttemptAt
+func (r *Repository) MarkRetry(ctx context.Context, id int64, lastError string, nextAttemptAt, now time.Time) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET status = $2, last_error = $3, next_attempt_at = $4, updated_at = $5, locked_until = NULL
+		WHERE id = $1
+	`, r.tableName)
+
+	return r.exec(ctx, query, id, StatusPending, lastError, nextAttemptAt, now)
+}
+
+// MarkDead убирает сообщение из очереди, повторить его можно только вручную.
+// Текст сообщений с кодами стирается, как и при отправке
+func (r *Repository) MarkDead(ctx context.Context, id int64, lastError string, now time.Time) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET status = $2, last_error = $3, updated_at = $4, locked_until = NULL,
+			body = CASE WHEN sensitive THEN '' ELSE body END,
+			html_body = CASE WHEN sensitive THEN '' ELSE html_body END
+		WHERE id = $1
+	`, r.tableName)
+
+	return r.exec(ctx, query, id, StatusDead, lastError, now)
+}
+
+// Retry возвращает dead-сообщение в очередь с обнулённым счётчиком попыток
+func (r *Repository) Retry(ctx context.Context, id int64, now time.Time) (*MessageEnt, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET status = $2, attempts = 0, next_attempt_at = $4, updated_at = $4, locked_until = NULL
+		WHERE id = $1 AND status = $3
+		RETURNING %s
+	`, r.tableName, messageColumns)
+
+	var e MessageEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, id, StatusPending, StatusDead, now)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotRetryable
+	}
+	if err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int64) (*MessageEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, messageColumns, r.tableName)
+
+	var e MessageEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+func (r *Repository) List(ctx context.Context, filter ListRequest) ([]MessageEnt, int, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		where = append(where, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Channel != "" {
+		args = append(args, filter.Channel)
+		where = append(where, fmt.Sprintf("channel = $%d", len(args)))
+	}
+	if filter.Recipient != "" {
+		args = append(args, filter.Recipient)
+		where = append(where, fmt.Sprintf("recipient = $%d", len(args)))
+	}
+
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s %s`, r.tableName, whereSQL)
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &total, countQuery, args...); err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, messageColumns, r.tableName, whereSQL, len(args)+1, len(args)+2)
+
+	var messages []MessageEnt
+	err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &messages, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	return messages, total, nil
+}
+
+func (r *Repository) exec(ctx context.Context, query string, args ...any) error {
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
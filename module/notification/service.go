@@ -0,0 +1,290 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-monolite/internal/config"
+	"go-monolite/internal/infra/sender"
+	"go-monolite/internal/store"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/middleware/locale"
+	"time"
+)
+
+type Service struct {
+	repo      *Repository
+	templates *Templates
+	cfg       config.Notification
+}
+
+func NewService(repo *Repository, templates *Templates, cfg config.Notification) *Service {
+	return &Service{
+		repo:      repo,
+		templates: templates,
+		cfg:       cfg,
+	}
+}
+
+// Notify строит сообщение по шаблону на языке клиента и ставит его в outbox
+func (s *Service) Notify(ctx context.Context, n Notification) error {
+	loc := locale.FromContext(ctx)
+	rendered, err := s.templates.Render(ctx, n.Template, loc, n.Data)
+	if err != nil {
+		return fmt.Errorf("failed to render notification: %w", err)
+	}
+
+	m := Message{
+		Key:       n.Key,
+		Channel:   n.Channel,
+		Recipient: n.Recipient,
+		Template:  n.Template,
+		Locale:    loc,
+		Sensitive: n.Sensitive,
+		ExpiresAt: n.ExpiresAt,
+	}
+	if n.Channel == sender.ChannelEmail {
+		m.Subject, m.Body, m.HTML = rendered.Subject, rendered.Text, rendered.HTML
+	} else {
+		m.Body = rendered.Sms
+	}
+
+	return s.Enqueue(ctx, m)
+}
+
+// Enqueue ставит сообщение в outbox. Вызывается в транзакции бизнес-изменения: при её откате сообщение не уйдёт.
+// Повторная постановка с тем же ключом ничего не делает
+func (s *Service) Enqueue(ctx context.Context, m Message) error {
+	if m.Key == "" || m.Recipient == "" {
+		return errors.New("notification key and recipient are required")
+	}
+	if m.Channel != sender.ChannelEmail && m.Channel != sender.ChannelSms {
+		return fmt.Errorf("unknown channel: %q", m.Channel)
+	}
+
+	e := &MessageEnt{
+		IdempotencyKey: m.Key,
+		Channel:        m.Channel,
+		Recipient:      m.Recipient,
+		Subject:        m.Subject,
+		Body:           m.Body,
+		HTMLBody:       m.HTML,
+		Template:       m.Template,
+		Locale:         m.Locale,
+		Sensitive:      m.Sensitive,
+		MaxAttempts:    max(s.cfg.MaxAttempts, 1),
+		ExpiresAt:      m.ExpiresAt,
+	}
+	if _, err := s.repo.Enqueue(ctx, e); err != nil {
+		return fmt.Errorf("failed to enqueue notification: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) List(ctx context.Context, req ListRequest) (*ListResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	messages, total, err := s.repo.List(ctx, req)
+	if err != nil {
+		return nil, "произошла ошибка при получении уведомлений", err
+	}
+
+	return &ListResponse{Items: helper.ToResponse(messages), Total: total}, "", nil
+}
+
+func (s *Service) Get(ctx context.Context, id int64) (*MessageResponse, string, error) {
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "уведомление не найдено", err
+		}
+		return nil, "произошла ошибка при получении уведомления", err
+	}
+
+	resp := e.ToResponse()
+	return &resp, "", nil
+}
+
+// Retry возвращает в очередь сообщение, для которого исчерпаны попытки
+func (s *Service) Retry(ctx context.Context, id int64) (*MessageResponse, string, error) {
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "уведомление не найдено", err
+		}
+		return nil, "произошла ошибка при повторе уведомления", err
+	}
+	if e.Expired(time.Now()) {
+		return nil, "уведомление устарело, повторить его нельзя", ErrExpired
+	}
+	if e.Sensitive && e.Status == StatusDead {
+		return nil, "текст уведомления с кодом удалён, запросите код заново", ErrSensitive
+	}
+
+	e, err = s.repo.Retry(ctx, id, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return nil, "уведомление не найдено", err
+		case errors.Is(err, ErrNotRetryable):
+			return nil, "повторить можно только уведомление, для которого исчерпаны попытки", err
+		}
+		return nil, "произошла ошибка при повторе уведомления", err
+	}
+
+	resp := e.ToResponse()
+	return &resp, "уведомление поставлено в очередь", nil
+}
+
+// GetTemplates — все шаблоны на всех языках с отметкой, какие изменены администратором
+func (s *Service) GetTemplates(ctx context.Context) ([]TemplateInfoResponse, string, error) {
+	overrides, err := s.templates.repo.GetList(ctx)
+	if err != nil {
+		return nil, "произошла ошибка при получении шаблонов", err
+	}
+
+	updated := make(map[templateKey]time.Time, len(overrides))
+	for _, e := range overrides {
+		updated[templateKey{e.Name, e.Locale}] = e.UpdatedAt
+	}
+
+	resp := make([]TemplateInfoResponse, 0, len(sampleData)*len(locale.Supported))
+	for _, name := range TemplateNames() {
+		for _, loc := range locale.Supported {
+			info := TemplateInfoResponse{Name: name, Locale: loc}
+			if at, ok := updated[templateKey{name, loc}]; ok {
+				info.Overridden = true
+				info.UpdatedAt = &at
+			}
+			resp = append(resp, info)
+		}
+	}
+
+	return resp, "", nil
+}
+
+func (s *Service) GetTemplate(ctx context.Context, name, loc string) (*TemplateResponse, string, error) {
+	src, overridden, err := s.templates.Source(ctx, name, loc)
+	if err != nil {
+		if errors.Is(err, ErrTemplateNotFound) {
+			return nil, "шаблон не найден", err
+		}
+		return nil, "произошла ошибка при получении шаблона", err
+	}
+
+	resp := &TemplateResponse{
+		Name:       name,
+		Locale:     loc,
+		Subject:    src.Subject,
+		Text:       src.Text,
+		HTML:       src.HTML,
+		Sms:        src.Sms,
+		Overridden: overridden,
+	}
+	if overridden {
+		e, err := s.templates.repo.Get(ctx, name, loc)
+		if err != nil {
+			return nil, "произошла ошибка при получении шаблона", err
+		}
+		resp.UpdatedAt = &e.UpdatedAt
+	}
+
+	return resp, "", nil
+}
+
+// SaveTemplate заменяет встроенный шаблон текстами администратора. Шаблон проверяется на примере данных до сохранения
+func (s *Service) SaveTemplate(ctx context.Context, name, loc string, req TemplateRequest, updatedBy *int64) (*TemplateResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	sample, ok := sampleData[name]
+	if !ok || !locale.IsSupported(loc) {
+		return nil, "шаблон не найден", ErrTemplateNotFound
+	}
+	if _, err := render(req.Source(), sample()); err != nil {
+		return nil, "шаблон содержит ошибку: " + err.Error(), err
+	}
+
+	e := &TemplateEnt{
+		Name:      name,
+		Locale:    loc,
+		Subject:   req.Subject,
+		Text:      req.Text,
+		HTML:      req.HTML,
+		Sms:       req.Sms,
+		UpdatedBy: updatedBy,
+	}
+	if err := s.templates.repo.Upsert(ctx, e); err != nil {
+		return nil, "произошла ошибка при сохранении шаблона", err
+	}
+
+	resp, mess, err := s.GetTemplate(ctx, name, loc)
+	if err != nil {
+		return nil, mess, err
+	}
+	return resp, "шаблон сохранён", nil
+}
+
+// ResetTemplate удаляет изменения администратора, снова действует встроенный шаблон
+func (s *Service) ResetTemplate(ctx context.Context, name, loc string) (string, error) {
+	if err := s.templates.repo.Delete(ctx, name, loc); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "шаблон не изменялся", err
+		}
+		return "произошла ошибка при сбросе шаблона", err
+	}
+
+	return "восстановлен встроенный шаблон", nil
+}
+
+// Preview строит сообщение по действующему шаблону или черновику на примере данных
+func (s *Service) Preview(ctx context.Context, name string, req PreviewRequest) (*Rendered, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	sample, ok := sampleData[name]
+	if !ok {
+		return nil, "шаблон не найден", ErrTemplateNotFound
+	}
+	data := sample()
+	if len(req.Data) > 0 {
+		if err := json.Unmarshal(req.Data, data); err != nil {
+			return nil, "некорректные данные для шаблона", fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+		}
+	}
+
+	loc := req.Locale
+	if loc == "" {
+		loc = locale.FromContext(ctx)
+	}
+
+	if req.Template != nil {
+		if err := req.Template.Validate(); err != nil {
+			return nil, "", err
+		}
+		rendered, err := render(req.Template.Source(), data)
+		if err != nil {
+			return nil, "шаблон содержит ошибку: " + err.Error(), err
+		}
+		return rendered, "", nil
+	}
+
+	rendered, err := s.templates.Render(ctx, name, loc, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTemplateNotFound):
+			return nil, "шаблон не найден", err
+		case errors.Is(err, ErrInvalidTemplate):
+			return nil, "шаблон содержит ошибку: " + err.Error(), err
+		}
+		return nil, "произошла ошибка при построении сообщения", err
+	}
+
+	return rendered, "", nil
+}
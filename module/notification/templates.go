@@ -0,0 +1,241 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"go-monolite/pkg/middleware/locale"
+	htmltemplate "html/template"
+	"io/fs"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+)
+
+// встроенные шаблоны: templates/<язык>/<имя>.subject.txt, <имя>.txt, <имя>.html и <имя>.sms.txt.
+// HTML и SMS необязательны: без HTML письмо только текстовое, без SMS отправляется текстовая версия
+//
+//go:embed templates
+var templatesFS embed.FS
+
+const (
+	TemplateOTP           = "otp"
+	TemplatePasswordReset = "password_reset"
+	TemplateBackInStock   = "back_in_stock"
+	TemplateOrderStatus   = "order_status"
+)
+
+// данные шаблонов, поля доступны в тексте как {{.Code}}, {{.Link}} и т.д.
+type (
+	OTPData struct {
+		Code       string `json:"code"`
+		TTLMinutes int    `json:"ttl_minutes"`
+	}
+	PasswordResetData struct {
+		Link       string `json:"link"`
+		Checkword  string `json:"checkword"`
+		TTLMinutes int    `json:"ttl_minutes"`
+	}
+	BackInStockData struct {
+		ProductName string `json:"product_name"`
+		ProductURL  string `json:"product_url"`
+	}
+	OrderStatusData struct {
+		OrderNumber string `json:"order_number"`
+		Status      string `json:"status"`
+		StatusName  string `json:"status_name"`
+		OrderURL    string `json:"order_url"`
+	}
+)
+
+// sampleData — пример данных для каждого шаблона: по нему проверяются изменённые шаблоны и строится предпросмотр
+var sampleData = map[string]func() any{
+	TemplateOTP: func() any {
+		return &OTPData{Code: "123456", TTLMinutes: 5}
+	},
+	TemplatePasswordReset: func() any {
+		return &PasswordResetData{Link: "https://example.com/reset-password?checkword=sample", Checkword: "12345678", TTLMinutes: 60}
+	},
+	TemplateBackInStock: func() any {
+		return &BackInStockData{ProductName: "Перфоратор", ProductURL: "https://example.com/product/sample"}
+	},
+	TemplateOrderStatus: func() any {
+		return &OrderStatusData{OrderNumber: "A-000001", Status: "shipped", StatusName: "Отправлен", OrderURL: "https://example.com/order/A-000001"}
+	},
+}
+
+// TemplateNames — все шаблоны уведомлений
+func TemplateNames() []string {
+	names := make([]string, 0, len(sampleData))
+	for name := range sampleData {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// TemplateSource — тексты шаблона на одном языке
+type TemplateSource struct {
+	Subject string
+	Text    string
+	HTML    string
+	Sms     string
+}
+
+// Rendered — готовые тексты сообщения
+type Rendered struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+	Sms     string `json:"sms"`
+}
+
+type templateKey struct{ name, locale string }
+
+var embeddedTemplates = mustLoadEmbedded()
+
+func mustLoadEmbedded() map[templateKey]TemplateSource {
+	templates := make(map[templateKey]TemplateSource)
+	read := func(path string) string {
+		data, err := fs.ReadFile(templatesFS, path)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	for _, loc := range locale.Supported {
+		for name := range sampleData {
+			prefix := "templates/" + loc + "/" + name
+			src := TemplateSource{
+				Subject: read(prefix + ".subject.txt"),
+				Text:    read(prefix + ".txt"),
+				HTML:    read(prefix + ".html"),
+				Sms:     read(prefix + ".sms.txt"),
+			}
+			if src.Text == "" {
+				continue
+			}
+			if _, err := render(src, sampleData[name]()); err != nil {
+				panic(fmt.Sprintf("notification template %s/%s: %s", loc, name, err))
+			}
+			templates[templateKey{name, loc}] = src
+		}
+	}
+
+	return templates
+}
+
+// Templates выбирает текст шаблона: изменённый администратором, встроенный на языке клиента или встроенный на языке по умолчанию
+type Templates struct {
+	repo *TemplateRepository
+}
+
+func NewTemplates(repo *TemplateRepository) *Templates {
+	return &Templates{repo: repo}
+}
+
+// Source возвращает действующие тексты шаблона и признак того, что они изменены в БД
+func (t *Templates) Source(ctx context.Context, name, loc string) (TemplateSource, bool, error) {
+	if _, ok := sampleData[name]; !ok || !locale.IsSupported(loc) {
+		return TemplateSource{}, false, ErrTemplateNotFound
+	}
+
+	e, err := t.repo.Get(ctx, name, loc)
+	if err == nil {
+		return e.Source(), true, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return TemplateSource{}, false, err
+	}
+
+	if src, ok := embeddedTemplates[templateKey{name, loc}]; ok {
+		return src, false, nil
+	}
+	if src, ok := embeddedTemplates[templateKey{name, locale.Default}]; ok {
+		return src, false, nil
+	}
+
+	return TemplateSource{}, false, ErrTemplateNotFound
+}
+
+func (t *Templates) Render(ctx context.Context, name, loc string, data any) (*Rendered, error) {
+	src, _, err := t.Source(ctx, name, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := render(src, data)
+	if err != nil {
+		return nil, fmt.Errorf("template %s/%s: %w", loc, name, err)
+	}
+
+	return rendered, nil
+}
+
+// render выполняет все части шаблона, текст — через text/template, HTML — через html/template с экранированием
+func render(src TemplateSource, data any) (*Rendered, error) {
+	var (
+		r   Rendered
+		err error
+	)
+	if r.Subject, err = renderText("subject", src.Subject, data); err != nil {
+		return nil, err
+	}
+	if r.Text, err = renderText("text", src.Text, data); err != nil {
+		return nil, err
+	}
+	if r.Sms, err = renderText("sms", src.Sms, data); err != nil {
+		return nil, err
+	}
+	if r.HTML, err = renderHTML(src.HTML, data); err != nil {
+		return nil, err
+	}
+
+	// тема письма — одна строка
+	r.Subject = strings.Join(strings.Fields(r.Subject), " ")
+	if r.Sms == "" {
+		r.Sms = r.Text
+	}
+
+	return &r, nil
+}
+
+func renderText(part, text string, data any) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := texttemplate.New(part).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func renderHTML(text string, data any) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := htmltemplate.New("html").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,98 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const templateColumns = `name, locale, subject, text_body, html_body, sms_body, updated_by, updated_at`
+
+type TemplateRepository struct {
+	store     *store.Store
+	tableName string
+}
+
+func NewTemplateRepository(store *store.Store) *TemplateRepository {
+	return &TemplateRepository{
+		store:     store,
+		tableName: "notification_templates",
+	}
+}
+
+func (r *TemplateRepository) Get(ctx context.Context, name, locale string) (*TemplateEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE name = $1 AND locale = $2`, templateColumns, r.tableName)
+
+	var e TemplateEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, name, locale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+func (r *TemplateRepository) GetList(ctx context.Context) ([]TemplateEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s ORDER BY name, locale`, templateColumns, r.tableName)
+
+	var templates []TemplateEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &templates, query); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return templates, nil
+}
+
+func (r *TemplateRepository) Upsert(ctx context.Context, e *TemplateEnt) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (name, locale, subject, text_body, html_body, sms_body, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (name, locale) DO UPDATE SET
+			subject = EXCLUDED.subject,
+			text_body = EXCLUDED.text_body,
+			html_body = EXCLUDED.html_body,
+			sms_body = EXCLUDED.sms_body,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`, r.tableName)
+
+	e.UpdatedAt = time.Now()
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query,
+		e.Name,
+		e.Locale,
+		e.Subject,
+		e.Text,
+		e.HTML,
+		e.Sms,
+		e.UpdatedBy,
+		e.UpdatedAt,
+	)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+func (r *TemplateRepository) Delete(ctx context.Context, name, locale string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE name = $1 AND locale = $2`, r.tableName)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, name, locale)
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package order
+
+import (
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// CustomerHandler — оформление и просмотр заказов покупателем, подключается к /user/me/orders
+type CustomerHandler struct {
+	service  *Service
+	userAuth func(next http.Handler) http.Handler
+}
+
+// NewCustomerHandler — userAuth (auth.UserAuth) собирается один раз в роутере сервера
+func NewCustomerHandler(store *store.Store, cfg *config.Config, userAuth func(next http.Handler) http.Handler, hooks ...StatusHook) *CustomerHandler {
+	return &CustomerHandler{
+		service:  newService(store, cfg, hooks...),
+		userAuth: userAuth,
+	}
+}
+
+func (h *CustomerHandler) Init(r chi.Router) {
+	r.Use(h.userAuth)
+
+	r.Get("/", h.GetList)
+	r.Post("/", h.Checkout)
+	r.Get("/{number}", h.Get)
+	r.Post("/{number}/cancel", h.Cancel)
+}
+
+// @Summary Checkout
+// @Description Place an order with current prices of the customer's price type. Contacts default to the user's profile, orders of company employees are placed for the company. Ordered items are taken from stock, the pickup storage first; a cancelled order returns them
+// @Tags order
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param request body CheckoutRequest true "Items and delivery"
+// @Success 201 {object} respond.SuccessResponse{data=OrderResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /user/me/orders [post]
+func (h *CustomerHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request CheckoutRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.Checkout(r.Context(), identity.UserID, request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusCreated, mess, resp)
+}
+
+// @Summary My orders
+// @Description Get orders of the authenticated user, newest first
+// @Tags order
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param status query string false "Filter by status"
+// @Param limit query int false "Page size, 50 by default, at most 100"
+// @Param offset query int false "Offset"
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /user/me/orders [get]
+func (h *CustomerHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.CurrentUser(r.Context())
+	request := ListRequest{
+		Status: r.URL.Query().Get("status"),
+		UserID: identity.UserID,
+	}
+
+	var err error
+	if request.Limit, err = queryInt(r, "limit", defaultListLimit); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный limit")
+		return
+	}
+	if request.Offset, err = queryInt(r, "offset", 0); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный offset")
+		return
+	}
+
+	resp, mess, err := h.service.List(r.Context(), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary My order
+// @Description Get an order of the authenticated user with its items and status history
+// @Tags order
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param number path string true "Order number"
+// @Success 200 {object} respond.SuccessResponse{data=OrderResponse}
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /user/me/orders/{number} [get]
+func (h *CustomerHandler) Get(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.UserOrder(r.Context(), identity.UserID, chi.URLParam(r, "number"))
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Cancel my order
+// @Description Cancel an order of the authenticated user that has not been confirmed yet, ordered items are returned to stock
+// @Tags order
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param number path string true "Order number"
+// @Param request body CancelRequest false "Reason"
+// @Success 200 {object} respond.SuccessResponse{data=OrderResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /user/me/orders/{number}/cancel [post]
+func (h *CustomerHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request CancelRequest
+	if len(body) > 0 {
+		mess, err := helper.Unmarshal(body, &request)
+		if err != nil {
+			respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+			return
+		}
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.Cancel(r.Context(), identity.UserID, chi.URLParam(r, "number"), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "заказ отменён", resp)
+}
@@ -0,0 +1,116 @@
+package order
+
+import (
+	"go-monolite/pkg/validator"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CheckoutRequest — DTO для POST /user/me/orders. Контакты по умолчанию берутся из профиля пользователя
+type CheckoutRequest struct {
+	Items          []CheckoutItem `json:"items" validate:"required,min=1,max=100,dive"`
+	DeliveryMethod string         `json:"delivery_method" validate:"required,oneof=pickup delivery" example:"pickup"`
+	StorageUUID    *uuid.UUID     `json:"storage_uuid,omitempty" validate:"required_if=DeliveryMethod pickup"` // склад самовывоза
+	Address        string         `json:"address,omitempty" validate:"required_if=DeliveryMethod delivery,max=500"`
+	CustomerName   string         `json:"customer_name,omitempty" validate:"max=255" example:"Иван Петров"`
+	Email          string         `json:"email,omitempty" validate:"omitempty,email,max=255"`
+	Phone          string         `json:"phone,omitempty" validate:"omitempty,phone" example:"79991234567"`
+	Comment        string         `json:"comment,omitempty" validate:"max=1000"`
+	PromoCode      string         `json:"promo_code,omitempty" validate:"max=50" example:"SPRING10"`
+}
+
+type CheckoutItem struct {
+	ProductUUID uuid.UUID `json:"product_uuid" validate:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Quantity    int       `json:"quantity" validate:"required,gt=0,max=100000" example:"2"`
+}
+
+func (d *CheckoutRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// ListRequest — фильтр списков заказов
+type ListRequest struct {
+	Status string `validate:"omitempty,oneof=new confirmed assembled shipped delivered cancelled"`
+	UserID int64  `validate:"min=0"`
+	Number string `validate:"max=32"`
+	Limit  int    `validate:"min=1,max=100"`
+	Offset int    `validate:"min=0"`
+}
+
+func (d *ListRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// StatusRequest — DTO для POST /order/{id}/status
+type StatusRequest struct {
+	Status  string `json:"status" validate:"required,oneof=new confirmed assembled shipped delivered cancelled" example:"confirmed"`
+	Comment string `json:"comment,omitempty" validate:"max=1000"`
+}
+
+func (d *StatusRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// CancelRequest — DTO для POST /user/me/orders/{number}/cancel
+type CancelRequest struct {
+	Comment string `json:"comment,omitempty" validate:"max=1000" example:"Передумал"`
+}
+
+func (d *CancelRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+type OrderResponse struct {
+	ID              int64      `json:"id" example:"1"`
+	Number          string     `json:"number" example:"A-000001"`
+	UserID          *int64     `json:"user_id,omitempty" example:"1"`
+	Status          string     `json:"status" example:"new"`
+	StatusName      string     `json:"status_name" example:"Принят"`
+	PriceTypeUUID   *uuid.UUID `json:"price_type_uuid,omitempty"`
+	Discount        float64    `json:"discount" example:"500"`
+	Total           float64    `json:"total" example:"4515"`
+	PromoCode       *string    `json:"promo_code,omitempty" example:"SPRING10"`
+	DeliveryMethod  string     `json:"delivery_method" example:"pickup"`
+	StorageUUID     *uuid.UUID `json:"storage_uuid,omitempty"`
+	DeliveryAddress *string    `json:"delivery_address,omitempty"`
+	CustomerName    *string    `json:"customer_name,omitempty"`
+	CustomerEmail   *string    `json:"customer_email,omitempty"`
+	CustomerPhone   *string    `json:"customer_phone,omitempty"`
+	CompanyID       *int64     `json:"company_id,omitempty"`
+	CompanyName     *string    `json:"company_name,omitempty"`
+	CompanyINN      *string    `json:"company_inn,omitempty"`
+	CompanyKPP      *string    `json:"company_kpp,omitempty"`
+	Comment         *string    `json:"comment,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	Items   []ItemResponse    `json:"items,omitempty"`
+	History []HistoryResponse `json:"history,omitempty"`
+}
+
+type ItemResponse struct {
+	ProductUUID uuid.UUID `json:"product_uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name        string    `json:"name" example:"Дрель"`
+	Code        int       `json:"code" example:"1001"`
+	Article     *string   `json:"article,omitempty"`
+	Unit        *string   `json:"unit,omitempty" example:"шт"`
+	Quantity    int       `json:"quantity" example:"2"`
+	Price       float64   `json:"price" example:"2500"`           // цена до скидок
+	Discount    float64   `json:"discount" example:"500"`         // скидка на позицию
+	Sum         float64   `json:"sum" example:"4500"`             // сумма со скидкой
+	Gift        bool      `json:"gift,omitempty" example:"false"` // подарок по акции
+}
+
+type HistoryResponse struct {
+	FromStatus *string   `json:"from_status,omitempty" example:"new"`
+	ToStatus   string    `json:"to_status" example:"confirmed"`
+	Comment    *string   `json:"comment,omitempty"`
+	ChangedBy  *int64    `json:"changed_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ListResponse struct {
+	Items []OrderResponse `json:"items"`
+	Total int             `json:"total"`
+}
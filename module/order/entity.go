@@ -0,0 +1,176 @@
+package order
+
+import (
+	"go-monolite/pkg/middleware/locale"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatusNew       = "new"
+	StatusConfirmed = "confirmed"
+	StatusAssembled = "assembled"
+	StatusShipped   = "shipped"
+	StatusDelivered = "delivered"
+	StatusCancelled = "cancelled"
+)
+
+const (
+	DeliveryPickup   = "pickup"   // самовывоз со склада
+	DeliveryDelivery = "delivery" // доставка по адресу
+)
+
+// transitions — допустимые переходы статусов, delivered и cancelled конечные
+var transitions = map[string][]string{
+	StatusNew:       {StatusConfirmed, StatusCancelled},
+	StatusConfirmed: {StatusAssembled, StatusCancelled},
+	StatusAssembled: {StatusShipped, StatusCancelled},
+	StatusShipped:   {StatusDelivered},
+}
+
+// CanTransition — можно ли перевести заказ из статуса from в to
+func CanTransition(from, to string) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// названия статусов для покупателя
+var statusNames = map[string]map[string]string{
+	locale.RU: {
+		StatusNew:       "Принят",
+		StatusConfirmed: "Подтверждён",
+		StatusAssembled: "Собран",
+		StatusShipped:   "Отправлен",
+		StatusDelivered: "Доставлен",
+		StatusCancelled: "Отменён",
+	},
+	locale.EN: {
+		StatusNew:       "Received",
+		StatusConfirmed: "Confirmed",
+		StatusAssembled: "Assembled",
+		StatusShipped:   "Shipped",
+		StatusDelivered: "Delivered",
+		StatusCancelled: "Cancelled",
+	},
+}
+
+// StatusName — название статуса на языке loc, для неизвестного языка — на языке по умолчанию
+func StatusName(status, loc string) string {
+	names, ok := statusNames[loc]
+	if !ok {
+		names = statusNames[locale.Default]
+	}
+	if name, ok := names[status]; ok {
+		return name
+	}
+	return status
+}
+
+type OrderEnt struct {
+	ID              int64      `db:"id"`
+	Number          string     `db:"number"`
+	UserID          *int64     `db:"user_id"`
+	Status          string     `db:"status"`
+	PriceTypeUUID   *uuid.UUID `db:"price_type_uuid"`
+	Discount        float64    `db:"discount"`
+	Total           float64    `db:"total"`
+	PromoCode       *string    `db:"promo_code"`
+	DeliveryMethod  string     `db:"delivery_method"`
+	StorageUUID     *uuid.UUID `db:"storage_uuid"`
+	DeliveryAddress *string    `db:"delivery_address"`
+	CustomerName    *string    `db:"customer_name"`
+	CustomerEmail   *string    `db:"customer_email"`
+	CustomerPhone   *string    `db:"customer_phone"`
+	CompanyID       *int64     `db:"company_id"`
+	CompanyName     *string    `db:"company_name"`
+	CompanyINN      *string    `db:"company_inn"`
+	CompanyKPP      *string    `db:"company_kpp"`
+	Comment         *string    `db:"comment"`
+	Locale          string     `db:"locale"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+}
+
+func (e OrderEnt) ToResponse() OrderResponse {
+	return OrderResponse{
+		ID:              e.ID,
+		Number:          e.Number,
+		UserID:          e.UserID,
+		Status:          e.Status,
+		StatusName:      StatusName(e.Status, e.Locale),
+		PriceTypeUUID:   e.PriceTypeUUID,
+		Discount:        e.Discount,
+		Total:           e.Total,
+		PromoCode:       e.PromoCode,
+		DeliveryMethod:  e.DeliveryMethod,
+		StorageUUID:     e.StorageUUID,
+		DeliveryAddress: e.DeliveryAddress,
+		CustomerName:    e.CustomerName,
+		CustomerEmail:   e.CustomerEmail,
+		CustomerPhone:   e.CustomerPhone,
+		CompanyID:       e.CompanyID,
+		CompanyName:     e.CompanyName,
+		CompanyINN:      e.CompanyINN,
+		CompanyKPP:      e.CompanyKPP,
+		Comment:         e.Comment,
+		CreatedAt:       e.CreatedAt,
+		UpdatedAt:       e.UpdatedAt,
+	}
+}
+
+type ItemEnt struct {
+	OrderID     int64     `db:"order_id"`
+	ProductUUID uuid.UUID `db:"product_uuid"`
+	Name        string    `db:"name"`
+	Code        int       `db:"code"`
+	Article     *string   `db:"article"`
+	Unit        *string   `db:"unit"`
+	Quantity    int       `db:"quantity"`
+	Price       float64   `db:"price"`
+	Discount    float64   `db:"discount"`
+	Sum         float64   `db:"sum"`
+	Gift        bool      `db:"gift"`
+}
+
+func (e ItemEnt) ToResponse() ItemResponse {
+	return ItemResponse{
+		ProductUUID: e.ProductUUID,
+		Name:        e.Name,
+		Code:        e.Code,
+		Article:     e.Article,
+		Unit:        e.Unit,
+		Quantity:    e.Quantity,
+		Price:       e.Price,
+		Discount:    e.Discount,
+		Sum:         e.Sum,
+		Gift:        e.Gift,
+	}
+}
+
+type HistoryEnt struct {
+	ID         int64     `db:"id"`
+	OrderID    int64     `db:"order_id"`
+	FromStatus *string   `db:"from_status"`
+	ToStatus   string    `db:"to_status"`
+	Comment    *string   `db:"comment"`
+	ChangedBy  *int64    `db:"changed_by"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// StockEnt — остаток товара на складе, с которого списывается заказ
+type StockEnt struct {
+	ID          int64     `db:"id"`
+	StorageUUID uuid.UUID `db:"storage_uuid"`
+	Quantity    int       `db:"quantity"`
+}
+
+func (e HistoryEnt) ToResponse() HistoryResponse {
+	return HistoryResponse{
+		FromStatus: e.FromStatus,
+		ToStatus:   e.ToStatus,
+		Comment:    e.Comment,
+		ChangedBy:  e.ChangedBy,
+		CreatedAt:  e.CreatedAt,
+	}
+}
@@ -0,0 +1,5 @@
+package order
+
+import "errors"
+
+var ErrInvalidTransition = errors.New("invalid status transition")
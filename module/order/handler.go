@@ -0,0 +1,217 @@
+package order
+
+import (
+	"errors"
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/module/cart"
+	"go-monolite/module/company"
+	"go-monolite/module/notification"
+	"go-monolite/module/promotion"
+	"go-monolite/module/user"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+const defaultListLimit = 50
+
+// Handler — управление заказами для администраторов
+type Handler struct {
+	service     *Service
+	adminAuth   func(next http.Handler) http.Handler
+	requireRole func(next http.Handler) http.Handler
+}
+
+// NewHandler — hooks выполняются при каждой смене статуса вместе с уведомлением покупателя,
+// authenticate и permissions собираются один раз в роутере сервера
+func NewHandler(store *store.Store, cfg *config.Config, authenticate func(next http.Handler) http.Handler, permissions auth.PermissionChecker, hooks ...StatusHook) *Handler {
+	return &Handler{
+		service:     newService(store, cfg, hooks...),
+		adminAuth:   authenticate,
+		requireRole: auth.RequirePermission(permissions, user.PermissionOrdersManage),
+	}
+}
+
+func newService(store *store.Store, cfg *config.Config, hooks ...StatusHook) *Service {
+	userRepo := user.NewRepository(store)
+	carts := cart.NewService(store, cart.NewRepository(store), userRepo, cfg.Cart)
+	notifications := notification.NewService(notification.NewRepository(store), notification.NewTemplates(notification.NewTemplateRepository(store)), cfg.Notification)
+	companyRepo := company.NewRepository(store)
+	promotions := promotion.NewService(store, promotion.NewRepository(store), carts, userRepo, companyRepo)
+	service := NewService(store, NewRepository(store), carts, promotions, userRepo, companyRepo)
+	service.OnStatusChange(NotifyCustomer(notifications, cfg.Order.URL))
+	service.OnStatusChange(hooks...)
+	return service
+}
+
+func (h *Handler) Init(r chi.Router) {
+	r.Use(h.adminAuth, h.requireRole)
+
+	r.Get("/", h.GetList)
+	r.Get("/{id}", h.Get)
+	r.Post("/{id}/status", h.ChangeStatus)
+}
+
+// @Summary Get orders
+// @Description Get orders of all customers
+// @Tags order
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param status query string false "Filter by status"
+// @Param user_id query int false "Filter by customer"
+// @Param number query string false "Filter by order number"
+// @Param limit query int false "Page size, 50 by default, at most 100"
+// @Param offset query int false "Offset"
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [get]
+func (h *Handler) GetList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := ListRequest{
+		Status: query.Get("status"),
+		Number: query.Get("number"),
+	}
+
+	userID, err := queryInt(r, "user_id", 0)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный user_id")
+		return
+	}
+	request.UserID = int64(userID)
+
+	if request.Limit, err = queryInt(r, "limit", defaultListLimit); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный limit")
+		return
+	}
+	if request.Offset, err = queryInt(r, "offset", 0); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный offset")
+		return
+	}
+
+	resp, mess, err := h.service.List(r.Context(), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Get order
+// @Description Get an order with its items and status history
+// @Tags order
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Order ID"
+// @Success 200 {object} respond.SuccessResponse{data=OrderResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	resp, mess, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Change order status
+// @Description Move an order to the next status: new → confirmed → assembled → shipped → delivered, cancelled from any status before shipping. A cancelled order returns its items to stock. The customer is notified
+// @Tags order
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Order ID"
+// @Param request body StatusRequest true "New status"
+// @Success 200 {object} respond.SuccessResponse{data=OrderResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id}/status [post]
+func (h *Handler) ChangeStatus(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	body := respond.ParseBody(w, r)
+
+	var request StatusRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	var changedBy *int64
+	if identity, _ := auth.CurrentUser(r.Context()); identity.UserID != 0 {
+		changedBy = &identity.UserID
+	}
+
+	resp, mess, err := h.service.ChangeStatus(r.Context(), id, request, changedBy)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "статус заказа изменён", resp)
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный идентификатор заказа")
+		return 0, false
+	}
+	return id, true
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, err error, mess string) {
+	var validationErrors validator.ValidationError
+	if errors.As(err, &validationErrors) {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+		return
+	}
+
+	switch {
+	case errors.Is(err, store.ErrNotFound), errors.Is(err, cart.ErrProductNotFound):
+		respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+	case errors.Is(err, ErrInvalidTransition), errors.Is(err, cart.ErrNoPrice), errors.Is(err, cart.ErrNotEnoughStock):
+		respond.ErrorHandler(w, r, http.StatusConflict, err, mess)
+	default:
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+	}
+}
@@ -0,0 +1,311 @@
+package order_test
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/internal/infra/sender"
+	"go-monolite/module/notification"
+	"go-monolite/module/order"
+	"go-monolite/module/user"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"go-monolite/pkg/token"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	access := testinit.NewAuth(store, config)
+	admin := testinit.SetupTestServer(t, order.NewHandler(store, config, access.Authenticate, access.Users))
+	defer admin.Close()
+	customer := testinit.SetupTestServer(t, order.NewCustomerHandler(store, config, access.UserAuth))
+	defer customer.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	ctx := context.Background()
+	tokenManager := token.NewManager(config.Auth.JWTSecret, time.Minute)
+
+	// каталог: дрель продаётся по одной, саморезы — упаковками по 5, у кабеля нет цены,
+	// последний перфоратор лежит на втором складе
+	categoryUUID, typePriceUUID, storageUUID, closedStorageUUID, reserveStorageUUID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	drill, screws, cable, hammer := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	for _, query := range []string{
+		fmt.Sprintf(`INSERT INTO categories (uuid, slug, name) VALUES ('%s', 'tools', 'Инструмент')`, categoryUUID),
+		fmt.Sprintf(`INSERT INTO type_price (uuid, name) VALUES ('%s', 'Розничная')`, typePriceUUID),
+		fmt.Sprintf(`INSERT INTO storage (uuid, name) VALUES ('%s', 'Основной')`, storageUUID),
+		fmt.Sprintf(`INSERT INTO storage (uuid, name, active) VALUES ('%s', 'Закрытый', 'N')`, closedStorageUUID),
+		fmt.Sprintf(`INSERT INTO storage (uuid, name) VALUES ('%s', 'Резервный')`, reserveStorageUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Дрель', 9001, 'drill', '%s')`, drill, categoryUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, step, category_uuid) VALUES ('%s', 'Саморезы', 9002, 'screws', 5, '%s')`, screws, categoryUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Кабель', 9003, 'cable', '%s')`, cable, categoryUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Перфоратор', 9004, 'hammer', '%s')`, hammer, categoryUUID),
+		fmt.Sprintf(`INSERT INTO product_prices (product_uuid, type_price_uuid, price) VALUES ('%s', '%s', 2500), ('%s', '%s', 1.5), ('%s', '%s', 7000)`, drill, typePriceUUID, screws, typePriceUUID, hammer, typePriceUUID),
+		fmt.Sprintf(`INSERT INTO product_storages (product_uuid, storage_uuid, quantity) VALUES ('%s', '%s', 3), ('%s', '%s', 7), ('%s', '%s', 100), ('%s', '%s', 10), ('%s', '%s', 1)`,
+			drill, storageUUID, drill, reserveStorageUUID, screws, storageUUID, cable, storageUUID, hammer, reserveStorageUUID),
+	} {
+		_, err := store.Db.Exec(query)
+		require.NoError(t, err)
+	}
+
+	const email = "buyer@example.com"
+	users := user.NewRepository(store)
+	newCustomer := func(t *testing.T, phone string, email *string) string {
+		t.Helper()
+		tv := user.InitialTokenVersion
+		userID, err := users.Create(ctx, &user.UserEnt{
+			Phone:        &phone,
+			Email:        email,
+			UserType:     user.UserTypeIndividual,
+			Active:       user.ActiveYes,
+			TokenVersion: &tv,
+		})
+		require.NoError(t, err)
+		accessToken, _, err := tokenManager.Generate(userID, tv, "")
+		require.NoError(t, err)
+		return accessToken
+	}
+	buyerEmail := email
+	buyer := newCustomer(t, "79990000101", &buyerEmail)
+	stranger := newCustomer(t, "79990000102", nil)
+
+	send := func(t *testing.T, server *httptest.Server, method, path, body, accessToken string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	decode := func(t *testing.T, resp *http.Response, status int, v any) {
+		t.Helper()
+		require.Equal(t, status, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		testinit.MarshalUnmarshal(t, response.Data, v)
+	}
+
+	checkout := func(t *testing.T, accessToken string) order.OrderResponse {
+		t.Helper()
+		body := fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": 1}, {"product_uuid": "%s", "quantity": 10}], "delivery_method": "pickup", "storage_uuid": "%s"}`, drill, screws, storageUUID)
+		var o order.OrderResponse
+		decode(t, send(t, customer, http.MethodPost, "/", body, accessToken), http.StatusCreated, &o)
+		return o
+	}
+
+	stock := func(t *testing.T, product, storage uuid.UUID) int {
+		t.Helper()
+		var quantity int
+		err := store.Db.Get(&quantity, `SELECT quantity FROM product_storages WHERE product_uuid = $1 AND storage_uuid = $2`, product, storage)
+		require.NoError(t, err)
+		return quantity
+	}
+
+	changeStatus := func(t *testing.T, id int64, status string) *http.Response {
+		t.Helper()
+		return send(t, admin, http.MethodPost, fmt.Sprintf("/%d/status", id), fmt.Sprintf(`{"status": "%s"}`, status), config.HTTPServer.BearerToken)
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		resp := send(t, customer, http.MethodGet, "/", "", "invalid")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = send(t, admin, http.MethodGet, "/", "", buyer)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Checkout Validation", func(t *testing.T) {
+		for _, body := range []string{
+			`{"items": [], "delivery_method": "pickup"}`,
+			fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": 1}], "delivery_method": "pickup"}`, drill),
+			fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": 1}], "delivery_method": "delivery"}`, drill),
+			fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": 1}], "delivery_method": "pickup", "storage_uuid": "%s"}`, drill, closedStorageUUID),
+			// саморезы продаются упаковками по 5
+			fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": 7}], "delivery_method": "delivery", "address": "Москва"}`, screws),
+		} {
+			resp := send(t, customer, http.MethodPost, "/", body, buyer)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		}
+
+		resp := send(t, customer, http.MethodPost, "/", fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": 11}], "delivery_method": "delivery", "address": "Москва"}`, drill), buyer)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = send(t, customer, http.MethodPost, "/", fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": 1}], "delivery_method": "delivery", "address": "Москва"}`, cable), buyer)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = send(t, customer, http.MethodPost, "/", fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": 1}], "delivery_method": "delivery", "address": "Москва"}`, uuid.New()), buyer)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Checkout", func(t *testing.T) {
+		o := checkout(t, buyer)
+
+		assert.True(t, strings.HasPrefix(o.Number, "A-"))
+		assert.Equal(t, order.StatusNew, o.Status)
+		assert.Equal(t, 2515.0, o.Total)
+		require.NotNil(t, o.PriceTypeUUID)
+		assert.Equal(t, typePriceUUID, *o.PriceTypeUUID)
+		require.NotNil(t, o.StorageUUID)
+		assert.Equal(t, storageUUID, *o.StorageUUID)
+		require.NotNil(t, o.CustomerEmail)
+		assert.Equal(t, email, *o.CustomerEmail)
+		require.Len(t, o.Items, 2)
+
+		// цена зафиксирована в заказе и не меняется вместе с прайсом
+		_, err := store.Db.Exec(fmt.Sprintf(`UPDATE product_prices SET price = 3000 WHERE product_uuid = '%s'`, drill))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_, err := store.Db.Exec(fmt.Sprintf(`UPDATE product_prices SET price = 2500 WHERE product_uuid = '%s'`, drill))
+			require.NoError(t, err)
+		})
+
+		var got order.OrderResponse
+		decode(t, send(t, customer, http.MethodGet, "/"+o.Number, "", buyer), http.StatusOK, &got)
+		assert.Equal(t, 2515.0, got.Total)
+		require.Len(t, got.History, 1)
+		assert.Nil(t, got.History[0].FromStatus)
+		assert.Equal(t, order.StatusNew, got.History[0].ToStatus)
+	})
+
+	t.Run("Customer Orders", func(t *testing.T) {
+		o := checkout(t, buyer)
+
+		var list order.ListResponse
+		decode(t, send(t, customer, http.MethodGet, "/", "", buyer), http.StatusOK, &list)
+		require.NotEmpty(t, list.Items)
+		assert.Equal(t, o.Number, list.Items[0].Number)
+
+		decode(t, send(t, customer, http.MethodGet, "/", "", stranger), http.StatusOK, &list)
+		assert.Empty(t, list.Items)
+
+		resp := send(t, customer, http.MethodGet, "/"+o.Number, "", stranger)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Status Transitions", func(t *testing.T) {
+		o := checkout(t, buyer)
+
+		resp := changeStatus(t, o.ID, order.StatusShipped)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		var got order.OrderResponse
+		decode(t, changeStatus(t, o.ID, order.StatusConfirmed), http.StatusOK, &got)
+		assert.Equal(t, order.StatusConfirmed, got.Status)
+		require.Len(t, got.History, 2)
+
+		// покупатель получает письмо о смене статуса
+		_, err := notification.NewDispatcher(notification.NewRepository(store), sender.New(sender.Memory, sender.Memory), config.Notification).Dispatch(ctx)
+		require.NoError(t, err)
+		m, ok := sender.Memory.Last(email)
+		require.True(t, ok)
+		assert.Contains(t, m.Body, o.Number)
+
+		for _, status := range []string{order.StatusAssembled, order.StatusShipped, order.StatusDelivered} {
+			decode(t, changeStatus(t, o.ID, status), http.StatusOK, &got)
+		}
+		assert.Equal(t, order.StatusDelivered, got.Status)
+
+		resp = changeStatus(t, o.ID, order.StatusCancelled)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = changeStatus(t, 0, order.StatusConfirmed)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		o := checkout(t, buyer)
+
+		resp := send(t, customer, http.MethodPost, "/"+o.Number+"/cancel", "", stranger)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		var got order.OrderResponse
+		decode(t, send(t, customer, http.MethodPost, "/"+o.Number+"/cancel", `{"comment": "Передумал"}`, buyer), http.StatusOK, &got)
+		assert.Equal(t, order.StatusCancelled, got.Status)
+
+		// подтверждённый заказ отменяет только магазин
+		confirmed := checkout(t, buyer)
+		require.Equal(t, http.StatusOK, changeStatus(t, confirmed.ID, order.StatusConfirmed).StatusCode)
+		resp = send(t, customer, http.MethodPost, "/"+confirmed.Number+"/cancel", "", buyer)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		var list order.ListResponse
+		decode(t, send(t, admin, http.MethodGet, "/?status=cancelled", "", config.HTTPServer.BearerToken), http.StatusOK, &list)
+		require.Len(t, list.Items, 1)
+		assert.Equal(t, o.Number, list.Items[0].Number)
+	})
+
+	t.Run("Stock Reserved", func(t *testing.T) {
+		mainBefore, reserveBefore := stock(t, drill, storageUUID), stock(t, drill, reserveStorageUUID)
+
+		// остатка склада самовывоза не хватает, недостающее списывается с резервного
+		body := fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": %d}], "delivery_method": "pickup", "storage_uuid": "%s"}`, drill, mainBefore+2, storageUUID)
+		var o order.OrderResponse
+		decode(t, send(t, customer, http.MethodPost, "/", body, buyer), http.StatusCreated, &o)
+		assert.Zero(t, stock(t, drill, storageUUID))
+		assert.Equal(t, reserveBefore-2, stock(t, drill, reserveStorageUUID))
+
+		// списанное уже не продать
+		resp := send(t, customer, http.MethodPost, "/", fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": %d}], "delivery_method": "delivery", "address": "Москва"}`, drill, reserveBefore-1), buyer)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		require.Equal(t, http.StatusOK, changeStatus(t, o.ID, order.StatusCancelled).StatusCode)
+		assert.Equal(t, mainBefore, stock(t, drill, storageUUID))
+		assert.Equal(t, reserveBefore, stock(t, drill, reserveStorageUUID))
+	})
+
+	t.Run("Concurrent Checkout", func(t *testing.T) {
+		const buyers = 5
+		body := fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": 1}], "delivery_method": "delivery", "address": "Москва"}`, hammer)
+
+		statuses := make(chan int, buyers)
+		var wg sync.WaitGroup
+		for range buyers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req, err := http.NewRequest(http.MethodPost, customer.URL+"/", strings.NewReader(body))
+				if err != nil {
+					statuses <- 0
+					return
+				}
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", "Bearer "+buyer)
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					statuses <- 0
+					return
+				}
+				resp.Body.Close()
+				statuses <- resp.StatusCode
+			}()
+		}
+		wg.Wait()
+		close(statuses)
+
+		// последний перфоратор достаётся только одному покупателю
+		count := map[int]int{}
+		for status := range statuses {
+			count[status]++
+		}
+		assert.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusConflict: buyers - 1}, count)
+		assert.Zero(t, stock(t, hammer, reserveStorageUUID))
+	})
+}
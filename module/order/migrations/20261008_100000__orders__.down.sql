@@ -0,0 +1,6 @@
+DELETE FROM permissions WHERE code = 'orders:manage';
+
+DROP TABLE IF EXISTS order_status_history;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP SEQUENCE IF EXISTS order_number_seq;
@@ -0,0 +1,63 @@
+CREATE SEQUENCE IF NOT EXISTS order_number_seq;
+
+-- заказ хранит снимок цен, покупателя и компании на момент оформления
+CREATE TABLE IF NOT EXISTS orders (
+  id BIGSERIAL PRIMARY KEY,
+  number VARCHAR(32) NOT NULL UNIQUE,
+  user_id INT REFERENCES users(id) ON DELETE SET NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'new'
+    CHECK (status IN ('new', 'confirmed', 'assembled', 'shipped', 'delivered', 'cancelled')),
+  price_type_uuid UUID,
+  total NUMERIC(12, 2) NOT NULL,
+  delivery_method VARCHAR(20) NOT NULL CHECK (delivery_method IN ('pickup', 'delivery')),
+  storage_uuid UUID REFERENCES storage(uuid) ON DELETE SET NULL,
+  delivery_address TEXT,
+  customer_name VARCHAR(255),
+  customer_email VARCHAR(255),
+  customer_phone VARCHAR(50),
+  company_id INT REFERENCES companies(id) ON DELETE SET NULL,
+  company_name VARCHAR(255),
+  company_inn VARCHAR(20),
+  company_kpp VARCHAR(20),
+  comment TEXT,
+  locale VARCHAR(8) NOT NULL DEFAULT 'ru',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS orders_user_idx ON orders (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS orders_status_idx ON orders (status, created_at DESC);
+
+-- нет связи REFERENCES products(uuid): заказ остаётся, даже если товар удалён из каталога
+CREATE TABLE IF NOT EXISTS order_items (
+  order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  product_uuid UUID NOT NULL,
+  name VARCHAR(455) NOT NULL,
+  code INT NOT NULL,
+  article VARCHAR(32),
+  unit VARCHAR(32),
+  quantity INT NOT NULL CHECK (quantity > 0),
+  price NUMERIC(12, 2) NOT NULL,
+  sum NUMERIC(12, 2) NOT NULL,
+  PRIMARY KEY (order_id, product_uuid)
+);
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+  id BIGSERIAL PRIMARY KEY,
+  order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  from_status VARCHAR(20),
+  to_status VARCHAR(20) NOT NULL,
+  comment TEXT,
+  changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_idx ON order_status_history (order_id, id);
+
+INSERT INTO permissions (code, name) VALUES ('orders:manage', 'Управление заказами')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.code = 'admin' AND p.code = 'orders:manage'
+ON CONFLICT DO NOTHING;
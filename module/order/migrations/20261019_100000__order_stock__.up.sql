@@ -0,0 +1,7 @@
+-- сколько товара заказ списал с каждого остатка, при отмене заказа количество возвращается
+CREATE TABLE IF NOT EXISTS order_stock (
+  order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  product_storage_id INT NOT NULL REFERENCES product_storages(id) ON DELETE CASCADE,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  PRIMARY KEY (order_id, product_storage_id)
+);
@@ -0,0 +1,43 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/module/notification"
+	"go-monolite/pkg/middleware/locale"
+	"strings"
+)
+
+// NotifyCustomer — хук, который сообщает покупателю о смене статуса заказа на email, а без email — по SMS.
+// Письмо пишется на языке, на котором оформлен заказ, orderURL — страница заказа без номера
+func NotifyCustomer(notifications *notification.Service, orderURL string) StatusHook {
+	return func(ctx context.Context, e *OrderEnt, h *HistoryEnt) error {
+		// об оформлении покупатель узнаёт из ответа на запрос
+		if h.FromStatus == nil {
+			return nil
+		}
+
+		var email, phone string
+		if e.CustomerEmail != nil {
+			email = *e.CustomerEmail
+		}
+		if e.CustomerPhone != nil {
+			phone = *e.CustomerPhone
+		}
+		if email == "" && phone == "" {
+			return nil
+		}
+
+		data := notification.OrderStatusData{
+			OrderNumber: e.Number,
+			Status:      e.Status,
+			StatusName:  StatusName(e.Status, e.Locale),
+		}
+		if orderURL != "" {
+			data.OrderURL = strings.TrimRight(orderURL, "/") + "/" + e.Number
+		}
+
+		ctx = locale.WithLocale(ctx, e.Locale)
+		return notifications.Notify(ctx, notification.OrderStatusMessage(email, phone, data, fmt.Sprintf("%d:%d", e.ID, h.ID)))
+	}
+}
@@ -0,0 +1,325 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	orderColumns = `id, number, user_id, status, price_type_uuid, discount, total, promo_code, delivery_method, storage_uuid,
+	delivery_address, customer_name, customer_email, customer_phone, company_id, company_name, company_inn, company_kpp, comment, locale,
+	created_at, updated_at`
+	itemColumns    = `order_id, product_uuid, name, code, article, unit, quantity, price, discount, sum, gift`
+	historyColumns = `id, order_id, from_status, to_status, comment, changed_by, created_at`
+	stockColumns   = `ps.id, ps.storage_uuid, ps.quantity`
+)
+
+type Repository struct {
+	store       *store.Store
+	tableName   string
+	itemsName   string
+	historyName string
+	stockName   string
+}
+
+func NewRepository(store *store.Store) *Repository {
+	return &Repository{
+		store:       store,
+		tableName:   "orders",
+		itemsName:   "order_items",
+		historyName: "order_status_history",
+		stockName:   "order_stock",
+	}
+}
+
+// NextNumber — следующий номер заказа вида A-000001
+func (r *Repository) NextNumber(ctx context.Context) (string, error) {
+	var n int64
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &n, `SELECT nextval('order_number_seq')`); err != nil {
+		return "", store.ContextError(err)
+	}
+
+	return fmt.Sprintf("A-%06d", n), nil
+}
+
+func (r *Repository) Create(ctx context.Context, e *OrderEnt) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			number, user_id, status, price_type_uuid, discount, total, promo_code, delivery_method, storage_uuid,
+			delivery_address, customer_name, customer_email, customer_phone, company_id, company_name, company_inn,
+			company_kpp, comment, locale, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $20
+		)
+		RETURNING id
+	`, r.tableName)
+
+	now := time.Now()
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query,
+		e.Number,
+		e.UserID,
+		e.Status,
+		e.PriceTypeUUID,
+		e.Discount,
+		e.Total,
+		e.PromoCode,
+		e.DeliveryMethod,
+		e.StorageUUID,
+		e.DeliveryAddress,
+		e.CustomerName,
+		e.CustomerEmail,
+		e.CustomerPhone,
+		e.CompanyID,
+		e.CompanyName,
+		e.CompanyINN,
+		e.CompanyKPP,
+		e.Comment,
+		e.Locale,
+		now,
+	).Scan(&e.ID)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) CreateItems(ctx context.Context, items []ItemEnt) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, len(items)*11)
+	values := make([]string, 0, len(items))
+	for i, item := range items {
+		n := i * 11
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11))
+		args = append(args, item.OrderID, item.ProductUUID, item.Name, item.Code, item.Article, item.Unit,
+			item.Quantity, item.Price, item.Discount, item.Sum, item.Gift)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (%s) VALUES %s`, r.itemsName, itemColumns, strings.Join(values, ", "))
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, args...); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int64) (*OrderEnt, error) {
+	return r.getBy(ctx, "id", id, false)
+}
+
+// GetByIDForUpdate блокирует заказ до конца транзакции, чтобы статус не изменили параллельно
+func (r *Repository) GetByIDForUpdate(ctx context.Context, id int64) (*OrderEnt, error) {
+	return r.getBy(ctx, "id", id, true)
+}
+
+func (r *Repository) GetByNumber(ctx context.Context, number string) (*OrderEnt, error) {
+	return r.getBy(ctx, "number", number, false)
+}
+
+func (r *Repository) UpdateStatus(ctx context.Context, e *OrderEnt) error {
+	query := fmt.Sprintf(`UPDATE %s SET status = $2, updated_at = $3 WHERE id = $1`, r.tableName)
+
+	e.UpdatedAt = time.Now()
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, e.ID, e.Status, e.UpdatedAt)
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) GetItems(ctx context.Context, orderID int64) ([]ItemEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE order_id = $1 ORDER BY gift, name`, itemColumns, r.itemsName)
+
+	var items []ItemEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &items, query, orderID); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return items, nil
+}
+
+func (r *Repository) AddHistory(ctx context.Context, e *HistoryEnt) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (order_id, from_status, to_status, comment, changed_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, r.historyName)
+
+	e.CreatedAt = time.Now()
+
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query,
+		e.OrderID,
+		e.FromStatus,
+		e.ToStatus,
+		e.Comment,
+		e.ChangedBy,
+		e.CreatedAt,
+	).Scan(&e.ID)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetHistory(ctx context.Context, orderID int64) ([]HistoryEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE order_id = $1 ORDER BY id`, historyColumns, r.historyName)
+
+	var history []HistoryEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &history, query, orderID); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return history, nil
+}
+
+func (r *Repository) List(ctx context.Context, filter ListRequest) ([]OrderEnt, int, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		where = append(where, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.UserID != 0 {
+		args = append(args, filter.UserID)
+		where = append(where, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.Number != "" {
+		args = append(args, filter.Number)
+		where = append(where, fmt.Sprintf("number = $%d", len(args)))
+	}
+
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s %s`, r.tableName, whereSQL)
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &total, countQuery, args...); err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, orderColumns, r.tableName, whereSQL, len(args)+1, len(args)+2)
+
+	var orders []OrderEnt
+	err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &orders, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	return orders, total, nil
+}
+
+// LockStock — ненулевые остатки товара на активных складах, строки блокируются до конца транзакции.
+// Строки блокируются по порядку id, чтобы параллельные заказы не ждали друг друга по кругу
+func (r *Repository) LockStock(ctx context.Context, productUUID uuid.UUID) ([]StockEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM product_storages ps
+		JOIN storage s ON s.uuid = ps.storage_uuid AND s.active = 'Y' AND s.deleted_at IS NULL
+		WHERE ps.product_uuid = $1 AND ps.active = 'Y' AND ps.quantity > 0
+		ORDER BY ps.id
+		FOR UPDATE OF ps
+	`, stockColumns)
+
+	var stock []StockEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &stock, query, productUUID); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return stock, nil
+}
+
+// TakeStock списывает quantity с остатка и запоминает, сколько взял заказ
+func (r *Repository) TakeStock(ctx context.Context, orderID, stockID int64, quantity int) error {
+	query := `UPDATE product_storages SET quantity = quantity - $2, updated_at = $3 WHERE id = $1`
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, stockID, quantity, time.Now()); err != nil {
+		return store.ContextError(err)
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (order_id, product_storage_id, quantity) VALUES ($1, $2, $3)`, r.stockName)
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, orderID, stockID, quantity); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// ReleaseStock возвращает на склады всё, что списал заказ
+func (r *Repository) ReleaseStock(ctx context.Context, orderID int64) error {
+	query := fmt.Sprintf(`
+		UPDATE product_storages ps
+		SET quantity = ps.quantity + os.quantity, updated_at = $2
+		FROM %s os
+		WHERE os.order_id = $1 AND ps.id = os.product_storage_id
+	`, r.stockName)
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, orderID, time.Now()); err != nil {
+		return store.ContextError(err)
+	}
+
+	query = fmt.Sprintf(`DELETE FROM %s WHERE order_id = $1`, r.stockName)
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, orderID); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// StorageActive — склад существует, не в архиве и работает, с него можно забрать заказ
+func (r *Repository) StorageActive(ctx context.Context, storageUUID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM storage WHERE uuid = $1 AND active = 'Y' AND deleted_at IS NULL)`
+
+	var exists bool
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &exists, query, storageUUID); err != nil {
+		return false, store.ContextError(err)
+	}
+
+	return exists, nil
+}
+
+func (r *Repository) getBy(ctx context.Context, column string, value any, forUpdate bool) (*OrderEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s = $1`, orderColumns, r.tableName, column)
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var e OrderEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
@@ -0,0 +1,554 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"go-monolite/module/cart"
+	"go-monolite/module/company"
+	"go-monolite/module/promotion"
+	"go-monolite/module/user"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/middleware/locale"
+	"go-monolite/pkg/validator"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// StatusHook вызывается в транзакции смены статуса, в том числе при оформлении заказа (h.FromStatus пустой).
+// Ошибка хука отменяет смену статуса
+type StatusHook func(ctx context.Context, e *OrderEnt, h *HistoryEnt) error
+
+type Service struct {
+	store       *store.Store
+	repo        *Repository
+	carts       *cart.Service
+	promotions  *promotion.Service
+	userRepo    *user.Repository
+	companyRepo *company.Repository
+	hooks       []StatusHook
+}
+
+func NewService(store *store.Store, repo *Repository, carts *cart.Service, promotions *promotion.Service, userRepo *user.Repository, companyRepo *company.Repository) *Service {
+	return &Service{
+		store:       store,
+		repo:        repo,
+		carts:       carts,
+		promotions:  promotions,
+		userRepo:    userRepo,
+		companyRepo: companyRepo,
+	}
+}
+
+// OnStatusChange добавляет хуки смены статуса
+func (s *Service) OnStatusChange(hooks ...StatusHook) {
+	s.hooks = append(s.hooks, hooks...)
+}
+
+// Checkout оформляет заказ пользователя: фиксирует текущие цены его типа цен, списывает товар с остатков,
+// применяет акции и промокод, запоминает контакты и компанию покупателя
+func (s *Service) Checkout(ctx context.Context, userID int64, req CheckoutRequest) (*OrderResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, "произошла ошибка при оформлении заказа", err
+	}
+
+	e := &OrderEnt{
+		UserID:         &userID,
+		Status:         StatusNew,
+		DeliveryMethod: req.DeliveryMethod,
+		CustomerName:   nullableString(req.CustomerName),
+		CustomerEmail:  nullableString(req.Email),
+		CustomerPhone:  nullableString(req.Phone),
+		Comment:        nullableString(req.Comment),
+		Locale:         locale.FromContext(ctx),
+	}
+	if e.CustomerName == nil {
+		e.CustomerName = fullName(u)
+	}
+	if e.CustomerEmail == nil && e.CustomerPhone == nil {
+		e.CustomerEmail, e.CustomerPhone = u.Email, u.Phone
+	}
+
+	if req.DeliveryMethod == DeliveryPickup {
+		active, err := s.repo.StorageActive(ctx, *req.StorageUUID)
+		if err != nil {
+			return nil, "произошла ошибка при оформлении заказа", err
+		}
+		if !active {
+			return nil, "", validator.ValidationError{
+				Err:    validator.ErrorValidation,
+				Fields: map[string]string{"storage_uuid": "Склад самовывоза не найден"},
+			}
+		}
+		e.StorageUUID = req.StorageUUID
+	} else {
+		e.DeliveryAddress = nullableString(req.Address)
+	}
+
+	if err := s.attachCompany(ctx, e, userID); err != nil {
+		return nil, "произошла ошибка при оформлении заказа", err
+	}
+
+	if e.PriceTypeUUID, err = s.carts.PriceType(ctx, cart.Owner{UserID: userID}); err != nil {
+		return nil, "произошла ошибка при оформлении заказа", err
+	}
+
+	var items []ItemEnt
+	err = s.store.WithinTx(ctx, func(ctx context.Context) error {
+		items, err = s.snapshotItems(ctx, e, mergeLines(req.Items))
+		if err != nil {
+			return err
+		}
+		if items, err = s.applyPromotions(ctx, e, u, items, req.PromoCode); err != nil {
+			return err
+		}
+
+		if e.Number, err = s.repo.NextNumber(ctx); err != nil {
+			return err
+		}
+		if err := s.repo.Create(ctx, e); err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].OrderID = e.ID
+		}
+		if err := s.repo.CreateItems(ctx, items); err != nil {
+			return err
+		}
+		if err := s.reserveStock(ctx, e, items); err != nil {
+			return err
+		}
+		if e.PromoCode != nil {
+			if err := s.promotions.Redeem(ctx, *e.PromoCode, userID, e.ID); err != nil {
+				return err
+			}
+		}
+
+		return s.recordStatus(ctx, e, nil, "", &userID)
+	})
+	if err != nil {
+		return nil, checkoutMessage(err), err
+	}
+
+	resp := e.ToResponse()
+	resp.Items = helper.ToResponse(items)
+	return &resp, "заказ оформлен", nil
+}
+
+// List — заказы для администратора
+func (s *Service) List(ctx context.Context, req ListRequest) (*ListResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	orders, total, err := s.repo.List(ctx, req)
+	if err != nil {
+		return nil, "произошла ошибка при получении заказов", err
+	}
+
+	return &ListResponse{Items: helper.ToResponse(orders), Total: total}, "", nil
+}
+
+// Get — заказ с позициями и историей статусов
+func (s *Service) Get(ctx context.Context, id int64) (*OrderResponse, string, error) {
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "заказ не найден", err
+		}
+		return nil, "произошла ошибка при получении заказа", err
+	}
+
+	return s.details(ctx, e)
+}
+
+// UserOrder — заказ пользователя по номеру, чужие заказы не видны
+func (s *Service) UserOrder(ctx context.Context, userID int64, number string) (*OrderResponse, string, error) {
+	e, err := s.userOrder(ctx, userID, number)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "заказ не найден", err
+		}
+		return nil, "произошла ошибка при получении заказа", err
+	}
+
+	return s.details(ctx, e)
+}
+
+// ChangeStatus переводит заказ в новый статус, если такой переход допустим
+func (s *Service) ChangeStatus(ctx context.Context, id int64, req StatusRequest, changedBy *int64) (*OrderResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	var e *OrderEnt
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if e, err = s.repo.GetByIDForUpdate(ctx, id); err != nil {
+			return err
+		}
+		return s.transition(ctx, e, req.Status, req.Comment, changedBy)
+	})
+	if err != nil {
+		return nil, statusMessage(err), err
+	}
+
+	return s.details(ctx, e)
+}
+
+// Cancel — отмена заказа покупателем, возможна только до подтверждения
+func (s *Service) Cancel(ctx context.Context, userID int64, number string, req CancelRequest) (*OrderResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	var e *OrderEnt
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		found, err := s.userOrder(ctx, userID, number)
+		if err != nil {
+			return err
+		}
+		if e, err = s.repo.GetByIDForUpdate(ctx, found.ID); err != nil {
+			return err
+		}
+		if e.Status != StatusNew {
+			return ErrInvalidTransition
+		}
+		return s.transition(ctx, e, StatusCancelled, req.Comment, &userID)
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidTransition) {
+			return nil, "заказ уже подтверждён, для отмены свяжитесь с магазином", err
+		}
+		return nil, statusMessage(err), err
+	}
+
+	return s.details(ctx, e)
+}
+
+func (s *Service) transition(ctx context.Context, e *OrderEnt, to, comment string, changedBy *int64) error {
+	if !CanTransition(e.Status, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, e.Status, to)
+	}
+
+	from := e.Status
+	e.Status = to
+	if err := s.repo.UpdateStatus(ctx, e); err != nil {
+		return err
+	}
+	if to == StatusCancelled {
+		if err := s.repo.ReleaseStock(ctx, e.ID); err != nil {
+			return err
+		}
+	}
+
+	return s.recordStatus(ctx, e, &from, comment, changedBy)
+}
+
+// recordStatus пишет смену статуса в историю и вызывает хуки
+func (s *Service) recordStatus(ctx context.Context, e *OrderEnt, from *string, comment string, changedBy *int64) error {
+	h := &HistoryEnt{
+		OrderID:    e.ID,
+		FromStatus: from,
+		ToStatus:   e.Status,
+		Comment:    nullableString(comment),
+		ChangedBy:  changedBy,
+	}
+	if err := s.repo.AddHistory(ctx, h); err != nil {
+		return err
+	}
+
+	for _, hook := range s.hooks {
+		if err := hook(ctx, e, h); err != nil {
+			return fmt.Errorf("order status hook: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// snapshotItems проверяет позиции и фиксирует их цены, считает сумму заказа
+func (s *Service) snapshotItems(ctx context.Context, e *OrderEnt, lines []CheckoutItem) ([]ItemEnt, error) {
+	items := make([]ItemEnt, 0, len(lines))
+	var total float64
+	for i, line := range lines {
+		product, err := s.carts.Line(ctx, e.PriceTypeUUID, line.ProductUUID, line.Quantity)
+		if err != nil {
+			var ve validator.ValidationError
+			if errors.As(err, &ve) {
+				return nil, validator.ValidationError{
+					Err:    validator.ErrorValidation,
+					Fields: map[string]string{fmt.Sprintf("items[%d].quantity", i): ve.Fields["quantity"]},
+				}
+			}
+			return nil, &lineError{productUUID: line.ProductUUID, err: err}
+		}
+
+		price := *product.Price
+		item := ItemEnt{
+			ProductUUID: line.ProductUUID,
+			Code:        *product.Code,
+			Name:        *product.Name,
+			Article:     product.Article,
+			Unit:        product.Unit,
+			Quantity:    line.Quantity,
+			Price:       price,
+			Sum:         roundMoney(price * float64(line.Quantity)),
+		}
+		total += item.Sum
+		items = append(items, item)
+	}
+	e.Total = roundMoney(total)
+
+	return items, nil
+}
+
+// reserveStock списывает позиции заказа с остатков: сначала со склада самовывоза, затем с остальных активных складов.
+// Остатки блокируются до конца транзакции, поэтому параллельные заказы не продадут один товар дважды.
+// Подарки не списываются: акции не проверяют их наличие
+func (s *Service) reserveStock(ctx context.Context, e *OrderEnt, items []ItemEnt) error {
+	// товары блокируются в одном порядке, чтобы заказы с одинаковыми позициями не ждали друг друга по кругу
+	sorted := make([]ItemEnt, 0, len(items))
+	for _, item := range items {
+		if !item.Gift {
+			sorted = append(sorted, item)
+		}
+	}
+	slices.SortFunc(sorted, func(a, b ItemEnt) int {
+		return strings.Compare(a.ProductUUID.String(), b.ProductUUID.String())
+	})
+
+	for _, item := range sorted {
+		stock, err := s.repo.LockStock(ctx, item.ProductUUID)
+		if err != nil {
+			return err
+		}
+		if e.StorageUUID != nil {
+			stock = preferStorage(stock, *e.StorageUUID)
+		}
+
+		left := item.Quantity
+		for _, st := range stock {
+			if left == 0 {
+				break
+			}
+			take := min(left, st.Quantity)
+			if err := s.repo.TakeStock(ctx, e.ID, st.ID, take); err != nil {
+				return err
+			}
+			left -= take
+		}
+		if left > 0 {
+			return &lineError{productUUID: item.ProductUUID, err: cart.ErrNotEnoughStock}
+		}
+	}
+
+	return nil
+}
+
+// applyPromotions распределяет скидки акций по позициям и добавляет подарки.
+// Промокод, который не дал скидки ни на одну позицию, — ошибка валидации
+func (s *Service) applyPromotions(ctx context.Context, e *OrderEnt, u *user.UserEnt, items []ItemEnt, code string) ([]ItemEnt, error) {
+	customer := promotion.Customer{UserID: u.ID, UserType: string(u.UserType), CompanyID: e.CompanyID}
+
+	lines := make([]promotion.Line, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, promotion.Line{ProductUUID: item.ProductUUID, Quantity: item.Quantity, Price: item.Price})
+	}
+
+	result, err := s.promotions.Apply(ctx, customer, lines, code)
+	if err != nil {
+		return nil, err
+	}
+	if code != "" && !result.CodeApplied {
+		return nil, validator.ValidationError{
+			Err:    validator.ErrorValidation,
+			Fields: map[string]string{"promo_code": "Промокод не применим к заказу"},
+		}
+	}
+
+	for i, line := range result.Lines {
+		items[i].Discount = line.Discount
+		items[i].Sum = line.Sum
+	}
+
+	gifts, err := s.promotions.Gifts(ctx, result.Gifts)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[uuid.UUID]int, len(gifts))
+	for _, g := range gifts {
+		if i, ok := index[g.ProductUUID]; ok {
+			items[i].Quantity += g.Quantity
+			continue
+		}
+		index[g.ProductUUID] = len(items)
+		items = append(items, ItemEnt{
+			ProductUUID: g.ProductUUID,
+			Code:        g.Code,
+			Name:        g.Name,
+			Article:     g.Article,
+			Unit:        g.Unit,
+			Quantity:    g.Quantity,
+			Gift:        true,
+		})
+	}
+
+	e.Discount = result.Discount
+	e.Total = result.Total
+	if result.CodeApplied {
+		e.PromoCode = &result.PromoCode
+	}
+
+	return items, nil
+}
+
+func (s *Service) attachCompany(ctx context.Context, e *OrderEnt, userID int64) error {
+	membership, err := s.companyRepo.GetMembership(ctx, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	c, err := s.companyRepo.GetByID(ctx, membership.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	e.CompanyID = &c.ID
+	e.CompanyName = &c.CompanyName
+	e.CompanyINN = &c.INN
+	e.CompanyKPP = c.KPP
+	return nil
+}
+
+func (s *Service) userOrder(ctx context.Context, userID int64, number string) (*OrderEnt, error) {
+	e, err := s.repo.GetByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if e.UserID == nil || *e.UserID != userID {
+		return nil, store.ErrNotFound
+	}
+	return e, nil
+}
+
+func (s *Service) details(ctx context.Context, e *OrderEnt) (*OrderResponse, string, error) {
+	items, err := s.repo.GetItems(ctx, e.ID)
+	if err != nil {
+		return nil, "произошла ошибка при получении заказа", err
+	}
+	history, err := s.repo.GetHistory(ctx, e.ID)
+	if err != nil {
+		return nil, "произошла ошибка при получении заказа", err
+	}
+
+	resp := e.ToResponse()
+	resp.Items = helper.ToResponse(items)
+	resp.History = helper.ToResponse(history)
+	return &resp, "", nil
+}
+
+// lineError — позицию заказа нельзя оформить
+type lineError struct {
+	productUUID uuid.UUID
+	err         error
+}
+
+func (e *lineError) Error() string {
+	return fmt.Sprintf("product %s: %s", e.productUUID, e.err)
+}
+
+func (e *lineError) Unwrap() error {
+	return e.err
+}
+
+// preferStorage ставит остатки склада storageUUID вперёд, порядок остальных не меняется
+func preferStorage(stock []StockEnt, storageUUID uuid.UUID) []StockEnt {
+	ordered := make([]StockEnt, 0, len(stock))
+	for _, st := range stock {
+		if st.StorageUUID == storageUUID {
+			ordered = append(ordered, st)
+		}
+	}
+	for _, st := range stock {
+		if st.StorageUUID != storageUUID {
+			ordered = append(ordered, st)
+		}
+	}
+	return ordered
+}
+
+// mergeLines складывает количество одинаковых товаров
+func mergeLines(lines []CheckoutItem) []CheckoutItem {
+	merged := make([]CheckoutItem, 0, len(lines))
+	index := make(map[uuid.UUID]int, len(lines))
+	for _, line := range lines {
+		if i, ok := index[line.ProductUUID]; ok {
+			merged[i].Quantity += line.Quantity
+			continue
+		}
+		index[line.ProductUUID] = len(merged)
+		merged = append(merged, line)
+	}
+	return merged
+}
+
+func checkoutMessage(err error) string {
+	var le *lineError
+	if !errors.As(err, &le) {
+		return "произошла ошибка при оформлении заказа"
+	}
+
+	switch {
+	case errors.Is(err, cart.ErrProductNotFound):
+		return fmt.Sprintf("товар %s не найден", le.productUUID)
+	case errors.Is(err, cart.ErrNoPrice):
+		return fmt.Sprintf("у товара %s нет цены", le.productUUID)
+	case errors.Is(err, cart.ErrNotEnoughStock):
+		return fmt.Sprintf("недостаточно товара %s на складе", le.productUUID)
+	}
+	return "произошла ошибка при оформлении заказа"
+}
+
+func statusMessage(err error) string {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return "заказ не найден"
+	case errors.Is(err, ErrInvalidTransition):
+		return "недопустимая смена статуса заказа"
+	}
+	return "произошла ошибка при смене статуса заказа"
+}
+
+func fullName(u *user.UserEnt) *string {
+	var parts []string
+	for _, part := range []*string{u.LastName, u.Name, u.SecondName} {
+		if part != nil && *part != "" {
+			parts = append(parts, *part)
+		}
+	}
+	return nullableString(strings.Join(parts, " "))
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,63 @@
+package price
+
+import (
+	"go-monolite/module/audit"
+	"maps"
+
+	"github.com/google/uuid"
+)
+
+// auditPrice — цена товара по одному типу цены в журнале изменений
+type auditPrice struct {
+	Price  float64 `json:"price"`
+	Active string  `json:"active"`
+}
+
+// typePriceEntries — изменения типов цен после выгрузки, архивный тип из выгрузки считается восстановленным
+func typePriceEntries(current map[uuid.UUID]TypePriceEnt, deletes, inserts, updates []TypePriceEnt) []audit.Entry {
+	entries := make([]audit.Entry, 0, len(deletes)+len(inserts)+len(updates))
+	for _, d := range deletes {
+		entries = append(entries, audit.Deleted(audit.EntityTypePrice, d.UUID.String(), d))
+	}
+	for _, i := range inserts {
+		entries = append(entries, audit.Created(audit.EntityTypePrice, i.UUID.String(), i))
+	}
+	for _, u := range updates {
+		if curr := current[u.UUID]; curr.DeletedAt != nil {
+			entries = append(entries, audit.Restored(audit.EntityTypePrice, u.UUID.String(), u))
+		} else {
+			entries = append(entries, audit.Updated(audit.EntityTypePrice, u.UUID.String(), curr, u))
+		}
+	}
+	return entries
+}
+
+// productPriceEntries — изменения цен по товарам: снимок товара — его цены по UUID типа цены
+func productPriceEntries(current map[uuid.UUID]map[uuid.UUID]ProductPriceEnt, inserts, updates []ProductPriceEnt) []audit.Entry {
+	changed := make(map[uuid.UUID]map[uuid.UUID]auditPrice)
+	for _, list := range [][]ProductPriceEnt{inserts, updates} {
+		for _, p := range list {
+			if changed[p.ProductUUID] == nil {
+				changed[p.ProductUUID] = make(map[uuid.UUID]auditPrice)
+			}
+			changed[p.ProductUUID][p.TypePriceUUID] = auditPrice{Price: p.Price, Active: p.Active}
+		}
+	}
+
+	entries := make([]audit.Entry, 0, len(changed))
+	for productUUID, prices := range changed {
+		before := make(map[uuid.UUID]auditPrice, len(current[productUUID]))
+		for typePriceUUID, p := range current[productUUID] {
+			before[typePriceUUID] = auditPrice{Price: p.Price, Active: p.Active}
+		}
+		after := maps.Clone(before)
+		maps.Copy(after, prices)
+
+		if len(before) == 0 {
+			entries = append(entries, audit.Created(audit.EntityProductPrice, productUUID.String(), after))
+		} else {
+			entries = append(entries, audit.Updated(audit.EntityProductPrice, productUUID.String(), before, after))
+		}
+	}
+	return entries
+}
@@ -1,7 +1,9 @@
 package price
 
 import (
+	"errors"
 	"go-monolite/internal/store"
+	"go-monolite/module/audit"
 	"go-monolite/pkg/helper"
 	"go-monolite/pkg/logger"
 	"go-monolite/pkg/respond"
@@ -18,13 +20,14 @@ type Handler struct {
 func NewHandler(store *store.Store) *Handler {
 	typePriceRepo := NewTypePriceRepository(store)
 	productPriceRepo := NewProductPricesRepository(store)
-	service := NewService(typePriceRepo, productPriceRepo)
+	service := NewService(store, typePriceRepo, productPriceRepo, audit.NewRecorder(store))
 	return &Handler{service: service}
 }
 
 func (h *Handler) Init(r chi.Router) {
 	r.Post("/upsert", h.Upsert)
 	r.Get("/type-price", h.GetTypePrice)
+	r.Post("/type-price/restore/{uuid}", h.RestoreTypePrice)
 }
 
 // @Summary Upsert price information
@@ -85,3 +88,36 @@ func (h *Handler) GetTypePrice(w http.ResponseWriter, r *http.Request) {
 
 	respond.SuccessHandler(w, r, http.StatusCreated, "", resp)
 }
+
+// @Summary Restore price type
+// @Description Restore a price type archived by the exchange, product prices of this type are kept while it is archived
+// @Tags prices
+// @Produce json
+// @Param uuid path string true "Price type UUID"
+// @Success 200 {object} respond.SuccessResponse{data=TypePriceResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /type-price/restore/{uuid} [post]
+func (h *Handler) RestoreTypePrice(w http.ResponseWriter, r *http.Request) {
+	typePriceUUID, err := validator.ParseUUID(chi.URLParam(r, "uuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	resp, err := h.service.Restore(r.Context(), typePriceUUID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, "Тип цены в архиве не найден")
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, "Произошла ошибка при восстановлении типа цены")
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, "Произошла ошибка при восстановлении типа цены")
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "Тип цены восстановлен", resp)
+}
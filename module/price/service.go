@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"go-monolite/internal/store"
+	"go-monolite/module/audit"
 	"go-monolite/pkg/helper"
 	"go-monolite/pkg/logger"
 
@@ -13,12 +14,14 @@ import (
 )
 
 type Service struct {
+	store            *store.Store
 	typePriceRepo    *TypePriceRepository
 	productPriceRepo *ProductPricesRepository
+	audit            *audit.Recorder
 }
 
-func NewService(typePriceRepo *TypePriceRepository, productPriceRepo *ProductPricesRepository) *Service {
-	return &Service{typePriceRepo, productPriceRepo}
+func NewService(store *store.Store, typePriceRepo *TypePriceRepository, productPriceRepo *ProductPricesRepository, recorder *audit.Recorder) *Service {
+	return &Service{store, typePriceRepo, productPriceRepo, recorder}
 }
 
 func (s *Service) GetTypePrice(ctx context.Context) ([]TypePriceResponse, string, error) {
@@ -33,6 +36,24 @@ func (s *Service) GetTypePrice(ctx context.Context) ([]TypePriceResponse, string
 	return helper.ToResponse(existing), "", nil
 }
 
+// Restore возвращает тип цены из архива
+func (s *Service) Restore(ctx context.Context, typePriceUUID uuid.UUID) (*TypePriceResponse, error) {
+	var price *TypePriceEnt
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if price, err = s.typePriceRepo.Restore(ctx, typePriceUUID.String()); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Restored(audit.EntityTypePrice, price.UUID.String(), price))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := price.ToResponse()
+	return &resp, nil
+}
+
 func (s *Service) Upsert(ctx context.Context, request UpsertRequest) (*UpsertResponse, string, error) {
 	if err := request.Validate(); err != nil {
 		return nil, "", err
@@ -72,7 +93,7 @@ func (s *Service) Upsert(ctx context.Context, request UpsertRequest) (*UpsertRes
 }
 
 func (s *Service) upsertTypePrices(ctx context.Context, request UpsertRequest) (*TypePriceResponseDetails, error) {
-	deletes, inserts, updates, err := s.prepareTypePricesDiff(ctx, request)
+	current, deletes, inserts, updates, err := s.prepareTypePricesDiff(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +102,10 @@ func (s *Service) upsertTypePrices(ctx context.Context, request UpsertRequest) (
 		return nil, err
 	}
 
+	if err := s.audit.Record(ctx, typePriceEntries(current, deletes, inserts, updates)...); err != nil {
+		return nil, err
+	}
+
 	return &TypePriceResponseDetails{
 		CountDeleted:  len(deletes),
 		CountInserted: len(inserts),
@@ -89,7 +114,7 @@ func (s *Service) upsertTypePrices(ctx context.Context, request UpsertRequest) (
 }
 
 func (s *Service) upsertPricesValue(ctx context.Context, requestData []ProductPriceDto) (*ProductPriceResponseDetails, error) {
-	inserts, updates, err := s.preparePricesValueDiff(ctx, requestData)
+	current, inserts, updates, err := s.preparePricesValueDiff(ctx, requestData)
 	if err != nil {
 		return nil, err
 	}
@@ -103,6 +128,10 @@ func (s *Service) upsertPricesValue(ctx context.Context, requestData []ProductPr
 		return nil, err
 	}
 
+	if err := s.audit.Record(ctx, productPriceEntries(current, inserts, updates)...); err != nil {
+		return nil, err
+	}
+
 	return &ProductPriceResponseDetails{
 		CountInserted: len(inserts),
 		CountUpdated:  len(updates),
@@ -114,12 +143,13 @@ func (s *Service) filterValidPriceUUIDs(ctx context.Context, inserts []ProductPr
 		return inserts, nil
 	}
 
-	existing, err := s.typePriceRepo.GetList(ctx)
+	// цены по архивному типу сохраняются, чтобы вернуться вместе с ним
+	existing, err := s.typePriceRepo.GetListWithArchived(ctx)
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
 			return nil, fmt.Errorf("ошибка Price не заполнен")
 		}
-		return nil, fmt.Errorf("ошибка при выполнении в filterValidPriceUUIDs typePriceRepo.GetList: %w", err)
+		return nil, fmt.Errorf("ошибка при выполнении в filterValidPriceUUIDs typePriceRepo.GetListWithArchived: %w", err)
 	}
 
 	valid := make(map[uuid.UUID]struct{}, len(existing))
@@ -139,25 +169,26 @@ func (s *Service) filterValidPriceUUIDs(ctx context.Context, inserts []ProductPr
 	return filtered, nil
 }
 
-func (s *Service) prepareTypePricesDiff(ctx context.Context, request UpsertRequest) (deletes, inserts, updates []TypePriceEnt, err error) {
-	existing, err := s.typePriceRepo.GetList(ctx)
+// prepareTypePricesDiff — изменения типов цен и их текущее состояние по UUID
+func (s *Service) prepareTypePricesDiff(ctx context.Context, request UpsertRequest) (current map[uuid.UUID]TypePriceEnt, deletes, inserts, updates []TypePriceEnt, err error) {
+	existing, err := s.typePriceRepo.GetListWithArchived(ctx)
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
 			if request.General == nil || (request.General.Prices == nil) {
-				return nil, nil, nil, fmt.Errorf("ошибка General должна быть заполнена")
+				return nil, nil, nil, nil, fmt.Errorf("ошибка General должна быть заполнена")
 			}
 		} else {
-			return nil, nil, nil, fmt.Errorf("ошибка при выполнении typePriceRepo.GetList: %w", err)
+			return nil, nil, nil, nil, fmt.Errorf("ошибка при выполнении typePriceRepo.GetListWithArchived: %w", err)
 		}
 	}
 
 	desired := toTypePriceSlice(request.General.Prices)
 
-	currentMap := toTypePricesMap(existing)
+	current = toTypePricesMap(existing)
 	desiredMap := toTypePricesMap(desired)
 
-	deletes, inserts, updates = diffTypePrices(currentMap, desiredMap)
-	return deletes, inserts, updates, nil
+	deletes, inserts, updates = diffTypePrices(current, desiredMap)
+	return current, deletes, inserts, updates, nil
 }
 
 func (s *Service) applyTypePriceChanges(ctx context.Context, deletes, inserts, updates []TypePriceEnt) error {
@@ -236,20 +267,21 @@ func (s *Service) applyPriceValueChanges(ctx context.Context, inserts, updates [
 	return g.Wait()
 }
 
-func (s *Service) preparePricesValueDiff(ctx context.Context, requestData []ProductPriceDto) (inserts, updates []ProductPriceEnt, err error) {
+// preparePricesValueDiff — изменения цен товаров и текущие цены по товару и типу цены
+func (s *Service) preparePricesValueDiff(ctx context.Context, requestData []ProductPriceDto) (current map[uuid.UUID]map[uuid.UUID]ProductPriceEnt, inserts, updates []ProductPriceEnt, err error) {
 	desiredMap := toProductPriceDataMap(requestData)
 
 	productUUIDs := helper.GetKeys(desiredMap)
 
 	existing, err := s.productPriceRepo.GetByProductUUIDs(ctx, productUUIDs)
 	if err != nil && !errors.Is(err, store.ErrNotFound) {
-		return nil, nil, fmt.Errorf("ошибка при выполнении productPriceRepo.GetByProductUUIDs: %w", err)
+		return nil, nil, nil, fmt.Errorf("ошибка при выполнении productPriceRepo.GetByProductUUIDs: %w", err)
 	}
 
-	currentMap := toProductPriceMap(existing)
+	current = toProductPriceMap(existing)
 
-	inserts, updates = diffProductPrices(currentMap, desiredMap)
-	return inserts, updates, nil
+	inserts, updates = diffProductPrices(current, desiredMap)
+	return current, inserts, updates, nil
 }
 
 func toTypePricesMap(list []TypePriceEnt) map[uuid.UUID]TypePriceEnt {
@@ -286,16 +318,17 @@ func toProductPriceDataMap(list []ProductPriceDto) map[uuid.UUID]map[uuid.UUID]P
 	return result
 }
 
+// diffTypePrices — типы цен, которых нет в выгрузке, уходят в архив, архивные типы из выгрузки возвращаются обновлением
 func diffTypePrices(current, desired map[uuid.UUID]TypePriceEnt) (deletes, inserts, updates []TypePriceEnt) {
 	for key, curr := range current {
-		if _, ok := desired[key]; !ok {
+		if _, ok := desired[key]; !ok && curr.DeletedAt == nil {
 			deletes = append(deletes, curr)
 		}
 	}
 	for key, want := range desired {
 		curr, ok := current[key]
 		if ok {
-			if !isPriceEqual(curr, want) {
+			if curr.DeletedAt != nil || !isPriceEqual(curr, want) {
 				updates = append(updates, want)
 			}
 		} else {
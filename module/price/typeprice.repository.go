@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"go-monolite/internal/store"
 	"time"
+
+	"github.com/jmoiron/sqlx"
 )
 
 type TypePriceRepository struct {
@@ -51,12 +53,23 @@ func (r *TypePriceRepository) Create(ctx context.Context, p *TypePriceEnt) (*uin
 	return &id, nil
 }
 
+// GetList — типы цен не из архива
 func (r *TypePriceRepository) GetList(ctx context.Context) ([]TypePriceEnt, error) {
+	return r.getList(ctx, "WHERE deleted_at IS NULL")
+}
+
+// GetListWithArchived — все типы цен вместе с архивными, по ним обмен решает, что добавить, обновить или вернуть из архива
+func (r *TypePriceRepository) GetListWithArchived(ctx context.Context) ([]TypePriceEnt, error) {
+	return r.getList(ctx, "")
+}
+
+func (r *TypePriceRepository) getList(ctx context.Context, filter string) ([]TypePriceEnt, error) {
 	query := fmt.Sprintf(`
-		SELECT id, uuid, name, active, created_at, updated_at
+		SELECT id, uuid, name, active, created_at, updated_at, deleted_at
 		FROM %s
+		%s
 		ORDER BY created_at DESC
-	`, r.tableName)
+	`, r.tableName, filter)
 
 	var prices []TypePriceEnt
 	err := r.store.Db.SelectContext(ctx, &prices, query)
@@ -74,10 +87,11 @@ func (r *TypePriceRepository) GetList(ctx context.Context) ([]TypePriceEnt, erro
 	return prices, nil
 }
 
+// Update обновляет тип цены, архивный тип из выгрузки возвращается из архива
 func (r *TypePriceRepository) Update(ctx context.Context, p *TypePriceEnt) error {
 	query := fmt.Sprintf(`
 		UPDATE %s
-		SET name = $1, active = $2, updated_at = $3
+		SET name = $1, active = $2, updated_at = $3, deleted_at = NULL
 		WHERE uuid = $4
 	`, r.tableName)
 
@@ -104,10 +118,11 @@ func (r *TypePriceRepository) Update(ctx context.Context, p *TypePriceEnt) error
 	return nil
 }
 
+// Delete переносит тип цены в архив, окончательно его удаляет задача очистки
 func (r *TypePriceRepository) Delete(ctx context.Context, uuid string) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE uuid = $1`, r.tableName)
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = $2 WHERE uuid = $1 AND deleted_at IS NULL`, r.tableName)
 
-	result, err := r.store.Db.ExecContext(ctx, query, uuid)
+	result, err := r.store.Db.ExecContext(ctx, query, uuid, time.Now())
 	if err != nil {
 		return store.ContextError(err)
 	}
@@ -122,3 +137,39 @@ func (r *TypePriceRepository) Delete(ctx context.Context, uuid string) error {
 
 	return nil
 }
+
+// Restore возвращает тип цены из архива
+func (r *TypePriceRepository) Restore(ctx context.Context, uuid string) (*TypePriceEnt, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s SET deleted_at = NULL, updated_at = $2
+		WHERE uuid = $1 AND deleted_at IS NOT NULL
+		RETURNING id, uuid, name, active, created_at, updated_at, deleted_at
+	`, r.tableName)
+
+	var price TypePriceEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &price, query, uuid, time.Now())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &price, nil
+}
+
+// Purge окончательно удаляет типы цен, которые лежат в архиве с момента before, вместе с ценами товаров по ним
+func (r *TypePriceRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE deleted_at < $1`, r.tableName)
+
+	result, err := r.store.Db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+	return rows, nil
+}
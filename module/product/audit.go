@@ -0,0 +1,65 @@
+package product
+
+import (
+	"context"
+	"go-monolite/module/audit"
+
+	"github.com/google/uuid"
+)
+
+// auditProduct — снимок товара для журнала изменений, у родителя вместе со свойствами вариантов
+type auditProduct struct {
+	ProductEnt
+	VariantProperties []uuid.UUID `json:"variant_properties,omitempty"`
+}
+
+// groupSnapshot — товар с вариантами и товары uuids в текущем состоянии, включая архивные
+func (s *Service) groupSnapshot(ctx context.Context, productUUID uuid.UUID, uuids []uuid.UUID) ([]auditProduct, error) {
+	products, err := s.repo.Group(ctx, productUUID, uuids)
+	if err != nil {
+		return nil, err
+	}
+	properties, err := s.repo.VariantProperties(ctx, productUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make([]auditProduct, 0, len(products))
+	for _, p := range products {
+		item := auditProduct{ProductEnt: p}
+		if p.UUID == productUUID {
+			for _, property := range properties {
+				item.VariantProperties = append(item.VariantProperties, property.PropertyUUID)
+			}
+		}
+		snapshot = append(snapshot, item)
+	}
+	return snapshot, nil
+}
+
+// groupEntries — изменения товаров группы: товары, которых не было до записи, — созданные, остальные — обновлённые
+func groupEntries(before, after []auditProduct) []audit.Entry {
+	existed := make(map[uuid.UUID]auditProduct, len(before))
+	for _, p := range before {
+		existed[p.UUID] = p
+	}
+
+	entries := make([]audit.Entry, 0, len(after))
+	for _, p := range after {
+		if old, ok := existed[p.UUID]; ok {
+			entries = append(entries, audit.Updated(audit.EntityProduct, p.UUID.String(), old, p))
+		} else {
+			entries = append(entries, audit.Created(audit.EntityProduct, p.UUID.String(), p))
+		}
+	}
+	return entries
+}
+
+// snapshotUUIDs — UUID товаров снимка
+func snapshotUUIDs(snapshot []auditProduct) []uuid.UUID {
+	result := make([]uuid.UUID, 0, len(snapshot))
+	for _, p := range snapshot {
+		result = append(result, p.UUID)
+	}
+	return result
+}
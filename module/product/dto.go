@@ -2,6 +2,7 @@ package product
 
 import (
 	"encoding/json"
+	"fmt"
 	"go-monolite/pkg/validator"
 
 	"github.com/google/uuid"
@@ -52,3 +53,99 @@ func (p *ProductDto) ToEntity() *ProductEnt {
 func (d *ProductDto) Validate() error {
 	return validator.Validate(d)
 }
+
+// ListRequest — фильтр и сортировка списка товаров
+type ListRequest struct {
+	CategoryUUID *uuid.UUID
+	Sort         string `validate:"omitempty,oneof=new name rating"`
+	Collapse     bool   // варианты не показываются, только родители и товары без вариантов
+	Limit        int    `validate:"min=1,max=100"`
+	Offset       int    `validate:"min=0"`
+}
+
+func (d *ListRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+type ListResponse struct {
+	Items []ProductEnt `json:"items"`
+	Total int          `json:"total"`
+}
+
+// VariantsRequest — DTO для PUT /product/{uuid}/variants. Список вариантов заменяет прежний,
+// варианты, которых нет в списке, выключаются
+type VariantsRequest struct {
+	Properties []uuid.UUID  `json:"properties" validate:"required,min=1,max=5" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	Variants   []VariantDto `json:"variants" validate:"required,min=1,max=500,dive"`
+}
+
+// VariantDto — вариант товара. Категория, единица, шаг и бренд берутся у родителя,
+// property содержит значения свойств из properties
+type VariantDto struct {
+	UUID     uuid.UUID       `json:"uuid" validate:"required" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Name     string          `json:"name,omitempty" validate:"max=455" example:"Футболка, XL, красная"` // по умолчанию — название родителя
+	Code     int             `json:"code" validate:"required,gt=0" example:"123457"`
+	Article  *string         `json:"article,omitempty" validate:"omitempty,max=32" example:"A-1234-XL"`
+	Active   string          `json:"active" validate:"required,oneof=Y N" example:"Y"`
+	Property json.RawMessage `json:"property" validate:"required" swaggertype:"object"`
+}
+
+func (d *VariantsRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// ToEntity — вариант для записи, без названия берёт название родителя. Slug дополняется кодом,
+// чтобы варианты с одинаковым названием не пересекались
+func (d VariantDto) ToEntity(parentName string) *ProductEnt {
+	name := d.Name
+	if name == "" {
+		name = parentName
+	}
+	return &ProductEnt{
+		UUID:     d.UUID,
+		Name:     name,
+		Code:     d.Code,
+		Article:  d.Article,
+		Slug:     slug.Make(fmt.Sprintf("%s-%d", name, d.Code)),
+		Active:   d.Active,
+		Property: d.Property,
+	}
+}
+
+// CardResponse — карточка товара. Для товара с вариантами и для самого варианта
+// содержит варианты родителя и значения свойств для выбора
+type CardResponse struct {
+	ProductEnt
+	Variants          []VariantResponse         `json:"variants,omitempty"`
+	VariantProperties []VariantPropertyResponse `json:"variant_properties,omitempty"`
+}
+
+type VariantResponse struct {
+	UUID      uuid.UUID              `json:"uuid" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Name      string                 `json:"name" example:"Футболка, XL, красная"`
+	Code      int                    `json:"code" example:"123457"`
+	Article   *string                `json:"article,omitempty" example:"A-1234-XL"`
+	Values    map[string]string      `json:"values"` // UUID свойства → ключ значения
+	Prices    []VariantPriceResponse `json:"prices"`
+	Stock     int                    `json:"stock" example:"12"`
+	Available bool                   `json:"available"` // есть цена и остаток
+}
+
+type VariantPriceResponse struct {
+	TypePriceUUID uuid.UUID `json:"type_price_uuid" example:"550e8400-e29b-41d4-a713-446655440000"`
+	Price         float64   `json:"price" example:"1500"`
+}
+
+// VariantPropertyResponse — свойство для выбора варианта и его значения у включённых вариантов
+type VariantPropertyResponse struct {
+	PropertyUUID uuid.UUID               `json:"property_uuid" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	Slug         string                  `json:"slug" example:"razmer"`
+	Name         string                  `json:"name" example:"Размер"`
+	Values       []VariantOptionResponse `json:"values"`
+}
+
+type VariantOptionResponse struct {
+	Key      string      `json:"key" example:"xl"`
+	Value    string      `json:"value" example:"XL"`
+	Variants []uuid.UUID `json:"variants"` // варианты с этим значением
+}
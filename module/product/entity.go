@@ -25,8 +25,12 @@ type ProductEnt struct {
 	Height       *float64        `db:"height" json:"height,omitempty"`
 	Volume       *float64        `db:"volume" json:"volume,omitempty"`
 	CategoryUUID uuid.UUID       `db:"category_uuid" json:"category_uuid"`
+	ParentUUID   *uuid.UUID      `db:"parent_uuid" json:"parent_uuid,omitempty"` // родитель, если товар — вариант
+	Rating       *float64        `db:"rating" json:"rating,omitempty"`           // средняя оценка одобренных отзывов
+	ReviewsCount int             `db:"reviews_count" json:"reviews_count"`
 	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt    time.Time       `db:"updated_at" json:"updated_at"`
+	DeletedAt    *time.Time      `db:"deleted_at" json:"deleted_at,omitempty"` // не пустой — товар в архиве
 }
 
 func (e *ProductEnt) PatchDto(request ProductDto) ProductDto {
@@ -75,3 +79,27 @@ func (e *ProductEnt) PatchDto(request ProductDto) ProductDto {
 	}
 	return request
 }
+
+// VariantEnt — вариант товара с остатком на включённых складах
+type VariantEnt struct {
+	UUID     uuid.UUID       `db:"uuid"`
+	Name     string          `db:"name"`
+	Code     int             `db:"code"`
+	Article  *string         `db:"article"`
+	Property json.RawMessage `db:"property"`
+	Stock    int             `db:"stock"`
+}
+
+// VariantPriceEnt — включённая цена варианта
+type VariantPriceEnt struct {
+	ProductUUID   uuid.UUID `db:"product_uuid"`
+	TypePriceUUID uuid.UUID `db:"type_price_uuid"`
+	Price         float64   `db:"price"`
+}
+
+// VariantPropertyEnt — свойство, которым отличаются варианты товара
+type VariantPropertyEnt struct {
+	PropertyUUID uuid.UUID `db:"property_uuid"`
+	Slug         string    `db:"slug"`
+	Name         string    `db:"name"`
+}
@@ -0,0 +1,11 @@
+package product
+
+import "errors"
+
+var (
+	ErrNestedVariant   = errors.New("variant cannot have variants")
+	ErrVariantConflict = errors.New("variant belongs to another product")
+
+	ErrParentArchived   = errors.New("parent product is archived")
+	ErrCategoryArchived = errors.New("product category is archived")
+)
@@ -1,11 +1,22 @@
 package product
 
 import (
+	"errors"
 	"go-monolite/internal/store"
+	"go-monolite/module/audit"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi"
+	"github.com/google/uuid"
 )
 
+const defaultListLimit = 50
+
 var (
 	MessNotFound    = "Товар не найден"
 	MessGetProduct  = "Произошла ошибка при получении товара"
@@ -18,40 +29,142 @@ type Handler struct {
 
 func NewHandler(store *store.Store) *Handler {
 	repo := NewRepository(store)
-	service := NewService(repo)
+	service := NewService(store, repo, audit.NewRecorder(store))
 	return &Handler{service: service}
 }
 
 func (h *Handler) Init(r chi.Router) {
-	// r.Get("/", h.GetList)
-	// r.Get("/{uuid}", h.GetByUUID)
+	r.Get("/", h.GetList)
+	r.Get("/{uuid}", h.GetByUUID)
+	r.Put("/{uuid}/variants", h.SetVariants)
+	r.Delete("/{uuid}/variants", h.DeleteVariants)
 	// r.Post("/create", h.Create)
 	// r.Put("/update/{uuid}", h.Update)
-	// r.Delete("/delete/{uuid}", h.Delete)
+	r.Delete("/delete/{uuid}", h.Delete)
+	r.Post("/restore/{uuid}", h.Restore)
 }
 
-// func (h *Handler) GetByUUID(w http.ResponseWriter, r *http.Request) {
-// 	uuidStr := chi.URLParam(r, "uuid")
+// @Summary Get product
+// @Description Get an active product card with its rating and number of approved reviews. For a product with variants and for a variant itself the card lists active variants of the parent with prices, stock and the property values to choose from
+// @Tags products
+// @Produce json
+// @Param uuid path string true "Product UUID"
+// @Success 200 {object} respond.SuccessResponse{data=CardResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /product/{uuid} [get]
+func (h *Handler) GetByUUID(w http.ResponseWriter, r *http.Request) {
+	productUUID, err := validator.ParseUUID(chi.URLParam(r, "uuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
 
-// 	err := validator.ParseUUID(uuidStr)
-// 	if err != nil {
-// 		respond.ErrorHandler(w, r, http.StatusBadRequest, err)
-// 		return
-// 	}
+	product, err := h.service.GetCard(r.Context(), productUUID.String())
+	if err != nil {
+		logger.ErrorCtx(r.Context(), err, MessGetProduct)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, MessGetProduct)
+		return
+	}
+	if product == nil || product.Active != "Y" {
+		respond.ErrorHandler(w, r, http.StatusNotFound, nil, MessNotFound)
+		return
+	}
 
-// 	product, err := h.service.GetByUUID(r.Context(), uuidStr)
-// 	if err != nil {
-// 		logger.ErrorCtx(r.Context(), err, MessGetProduct)
-// 		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, MessGetProduct)
-// 		return
-// 	}
-// 	if product == nil {
-// 		respond.ErrorHandler(w, r, http.StatusNotFound, nil, MessNotFound)
-// 		return
-// 	}
+	respond.SuccessHandler(w, r, http.StatusOK, "", product)
+}
 
-// 	respond.SuccessHandler(w, r, http.StatusOK, "", product)
-// }
+// @Summary Set product variants
+// @Description Replace variants of a product: variants are upserted as products under the parent with the parent's category, unit, step and brand, variants missing from the list are deactivated. Every variant needs a value of each variant property, combinations must be unique. Prices and stock of variants are loaded by their UUID through /price/upsert and /storage/upsert
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param uuid path string true "Parent product UUID"
+// @Param request body VariantsRequest true "Variant properties and variants"
+// @Success 200 {object} respond.SuccessResponse{data=CardResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /product/{uuid}/variants [put]
+func (h *Handler) SetVariants(w http.ResponseWriter, r *http.Request) {
+	productUUID, err := validator.ParseUUID(chi.URLParam(r, "uuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	body := respond.ParseBody(w, r)
+
+	var request VariantsRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	card, err := h.service.SetVariants(r.Context(), productUUID, request)
+	if err != nil {
+		respondVariantError(w, r, err, "Произошла ошибка при сохранении вариантов товара")
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "Варианты товара сохранены", card)
+}
+
+// @Summary Ungroup product variants
+// @Description Detach all variants from a product, they become separate products
+// @Tags products
+// @Produce json
+// @Param uuid path string true "Parent product UUID"
+// @Success 200 {object} respond.SuccessResponse{data=CardResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /product/{uuid}/variants [delete]
+func (h *Handler) DeleteVariants(w http.ResponseWriter, r *http.Request) {
+	productUUID, err := validator.ParseUUID(chi.URLParam(r, "uuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	card, err := h.service.DeleteVariants(r.Context(), productUUID)
+	if err != nil {
+		respondVariantError(w, r, err, "Произошла ошибка при разгруппировке вариантов товара")
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "Варианты товара разгруппированы", card)
+}
+
+func respondVariantError(w http.ResponseWriter, r *http.Request, err error, mess string) {
+	var validationErrors validator.ValidationError
+	if errors.As(err, &validationErrors) {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+		return
+	}
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		respond.ErrorHandler(w, r, http.StatusNotFound, err, MessNotFound)
+	case errors.Is(err, ErrNestedVariant):
+		respond.ErrorHandler(w, r, http.StatusConflict, err, "Вариант не может иметь своих вариантов")
+	case errors.Is(err, ErrVariantConflict):
+		respond.ErrorHandler(w, r, http.StatusConflict, err, "Вариант уже относится к другому товару")
+	case errors.Is(err, store.ErrConflict):
+		respond.ErrorHandler(w, r, http.StatusConflict, err, "Код или название варианта заняты другим товаром")
+	default:
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+	}
+}
 
 // // @Summary Create new product
 // // @Description Create a new product with the provided details
@@ -147,41 +260,134 @@ func (h *Handler) Init(r chi.Router) {
 // 	respond.SuccessHandler(w, r, http.StatusOK, "Товар успешно обновлен", nil)
 // }
 
-// func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
-// 	uuidStr := chi.URLParam(r, "uuid")
+// @Summary Delete product
+// @Description Move a product to the archive together with its variants. Archived products are hidden from the catalog, can be restored and are purged after the retention period
+// @Tags products
+// @Produce json
+// @Param uuid path string true "Product UUID"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /product/delete/{uuid} [delete]
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	productUUID, err := validator.ParseUUID(chi.URLParam(r, "uuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
 
-// 	err := validator.ParseUUID(uuidStr)
-// 	if err != nil {
-// 		respond.ErrorHandler(w, r, http.StatusBadRequest, err)
-// 		return
-// 	}
+	err = h.service.Delete(r.Context(), productUUID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, MessNotFound)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, "Произошла ошибка при удалении товара")
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, "Произошла ошибка при удалении товара")
+		return
+	}
 
-// 	err = h.service.Delete(r.Context(), uuidStr)
-// 	if err != nil {
-// 		if errors.Is(err, store.ErrNotFound) {
-// 			respond.ErrorHandler(w, r, http.StatusNotFound, err, MessNotFound)
-// 			return
-// 		}
-// 		logger.ErrorCtx(r.Context(), err, "Произошла ошибка при удалении товара")
-// 		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, "Произошла ошибка при удалении товара")
-// 		return
-// 	}
+	respond.SuccessHandler(w, r, http.StatusOK, "Товар перенесён в архив", nil)
+}
 
-// 	respond.SuccessHandler(w, r, http.StatusOK, "Товар успешно удален", nil)
-// }
+// @Summary Restore product
+// @Description Restore an archived product together with the variants archived with it. A variant of an archived parent and a product of an archived category cannot be restored
+// @Tags products
+// @Produce json
+// @Param uuid path string true "Product UUID"
+// @Success 200 {object} respond.SuccessResponse{data=CardResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /product/restore/{uuid} [post]
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	productUUID, err := validator.ParseUUID(chi.URLParam(r, "uuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
 
-// func (h *Handler) GetList(w http.ResponseWriter, r *http.Request) {
-// 	products, err := h.service.GetList(r.Context())
-// 	if err != nil {
-// 		logger.ErrorCtx(r.Context(), err, "Произошла ошибка при получении списка товаров")
-// 		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, "Произошла ошибка при получении списка товаров")
-// 		return
-// 	}
-// 	if products == nil {
-// 		respond.ErrorHandler(w, r, http.StatusNotFound, err, MessNotFound)
-// 		return
-// 	}
-// 	// TODO: перевести а Response
-// 	// productResponses := ToProductResponseList(products)
-// 	respond.SuccessHandler(w, r, http.StatusOK, "", products)
-// }
+	card, err := h.service.Restore(r.Context(), productUUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, "Товар в архиве не найден")
+		case errors.Is(err, ErrParentArchived):
+			respond.ErrorHandler(w, r, http.StatusConflict, err, "Сначала восстановите родительский товар")
+		case errors.Is(err, ErrCategoryArchived):
+			respond.ErrorHandler(w, r, http.StatusConflict, err, "Сначала восстановите категорию товара")
+		default:
+			logger.ErrorCtx(r.Context(), err, "Произошла ошибка при восстановлении товара")
+			respond.ErrorHandler(w, r, http.StatusInternalServerError, err, "Произошла ошибка при восстановлении товара")
+		}
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "Товар восстановлен", card)
+}
+
+// @Summary List products
+// @Description Get active products. collapse=true groups variants under their parent, sort=rating puts the best rated products first, products without reviews last
+// @Tags products
+// @Produce json
+// @Param category_uuid query string false "Category UUID"
+// @Param sort query string false "Sort order: new, name, rating" default(new)
+// @Param collapse query bool false "Hide variants, show only parents and products without variants"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /product [get]
+func (h *Handler) GetList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := ListRequest{Sort: query.Get("sort"), Limit: defaultListLimit}
+
+	if value := query.Get("category_uuid"); value != "" {
+		categoryUUID, err := uuid.Parse(value)
+		if err != nil {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, "Некорректный category_uuid")
+			return
+		}
+		request.CategoryUUID = &categoryUUID
+	}
+
+	var err error
+	if value := query.Get("collapse"); value != "" {
+		if request.Collapse, err = strconv.ParseBool(value); err != nil {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, "Некорректный collapse")
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if request.Limit, err = strconv.Atoi(value); err != nil {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, "Некорректный limit")
+			return
+		}
+	}
+	if value := query.Get("offset"); value != "" {
+		if request.Offset, err = strconv.Atoi(value); err != nil {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, "Некорректный offset")
+			return
+		}
+	}
+
+	products, err := h.service.GetList(r.Context(), request)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, "Произошла ошибка при получении списка товаров")
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, "Произошла ошибка при получении списка товаров")
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", products)
+}
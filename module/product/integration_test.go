@@ -1,5 +1,19 @@
 package product_test
 
+import (
+	"fmt"
+	"go-monolite/module/product"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
 // import (
 // 	"go-monolite/internal/module/product"
 // 	"go-monolite/pkg/testinit"
@@ -155,3 +169,200 @@ package product_test
 // // 		assert.Equal(t, http.StatusNotFound, getResp.StatusCode)
 // // 	})
 // // }
+
+func TestProductVariantsIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	server := testinit.SetupTestServer(t, product.NewHandler(store))
+	defer server.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	// футболка в двух размерах и двух цветах: размер — справочный, цвет — строкой по slug
+	categoryUUID, sizeUUID, colorUUID, typePriceUUID, storageUUID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	shirt, other, small, medium, mediumBlue := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	for _, query := range []string{
+		fmt.Sprintf(`INSERT INTO categories (uuid, slug, name) VALUES ('%s', 'clothes', 'Одежда')`, categoryUUID),
+		fmt.Sprintf(`INSERT INTO property (uuid, slug, type, name) VALUES ('%s', 'size', 'Справочник', 'Размер'), ('%s', 'color', 'Строка', 'Цвет')`, sizeUUID, colorUUID),
+		fmt.Sprintf(`INSERT INTO property_values (key, slug, value, property_uuid) VALUES ('size-s', 's', 'S', '%s'), ('size-m', 'm', 'M', '%s')`, sizeUUID, sizeUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid, unit) VALUES ('%s', 'Футболка', 9401, 'shirt', '%s', 'шт')`, shirt, categoryUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Куртка', 9409, 'jacket', '%s')`, other, categoryUUID),
+		fmt.Sprintf(`INSERT INTO type_price (uuid, name) VALUES ('%s', 'Розничная')`, typePriceUUID),
+		fmt.Sprintf(`INSERT INTO storage (uuid, name) VALUES ('%s', 'Основной')`, storageUUID),
+		fmt.Sprintf(`INSERT INTO product_prices (product_uuid, type_price_uuid, price) VALUES ('%s', '%s', 900), ('%s', '%s', 1000)`, small, typePriceUUID, medium, typePriceUUID),
+		fmt.Sprintf(`INSERT INTO product_storages (product_uuid, storage_uuid, quantity) VALUES ('%s', '%s', 5)`, small, storageUUID),
+	} {
+		_, err := store.Db.Exec(query)
+		require.NoError(t, err)
+	}
+
+	send := func(t *testing.T, method, path, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	decode := func(t *testing.T, resp *http.Response, v any) {
+		t.Helper()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		testinit.MarshalUnmarshal(t, response.Data, v)
+	}
+
+	variant := func(id uuid.UUID, code int, size, color string) string {
+		return fmt.Sprintf(`{"uuid": "%s", "code": %d, "active": "Y", "property": {"%s": "%s", "color": "%s"}}`, id, code, sizeUUID, size, color)
+	}
+	variants := func(items ...string) string {
+		return fmt.Sprintf(`{"properties": ["%s", "%s"], "variants": [%s]}`, sizeUUID, colorUUID, strings.Join(items, ", "))
+	}
+
+	t.Run("Set Variants", func(t *testing.T) {
+		var card product.CardResponse
+		decode(t, send(t, http.MethodPut, "/"+shirt.String()+"/variants", variants(
+			variant(small, 9402, "size-s", "Красный"),
+			variant(medium, 9403, "size-m", "Красный"),
+			variant(mediumBlue, 9404, "size-m", "Синий"),
+		)), &card)
+
+		require.Len(t, card.Variants, 3)
+		assert.Equal(t, small, card.Variants[0].UUID)
+		assert.Equal(t, "Футболка", card.Variants[0].Name)
+		assert.Equal(t, "size-s", card.Variants[0].Values[sizeUUID.String()])
+		require.Len(t, card.Variants[0].Prices, 1)
+		assert.Equal(t, 900.0, card.Variants[0].Prices[0].Price)
+		assert.True(t, card.Variants[0].Available)
+		assert.False(t, card.Variants[1].Available) // нет остатка
+		assert.False(t, card.Variants[2].Available) // нет цены
+
+		require.Len(t, card.VariantProperties, 2)
+		size := card.VariantProperties[0]
+		assert.Equal(t, "Размер", size.Name)
+		require.Len(t, size.Values, 2)
+		assert.Equal(t, "S", size.Values[0].Value)
+		assert.Equal(t, []uuid.UUID{small}, size.Values[0].Variants)
+		assert.Equal(t, "M", size.Values[1].Value)
+		assert.Equal(t, []uuid.UUID{medium, mediumBlue}, size.Values[1].Variants)
+		color := card.VariantProperties[1]
+		require.Len(t, color.Values, 2)
+		assert.Equal(t, "Красный", color.Values[0].Value)
+		assert.Equal(t, []uuid.UUID{mediumBlue}, color.Values[1].Variants)
+
+		// карточка варианта показывает варианты родителя
+		decode(t, send(t, http.MethodGet, "/"+medium.String(), ""), &card)
+		require.NotNil(t, card.ParentUUID)
+		assert.Equal(t, shirt, *card.ParentUUID)
+		assert.Equal(t, "шт", *card.Unit)
+		assert.Len(t, card.Variants, 3)
+	})
+
+	t.Run("Collapse", func(t *testing.T) {
+		var l product.ListResponse
+		decode(t, send(t, http.MethodGet, "/?collapse=true&sort=name", ""), &l)
+		assert.Equal(t, 2, l.Total)
+		require.Len(t, l.Items, 2)
+		assert.Equal(t, other, l.Items[0].UUID)
+		assert.Equal(t, shirt, l.Items[1].UUID)
+
+		decode(t, send(t, http.MethodGet, "/", ""), &l)
+		assert.Equal(t, 5, l.Total)
+
+		resp := send(t, http.MethodGet, "/?collapse=maybe", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Invalid Variants", func(t *testing.T) {
+		path := "/" + shirt.String() + "/variants"
+
+		// нет значения свойства, повтор сочетания, неизвестное свойство
+		resp := send(t, http.MethodPut, path, variants(fmt.Sprintf(`{"uuid": "%s", "code": 9405, "active": "Y", "property": {"color": "Красный"}}`, uuid.New())))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, http.MethodPut, path, variants(variant(small, 9402, "size-s", "Красный"), variant(uuid.New(), 9405, "size-s", "Красный")))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, http.MethodPut, path, fmt.Sprintf(`{"properties": ["%s"], "variants": [%s]}`, uuid.New(), variant(small, 9402, "size-s", "Красный")))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		// вариант не группирует другие товары и не переходит к другому родителю
+		resp = send(t, http.MethodPut, "/"+small.String()+"/variants", variants(variant(uuid.New(), 9405, "size-s", "Красный")))
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		resp = send(t, http.MethodPut, "/"+other.String()+"/variants", variants(variant(small, 9402, "size-s", "Красный")))
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		// код занят другим товаром
+		resp = send(t, http.MethodPut, path, variants(variant(small, 9409, "size-s", "Красный")))
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = send(t, http.MethodPut, "/"+uuid.New().String()+"/variants", variants(variant(uuid.New(), 9405, "size-s", "Красный")))
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Archive And Restore", func(t *testing.T) {
+		// товар уходит в архив вместе с вариантами
+		resp := send(t, http.MethodDelete, "/delete/"+shirt.String(), "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = send(t, http.MethodDelete, "/delete/"+shirt.String(), "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp = send(t, http.MethodGet, "/"+medium.String(), "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		var l product.ListResponse
+		decode(t, send(t, http.MethodGet, "/", ""), &l)
+		assert.Equal(t, 1, l.Total)
+
+		// вариант и товар архивной категории не возвращаются раньше родителя
+		resp = send(t, http.MethodPost, "/restore/"+small.String(), "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		_, err := store.Db.Exec(`UPDATE categories SET deleted_at = NOW() WHERE uuid = $1`, categoryUUID)
+		require.NoError(t, err)
+		resp = send(t, http.MethodPost, "/restore/"+shirt.String(), "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		_, err = store.Db.Exec(`UPDATE categories SET deleted_at = NULL WHERE uuid = $1`, categoryUUID)
+		require.NoError(t, err)
+
+		var card product.CardResponse
+		decode(t, send(t, http.MethodPost, "/restore/"+shirt.String(), ""), &card)
+		assert.Len(t, card.Variants, 3)
+		resp = send(t, http.MethodPost, "/restore/"+shirt.String(), "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		// вариант, удалённый раньше родителя, остаётся в архиве
+		resp = send(t, http.MethodDelete, "/delete/"+small.String(), "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = send(t, http.MethodDelete, "/delete/"+shirt.String(), "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		decode(t, send(t, http.MethodPost, "/restore/"+shirt.String(), ""), &card)
+		assert.Len(t, card.Variants, 2)
+
+		decode(t, send(t, http.MethodPost, "/restore/"+small.String(), ""), &card)
+		assert.Len(t, card.Variants, 3)
+	})
+
+	t.Run("Replace And Ungroup", func(t *testing.T) {
+		var card product.CardResponse
+		decode(t, send(t, http.MethodPut, "/"+shirt.String()+"/variants", variants(
+			variant(small, 9402, "size-s", "Красный"),
+			variant(medium, 9403, "size-m", "Красный"),
+		)), &card)
+		require.Len(t, card.Variants, 2)
+
+		// выключенный вариант не открывается
+		resp := send(t, http.MethodGet, "/"+mediumBlue.String(), "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		decode(t, send(t, http.MethodDelete, "/"+shirt.String()+"/variants", ""), &card)
+		assert.Empty(t, card.Variants)
+		assert.Empty(t, card.VariantProperties)
+
+		var l product.ListResponse
+		decode(t, send(t, http.MethodGet, "/?collapse=true", ""), &l)
+		assert.Equal(t, 4, l.Total)
+	})
+}
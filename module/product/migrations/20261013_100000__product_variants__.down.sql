@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS product_variant_properties;
+
+DROP INDEX IF EXISTS products_parent_idx;
+
+ALTER TABLE products
+  DROP CONSTRAINT IF EXISTS products_parent_check,
+  DROP COLUMN IF EXISTS parent_uuid;
@@ -0,0 +1,14 @@
+-- вариант (торговое предложение) — отдельный товар со своими кодом, ценами и остатками, сгруппированный под родителем
+ALTER TABLE products
+  ADD COLUMN IF NOT EXISTS parent_uuid UUID REFERENCES products(uuid) ON DELETE CASCADE,
+  ADD CONSTRAINT products_parent_check CHECK (parent_uuid <> uuid);
+
+CREATE INDEX IF NOT EXISTS products_parent_idx ON products (parent_uuid) WHERE parent_uuid IS NOT NULL;
+
+-- свойства, которыми отличаются варианты товара (размер, цвет), в порядке выбора на карточке
+CREATE TABLE IF NOT EXISTS product_variant_properties (
+  product_uuid UUID NOT NULL REFERENCES products(uuid) ON DELETE CASCADE,
+  property_uuid UUID NOT NULL REFERENCES property(uuid) ON DELETE CASCADE,
+  sort INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (product_uuid, property_uuid)
+);
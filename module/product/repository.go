@@ -6,8 +6,22 @@ import (
 	"fmt"
 	"go-monolite/internal/store"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
+const productColumns = `id, uuid, name, unit, code, article, slug, active, step, brand_uuid, property,
+	weight, width, length, height, volume, category_uuid, parent_uuid, rating, reviews_count, created_at, updated_at, deleted_at`
+
+// listSorts — порядок списка товаров по параметру sort
+var listSorts = map[string]string{
+	"":       "created_at DESC, id DESC",
+	"new":    "created_at DESC, id DESC",
+	"name":   "name, id",
+	"rating": "rating DESC NULLS LAST, reviews_count DESC, id",
+}
+
 type Repository struct {
 	store     *store.Store
 	tableName string
@@ -66,13 +80,13 @@ func (r *Repository) Create(ctx context.Context, p *ProductEnt) (*uint, error) {
 	return &id, nil
 }
 
+// GetByUUID — товар не из архива
 func (r *Repository) GetByUUID(ctx context.Context, uuid string) (*ProductEnt, error) {
 	query := fmt.Sprintf(`
-		SELECT id, uuid, name, unit, code, article, slug, active, step, brand_uuid, property,
-			weight, width, length, height, volume, category_uuid, created_at, updated_at
+		SELECT %s
 		FROM %s
-		WHERE uuid = $1
-	`, r.tableName)
+		WHERE uuid = $1 AND deleted_at IS NULL
+	`, productColumns, r.tableName)
 
 	var product ProductEnt
 	err := r.store.Db.GetContext(ctx, &product, query, uuid)
@@ -92,7 +106,7 @@ func (r *Repository) Update(ctx context.Context, p *ProductEnt) error {
 		SET name = $1, unit = $2, code = $3, article = $4, slug = $5, active = $6,
 			step = $7, brand_uuid = $8, property = $9, weight = $10, width = $11,
 			length = $12, height = $13, volume = $14, category_uuid = $15, updated_at = $16
-		WHERE uuid = $17
+		WHERE uuid = $17 AND deleted_at IS NULL
 	`, r.tableName)
 
 	p.UpdatedAt = time.Now()
@@ -131,10 +145,15 @@ func (r *Repository) Update(ctx context.Context, p *ProductEnt) error {
 	return nil
 }
 
+// Delete переносит товар в архив вместе с его вариантами, окончательно их удаляет задача очистки
 func (r *Repository) Delete(ctx context.Context, uuid string) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE uuid = $1`, r.tableName)
+	query := fmt.Sprintf(`
+		UPDATE %[1]s SET deleted_at = $2
+		WHERE deleted_at IS NULL
+			AND (uuid = $1 OR parent_uuid = $1 AND EXISTS (SELECT 1 FROM %[1]s WHERE uuid = $1 AND deleted_at IS NULL))
+	`, r.tableName)
 
-	result, err := r.store.Db.ExecContext(ctx, query, uuid)
+	result, err := r.store.Conn(ctx).ExecContext(ctx, query, uuid, time.Now())
 	if err != nil {
 		return store.ContextError(err)
 	}
@@ -150,19 +169,111 @@ func (r *Repository) Delete(ctx context.Context, uuid string) error {
 	return nil
 }
 
-func (r *Repository) GetList(ctx context.Context) ([]ProductEnt, error) {
+// GetArchived — товар из архива
+func (r *Repository) GetArchived(ctx context.Context, productUUID uuid.UUID) (*ProductEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE uuid = $1 AND deleted_at IS NOT NULL`, productColumns, r.tableName)
+
+	var product ProductEnt
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &product, query, productUUID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &product, nil
+}
+
+// Restore возвращает из архива товар и его варианты, удалённые вместе с ним в момент deletedAt
+func (r *Repository) Restore(ctx context.Context, productUUID uuid.UUID, deletedAt time.Time) error {
 	query := fmt.Sprintf(`
-		SELECT id, uuid, name, unit, code, article, slug, active, step, brand_uuid, property,
-			weight, width, length, height, volume, category_uuid, created_at, updated_at
-		FROM %s
-		ORDER BY created_at DESC
+		UPDATE %s SET deleted_at = NULL, updated_at = $3
+		WHERE (uuid = $1 OR parent_uuid = $1) AND deleted_at = $2
+	`, r.tableName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, productUUID, deletedAt, time.Now()); err != nil {
+		return store.ContextError(err)
+	}
+	return nil
+}
+
+// Archived — товар в архиве
+func (r *Repository) Archived(ctx context.Context, productUUID uuid.UUID) (bool, error) {
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE uuid = $1 AND deleted_at IS NOT NULL)`, r.tableName)
+
+	var archived bool
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &archived, query, productUUID); err != nil {
+		return false, store.ContextError(err)
+	}
+	return archived, nil
+}
+
+// CategoryArchived — категория в архиве
+func (r *Repository) CategoryArchived(ctx context.Context, categoryUUID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM categories WHERE uuid = $1 AND deleted_at IS NOT NULL)`
+
+	var archived bool
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &archived, query, categoryUUID); err != nil {
+		return false, store.ContextError(err)
+	}
+	return archived, nil
+}
+
+// Purge окончательно удаляет товары, которые лежат в архиве с момента before.
+// Родитель ждёт, пока срок хранения выйдет у всех его вариантов, — они удаляются вместе с ним каскадом
+func (r *Repository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %[1]s p
+		WHERE p.deleted_at < $1
+			AND NOT EXISTS (
+				SELECT 1 FROM %[1]s v
+				WHERE v.parent_uuid = p.uuid AND (v.deleted_at IS NULL OR v.deleted_at >= $1)
+			)
 	`, r.tableName)
 
+	result, err := r.store.Db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+	return rows, nil
+}
+
+// GetList — включённые товары не из архива, collapse скрывает варианты, sort=rating — сначала с высокой оценкой, товары без отзывов в конце
+func (r *Repository) GetList(ctx context.Context, req ListRequest) ([]ProductEnt, int, error) {
+	filter := "WHERE active = 'Y' AND deleted_at IS NULL"
+	var args []any
+	if req.CategoryUUID != nil {
+		args = append(args, *req.CategoryUUID)
+		filter += fmt.Sprintf(" AND category_uuid = $%d", len(args))
+	}
+	if req.Collapse {
+		filter += " AND parent_uuid IS NULL"
+	}
+
+	var total int
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s %s`, r.tableName, filter)
+	if err := r.store.Db.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, productColumns, r.tableName, filter, listSorts[req.Sort], len(args)+1, len(args)+2)
+
 	var products []ProductEnt
-	err := r.store.Db.SelectContext(ctx, &products, query)
+	err := r.store.Db.SelectContext(ctx, &products, query, append(args, req.Limit, req.Offset)...)
 	if err != nil {
-		return nil, store.ContextError(err)
+		return nil, 0, store.ContextError(err)
 	}
 
-	return products, nil
+	return products, total, nil
 }
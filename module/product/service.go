@@ -3,15 +3,23 @@ package product
 import (
 	"context"
 	"errors"
+	"fmt"
 	"go-monolite/internal/store"
+	"go-monolite/module/audit"
+	"go-monolite/pkg/validator"
+	"strings"
+
+	"github.com/google/uuid"
 )
 
 type Service struct {
-	repo *Repository
+	store *store.Store
+	repo  *Repository
+	audit *audit.Recorder
 }
 
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+func NewService(store *store.Store, repo *Repository, recorder *audit.Recorder) *Service {
+	return &Service{store: store, repo: repo, audit: recorder}
 }
 
 func (s *Service) Create(ctx context.Context, request *ProductDto) (*uint, error) {
@@ -49,10 +57,318 @@ func (s *Service) Update(ctx context.Context, request *ProductDto) error {
 	)
 }
 
-func (s *Service) Delete(ctx context.Context, uuid string) error {
-	return s.repo.Delete(ctx, uuid)
+func (s *Service) Delete(ctx context.Context, productUUID uuid.UUID) error {
+	return s.store.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.groupSnapshot(ctx, productUUID, nil)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, productUUID.String()); err != nil {
+			return err
+		}
+
+		entries := make([]audit.Entry, 0, len(before))
+		for _, p := range before {
+			if p.DeletedAt == nil {
+				entries = append(entries, audit.Deleted(audit.EntityProduct, p.UUID.String(), p))
+			}
+		}
+		return s.audit.Record(ctx, entries...)
+	})
+}
+
+// Restore возвращает товар из архива вместе с вариантами, удалёнными с ним.
+// Вариант архивного родителя и товар архивной категории не восстанавливаются
+func (s *Service) Restore(ctx context.Context, productUUID uuid.UUID) (*CardResponse, error) {
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		product, err := s.repo.GetArchived(ctx, productUUID)
+		if err != nil {
+			return err
+		}
+
+		if product.ParentUUID != nil {
+			archived, err := s.repo.Archived(ctx, *product.ParentUUID)
+			if err != nil {
+				return err
+			}
+			if archived {
+				return ErrParentArchived
+			}
+		}
+
+		archived, err := s.repo.CategoryArchived(ctx, product.CategoryUUID)
+		if err != nil {
+			return err
+		}
+		if archived {
+			return ErrCategoryArchived
+		}
+
+		before, err := s.groupSnapshot(ctx, productUUID, nil)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Restore(ctx, productUUID, *product.DeletedAt); err != nil {
+			return err
+		}
+		after, err := s.groupSnapshot(ctx, productUUID, nil)
+		if err != nil {
+			return err
+		}
+
+		restored := make(map[uuid.UUID]auditProduct, len(after))
+		for _, p := range after {
+			restored[p.UUID] = p
+		}
+		entries := make([]audit.Entry, 0, len(before))
+		for _, p := range before {
+			if p.DeletedAt != nil && p.DeletedAt.Equal(*product.DeletedAt) {
+				entries = append(entries, audit.Restored(audit.EntityProduct, p.UUID.String(), restored[p.UUID]))
+			}
+		}
+		return s.audit.Record(ctx, entries...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCard(ctx, productUUID.String())
+}
+
+func (s *Service) GetList(ctx context.Context, req ListRequest) (*ListResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	products, total, err := s.repo.GetList(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if products == nil {
+		products = []ProductEnt{}
+	}
+
+	return &ListResponse{Items: products, Total: total}, nil
+}
+
+// GetCard — карточка товара с вариантами. Если товар сам вариант, показываются варианты его родителя
+func (s *Service) GetCard(ctx context.Context, productUUID string) (*CardResponse, error) {
+	product, err := s.GetByUUID(ctx, productUUID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	card := &CardResponse{ProductEnt: *product}
+
+	groupUUID := product.UUID
+	if product.ParentUUID != nil {
+		groupUUID = *product.ParentUUID
+	}
+
+	properties, err := s.repo.VariantProperties(ctx, groupUUID)
+	if err != nil || len(properties) == 0 {
+		return card, err
+	}
+
+	variants, err := s.repo.Variants(ctx, groupUUID)
+	if err != nil {
+		return nil, err
+	}
+	variantUUIDs := make([]uuid.UUID, 0, len(variants))
+	for _, v := range variants {
+		variantUUIDs = append(variantUUIDs, v.UUID)
+	}
+	prices, err := s.repo.VariantPrices(ctx, variantUUIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	propertyUUIDs := make([]uuid.UUID, 0, len(properties))
+	for _, p := range properties {
+		propertyUUIDs = append(propertyUUIDs, p.PropertyUUID)
+	}
+	values, err := s.repo.PropertyValues(ctx, propertyUUIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	card.Variants, card.VariantProperties = variantMatrix(variants, properties, values, prices)
+	return card, nil
+}
+
+// SetVariants заменяет варианты товара: добавляет и обновляет переданные, выключает остальные.
+// Каждый вариант должен иметь значения всех свойств из properties, и сочетания значений не должны повторяться
+func (s *Service) SetVariants(ctx context.Context, parentUUID uuid.UUID, req VariantsRequest) (*CardResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		parent, err := s.repo.GetForUpdate(ctx, parentUUID)
+		if err != nil {
+			return err
+		}
+		if parent.ParentUUID != nil {
+			return ErrNestedVariant
+		}
+
+		properties, err := s.variantProperties(ctx, req.Properties)
+		if err != nil {
+			return err
+		}
+		if err := checkVariants(parent, properties, req.Variants); err != nil {
+			return err
+		}
+
+		variantUUIDs := make([]uuid.UUID, 0, len(req.Variants))
+		for _, v := range req.Variants {
+			variantUUIDs = append(variantUUIDs, v.UUID)
+		}
+		parents, err := s.repo.Parents(ctx, variantUUIDs)
+		if err != nil {
+			return err
+		}
+		for _, current := range parents {
+			if current != nil && *current != parent.UUID {
+				return ErrVariantConflict
+			}
+		}
+		hasVariants, err := s.repo.HasVariants(ctx, variantUUIDs)
+		if err != nil {
+			return err
+		}
+		if len(hasVariants) > 0 {
+			return ErrNestedVariant
+		}
+
+		before, err := s.groupSnapshot(ctx, parent.UUID, variantUUIDs)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range req.Variants {
+			if err := s.repo.UpsertVariant(ctx, parent, v.ToEntity(parent.Name)); err != nil {
+				return err
+			}
+		}
+		if err := s.repo.DeactivateVariants(ctx, parent.UUID, variantUUIDs); err != nil {
+			return err
+		}
+		if err := s.repo.SetVariantProperties(ctx, parent.UUID, req.Properties); err != nil {
+			return err
+		}
+
+		after, err := s.groupSnapshot(ctx, parent.UUID, variantUUIDs)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, groupEntries(before, after)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCard(ctx, parentUUID.String())
 }
 
-func (s *Service) GetList(ctx context.Context) ([]ProductEnt, error) {
-	return s.repo.GetList(ctx)
+// DeleteVariants разгруппировывает товар, варианты остаются отдельными товарами
+func (s *Service) DeleteVariants(ctx context.Context, parentUUID uuid.UUID) (*CardResponse, error) {
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.repo.GetForUpdate(ctx, parentUUID); err != nil {
+			return err
+		}
+
+		before, err := s.groupSnapshot(ctx, parentUUID, nil)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.DetachVariants(ctx, parentUUID); err != nil {
+			return err
+		}
+		if err := s.repo.SetVariantProperties(ctx, parentUUID, nil); err != nil {
+			return err
+		}
+
+		// отвязанные варианты уже не в группе, их ищем по UUID
+		after, err := s.groupSnapshot(ctx, parentUUID, snapshotUUIDs(before))
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, groupEntries(before, after)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCard(ctx, parentUUID.String())
+}
+
+// variantProperties — свойства вариантов в порядке запроса
+func (s *Service) variantProperties(ctx context.Context, uuids []uuid.UUID) ([]VariantPropertyEnt, error) {
+	found, err := s.repo.Properties(ctx, uuids)
+	if err != nil {
+		return nil, err
+	}
+	byUUID := make(map[uuid.UUID]VariantPropertyEnt, len(found))
+	for _, p := range found {
+		byUUID[p.PropertyUUID] = p
+	}
+
+	properties := make([]VariantPropertyEnt, 0, len(uuids))
+	for i, id := range uuids {
+		p, ok := byUUID[id]
+		if !ok {
+			return nil, validator.ValidationError{Err: validator.ErrorValidation, Fields: map[string]string{
+				fmt.Sprintf("properties[%d]", i): "Свойство не найдено",
+			}}
+		}
+		delete(byUUID, id)
+		properties = append(properties, p)
+	}
+	return properties, nil
+}
+
+// checkVariants проверяет варианты до записи: UUID, коды и сочетания значений свойств не повторяются
+func checkVariants(parent *ProductEnt, properties []VariantPropertyEnt, variants []VariantDto) error {
+	fields := map[string]string{}
+	seenUUIDs, seenCodes, seenValues := map[uuid.UUID]bool{}, map[int]bool{}, map[string]bool{}
+
+	for i, v := range variants {
+		field := fmt.Sprintf("variants[%d]", i)
+		switch {
+		case v.UUID == parent.UUID:
+			fields[field+".uuid"] = "Вариант не может совпадать с товаром"
+		case seenUUIDs[v.UUID]:
+			fields[field+".uuid"] = "UUID вариантов не должны повторяться"
+		}
+		if seenCodes[v.Code] || v.Code == parent.Code {
+			fields[field+".code"] = "Коды вариантов не должны повторяться"
+		}
+		seenUUIDs[v.UUID], seenCodes[v.Code] = true, true
+
+		values := variantValues(v.Property)
+		keys := make([]string, 0, len(properties))
+		for _, p := range properties {
+			key, ok := variantValue(values, p)
+			if !ok {
+				fields[field+".property"] = fmt.Sprintf("Не указано значение свойства «%s»", p.Name)
+				break
+			}
+			keys = append(keys, key)
+		}
+		if len(keys) < len(properties) {
+			continue
+		}
+		combination := strings.Join(keys, "\x00")
+		if seenValues[combination] {
+			fields[field+".property"] = "Вариант с такими значениями свойств уже есть"
+		}
+		seenValues[combination] = true
+	}
+
+	if len(fields) > 0 {
+		return validator.ValidationError{Err: validator.ErrorValidation, Fields: fields}
+	}
+	return nil
 }
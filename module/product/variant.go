@@ -0,0 +1,122 @@
+package product
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-monolite/module/property"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// variantValues разбирает products.property варианта — объект {UUID или slug свойства: значение}
+func variantValues(raw json.RawMessage) map[string]any {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var result map[string]any
+	if err := decoder.Decode(&result); err != nil {
+		return nil
+	}
+	return result
+}
+
+// variantValue — ключ значения свойства у варианта: ключ из property_values, строка, число или логическое значение.
+// Списки и вложенные объекты вариант не определяют
+func variantValue(values map[string]any, p VariantPropertyEnt) (string, bool) {
+	v, ok := values[p.PropertyUUID.String()]
+	if !ok {
+		v = values[p.Slug]
+	}
+
+	switch value := v.(type) {
+	case string:
+		return value, value != ""
+	case json.Number:
+		return value.String(), true
+	case bool:
+		return strconv.FormatBool(value), true
+	}
+	return "", false
+}
+
+// displayValue — значение для показа: из справочника свойства, иначе сам ключ
+func displayValue(key string, names map[string]string) string {
+	if name, ok := names[key]; ok {
+		return name
+	}
+	switch key {
+	case "true":
+		return "Да"
+	case "false":
+		return "Нет"
+	}
+	return key
+}
+
+// variantMatrix собирает варианты с их ценами и значения свойств для выбора.
+// Значения идут в порядке первого появления у вариантов, вариант без значения свойства в нём не выбирается
+func variantMatrix(variants []VariantEnt, properties []VariantPropertyEnt, values []property.PropertyValueEnt, prices []VariantPriceEnt) ([]VariantResponse, []VariantPropertyResponse) {
+	valueNames := make(map[uuid.UUID]map[string]string, len(properties))
+	for _, v := range values {
+		if valueNames[v.PropertyUUID] == nil {
+			valueNames[v.PropertyUUID] = map[string]string{}
+		}
+		valueNames[v.PropertyUUID][v.Key] = v.Value
+	}
+	variantPrices := make(map[uuid.UUID][]VariantPriceResponse, len(variants))
+	for _, p := range prices {
+		variantPrices[p.ProductUUID] = append(variantPrices[p.ProductUUID], VariantPriceResponse{TypePriceUUID: p.TypePriceUUID, Price: p.Price})
+	}
+
+	matrix := make([]VariantPropertyResponse, len(properties))
+	options := make([]map[string]int, len(properties))
+	for i, p := range properties {
+		matrix[i] = VariantPropertyResponse{PropertyUUID: p.PropertyUUID, Slug: p.Slug, Name: p.Name, Values: []VariantOptionResponse{}}
+		options[i] = map[string]int{}
+	}
+
+	result := make([]VariantResponse, 0, len(variants))
+	for _, v := range variants {
+		resp := VariantResponse{
+			UUID:    v.UUID,
+			Name:    v.Name,
+			Code:    v.Code,
+			Article: v.Article,
+			Values:  map[string]string{},
+			Prices:  variantPrices[v.UUID],
+			Stock:   v.Stock,
+		}
+		if resp.Prices == nil {
+			resp.Prices = []VariantPriceResponse{}
+		}
+		resp.Available = len(resp.Prices) > 0 && v.Stock > 0
+
+		own := variantValues(v.Property)
+		for i, p := range properties {
+			key, ok := variantValue(own, p)
+			if !ok {
+				continue
+			}
+			resp.Values[p.PropertyUUID.String()] = key
+
+			index, found := options[i][key]
+			if !found {
+				index = len(matrix[i].Values)
+				options[i][key] = index
+				matrix[i].Values = append(matrix[i].Values, VariantOptionResponse{
+					Key:   key,
+					Value: displayValue(key, valueNames[p.PropertyUUID]),
+				})
+			}
+			matrix[i].Values[index].Variants = append(matrix[i].Values[index].Variants, v.UUID)
+		}
+		result = append(result, resp)
+	}
+
+	return result, matrix
+}
@@ -0,0 +1,234 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-monolite/internal/store"
+	"go-monolite/module/property"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// GetForUpdate — товар не из архива, заблокированный до конца транзакции
+func (r *Repository) GetForUpdate(ctx context.Context, productUUID uuid.UUID) (*ProductEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE uuid = $1 AND deleted_at IS NULL FOR UPDATE`, productColumns, r.tableName)
+
+	var product ProductEnt
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &product, query, productUUID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &product, nil
+}
+
+// Group — товар, его варианты и товары из uuids, включая архивные, по UUID
+func (r *Repository) Group(ctx context.Context, productUUID uuid.UUID, uuids []uuid.UUID) ([]ProductEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s
+		WHERE uuid = $1 OR parent_uuid = $1 OR uuid = ANY($2)
+		ORDER BY uuid
+	`, productColumns, r.tableName)
+
+	var products []ProductEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &products, query, productUUID, pq.Array(uuids)); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return products, nil
+}
+
+// Parents — родители товаров из списка, nil — товар не вариант.
+// Отсутствующих в каталоге товаров в результате нет
+func (r *Repository) Parents(ctx context.Context, uuids []uuid.UUID) (map[uuid.UUID]*uuid.UUID, error) {
+	query := fmt.Sprintf(`SELECT uuid, parent_uuid FROM %s WHERE uuid = ANY($1)`, r.tableName)
+
+	var rows []struct {
+		UUID       uuid.UUID  `db:"uuid"`
+		ParentUUID *uuid.UUID `db:"parent_uuid"`
+	}
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &rows, query, pq.Array(uuids)); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	parents := make(map[uuid.UUID]*uuid.UUID, len(rows))
+	for _, row := range rows {
+		parents[row.UUID] = row.ParentUUID
+	}
+	return parents, nil
+}
+
+// HasVariants — у кого из товаров есть свои варианты
+func (r *Repository) HasVariants(ctx context.Context, uuids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	query := fmt.Sprintf(`SELECT DISTINCT parent_uuid FROM %s WHERE parent_uuid = ANY($1)`, r.tableName)
+
+	var parents []uuid.UUID
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &parents, query, pq.Array(uuids)); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	result := make(map[uuid.UUID]bool, len(parents))
+	for _, parent := range parents {
+		result[parent] = true
+	}
+	return result, nil
+}
+
+// UpsertVariant добавляет или обновляет вариант, категория, единица, шаг и бренд берутся у родителя.
+// Вариант из архива возвращается. Если код или slug занят другим товаром — store.ErrConflict
+func (r *Repository) UpsertVariant(ctx context.Context, parent *ProductEnt, v *ProductEnt) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			uuid, name, unit, code, article, slug, active, step, brand_uuid, property,
+			category_uuid, parent_uuid, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $13
+		)
+		ON CONFLICT (uuid) DO UPDATE SET
+			name = EXCLUDED.name, unit = EXCLUDED.unit, code = EXCLUDED.code, article = EXCLUDED.article,
+			slug = EXCLUDED.slug, active = EXCLUDED.active, step = EXCLUDED.step, brand_uuid = EXCLUDED.brand_uuid,
+			property = EXCLUDED.property, category_uuid = EXCLUDED.category_uuid, parent_uuid = EXCLUDED.parent_uuid,
+			updated_at = EXCLUDED.updated_at, deleted_at = NULL
+	`, r.tableName)
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query,
+		v.UUID, v.Name, parent.Unit, v.Code, v.Article, v.Slug, v.Active, parent.Step, parent.BrandUUID, v.Property,
+		parent.CategoryUUID, parent.UUID, time.Now(),
+	)
+	if err != nil {
+		if store.IsUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// DeactivateVariants выключает варианты родителя, которых нет в keep
+func (r *Repository) DeactivateVariants(ctx context.Context, parentUUID uuid.UUID, keep []uuid.UUID) error {
+	query := fmt.Sprintf(`
+		UPDATE %s SET active = 'N', updated_at = $3
+		WHERE parent_uuid = $1 AND uuid <> ALL($2) AND active = 'Y'
+	`, r.tableName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, parentUUID, pq.Array(keep), time.Now()); err != nil {
+		return store.ContextError(err)
+	}
+	return nil
+}
+
+// DetachVariants разгруппировывает товар: варианты становятся отдельными товарами
+func (r *Repository) DetachVariants(ctx context.Context, parentUUID uuid.UUID) error {
+	query := fmt.Sprintf(`UPDATE %s SET parent_uuid = NULL, updated_at = $2 WHERE parent_uuid = $1`, r.tableName)
+
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, parentUUID, time.Now()); err != nil {
+		return store.ContextError(err)
+	}
+	return nil
+}
+
+// Variants — включённые варианты товара не из архива по возрастанию кода
+func (r *Repository) Variants(ctx context.Context, parentUUID uuid.UUID) ([]VariantEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT p.uuid, p.name, p.code, p.article, p.property,
+			COALESCE((SELECT SUM(ps.quantity) FROM product_storages ps
+				JOIN storage s ON s.uuid = ps.storage_uuid AND s.active = 'Y' AND s.deleted_at IS NULL
+				WHERE ps.product_uuid = p.uuid AND ps.active = 'Y'), 0) AS stock
+		FROM %s p
+		WHERE p.parent_uuid = $1 AND p.active = 'Y' AND p.deleted_at IS NULL
+		ORDER BY p.code
+	`, r.tableName)
+
+	var variants []VariantEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &variants, query, parentUUID); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return variants, nil
+}
+
+// VariantPrices — включённые цены товаров по включённым типам цен, по одной на тип
+func (r *Repository) VariantPrices(ctx context.Context, uuids []uuid.UUID) ([]VariantPriceEnt, error) {
+	query := `
+		SELECT DISTINCT ON (pp.product_uuid, tp.id) pp.product_uuid, pp.type_price_uuid, pp.price
+		FROM product_prices pp
+		JOIN type_price tp ON tp.uuid = pp.type_price_uuid AND tp.active = 'Y' AND tp.deleted_at IS NULL
+		WHERE pp.product_uuid = ANY($1) AND pp.active = 'Y'
+		ORDER BY pp.product_uuid, tp.id, pp.updated_at DESC
+	`
+
+	var prices []VariantPriceEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &prices, query, pq.Array(uuids)); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return prices, nil
+}
+
+// Properties — свойства справочника не из архива по UUID
+func (r *Repository) Properties(ctx context.Context, uuids []uuid.UUID) ([]VariantPropertyEnt, error) {
+	query := `SELECT uuid AS property_uuid, slug, name FROM property WHERE uuid = ANY($1) AND deleted_at IS NULL`
+
+	var properties []VariantPropertyEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &properties, query, pq.Array(uuids)); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return properties, nil
+}
+
+// VariantProperties — свойства, которыми отличаются варианты товара, в порядке выбора
+func (r *Repository) VariantProperties(ctx context.Context, productUUID uuid.UUID) ([]VariantPropertyEnt, error) {
+	query := `
+		SELECT vp.property_uuid, p.slug, p.name
+		FROM product_variant_properties vp
+		JOIN property p ON p.uuid = vp.property_uuid AND p.deleted_at IS NULL
+		WHERE vp.product_uuid = $1
+		ORDER BY vp.sort, p.name
+	`
+
+	var properties []VariantPropertyEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &properties, query, productUUID); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return properties, nil
+}
+
+// SetVariantProperties заменяет свойства вариантов товара, порядок в списке — порядок выбора
+func (r *Repository) SetVariantProperties(ctx context.Context, productUUID uuid.UUID, propertyUUIDs []uuid.UUID) error {
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, `DELETE FROM product_variant_properties WHERE product_uuid = $1`, productUUID); err != nil {
+		return store.ContextError(err)
+	}
+	if len(propertyUUIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO product_variant_properties (product_uuid, property_uuid, sort)
+		SELECT $1, property_uuid, sort - 1 FROM UNNEST($2::uuid[]) WITH ORDINALITY AS t(property_uuid, sort)
+	`
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, productUUID, pq.Array(propertyUUIDs)); err != nil {
+		return store.ContextError(err)
+	}
+	return nil
+}
+
+// PropertyValues — значения справочных свойств не из архива
+func (r *Repository) PropertyValues(ctx context.Context, propertyUUIDs []uuid.UUID) ([]property.PropertyValueEnt, error) {
+	query := `SELECT id, key, slug, property_uuid, value FROM property_values WHERE property_uuid = ANY($1) AND deleted_at IS NULL`
+
+	var values []property.PropertyValueEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &values, query, pq.Array(propertyUUIDs)); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,87 @@
+package productlink
+
+import (
+	"go-monolite/pkg/validator"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SetRequest — DTO для PUT /product-link/{productUuid}/{type}. Список заменяет связи этого типа, порядок в списке — порядок показа
+type SetRequest struct {
+	Products []uuid.UUID `json:"products" validate:"max=100,dive,required" example:"550e8400-e29b-41d4-a716-446655440001"`
+}
+
+func (d *SetRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// UpsertRequest — DTO для POST /product-link/upsert, связи из обмена
+type UpsertRequest struct {
+	Data []ProductLinksDto `json:"data" validate:"required,min=1,max=1000,dive"`
+}
+
+// ProductLinksDto — связи одного типа для товара, заменяют прежние связи этого типа из обмена
+type ProductLinksDto struct {
+	ProductUUID uuid.UUID   `json:"product_uuid" validate:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Type        string      `json:"type" validate:"required,oneof=accessory analogue bought_together" example:"analogue"`
+	Links       []uuid.UUID `json:"links" validate:"max=100,dive,required" example:"550e8400-e29b-41d4-a716-446655440001"`
+}
+
+func (d *UpsertRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// ListRequest — связанные товары для карточки
+type ListRequest struct {
+	Type  string `validate:"omitempty,oneof=accessory analogue bought_together"`
+	Limit int    `validate:"min=1,max=100"`
+}
+
+func (d *ListRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+type LinkResponse struct {
+	LinkedUUID uuid.UUID `json:"linked_uuid" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Name       string    `json:"name" example:"Сверло по бетону"`
+	Code       int       `json:"code" example:"123457"`
+	Active     bool      `json:"active"`
+	Sort       int       `json:"sort" example:"0"`
+	Source     string    `json:"source" example:"manual"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// LinksResponse — все связи товара одного типа, включая выключенные товары
+type LinksResponse struct {
+	ProductUUID uuid.UUID      `json:"product_uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Type        string         `json:"type" example:"accessory"`
+	Items       []LinkResponse `json:"items"`
+}
+
+type LinkedProductResponse struct {
+	UUID         uuid.UUID `json:"uuid" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Name         string    `json:"name" example:"Сверло по бетону"`
+	Slug         string    `json:"slug" example:"sverlo-po-betonu"`
+	Code         int       `json:"code" example:"123457"`
+	Article      *string   `json:"article,omitempty" example:"A-1234"`
+	Unit         *string   `json:"unit,omitempty" example:"шт"`
+	Price        float64   `json:"price" example:"350"`
+	Stock        int       `json:"stock" example:"12"`
+	Rating       *float64  `json:"rating,omitempty" example:"4.5"`
+	ReviewsCount int       `json:"reviews_count" example:"3"`
+}
+
+// RelatedResponse — связанные товары по типам, только доступные для покупки
+type RelatedResponse struct {
+	Accessory      []LinkedProductResponse `json:"accessory"`
+	Analogue       []LinkedProductResponse `json:"analogue"`
+	BoughtTogether []LinkedProductResponse `json:"bought_together"`
+}
+
+type UpsertResponse struct {
+	CountInserted int `json:"count_inserted"`
+	CountUpdated  int `json:"count_updated"`
+	CountDeleted  int `json:"count_deleted"`
+	CountSkipped  int `json:"count_skipped"` // связи с товарами, которых нет в каталоге, и с самим товаром
+}
@@ -0,0 +1,81 @@
+package productlink
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// типы связей товаров
+const (
+	TypeAccessory      = "accessory"
+	TypeAnalogue       = "analogue"
+	TypeBoughtTogether = "bought_together"
+)
+
+// Types — все типы связей в порядке показа на карточке
+var Types = []string{TypeAccessory, TypeAnalogue, TypeBoughtTogether}
+
+// источники связей
+const (
+	SourceManual   = "manual"
+	SourceExchange = "exchange"
+)
+
+// LinkEnt — связь товара с данными связанного товара из каталога
+type LinkEnt struct {
+	ID          int64     `db:"id"`
+	ProductUUID uuid.UUID `db:"product_uuid"`
+	LinkedUUID  uuid.UUID `db:"linked_uuid"`
+	Type        string    `db:"type"`
+	Sort        int       `db:"sort"`
+	Source      string    `db:"source"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+
+	Name   string `db:"name"`
+	Code   int    `db:"code"`
+	Active string `db:"active"`
+}
+
+func (e LinkEnt) ToResponse() LinkResponse {
+	return LinkResponse{
+		LinkedUUID: e.LinkedUUID,
+		Name:       e.Name,
+		Code:       e.Code,
+		Active:     e.Active == "Y",
+		Sort:       e.Sort,
+		Source:     e.Source,
+		UpdatedAt:  e.UpdatedAt,
+	}
+}
+
+// LinkedProductEnt — связанный товар, который можно купить: включён, есть цена и остаток
+type LinkedProductEnt struct {
+	Type         string    `db:"type"`
+	UUID         uuid.UUID `db:"uuid"`
+	Name         string    `db:"name"`
+	Slug         string    `db:"slug"`
+	Code         int       `db:"code"`
+	Article      *string   `db:"article"`
+	Unit         *string   `db:"unit"`
+	Price        float64   `db:"price"`
+	Stock        int       `db:"stock"`
+	Rating       *float64  `db:"rating"`
+	ReviewsCount int       `db:"reviews_count"`
+}
+
+func (e LinkedProductEnt) ToResponse() LinkedProductResponse {
+	return LinkedProductResponse{
+		UUID:         e.UUID,
+		Name:         e.Name,
+		Slug:         e.Slug,
+		Code:         e.Code,
+		Article:      e.Article,
+		Unit:         e.Unit,
+		Price:        e.Price,
+		Stock:        e.Stock,
+		Rating:       e.Rating,
+		ReviewsCount: e.ReviewsCount,
+	}
+}
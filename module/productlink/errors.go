@@ -0,0 +1,5 @@
+package productlink
+
+import "errors"
+
+var ErrProductNotFound = errors.New("product not found")
@@ -0,0 +1,243 @@
+package productlink
+
+import (
+	"errors"
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/module/auth"
+	"go-monolite/module/cart"
+	"go-monolite/module/user"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	middlewareAuth "go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const defaultRelatedLimit = 12
+
+// Handler — связи товаров. Запись защищена в роутере правом на запись каталога или scope ключа интеграции
+type Handler struct {
+	service  *Service
+	userAuth func(next http.Handler) http.Handler
+}
+
+// NewHandler — userAuth (middlewareAuth.UserAuth) собирается один раз в роутере сервера, гости проходят без него
+func NewHandler(store *store.Store, cfg *config.Config, userAuth func(next http.Handler) http.Handler) *Handler {
+	carts := cart.NewService(store, cart.NewRepository(store), user.NewRepository(store), cfg.Cart)
+	return &Handler{
+		service:  NewService(store, NewRepository(store), carts),
+		userAuth: middlewareAuth.Optional(userAuth),
+	}
+}
+
+func (h *Handler) Init(r chi.Router) {
+	r.With(h.userAuth).Get("/{productUuid}", h.Related)
+	r.Post("/upsert", h.Upsert)
+	r.Get("/{productUuid}/{type}", h.Links)
+	r.Put("/{productUuid}/{type}", h.Set)
+	r.Delete("/{productUuid}/{type}", h.Clear)
+}
+
+// @Summary Related products
+// @Description Get accessories, analogues and products bought together for the product card. Only active products with the customer's price and stock are returned, in the merchandiser's order
+// @Tags product-link
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param Device-Uid header string false "Device ID of a guest"
+// @Param productUuid path string true "Product UUID"
+// @Param type query string false "Link type: accessory, analogue, bought_together"
+// @Param limit query int false "Limit per link type" default(12)
+// @Success 200 {object} respond.SuccessResponse{data=RelatedResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{productUuid} [get]
+func (h *Handler) Related(w http.ResponseWriter, r *http.Request) {
+	productUUID, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	request := ListRequest{Type: r.URL.Query().Get("type")}
+	var err error
+	if request.Limit, err = queryInt(r, "limit", defaultRelatedLimit); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный limit")
+		return
+	}
+
+	identity, _ := middlewareAuth.CurrentUser(r.Context())
+	owner := cart.Owner{UserID: identity.UserID, DeviceID: r.Header.Get(auth.DeviceHeader)}
+
+	resp, mess, err := h.service.Related(r.Context(), owner, productUUID, request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Product links
+// @Description Get all links of the product of one type in order, including inactive products
+// @Tags product-link
+// @Produce json
+// @Param productUuid path string true "Product UUID"
+// @Param type path string true "Link type: accessory, analogue, bought_together"
+// @Success 200 {object} respond.SuccessResponse{data=LinksResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{productUuid}/{type} [get]
+func (h *Handler) Links(w http.ResponseWriter, r *http.Request) {
+	productUUID, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	resp, mess, err := h.service.Links(r.Context(), productUUID, chi.URLParam(r, "type"))
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Set product links
+// @Description Replace links of the product of one type, the list order is the display order. Links received from the exchange are replaced too
+// @Tags product-link
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param productUuid path string true "Product UUID"
+// @Param type path string true "Link type: accessory, analogue, bought_together"
+// @Param request body SetRequest true "Linked products"
+// @Success 200 {object} respond.SuccessResponse{data=LinksResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{productUuid}/{type} [put]
+func (h *Handler) Set(w http.ResponseWriter, r *http.Request) {
+	productUUID, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	body := respond.ParseBody(w, r)
+
+	var request SetRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.Set(r.Context(), productUUID, chi.URLParam(r, "type"), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "связи сохранены", resp)
+}
+
+// @Summary Clear product links
+// @Description Delete all links of the product of one type
+// @Tags product-link
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param productUuid path string true "Product UUID"
+// @Param type path string true "Link type: accessory, analogue, bought_together"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{productUuid}/{type} [delete]
+func (h *Handler) Clear(w http.ResponseWriter, r *http.Request) {
+	productUUID, ok := pathUUID(w, r)
+	if !ok {
+		return
+	}
+
+	mess, err := h.service.Clear(r.Context(), productUUID, chi.URLParam(r, "type"))
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess)
+}
+
+// @Summary Upsert product links
+// @Description Upsert product links from the exchange. For each product and type the previous exchange links are replaced, links set by merchandisers are kept. Links to unknown products are skipped
+// @Tags product-link
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token or API key"
+// @Param request body UpsertRequest true "Product links"
+// @Success 201 {object} respond.SuccessResponse{data=UpsertResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /upsert [post]
+func (h *Handler) Upsert(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request UpsertRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.Upsert(r.Context(), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusCreated, "", resp)
+}
+
+func pathUUID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "productUuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный UUID товара")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, err error, mess string) {
+	var validationErrors validator.ValidationError
+	if errors.As(err, &validationErrors) {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+	default:
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+	}
+}
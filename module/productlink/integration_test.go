@@ -0,0 +1,174 @@
+package productlink_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-monolite/module/productlink"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductLinkIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	access := testinit.NewAuth(store, config)
+	server := testinit.SetupTestServer(t, productlink.NewHandler(store, config, access.UserAuth))
+	defer server.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	// дрель и связанные с ней товары: у сверла есть цена и остаток, у бит нет остатка,
+	// у кейса нет цены, архивный патрон выключен
+	categoryUUID, typePriceUUID, storageUUID := uuid.New(), uuid.New(), uuid.New()
+	drill, bit, screwBits, caseBox, chuck, drill2 := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	for _, query := range []string{
+		fmt.Sprintf(`INSERT INTO categories (uuid, slug, name) VALUES ('%s', 'tools', 'Инструмент')`, categoryUUID),
+		fmt.Sprintf(`INSERT INTO type_price (uuid, name) VALUES ('%s', 'Розничная')`, typePriceUUID),
+		fmt.Sprintf(`INSERT INTO storage (uuid, name) VALUES ('%s', 'Основной')`, storageUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES
+			('%s', 'Дрель', 9301, 'drill', '%s'),
+			('%s', 'Сверло', 9302, 'bit', '%s'),
+			('%s', 'Набор бит', 9303, 'screw-bits', '%s'),
+			('%s', 'Кейс', 9304, 'case', '%s'),
+			('%s', 'Дрель ударная', 9306, 'drill-2', '%s')`,
+			drill, categoryUUID, bit, categoryUUID, screwBits, categoryUUID, caseBox, categoryUUID, drill2, categoryUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid, active) VALUES ('%s', 'Патрон', 9305, 'chuck', '%s', 'N')`, chuck, categoryUUID),
+		fmt.Sprintf(`INSERT INTO product_prices (product_uuid, type_price_uuid, price) VALUES
+			('%s', '%s', 350), ('%s', '%s', 900), ('%s', '%s', 500), ('%s', '%s', 7000)`,
+			bit, typePriceUUID, screwBits, typePriceUUID, chuck, typePriceUUID, drill2, typePriceUUID),
+		fmt.Sprintf(`INSERT INTO product_storages (product_uuid, storage_uuid, quantity) VALUES
+			('%s', '%s', 12), ('%s', '%s', 0), ('%s', '%s', 3), ('%s', '%s', 5), ('%s', '%s', 2)`,
+			bit, storageUUID, screwBits, storageUUID, caseBox, storageUUID, chuck, storageUUID, drill2, storageUUID),
+	} {
+		_, err := store.Db.Exec(query)
+		require.NoError(t, err)
+	}
+
+	send := func(t *testing.T, method, path string, body any) *http.Response {
+		t.Helper()
+		var payload []byte
+		if body != nil {
+			var err error
+			payload, err = json.Marshal(body)
+			require.NoError(t, err)
+		}
+		req, err := http.NewRequest(method, server.URL+path, bytes.NewReader(payload))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	decode := func(t *testing.T, resp *http.Response, status int, v any) {
+		t.Helper()
+		require.Equal(t, status, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		testinit.MarshalUnmarshal(t, response.Data, v)
+	}
+
+	t.Run("Set", func(t *testing.T) {
+		var links productlink.LinksResponse
+		decode(t, send(t, http.MethodPut, "/"+drill.String()+"/accessory", productlink.SetRequest{
+			Products: []uuid.UUID{caseBox, bit, screwBits, chuck},
+		}), http.StatusOK, &links)
+		require.Len(t, links.Items, 4)
+		assert.Equal(t, caseBox, links.Items[0].LinkedUUID)
+		assert.Equal(t, bit, links.Items[1].LinkedUUID)
+		assert.False(t, links.Items[3].Active)
+		assert.Equal(t, productlink.SourceManual, links.Items[0].Source)
+
+		// новый список заменяет прежний и задаёт порядок
+		decode(t, send(t, http.MethodPut, "/"+drill.String()+"/accessory", productlink.SetRequest{
+			Products: []uuid.UUID{bit, caseBox, screwBits, chuck},
+		}), http.StatusOK, &links)
+		require.Len(t, links.Items, 4)
+		assert.Equal(t, bit, links.Items[0].LinkedUUID)
+		assert.Equal(t, 0, links.Items[0].Sort)
+		assert.Equal(t, caseBox, links.Items[1].LinkedUUID)
+		assert.Equal(t, 1, links.Items[1].Sort)
+
+		resp := send(t, http.MethodPut, "/"+drill.String()+"/accessory", productlink.SetRequest{Products: []uuid.UUID{drill}})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, http.MethodPut, "/"+drill.String()+"/accessory", productlink.SetRequest{Products: []uuid.UUID{bit, bit}})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, http.MethodPut, "/"+drill.String()+"/accessory", productlink.SetRequest{Products: []uuid.UUID{uuid.New()}})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, http.MethodPut, "/"+drill.String()+"/spare", productlink.SetRequest{Products: []uuid.UUID{bit}})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, http.MethodPut, "/"+uuid.New().String()+"/accessory", productlink.SetRequest{Products: []uuid.UUID{bit}})
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Related", func(t *testing.T) {
+		// в карточку попадают только товары с ценой и остатком
+		var related productlink.RelatedResponse
+		decode(t, send(t, http.MethodGet, "/"+drill.String(), nil), http.StatusOK, &related)
+		require.Len(t, related.Accessory, 1)
+		assert.Equal(t, bit, related.Accessory[0].UUID)
+		assert.Equal(t, 350.0, related.Accessory[0].Price)
+		assert.Equal(t, 12, related.Accessory[0].Stock)
+		assert.Empty(t, related.Analogue)
+		assert.Empty(t, related.BoughtTogether)
+
+		resp := send(t, http.MethodGet, "/"+drill.String()+"?type=spare", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, http.MethodGet, "/"+drill.String()+"?limit=0", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Upsert", func(t *testing.T) {
+		// связь, заданная менеджером, остаётся ручной, неизвестные товары пропускаются
+		var result productlink.UpsertResponse
+		decode(t, send(t, http.MethodPost, "/upsert", productlink.UpsertRequest{Data: []productlink.ProductLinksDto{
+			{ProductUUID: drill, Type: productlink.TypeAnalogue, Links: []uuid.UUID{drill2, uuid.New(), drill}},
+			{ProductUUID: drill, Type: productlink.TypeAccessory, Links: []uuid.UUID{bit}},
+			{ProductUUID: uuid.New(), Type: productlink.TypeAnalogue, Links: []uuid.UUID{drill}},
+		}}), http.StatusCreated, &result)
+		assert.Equal(t, 1, result.CountInserted)
+		assert.Equal(t, 0, result.CountUpdated)
+		assert.Equal(t, 4, result.CountSkipped)
+
+		var links productlink.LinksResponse
+		decode(t, send(t, http.MethodGet, "/"+drill.String()+"/accessory", nil), http.StatusOK, &links)
+		require.Len(t, links.Items, 4)
+		assert.Equal(t, productlink.SourceManual, links.Items[0].Source)
+
+		var related productlink.RelatedResponse
+		decode(t, send(t, http.MethodGet, "/"+drill.String()+"?type=analogue", nil), http.StatusOK, &related)
+		require.Len(t, related.Analogue, 1)
+		assert.Equal(t, drill2, related.Analogue[0].UUID)
+		assert.Empty(t, related.Accessory)
+
+		// новая выгрузка заменяет прежние связи из обмена
+		decode(t, send(t, http.MethodPost, "/upsert", productlink.UpsertRequest{Data: []productlink.ProductLinksDto{
+			{ProductUUID: drill, Type: productlink.TypeAnalogue, Links: []uuid.UUID{}},
+		}}), http.StatusCreated, &result)
+		assert.Equal(t, 1, result.CountDeleted)
+
+		decode(t, send(t, http.MethodGet, "/"+drill.String()+"/analogue", nil), http.StatusOK, &links)
+		assert.Empty(t, links.Items)
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		resp := send(t, http.MethodDelete, "/"+drill.String()+"/accessory", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var links productlink.LinksResponse
+		decode(t, send(t, http.MethodGet, "/"+drill.String()+"/accessory", nil), http.StatusOK, &links)
+		assert.Empty(t, links.Items)
+	})
+}
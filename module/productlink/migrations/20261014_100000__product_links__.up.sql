@@ -0,0 +1,17 @@
+-- связи товаров: аксессуары, аналоги, покупают вместе. Порядок в пределах товара и типа задаёт sort.
+-- source разделяет связи менеджеров и связи из обмена, обмен заменяет только свои
+CREATE TABLE IF NOT EXISTS product_links (
+  id BIGSERIAL PRIMARY KEY,
+  product_uuid UUID NOT NULL REFERENCES products(uuid) ON DELETE CASCADE,
+  linked_uuid UUID NOT NULL REFERENCES products(uuid) ON DELETE CASCADE,
+  type VARCHAR(32) NOT NULL CHECK (type IN ('accessory', 'analogue', 'bought_together')),
+  sort INT NOT NULL DEFAULT 0,
+  source VARCHAR(16) NOT NULL DEFAULT 'manual' CHECK (source IN ('manual', 'exchange')),
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (product_uuid, type, linked_uuid),
+  CHECK (product_uuid <> linked_uuid)
+);
+
+CREATE INDEX IF NOT EXISTS product_links_product_idx ON product_links (product_uuid, type, sort);
+CREATE INDEX IF NOT EXISTS product_links_linked_idx ON product_links (linked_uuid);
@@ -0,0 +1,149 @@
+package productlink
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/internal/store"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	store     *store.Store
+	tableName string
+}
+
+func NewRepository(store *store.Store) *Repository {
+	return &Repository{
+		store:     store,
+		tableName: "product_links",
+	}
+}
+
+// ExistingProducts — какие товары из списка есть в каталоге и не в архиве
+func (r *Repository) ExistingProducts(ctx context.Context, uuids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	query := `SELECT uuid FROM products WHERE uuid = ANY($1) AND deleted_at IS NULL`
+
+	var found []uuid.UUID
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &found, query, pq.Array(uuids)); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	result := make(map[uuid.UUID]bool, len(found))
+	for _, id := range found {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// Delete удаляет связи товара этого типа, которых нет в keep. Если source не пустой — только связи из этого источника
+func (r *Repository) Delete(ctx context.Context, productUUID uuid.UUID, linkType string, keep []uuid.UUID, source string) (int, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE product_uuid = $1 AND type = $2 AND linked_uuid <> ALL($3) AND ($4 = '' OR source = $4)
+	`, r.tableName)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, productUUID, linkType, pq.Array(keep), source)
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+	return int(affected), nil
+}
+
+// Upsert добавляет связи в порядке списка и обновляет порядок существующих.
+// Связи из обмена не перезаписывают связи, заданные менеджером
+func (r *Repository) Upsert(ctx context.Context, productUUID uuid.UUID, linkType string, linked []uuid.UUID, source string) (inserted, updated int, err error) {
+	if len(linked) == 0 {
+		return 0, 0, nil
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (product_uuid, linked_uuid, type, sort, source, created_at, updated_at)
+		SELECT $1, linked_uuid, $2, sort - 1, $4, $5, $5
+		FROM UNNEST($3::uuid[]) WITH ORDINALITY AS t(linked_uuid, sort)
+		ON CONFLICT (product_uuid, type, linked_uuid) DO UPDATE SET
+			sort = EXCLUDED.sort, source = EXCLUDED.source, updated_at = EXCLUDED.updated_at
+		WHERE EXCLUDED.source = '%[2]s' OR %[1]s.source = '%[3]s'
+		RETURNING (xmax = 0) AS inserted
+	`, r.tableName, SourceManual, SourceExchange)
+
+	var rows []bool
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &rows, query,
+		productUUID, linkType, pq.Array(linked), source, time.Now(),
+	); err != nil {
+		return 0, 0, store.ContextError(err)
+	}
+
+	for _, isNew := range rows {
+		if isNew {
+			inserted++
+		} else {
+			updated++
+		}
+	}
+	return inserted, updated, nil
+}
+
+// Links — все связи товара этого типа по порядку, вместе с выключенными товарами, товары из архива выключены
+func (r *Repository) Links(ctx context.Context, productUUID uuid.UUID, linkType string) ([]LinkEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT l.id, l.product_uuid, l.linked_uuid, l.type, l.sort, l.source, l.created_at, l.updated_at,
+			p.name, p.code, CASE WHEN p.deleted_at IS NULL THEN p.active ELSE 'N' END AS active
+		FROM %s l
+		JOIN products p ON p.uuid = l.linked_uuid
+		WHERE l.product_uuid = $1 AND l.type = $2
+		ORDER BY l.sort, l.id
+	`, r.tableName)
+
+	var links []LinkEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &links, query, productUUID, linkType); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return links, nil
+}
+
+// Available — связанные товары, которые можно купить: включены, есть цена типа priceType и остаток
+// на включённых складах. По каждому типу не больше limit товаров
+func (r *Repository) Available(ctx context.Context, productUUID uuid.UUID, types []string, priceType *uuid.UUID, limit int) ([]LinkedProductEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT type, uuid, name, slug, code, article, unit, price, stock, rating, reviews_count
+		FROM (
+			SELECT l.type, p.uuid, p.name, p.slug, p.code, p.article, p.unit, p.rating, p.reviews_count,
+				pr.price, st.stock,
+				ROW_NUMBER() OVER (PARTITION BY l.type ORDER BY l.sort, l.id) AS position
+			FROM %s l
+			JOIN products p ON p.uuid = l.linked_uuid AND p.active = 'Y' AND p.deleted_at IS NULL
+			JOIN LATERAL (
+				SELECT pp.price FROM product_prices pp
+				WHERE pp.product_uuid = p.uuid AND pp.type_price_uuid = $3 AND pp.active = 'Y'
+				ORDER BY pp.updated_at DESC LIMIT 1
+			) pr ON pr.price > 0
+			JOIN LATERAL (
+				SELECT COALESCE(SUM(ps.quantity), 0) AS stock FROM product_storages ps
+				JOIN storage s ON s.uuid = ps.storage_uuid AND s.active = 'Y' AND s.deleted_at IS NULL
+				WHERE ps.product_uuid = p.uuid AND ps.active = 'Y'
+			) st ON st.stock > 0
+			WHERE l.product_uuid = $1 AND l.type = ANY($2)
+		) related
+		WHERE position <= $4
+		ORDER BY type, position
+	`, r.tableName)
+
+	var products []LinkedProductEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &products, query,
+		productUUID, pq.Array(types), priceType, limit,
+	); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return products, nil
+}
@@ -0,0 +1,230 @@
+package productlink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"go-monolite/module/cart"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/validator"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	store *store.Store
+	repo  *Repository
+	carts *cart.Service
+}
+
+func NewService(store *store.Store, repo *Repository, carts *cart.Service) *Service {
+	return &Service{
+		store: store,
+		repo:  repo,
+		carts: carts,
+	}
+}
+
+// Related — связанные товары для карточки по ценам покупателя, только те, что можно купить
+func (s *Service) Related(ctx context.Context, owner cart.Owner, productUUID uuid.UUID, req ListRequest) (*RelatedResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	resp := &RelatedResponse{
+		Accessory:      []LinkedProductResponse{},
+		Analogue:       []LinkedProductResponse{},
+		BoughtTogether: []LinkedProductResponse{},
+	}
+
+	priceType, err := s.carts.PriceType(ctx, owner)
+	if err != nil {
+		return nil, "произошла ошибка при получении связанных товаров", err
+	}
+	if priceType == nil {
+		return resp, "", nil
+	}
+
+	types := Types
+	if req.Type != "" {
+		types = []string{req.Type}
+	}
+
+	products, err := s.repo.Available(ctx, productUUID, types, priceType, req.Limit)
+	if err != nil {
+		return nil, "произошла ошибка при получении связанных товаров", err
+	}
+
+	for _, p := range products {
+		switch p.Type {
+		case TypeAccessory:
+			resp.Accessory = append(resp.Accessory, p.ToResponse())
+		case TypeAnalogue:
+			resp.Analogue = append(resp.Analogue, p.ToResponse())
+		case TypeBoughtTogether:
+			resp.BoughtTogether = append(resp.BoughtTogether, p.ToResponse())
+		}
+	}
+
+	return resp, "", nil
+}
+
+// Links — связи товара одного типа для менеджера, вместе с выключенными товарами
+func (s *Service) Links(ctx context.Context, productUUID uuid.UUID, linkType string) (*LinksResponse, string, error) {
+	if err := checkType(linkType); err != nil {
+		return nil, "", err
+	}
+
+	links, err := s.repo.Links(ctx, productUUID, linkType)
+	if err != nil {
+		return nil, "произошла ошибка при получении связей", err
+	}
+
+	return &LinksResponse{ProductUUID: productUUID, Type: linkType, Items: helper.ToResponse(links)}, "", nil
+}
+
+// Set заменяет связи товара одного типа списком менеджера, в том числе пришедшие из обмена
+func (s *Service) Set(ctx context.Context, productUUID uuid.UUID, linkType string, req SetRequest) (*LinksResponse, string, error) {
+	if err := checkType(linkType); err != nil {
+		return nil, "", err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	fields := map[string]string{}
+	seen := make(map[uuid.UUID]bool, len(req.Products))
+	for i, linked := range req.Products {
+		key := fmt.Sprintf("products[%d]", i)
+		switch {
+		case linked == productUUID:
+			fields[key] = "Товар не может быть связан сам с собой"
+		case seen[linked]:
+			fields[key] = "Товар указан дважды"
+		}
+		seen[linked] = true
+	}
+	if len(fields) > 0 {
+		return nil, "", validator.ValidationError{Err: validator.ErrorValidation, Fields: fields}
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		existing, err := s.repo.ExistingProducts(ctx, append([]uuid.UUID{productUUID}, req.Products...))
+		if err != nil {
+			return err
+		}
+		if !existing[productUUID] {
+			return ErrProductNotFound
+		}
+		for i, linked := range req.Products {
+			if !existing[linked] {
+				fields[fmt.Sprintf("products[%d]", i)] = "Товар не найден"
+			}
+		}
+		if len(fields) > 0 {
+			return validator.ValidationError{Err: validator.ErrorValidation, Fields: fields}
+		}
+
+		if _, err := s.repo.Delete(ctx, productUUID, linkType, req.Products, ""); err != nil {
+			return err
+		}
+		_, _, err = s.repo.Upsert(ctx, productUUID, linkType, req.Products, SourceManual)
+		return err
+	})
+	if err != nil {
+		return nil, linkMessage(err, "произошла ошибка при сохранении связей"), err
+	}
+
+	return s.Links(ctx, productUUID, linkType)
+}
+
+// Clear удаляет все связи товара одного типа
+func (s *Service) Clear(ctx context.Context, productUUID uuid.UUID, linkType string) (string, error) {
+	if err := checkType(linkType); err != nil {
+		return "", err
+	}
+
+	if _, err := s.repo.Delete(ctx, productUUID, linkType, nil, ""); err != nil {
+		return "произошла ошибка при удалении связей", err
+	}
+
+	return "связи удалены", nil
+}
+
+// Upsert сохраняет связи из обмена: для каждого товара и типа заменяет прежние связи из обмена,
+// связи менеджеров не трогает. Связи с товарами, которых нет в каталоге, и с самим товаром пропускаются
+func (s *Service) Upsert(ctx context.Context, req UpsertRequest) (*UpsertResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	resp := &UpsertResponse{}
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		var all []uuid.UUID
+		for _, item := range req.Data {
+			all = append(all, item.ProductUUID)
+			all = append(all, item.Links...)
+		}
+		existing, err := s.repo.ExistingProducts(ctx, all)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range req.Data {
+			if !existing[item.ProductUUID] {
+				resp.CountSkipped += len(item.Links)
+				continue
+			}
+
+			links := make([]uuid.UUID, 0, len(item.Links))
+			seen := make(map[uuid.UUID]bool, len(item.Links))
+			for _, linked := range item.Links {
+				if linked == item.ProductUUID || !existing[linked] || seen[linked] {
+					resp.CountSkipped++
+					continue
+				}
+				seen[linked] = true
+				links = append(links, linked)
+			}
+
+			deleted, err := s.repo.Delete(ctx, item.ProductUUID, item.Type, links, SourceExchange)
+			if err != nil {
+				return err
+			}
+			inserted, updated, err := s.repo.Upsert(ctx, item.ProductUUID, item.Type, links, SourceExchange)
+			if err != nil {
+				return err
+			}
+
+			resp.CountDeleted += deleted
+			resp.CountInserted += inserted
+			resp.CountUpdated += updated
+			// связь уже задана менеджером
+			resp.CountSkipped += len(links) - inserted - updated
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "произошла ошибка при обновлении связей", err
+	}
+
+	return resp, "", nil
+}
+
+func checkType(linkType string) error {
+	for _, t := range Types {
+		if t == linkType {
+			return nil
+		}
+	}
+	return validator.ValidationError{Err: validator.ErrorValidation, Fields: map[string]string{"type": "Тип связи: accessory, analogue или bought_together"}}
+}
+
+func linkMessage(err error, fallback string) string {
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		return "товар не найден"
+	}
+	return fallback
+}
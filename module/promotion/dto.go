@@ -0,0 +1,165 @@
+package promotion
+
+import (
+	"go-monolite/pkg/validator"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PromotionRequest — DTO для POST /promotion и PUT /promotion/{id}. Пустые списки условий — без ограничения
+type PromotionRequest struct {
+	Name            string      `json:"name" validate:"required,max=255" example:"Скидка 10% на инструмент"`
+	Description     *string     `json:"description,omitempty"`
+	Active          *bool       `json:"active,omitempty"` // по умолчанию включена
+	Priority        int         `json:"priority" example:"10"`
+	Exclusive       bool        `json:"exclusive"`
+	ByCode          bool        `json:"by_code"`
+	CategoryUUIDs   []uuid.UUID `json:"category_uuids,omitempty" validate:"max=100"`
+	ProductUUIDs    []uuid.UUID `json:"product_uuids,omitempty" validate:"max=1000"`
+	UserTypes       []string    `json:"user_types,omitempty" validate:"omitempty,dive,oneof=individual legal"`
+	CompanyIDs      []int64     `json:"company_ids,omitempty" validate:"omitempty,max=100,dive,gt=0"`
+	MinTotal        *float64    `json:"min_total,omitempty" validate:"omitempty,gt=0"`
+	StartsAt        *time.Time  `json:"starts_at,omitempty"`
+	EndsAt          *time.Time  `json:"ends_at,omitempty"`
+	Action          string      `json:"action" validate:"required,oneof=percent fixed price bundle gift" example:"percent"`
+	Value           *float64    `json:"value,omitempty" validate:"required_if=Action percent,required_if=Action fixed,required_if=Action price,omitempty,gte=0" example:"10"`
+	BuyQuantity     *int        `json:"buy_quantity,omitempty" validate:"required_if=Action bundle,omitempty,gt=1" example:"3"`
+	PayQuantity     *int        `json:"pay_quantity,omitempty" validate:"required_if=Action bundle,omitempty,gte=0" example:"2"`
+	GiftProductUUID *uuid.UUID  `json:"gift_product_uuid,omitempty" validate:"required_if=Action gift"`
+	GiftQuantity    *int        `json:"gift_quantity,omitempty" validate:"omitempty,gt=0,max=1000"`
+}
+
+func (d *PromotionRequest) Validate() error {
+	if err := validator.Validate(d); err != nil {
+		return err
+	}
+
+	fields := map[string]string{}
+	if d.Action == ActionPercent && *d.Value > 100 {
+		fields["value"] = "Скидка не может быть больше 100%"
+	}
+	if d.Action == ActionBundle && *d.PayQuantity >= *d.BuyQuantity {
+		fields["pay_quantity"] = "Оплачиваемых штук должно быть меньше, чем в комплекте"
+	}
+	if d.StartsAt != nil && d.EndsAt != nil && !d.EndsAt.After(*d.StartsAt) {
+		fields["ends_at"] = "Акция должна заканчиваться позже, чем начинается"
+	}
+	if len(fields) > 0 {
+		return validator.ValidationError{Err: validator.ErrorValidation, Fields: fields}
+	}
+
+	return nil
+}
+
+// CodeRequest — DTO для POST /promotion/{id}/codes
+type CodeRequest struct {
+	Code         string `json:"code" validate:"required,min=3,max=50,alphanum" example:"SUMMER"`
+	UsageLimit   *int   `json:"usage_limit,omitempty" validate:"omitempty,gt=0" example:"100"`
+	PerUserLimit *int   `json:"per_user_limit,omitempty" validate:"omitempty,gt=0" example:"1"`
+}
+
+func (d *CodeRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// EvaluateRequest — DTO для POST /promotion/evaluate
+type EvaluateRequest struct {
+	Items     []EvaluateItem `json:"items" validate:"required,min=1,max=100,dive"`
+	PromoCode string         `json:"promo_code,omitempty" validate:"max=50" example:"SUMMER"`
+}
+
+type EvaluateItem struct {
+	ProductUUID uuid.UUID `json:"product_uuid" validate:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Quantity    int       `json:"quantity" validate:"required,gt=0,max=100000" example:"3"`
+}
+
+func (d *EvaluateRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+type PromotionResponse struct {
+	ID              int64       `json:"id" example:"1"`
+	Name            string      `json:"name" example:"Скидка 10% на инструмент"`
+	Description     *string     `json:"description,omitempty"`
+	Active          bool        `json:"active"`
+	Priority        int         `json:"priority" example:"10"`
+	Exclusive       bool        `json:"exclusive"`
+	ByCode          bool        `json:"by_code"`
+	CategoryUUIDs   []uuid.UUID `json:"category_uuids"`
+	ProductUUIDs    []uuid.UUID `json:"product_uuids"`
+	UserTypes       []string    `json:"user_types"`
+	CompanyIDs      []int64     `json:"company_ids"`
+	MinTotal        *float64    `json:"min_total,omitempty"`
+	StartsAt        *time.Time  `json:"starts_at,omitempty"`
+	EndsAt          *time.Time  `json:"ends_at,omitempty"`
+	Action          string      `json:"action" example:"percent"`
+	Value           *float64    `json:"value,omitempty" example:"10"`
+	BuyQuantity     *int        `json:"buy_quantity,omitempty"`
+	PayQuantity     *int        `json:"pay_quantity,omitempty"`
+	GiftProductUUID *uuid.UUID  `json:"gift_product_uuid,omitempty"`
+	GiftQuantity    *int        `json:"gift_quantity,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+}
+
+type CodeResponse struct {
+	ID           int64     `json:"id" example:"1"`
+	Code         string    `json:"code" example:"SUMMER"`
+	UsageLimit   *int      `json:"usage_limit,omitempty" example:"100"`
+	PerUserLimit *int      `json:"per_user_limit,omitempty" example:"1"`
+	UsedCount    int       `json:"used_count" example:"3"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type AppliedResponse struct {
+	PromotionID int64   `json:"promotion_id" example:"1"`
+	Name        string  `json:"name" example:"Скидка 10% на инструмент"`
+	Discount    float64 `json:"discount" example:"250"`
+}
+
+type LineResponse struct {
+	ProductUUID uuid.UUID         `json:"product_uuid"`
+	Quantity    int               `json:"quantity" example:"3"`
+	Price       float64           `json:"price" example:"2500"` // цена до скидки
+	BaseSum     float64           `json:"base_sum" example:"7500"`
+	Discount    float64           `json:"discount" example:"2500"`
+	Sum         float64           `json:"sum" example:"5000"`
+	Promotions  []AppliedResponse `json:"promotions"`
+}
+
+type GiftResponse struct {
+	PromotionID int64     `json:"promotion_id" example:"2"`
+	ProductUUID uuid.UUID `json:"product_uuid"`
+	Name        string    `json:"name" example:"Набор бит"`
+	Code        int       `json:"code" example:"1002"`
+	Article     *string   `json:"article,omitempty"`
+	Unit        *string   `json:"unit,omitempty"`
+	Quantity    int       `json:"quantity" example:"1"`
+}
+
+type EvaluateResponse struct {
+	Items       []LineResponse `json:"items"`
+	Gifts       []GiftResponse `json:"gifts"`
+	BaseTotal   float64        `json:"base_total" example:"7500"`
+	Discount    float64        `json:"discount" example:"2500"`
+	Total       float64        `json:"total" example:"5000"`
+	PromoCode   string         `json:"promo_code,omitempty" example:"SUMMER"`
+	CodeApplied bool           `json:"code_applied"` // промокод действителен и его акция сработала
+}
+
+// CardResponse — цена товара в карточке с учётом акций
+type CardResponse struct {
+	ProductUUID uuid.UUID         `json:"product_uuid"`
+	Price       float64           `json:"price" example:"2500"`
+	FinalPrice  float64           `json:"final_price" example:"2250"` // за единицу при заданном количестве
+	Discount    float64           `json:"discount" example:"250"`
+	Promotions  []AppliedResponse `json:"promotions"`
+	Gifts       []GiftResponse    `json:"gifts"`
+}
+
+type ListResponse struct {
+	Items []PromotionResponse `json:"items"`
+	Total int                 `json:"total"`
+}
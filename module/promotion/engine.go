@@ -0,0 +1,211 @@
+package promotion
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Line — позиция для расчёта: цена до скидок и категория товара вместе со всеми родительскими
+type Line struct {
+	ProductUUID uuid.UUID
+	Quantity    int
+	Price       float64
+	Categories  []uuid.UUID
+}
+
+// Customer — покупатель, для которого считаются скидки, у гостя UserID нулевой
+type Customer struct {
+	UserID    int64
+	UserType  string
+	CompanyID *int64
+}
+
+// Applied — скидка одной акции на позиции
+type Applied struct {
+	PromotionID int64
+	Name        string
+	Discount    float64
+}
+
+type LineResult struct {
+	ProductUUID uuid.UUID
+	Quantity    int
+	Price       float64
+	BaseSum     float64
+	Discount    float64
+	Sum         float64
+	Promotions  []Applied
+
+	locked bool // позиция получила exclusive-акцию, другие к ней не применяются
+}
+
+type Gift struct {
+	PromotionID int64
+	ProductUUID uuid.UUID
+	Quantity    int
+}
+
+type Evaluation struct {
+	Lines     []LineResult
+	Gifts     []Gift
+	BaseTotal float64
+	Discount  float64
+	Total     float64
+	Applied   []int64 // сработавшие акции в порядке применения
+
+	PromoCode   string
+	CodeApplied bool // акция промокода сработала хотя бы на одной позиции
+}
+
+// Evaluate считает скидки позиций. Акции перебираются по убыванию priority, при равном — по id,
+// каждая следующая считается от цены после предыдущих. Exclusive-акция применяется только к позициям
+// без других скидок и закрывает их для следующих акций. Результат зависит только от входных данных
+func Evaluate(promotions []PromotionEnt, customer Customer, lines []Line, now time.Time) *Evaluation {
+	result := &Evaluation{Lines: make([]LineResult, 0, len(lines))}
+	for _, line := range lines {
+		sum := roundMoney(line.Price * float64(line.Quantity))
+		result.Lines = append(result.Lines, LineResult{
+			ProductUUID: line.ProductUUID,
+			Quantity:    line.Quantity,
+			Price:       line.Price,
+			BaseSum:     sum,
+			Sum:         sum,
+		})
+		result.BaseTotal += sum
+	}
+	result.BaseTotal = roundMoney(result.BaseTotal)
+
+	ordered := slices.Clone(promotions)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority > ordered[j].Priority
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	for i := range ordered {
+		p := &ordered[i]
+		if !p.Running(now) || !matchesCustomer(p, customer) {
+			continue
+		}
+		if p.MinTotal != nil && result.BaseTotal < *p.MinTotal {
+			continue
+		}
+
+		var applicable []int
+		for j := range lines {
+			state := &result.Lines[j]
+			if state.locked || (p.Exclusive && len(state.Promotions) > 0) || !matchesLine(p, &lines[j]) {
+				continue
+			}
+			applicable = append(applicable, j)
+		}
+		if len(applicable) == 0 {
+			continue
+		}
+
+		applied := false
+		if p.Action == ActionGift {
+			result.Gifts = append(result.Gifts, Gift{PromotionID: p.ID, ProductUUID: *p.GiftProductUUID, Quantity: *p.GiftQuantity})
+			applied = true
+		} else {
+			for _, j := range applicable {
+				state := &result.Lines[j]
+				discount := lineDiscount(p, state)
+				if discount <= 0 {
+					continue
+				}
+				state.Sum = roundMoney(state.Sum - discount)
+				state.Discount = roundMoney(state.Discount + discount)
+				state.Promotions = append(state.Promotions, Applied{PromotionID: p.ID, Name: p.Name, Discount: discount})
+				applied = true
+			}
+		}
+		if !applied {
+			continue
+		}
+
+		if p.Exclusive {
+			for _, j := range applicable {
+				result.Lines[j].locked = true
+			}
+		}
+		result.Applied = append(result.Applied, p.ID)
+	}
+
+	for _, line := range result.Lines {
+		result.Discount += line.Discount
+	}
+	result.Discount = roundMoney(result.Discount)
+	result.Total = roundMoney(result.BaseTotal - result.Discount)
+
+	return result
+}
+
+// lineDiscount — скидка акции на позицию от её текущей суммы, не больше самой суммы.
+// У позиции без количества скидки нет: цена единицы для bundle не определена
+func lineDiscount(p *PromotionEnt, line *LineResult) float64 {
+	if line.Quantity <= 0 {
+		return 0
+	}
+
+	quantity := float64(line.Quantity)
+	var discount float64
+	switch p.Action {
+	case ActionPercent:
+		discount = line.Sum * value(p.Value) / 100
+	case ActionFixed:
+		discount = value(p.Value) * quantity
+	case ActionPrice:
+		discount = line.Sum - value(p.Value)*quantity
+	case ActionBundle:
+		if p.BuyQuantity == nil || p.PayQuantity == nil || *p.BuyQuantity <= 0 {
+			return 0
+		}
+		free := (line.Quantity / *p.BuyQuantity) * (*p.BuyQuantity - *p.PayQuantity)
+		discount = line.Sum / quantity * float64(free)
+	}
+
+	return roundMoney(math.Max(0, math.Min(discount, line.Sum)))
+}
+
+func matchesCustomer(p *PromotionEnt, customer Customer) bool {
+	if len(p.UserTypes) > 0 && !slices.Contains(p.UserTypes, customer.UserType) {
+		return false
+	}
+	if len(p.CompanyIDs) > 0 && (customer.CompanyID == nil || !slices.Contains(p.CompanyIDs, *customer.CompanyID)) {
+		return false
+	}
+	return true
+}
+
+// matchesLine — товар из списка акции или из её категорий; акция без товаров и категорий действует на всю корзину
+func matchesLine(p *PromotionEnt, line *Line) bool {
+	if len(p.ProductUUIDs) == 0 && len(p.CategoryUUIDs) == 0 {
+		return true
+	}
+	if slices.Contains(p.ProductUUIDs, line.ProductUUID.String()) {
+		return true
+	}
+	for _, category := range line.Categories {
+		if slices.Contains(p.CategoryUUIDs, category.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+func value(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,182 @@
+package promotion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	drill, screws, gift, tools := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	promo := func(id int64, action string, value float64, opts ...func(*PromotionEnt)) PromotionEnt {
+		p := PromotionEnt{ID: id, Name: action, Active: true, Action: action, Value: &value}
+		for _, opt := range opts {
+			opt(&p)
+		}
+		return p
+	}
+	priority := func(n int) func(*PromotionEnt) {
+		return func(p *PromotionEnt) { p.Priority = n }
+	}
+	exclusive := func(p *PromotionEnt) { p.Exclusive = true }
+	products := func(ids ...uuid.UUID) func(*PromotionEnt) {
+		return func(p *PromotionEnt) {
+			for _, id := range ids {
+				p.ProductUUIDs = append(p.ProductUUIDs, id.String())
+			}
+		}
+	}
+	minTotal := func(v float64) func(*PromotionEnt) {
+		return func(p *PromotionEnt) { p.MinTotal = &v }
+	}
+	bundle := func(id int64, buy, pay int) PromotionEnt {
+		return PromotionEnt{ID: id, Name: ActionBundle, Active: true, Action: ActionBundle, BuyQuantity: &buy, PayQuantity: &pay}
+	}
+	giftOf := func(id int64, product uuid.UUID, quantity int, opts ...func(*PromotionEnt)) PromotionEnt {
+		p := PromotionEnt{ID: id, Name: ActionGift, Active: true, Action: ActionGift, GiftProductUUID: &product, GiftQuantity: &quantity}
+		for _, opt := range opts {
+			opt(&p)
+		}
+		return p
+	}
+
+	drills := func(quantity int) Line {
+		return Line{ProductUUID: drill, Quantity: quantity, Price: 100, Categories: []uuid.UUID{tools}}
+	}
+	screwsLine := Line{ProductUUID: screws, Quantity: 1, Price: 50}
+
+	tests := []struct {
+		name       string
+		promotions []PromotionEnt
+		lines      []Line
+		sums       []float64 // суммы позиций после скидок
+		applied    []int64
+		gifts      []Gift
+	}{
+		{
+			name:       "higher priority first",
+			promotions: []PromotionEnt{promo(1, ActionPercent, 10), promo(2, ActionFixed, 20, priority(5))},
+			lines:      []Line{drills(1)},
+			sums:       []float64{72},
+			applied:    []int64{2, 1},
+		},
+		{
+			name:       "equal priority by id",
+			promotions: []PromotionEnt{promo(2, ActionPercent, 10), promo(1, ActionFixed, 20)},
+			lines:      []Line{drills(1)},
+			sums:       []float64{72},
+			applied:    []int64{1, 2},
+		},
+		{
+			name:       "exclusive locks its lines",
+			promotions: []PromotionEnt{promo(1, ActionPercent, 50, priority(10), exclusive, products(drill)), promo(2, ActionPercent, 10)},
+			lines:      []Line{drills(1), screwsLine},
+			sums:       []float64{50, 45},
+			applied:    []int64{1, 2},
+		},
+		{
+			name:       "exclusive skips discounted lines",
+			promotions: []PromotionEnt{promo(1, ActionPercent, 10, priority(10)), promo(2, ActionFixed, 5, exclusive)},
+			lines:      []Line{drills(1)},
+			sums:       []float64{90},
+			applied:    []int64{1},
+		},
+		{
+			name:       "exclusive without discount does not lock",
+			promotions: []PromotionEnt{promo(1, ActionPrice, 200, priority(10), exclusive), promo(2, ActionPercent, 10)},
+			lines:      []Line{drills(1)},
+			sums:       []float64{90},
+			applied:    []int64{2},
+		},
+		{
+			name:       "bundle three for two",
+			promotions: []PromotionEnt{bundle(1, 3, 2)},
+			lines:      []Line{drills(7)},
+			sums:       []float64{500},
+			applied:    []int64{1},
+		},
+		{
+			name:       "bundle buy less than pay",
+			promotions: []PromotionEnt{bundle(1, 2, 3)},
+			lines:      []Line{drills(4)},
+			sums:       []float64{400},
+		},
+		{
+			name:       "bundle zero buy quantity",
+			promotions: []PromotionEnt{bundle(1, 0, 0)},
+			lines:      []Line{drills(4)},
+			sums:       []float64{400},
+		},
+		{
+			name:       "bundle with zero pay quantity",
+			promotions: []PromotionEnt{bundle(1, 2, 0)},
+			lines:      []Line{drills(3)},
+			sums:       []float64{100},
+			applied:    []int64{1},
+		},
+		{
+			name:       "zero line quantity",
+			promotions: []PromotionEnt{bundle(1, 3, 2), promo(2, ActionFixed, 10)},
+			lines:      []Line{drills(0), screwsLine},
+			sums:       []float64{0, 40},
+			applied:    []int64{2},
+		},
+		{
+			name:       "below min total",
+			promotions: []PromotionEnt{promo(1, ActionPercent, 10, minTotal(300))},
+			lines:      []Line{drills(2)},
+			sums:       []float64{200},
+		},
+		{
+			name:       "min total reached",
+			promotions: []PromotionEnt{promo(1, ActionPercent, 10, minTotal(300))},
+			lines:      []Line{drills(3)},
+			sums:       []float64{270},
+			applied:    []int64{1},
+		},
+		{
+			name:       "discount clamped to line sum",
+			promotions: []PromotionEnt{promo(1, ActionFixed, 150), promo(2, ActionPercent, 10)},
+			lines:      []Line{drills(2)},
+			sums:       []float64{0},
+			applied:    []int64{1},
+		},
+		{
+			name:       "gift",
+			promotions: []PromotionEnt{giftOf(1, gift, 2, func(p *PromotionEnt) { p.CategoryUUIDs = []string{tools.String()} })},
+			lines:      []Line{drills(1), screwsLine},
+			sums:       []float64{100, 50},
+			applied:    []int64{1},
+			gifts:      []Gift{{PromotionID: 1, ProductUUID: gift, Quantity: 2}},
+		},
+		{
+			name:       "gift without matching lines",
+			promotions: []PromotionEnt{giftOf(1, gift, 1, products(drill))},
+			lines:      []Line{screwsLine},
+			sums:       []float64{50},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Evaluate(tt.promotions, Customer{}, tt.lines, now)
+
+			require.Len(t, result.Lines, len(tt.sums))
+			var total float64
+			for i, line := range result.Lines {
+				assert.Equal(t, tt.sums[i], line.Sum, "line %d", i)
+				assert.Equal(t, roundMoney(line.BaseSum-line.Sum), line.Discount, "line %d", i)
+				total += line.Sum
+			}
+			assert.Equal(t, tt.applied, result.Applied)
+			assert.Equal(t, tt.gifts, result.Gifts)
+			assert.Equal(t, roundMoney(total), result.Total)
+			assert.Equal(t, roundMoney(result.BaseTotal-result.Total), result.Discount)
+		})
+	}
+}
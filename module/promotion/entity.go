@@ -0,0 +1,138 @@
+package promotion
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// действия акций
+const (
+	ActionPercent = "percent" // скидка value процентов
+	ActionFixed   = "fixed"   // скидка value на единицу товара
+	ActionPrice   = "price"   // цена value за единицу, если она ниже текущей
+	ActionBundle  = "bundle"  // из каждых buy_quantity штук оплачиваются pay_quantity
+	ActionGift    = "gift"    // подарок gift_product_uuid в количестве gift_quantity
+)
+
+type PromotionEnt struct {
+	ID              int64          `db:"id"`
+	Name            string         `db:"name"`
+	Description     *string        `db:"description"`
+	Active          bool           `db:"active"`
+	Priority        int            `db:"priority"`
+	Exclusive       bool           `db:"exclusive"`
+	ByCode          bool           `db:"by_code"`
+	CategoryUUIDs   pq.StringArray `db:"category_uuids"`
+	ProductUUIDs    pq.StringArray `db:"product_uuids"`
+	UserTypes       pq.StringArray `db:"user_types"`
+	CompanyIDs      pq.Int64Array  `db:"company_ids"`
+	MinTotal        *float64       `db:"min_total"`
+	StartsAt        *time.Time     `db:"starts_at"`
+	EndsAt          *time.Time     `db:"ends_at"`
+	Action          string         `db:"action"`
+	Value           *float64       `db:"value"`
+	BuyQuantity     *int           `db:"buy_quantity"`
+	PayQuantity     *int           `db:"pay_quantity"`
+	GiftProductUUID *uuid.UUID     `db:"gift_product_uuid"`
+	GiftQuantity    *int           `db:"gift_quantity"`
+	CreatedAt       time.Time      `db:"created_at"`
+	UpdatedAt       time.Time      `db:"updated_at"`
+}
+
+func (e PromotionEnt) ToResponse() PromotionResponse {
+	return PromotionResponse{
+		ID:              e.ID,
+		Name:            e.Name,
+		Description:     e.Description,
+		Active:          e.Active,
+		Priority:        e.Priority,
+		Exclusive:       e.Exclusive,
+		ByCode:          e.ByCode,
+		CategoryUUIDs:   parseUUIDs(e.CategoryUUIDs),
+		ProductUUIDs:    parseUUIDs(e.ProductUUIDs),
+		UserTypes:       []string(e.UserTypes),
+		CompanyIDs:      []int64(e.CompanyIDs),
+		MinTotal:        e.MinTotal,
+		StartsAt:        e.StartsAt,
+		EndsAt:          e.EndsAt,
+		Action:          e.Action,
+		Value:           e.Value,
+		BuyQuantity:     e.BuyQuantity,
+		PayQuantity:     e.PayQuantity,
+		GiftProductUUID: e.GiftProductUUID,
+		GiftQuantity:    e.GiftQuantity,
+		CreatedAt:       e.CreatedAt,
+		UpdatedAt:       e.UpdatedAt,
+	}
+}
+
+// Running — акция включена и действует в момент now
+func (e *PromotionEnt) Running(now time.Time) bool {
+	if !e.Active {
+		return false
+	}
+	if e.StartsAt != nil && now.Before(*e.StartsAt) {
+		return false
+	}
+	return e.EndsAt == nil || now.Before(*e.EndsAt)
+}
+
+type CodeEnt struct {
+	ID           int64     `db:"id"`
+	PromotionID  int64     `db:"promotion_id"`
+	Code         string    `db:"code"`
+	UsageLimit   *int      `db:"usage_limit"`
+	PerUserLimit *int      `db:"per_user_limit"`
+	UsedCount    int       `db:"used_count"`
+	Active       bool      `db:"active"`
+	CreatedAt    time.Time `db:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+func (e CodeEnt) ToResponse() CodeResponse {
+	return CodeResponse{
+		ID:           e.ID,
+		Code:         e.Code,
+		UsageLimit:   e.UsageLimit,
+		PerUserLimit: e.PerUserLimit,
+		UsedCount:    e.UsedCount,
+		Active:       e.Active,
+		CreatedAt:    e.CreatedAt,
+	}
+}
+
+// Exhausted — общий лимит использований исчерпан
+func (e *CodeEnt) Exhausted() bool {
+	return e.UsageLimit != nil && e.UsedCount >= *e.UsageLimit
+}
+
+// ProductEnt — товар с ценой для расчёта скидок
+type ProductEnt struct {
+	UUID    uuid.UUID `db:"uuid"`
+	Name    string    `db:"name"`
+	Code    int       `db:"code"`
+	Article *string   `db:"article"`
+	Unit    *string   `db:"unit"`
+	Active  string    `db:"active"`
+	Price   *float64  `db:"price"`
+}
+
+func parseUUIDs(values []string) []uuid.UUID {
+	result := make([]uuid.UUID, 0, len(values))
+	for _, v := range values {
+		if id, err := uuid.Parse(v); err == nil {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func formatUUIDs(values []uuid.UUID) pq.StringArray {
+	result := make(pq.StringArray, 0, len(values))
+	for _, v := range values {
+		result = append(result, v.String())
+	}
+	return result
+}
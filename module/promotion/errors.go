@@ -0,0 +1,9 @@
+package promotion
+
+import "errors"
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrNoPrice         = errors.New("product has no price")
+	ErrCodeExists      = errors.New("promo code already exists")
+)
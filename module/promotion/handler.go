@@ -0,0 +1,441 @@
+package promotion
+
+import (
+	"errors"
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/module/cart"
+	"go-monolite/module/company"
+	"go-monolite/module/user"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const defaultListLimit = 50
+
+// Handler — расчёт скидок для покупателей и управление акциями для администраторов
+type Handler struct {
+	service     *Service
+	userAuth    func(next http.Handler) http.Handler
+	adminAuth   func(next http.Handler) http.Handler
+	requireRole func(next http.Handler) http.Handler
+}
+
+// NewHandler — userAuth, authenticate и permissions собираются один раз в роутере сервера
+func NewHandler(store *store.Store, cfg *config.Config, userAuth, authenticate func(next http.Handler) http.Handler, permissions auth.PermissionChecker) *Handler {
+	return &Handler{
+		service:     newService(store, cfg),
+		userAuth:    auth.Optional(userAuth),
+		adminAuth:   authenticate,
+		requireRole: auth.RequirePermission(permissions, user.PermissionPromotionsManage),
+	}
+}
+
+func newService(store *store.Store, cfg *config.Config) *Service {
+	userRepo := user.NewRepository(store)
+	carts := cart.NewService(store, cart.NewRepository(store), userRepo, cfg.Cart)
+	return NewService(store, NewRepository(store), carts, userRepo, company.NewRepository(store))
+}
+
+func (h *Handler) Init(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.userAuth)
+
+		r.Post("/evaluate", h.Evaluate)
+		r.Get("/product/{productUuid}", h.Card)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.adminAuth, h.requireRole)
+
+		r.Get("/", h.GetList)
+		r.Post("/", h.Create)
+		r.Get("/{id}", h.Get)
+		r.Put("/{id}", h.Update)
+		r.Delete("/{id}", h.Delete)
+		r.Get("/{id}/codes", h.GetCodes)
+		r.Post("/{id}/codes", h.CreateCode)
+		r.Delete("/{id}/codes/{codeId}", h.DeleteCode)
+	})
+}
+
+// @Summary Evaluate discounts
+// @Description Calculate line-level discounts for a list of products with the customer's prices. Without authorization default prices and guest conditions are used
+// @Tags promotion
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param request body EvaluateRequest true "Product lines and an optional promo code"
+// @Success 200 {object} respond.SuccessResponse{data=EvaluateResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /evaluate [post]
+func (h *Handler) Evaluate(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request EvaluateRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.Evaluate(r.Context(), identity.UserID, request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Product card price
+// @Description Get the product price with promotions applied, as shown on the product card
+// @Tags promotion
+// @Produce json
+// @Param Authorization header string false "Bearer access token"
+// @Param productUuid path string true "Product UUID"
+// @Param quantity query int false "Quantity, 1 by default"
+// @Success 200 {object} respond.SuccessResponse{data=CardResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /product/{productUuid} [get]
+func (h *Handler) Card(w http.ResponseWriter, r *http.Request) {
+	productUUID, err := uuid.Parse(chi.URLParam(r, "productUuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный UUID товара")
+		return
+	}
+
+	quantity, err := queryInt(r, "quantity", 1)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректное количество")
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.Card(r.Context(), identity.UserID, productUUID, quantity)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Get promotions
+// @Description Get promotions in the order they are applied
+// @Tags promotion
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param limit query int false "Page size, 50 by default, at most 100"
+// @Param offset query int false "Offset"
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [get]
+func (h *Handler) GetList(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", defaultListLimit)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный limit")
+		return
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный offset")
+		return
+	}
+
+	resp, mess, err := h.service.List(r.Context(), limit, offset)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Create promotion
+// @Description Create a promotion: conditions (empty lists mean no restriction) and one action. Promotions are applied by descending priority, an exclusive promotion does not combine with others on the same lines
+// @Tags promotion
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param request body PromotionRequest true "Promotion"
+// @Success 201 {object} respond.SuccessResponse{data=PromotionResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [post]
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request PromotionRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.Create(r.Context(), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusCreated, mess, resp)
+}
+
+// @Summary Get promotion
+// @Description Get a promotion by ID
+// @Tags promotion
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} respond.SuccessResponse{data=PromotionResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	resp, mess, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Update promotion
+// @Description Replace a promotion's conditions and action
+// @Tags promotion
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Promotion ID"
+// @Param request body PromotionRequest true "Promotion"
+// @Success 200 {object} respond.SuccessResponse{data=PromotionResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id} [put]
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	body := respond.ParseBody(w, r)
+
+	var request PromotionRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.Update(r.Context(), id, request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "акция изменена", resp)
+}
+
+// @Summary Delete promotion
+// @Description Delete a promotion with its promo codes
+// @Tags promotion
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id} [delete]
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	mess, err := h.service.Delete(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess)
+}
+
+// @Summary Get promo codes
+// @Description Get promo codes of a promotion with their usage
+// @Tags promotion
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} respond.SuccessResponse{data=[]CodeResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id}/codes [get]
+func (h *Handler) GetCodes(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	resp, mess, err := h.service.Codes(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Create promo code
+// @Description Add a case-insensitive promo code to a promotion with optional total and per-user usage limits
+// @Tags promotion
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Promotion ID"
+// @Param request body CodeRequest true "Promo code"
+// @Success 201 {object} respond.SuccessResponse{data=CodeResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id}/codes [post]
+func (h *Handler) CreateCode(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	body := respond.ParseBody(w, r)
+
+	var request CodeRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.CreateCode(r.Context(), id, request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusCreated, mess, resp)
+}
+
+// @Summary Delete promo code
+// @Description Delete a promo code, orders that used it keep the code
+// @Tags promotion
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Promotion ID"
+// @Param codeId path int true "Promo code ID"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id}/codes/{codeId} [delete]
+func (h *Handler) DeleteCode(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	codeID, ok := pathID(w, r, "codeId")
+	if !ok {
+		return
+	}
+
+	mess, err := h.service.DeleteCode(r.Context(), id, codeID)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess)
+}
+
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный идентификатор")
+		return 0, false
+	}
+	return id, true
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, err error, mess string) {
+	var validationErrors validator.ValidationError
+	if errors.As(err, &validationErrors) {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+		return
+	}
+
+	switch {
+	case errors.Is(err, store.ErrNotFound), errors.Is(err, ErrProductNotFound):
+		respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+	case errors.Is(err, ErrCodeExists), errors.Is(err, ErrNoPrice):
+		respond.ErrorHandler(w, r, http.StatusConflict, err, mess)
+	default:
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+	}
+}
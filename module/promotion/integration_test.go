@@ -0,0 +1,314 @@
+package promotion_test
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/module/order"
+	"go-monolite/module/promotion"
+	"go-monolite/module/user"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"go-monolite/pkg/token"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromotionIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	access := testinit.NewAuth(store, config)
+	server := testinit.SetupTestServer(t, promotion.NewHandler(store, config, access.UserAuth, access.Authenticate, access.Users))
+	defer server.Close()
+	orders := testinit.SetupTestServer(t, order.NewCustomerHandler(store, config, access.UserAuth))
+	defer orders.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	ctx := context.Background()
+	tokenManager := token.NewManager(config.Auth.JWTSecret, time.Minute)
+
+	// каталог: дрели — подкатегория инструмента, шланг и перчатки — из сада, у перчаток нет цены
+	tools, drills, garden := uuid.New(), uuid.New(), uuid.New()
+	typePriceUUID, storageUUID := uuid.New(), uuid.New()
+	drill, bits, hose, gloves := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	for _, query := range []string{
+		fmt.Sprintf(`INSERT INTO categories (uuid, slug, name) VALUES ('%s', 'tools', 'Инструмент'), ('%s', 'garden', 'Сад')`, tools, garden),
+		fmt.Sprintf(`INSERT INTO categories (uuid, slug, name, parent_uuid) VALUES ('%s', 'drills', 'Дрели', '%s')`, drills, tools),
+		fmt.Sprintf(`INSERT INTO type_price (uuid, name) VALUES ('%s', 'Розничная')`, typePriceUUID),
+		fmt.Sprintf(`INSERT INTO storage (uuid, name) VALUES ('%s', 'Основной')`, storageUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Дрель', 9101, 'drill', '%s')`, drill, drills),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Биты', 9102, 'bits', '%s')`, bits, tools),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Шланг', 9103, 'hose', '%s')`, hose, garden),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Перчатки', 9104, 'gloves', '%s')`, gloves, garden),
+		fmt.Sprintf(`INSERT INTO product_prices (product_uuid, type_price_uuid, price) VALUES ('%s', '%s', 2000), ('%s', '%s', 100), ('%s', '%s', 300)`,
+			drill, typePriceUUID, bits, typePriceUUID, hose, typePriceUUID),
+		fmt.Sprintf(`INSERT INTO product_storages (product_uuid, storage_uuid, quantity) VALUES ('%s', '%s', 10), ('%s', '%s', 100)`,
+			drill, storageUUID, bits, storageUUID),
+	} {
+		_, err := store.Db.Exec(query)
+		require.NoError(t, err)
+	}
+
+	users := user.NewRepository(store)
+	newCustomer := func(t *testing.T, phone string, userType user.UserType) string {
+		t.Helper()
+		tv := user.InitialTokenVersion
+		userID, err := users.Create(ctx, &user.UserEnt{
+			Phone:        &phone,
+			UserType:     userType,
+			Active:       user.ActiveYes,
+			TokenVersion: &tv,
+		})
+		require.NoError(t, err)
+		accessToken, _, err := tokenManager.Generate(userID, tv, "")
+		require.NoError(t, err)
+		return accessToken
+	}
+	individual := newCustomer(t, "79990000201", user.UserTypeIndividual)
+	legal := newCustomer(t, "79990000202", user.UserTypeLegal)
+	admin := config.HTTPServer.BearerToken
+
+	send := func(t *testing.T, server *httptest.Server, method, path, body, accessToken string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	decode := func(t *testing.T, resp *http.Response, status int, v any) {
+		t.Helper()
+		require.Equal(t, status, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		testinit.MarshalUnmarshal(t, response.Data, v)
+	}
+
+	create := func(t *testing.T, body string) promotion.PromotionResponse {
+		t.Helper()
+		var p promotion.PromotionResponse
+		decode(t, send(t, server, http.MethodPost, "/", body, admin), http.StatusCreated, &p)
+		return p
+	}
+
+	evaluate := func(t *testing.T, accessToken, code string) (*http.Response, promotion.EvaluateResponse) {
+		t.Helper()
+		body := fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": 1}, {"product_uuid": "%s", "quantity": 3}, {"product_uuid": "%s", "quantity": 1}], "promo_code": "%s"}`,
+			drill, bits, hose, code)
+		resp := send(t, server, http.MethodPost, "/evaluate", body, accessToken)
+		var result promotion.EvaluateResponse
+		if resp.StatusCode == http.StatusOK {
+			decode(t, resp, http.StatusOK, &result)
+		}
+		return resp, result
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		resp := send(t, server, http.MethodGet, "/", "", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = send(t, server, http.MethodGet, "/", "", individual)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		for _, body := range []string{
+			`{"action": "percent", "value": 10}`,
+			`{"name": "Без значения", "action": "percent"}`,
+			`{"name": "Больше ста", "action": "percent", "value": 150}`,
+			`{"name": "Комплект", "action": "bundle", "buy_quantity": 3, "pay_quantity": 3}`,
+			`{"name": "Подарок", "action": "gift"}`,
+			`{"name": "Неизвестно", "action": "cashback", "value": 5}`,
+			`{"name": "Даты", "action": "fixed", "value": 5, "starts_at": "2026-02-01T00:00:00Z", "ends_at": "2026-01-01T00:00:00Z"}`,
+			`{"name": "Тип", "action": "fixed", "value": 5, "user_types": ["vip"]}`,
+		} {
+			resp := send(t, server, http.MethodPost, "/", body, admin)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		}
+	})
+
+	// акции применяются по убыванию приоритета, каждая — к цене после предыдущих
+	create(t, fmt.Sprintf(`{"name": "Инструмент -10%%", "priority": 10, "action": "percent", "value": 10, "category_uuids": ["%s"]}`, tools))
+	create(t, fmt.Sprintf(`{"name": "Биты 3 по цене 2", "priority": 5, "action": "bundle", "buy_quantity": 3, "pay_quantity": 2, "product_uuids": ["%s"]}`, bits))
+	create(t, fmt.Sprintf(`{"name": "Шланг -50", "priority": 20, "exclusive": true, "action": "fixed", "value": 50, "product_uuids": ["%s"]}`, hose))
+	create(t, `{"name": "Всё -5%", "priority": 1, "action": "percent", "value": 5}`)
+	expired := create(t, `{"name": "Прошедшая", "priority": 100, "action": "percent", "value": 50, "ends_at": "2020-01-01T00:00:00Z"}`)
+	byCode := create(t, fmt.Sprintf(`{"name": "Перчатки юрлицам", "by_code": true, "action": "gift", "gift_product_uuid": "%s", "user_types": ["legal"]}`, gloves))
+
+	t.Run("Admin", func(t *testing.T) {
+		var list promotion.ListResponse
+		decode(t, send(t, server, http.MethodGet, "/", "", admin), http.StatusOK, &list)
+		assert.Equal(t, 6, list.Total)
+		require.Len(t, list.Items, 6)
+		assert.Equal(t, expired.ID, list.Items[0].ID)
+
+		var got promotion.PromotionResponse
+		decode(t, send(t, server, http.MethodGet, fmt.Sprintf("/%d", byCode.ID), "", admin), http.StatusOK, &got)
+		assert.Equal(t, promotion.ActionGift, got.Action)
+		require.NotNil(t, got.GiftQuantity)
+		assert.Equal(t, 1, *got.GiftQuantity)
+		assert.Equal(t, []string{"legal"}, got.UserTypes)
+
+		body := `{"name": "Отключённая", "active": false, "priority": 100, "action": "percent", "value": 50}`
+		decode(t, send(t, server, http.MethodPut, fmt.Sprintf("/%d", expired.ID), body, admin), http.StatusOK, &got)
+		assert.False(t, got.Active)
+		assert.Nil(t, got.EndsAt)
+
+		resp := send(t, server, http.MethodGet, "/999999", "", admin)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Codes", func(t *testing.T) {
+		var code promotion.CodeResponse
+		decode(t, send(t, server, http.MethodPost, fmt.Sprintf("/%d/codes", byCode.ID), `{"code": "legal", "usage_limit": 5, "per_user_limit": 1}`, admin), http.StatusCreated, &code)
+		assert.Equal(t, "LEGAL", code.Code)
+
+		resp := send(t, server, http.MethodPost, fmt.Sprintf("/%d/codes", byCode.ID), `{"code": "Legal"}`, admin)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = send(t, server, http.MethodPost, fmt.Sprintf("/%d/codes", byCode.ID), `{"code": "no spaces"}`, admin)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, server, http.MethodPost, "/999999/codes", `{"code": "NOBODY"}`, admin)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		var temp promotion.CodeResponse
+		decode(t, send(t, server, http.MethodPost, fmt.Sprintf("/%d/codes", byCode.ID), `{"code": "TEMP"}`, admin), http.StatusCreated, &temp)
+		resp = send(t, server, http.MethodDelete, fmt.Sprintf("/%d/codes/%d", byCode.ID, temp.ID), "", admin)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var codes []promotion.CodeResponse
+		decode(t, send(t, server, http.MethodGet, fmt.Sprintf("/%d/codes", byCode.ID), "", admin), http.StatusOK, &codes)
+		require.Len(t, codes, 1)
+		assert.Equal(t, code.ID, codes[0].ID)
+	})
+
+	t.Run("Evaluate", func(t *testing.T) {
+		resp, result := evaluate(t, "", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Len(t, result.Items, 3)
+
+		// дрель: -10% на подкатегорию инструмента, затем -5% на всё
+		assert.Equal(t, 290.0, result.Items[0].Discount)
+		assert.Equal(t, 1710.0, result.Items[0].Sum)
+		require.Len(t, result.Items[0].Promotions, 2)
+		assert.Equal(t, "Инструмент -10%", result.Items[0].Promotions[0].Name)
+
+		// биты: 300 - 10% = 270, третья бита бесплатно = 180, затем -5%
+		assert.Equal(t, 129.0, result.Items[1].Discount)
+		assert.Equal(t, 171.0, result.Items[1].Sum)
+		assert.Len(t, result.Items[1].Promotions, 3)
+
+		// шланг получил exclusive-акцию, остальные к нему не применяются
+		assert.Equal(t, 50.0, result.Items[2].Discount)
+		require.Len(t, result.Items[2].Promotions, 1)
+		assert.Equal(t, "Шланг -50", result.Items[2].Promotions[0].Name)
+
+		assert.Equal(t, 2600.0, result.BaseTotal)
+		assert.Equal(t, 469.0, result.Discount)
+		assert.Equal(t, 2131.0, result.Total)
+		assert.Empty(t, result.Gifts)
+		assert.False(t, result.CodeApplied)
+	})
+
+	t.Run("Evaluate Promo Code", func(t *testing.T) {
+		resp, _ := evaluate(t, "", "UNKNOWN")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		// лимит на пользователя — гостю промокод недоступен
+		resp, _ = evaluate(t, "", "legal")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, result := evaluate(t, individual, "legal")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.False(t, result.CodeApplied)
+		assert.Empty(t, result.Gifts)
+
+		resp, result = evaluate(t, legal, "legal")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.True(t, result.CodeApplied)
+		assert.Equal(t, "LEGAL", result.PromoCode)
+		require.Len(t, result.Gifts, 1)
+		assert.Equal(t, gloves, result.Gifts[0].ProductUUID)
+		assert.Equal(t, "Перчатки", result.Gifts[0].Name)
+		assert.Equal(t, 2131.0, result.Total)
+	})
+
+	t.Run("Card", func(t *testing.T) {
+		var card promotion.CardResponse
+		decode(t, send(t, server, http.MethodGet, fmt.Sprintf("/product/%s", drill), "", ""), http.StatusOK, &card)
+		assert.Equal(t, 2000.0, card.Price)
+		assert.Equal(t, 1710.0, card.FinalPrice)
+		assert.Equal(t, 290.0, card.Discount)
+		assert.Len(t, card.Promotions, 2)
+
+		decode(t, send(t, server, http.MethodGet, fmt.Sprintf("/product/%s?quantity=3", bits), "", ""), http.StatusOK, &card)
+		assert.Equal(t, 57.0, card.FinalPrice)
+
+		resp := send(t, server, http.MethodGet, fmt.Sprintf("/product/%s", uuid.New()), "", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = send(t, server, http.MethodGet, fmt.Sprintf("/product/%s", gloves), "", "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = send(t, server, http.MethodGet, fmt.Sprintf("/product/%s?quantity=0", drill), "", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Checkout", func(t *testing.T) {
+		body := fmt.Sprintf(`{"items": [{"product_uuid": "%s", "quantity": 1}, {"product_uuid": "%s", "quantity": 3}], "delivery_method": "pickup", "storage_uuid": "%s", "promo_code": "legal"}`,
+			drill, bits, storageUUID)
+
+		resp := send(t, orders, http.MethodPost, "/", body, individual)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var o order.OrderResponse
+		decode(t, send(t, orders, http.MethodPost, "/", body, legal), http.StatusCreated, &o)
+		assert.Equal(t, 419.0, o.Discount)
+		assert.Equal(t, 1881.0, o.Total)
+		require.NotNil(t, o.PromoCode)
+		assert.Equal(t, "LEGAL", *o.PromoCode)
+		require.Len(t, o.Items, 3)
+		gift := o.Items[2]
+		assert.True(t, gift.Gift)
+		assert.Equal(t, gloves, gift.ProductUUID)
+		assert.Zero(t, gift.Sum)
+
+		var codes []promotion.CodeResponse
+		decode(t, send(t, server, http.MethodGet, fmt.Sprintf("/%d/codes", byCode.ID), "", admin), http.StatusOK, &codes)
+		require.Len(t, codes, 1)
+		assert.Equal(t, 1, codes[0].UsedCount)
+
+		// лимит на пользователя исчерпан
+		resp = send(t, orders, http.MethodPost, "/", body, legal)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		resp := send(t, server, http.MethodDelete, fmt.Sprintf("/%d", byCode.ID), "", admin)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = send(t, server, http.MethodDelete, fmt.Sprintf("/%d", byCode.ID), "", admin)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp, _ = evaluate(t, legal, "legal")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
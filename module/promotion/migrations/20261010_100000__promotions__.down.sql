@@ -0,0 +1,11 @@
+DELETE FROM permissions WHERE code = 'promotions:manage';
+
+DELETE FROM order_items WHERE gift;
+ALTER TABLE order_items DROP CONSTRAINT IF EXISTS order_items_pkey;
+ALTER TABLE order_items ADD PRIMARY KEY (order_id, product_uuid);
+ALTER TABLE order_items DROP COLUMN IF EXISTS gift, DROP COLUMN IF EXISTS discount;
+ALTER TABLE orders DROP COLUMN IF EXISTS promo_code, DROP COLUMN IF EXISTS discount;
+
+DROP TABLE IF EXISTS promo_code_usages;
+DROP TABLE IF EXISTS promo_codes;
+DROP TABLE IF EXISTS promotions;
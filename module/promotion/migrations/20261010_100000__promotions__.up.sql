@@ -0,0 +1,75 @@
+-- акции: условия (пустой список — без ограничения) и одно действие.
+-- Применяются по убыванию priority, exclusive-акция не сочетается с другими на тех же позициях
+CREATE TABLE IF NOT EXISTS promotions (
+  id BIGSERIAL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  description TEXT,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  priority INT NOT NULL DEFAULT 0,
+  exclusive BOOLEAN NOT NULL DEFAULT FALSE,
+  by_code BOOLEAN NOT NULL DEFAULT FALSE, -- действует только с промокодом
+  category_uuids UUID[] NOT NULL DEFAULT '{}', -- категории вместе с подкатегориями
+  product_uuids UUID[] NOT NULL DEFAULT '{}',
+  user_types TEXT[] NOT NULL DEFAULT '{}',
+  company_ids BIGINT[] NOT NULL DEFAULT '{}',
+  min_total NUMERIC(12, 2), -- сумма корзины до скидок
+  starts_at TIMESTAMP,
+  ends_at TIMESTAMP,
+  action VARCHAR(20) NOT NULL CHECK (action IN ('percent', 'fixed', 'price', 'bundle', 'gift')),
+  value NUMERIC(12, 2), -- процент, скидка на единицу товара или новая цена
+  buy_quantity INT CHECK (buy_quantity > 0), -- bundle: из каждых buy_quantity штук оплачиваются pay_quantity
+  pay_quantity INT CHECK (pay_quantity >= 0),
+  gift_product_uuid UUID,
+  gift_quantity INT CHECK (gift_quantity > 0),
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CHECK (action <> 'bundle' OR pay_quantity < buy_quantity),
+  CHECK (action <> 'gift' OR gift_product_uuid IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS promotions_priority_idx ON promotions (priority DESC, id) WHERE active;
+
+-- промокоды хранятся в верхнем регистре
+CREATE TABLE IF NOT EXISTS promo_codes (
+  id BIGSERIAL PRIMARY KEY,
+  promotion_id BIGINT NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+  code VARCHAR(50) NOT NULL UNIQUE,
+  usage_limit INT CHECK (usage_limit > 0), -- всего использований, пусто — без ограничения
+  per_user_limit INT CHECK (per_user_limit > 0),
+  used_count INT NOT NULL DEFAULT 0,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS promo_codes_promotion_idx ON promo_codes (promotion_id);
+
+CREATE TABLE IF NOT EXISTS promo_code_usages (
+  id BIGSERIAL PRIMARY KEY,
+  promo_code_id BIGINT NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE,
+  user_id INT REFERENCES users(id) ON DELETE SET NULL,
+  order_id BIGINT REFERENCES orders(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS promo_code_usages_user_idx ON promo_code_usages (promo_code_id, user_id);
+
+-- скидки в заказах: price — цена до скидки, sum — сумма позиции после скидки, подарки идут отдельными позициями
+ALTER TABLE orders
+  ADD COLUMN IF NOT EXISTS discount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS promo_code VARCHAR(50);
+
+ALTER TABLE order_items
+  ADD COLUMN IF NOT EXISTS discount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS gift BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE order_items DROP CONSTRAINT IF EXISTS order_items_pkey;
+ALTER TABLE order_items ADD PRIMARY KEY (order_id, product_uuid, gift);
+
+INSERT INTO permissions (code, name) VALUES ('promotions:manage', 'Управление акциями')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.code = 'admin' AND p.code = 'promotions:manage'
+ON CONFLICT DO NOTHING;
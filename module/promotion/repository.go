@@ -0,0 +1,310 @@
+package promotion
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-monolite/internal/store"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	promotionColumns = `id, name, description, active, priority, exclusive, by_code, category_uuids, product_uuids,
+	user_types, company_ids, min_total, starts_at, ends_at, action, value, buy_quantity, pay_quantity,
+	gift_product_uuid, gift_quantity, created_at, updated_at`
+	codeColumns = `id, promotion_id, code, usage_limit, per_user_limit, used_count, active, created_at, updated_at`
+)
+
+type Repository struct {
+	store      *store.Store
+	tableName  string
+	codesName  string
+	usagesName string
+}
+
+func NewRepository(store *store.Store) *Repository {
+	return &Repository{
+		store:      store,
+		tableName:  "promotions",
+		codesName:  "promo_codes",
+		usagesName: "promo_code_usages",
+	}
+}
+
+func (r *Repository) Create(ctx context.Context, e *PromotionEnt) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			name, description, active, priority, exclusive, by_code, category_uuids, product_uuids,
+			user_types, company_ids, min_total, starts_at, ends_at, action, value, buy_quantity, pay_quantity,
+			gift_product_uuid, gift_quantity, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $20
+		)
+		RETURNING id
+	`, r.tableName)
+
+	now := time.Now()
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query,
+		e.Name, e.Description, e.Active, e.Priority, e.Exclusive, e.ByCode, e.CategoryUUIDs, e.ProductUUIDs,
+		e.UserTypes, e.CompanyIDs, e.MinTotal, e.StartsAt, e.EndsAt, e.Action, e.Value, e.BuyQuantity, e.PayQuantity,
+		e.GiftProductUUID, e.GiftQuantity, now,
+	).Scan(&e.ID)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) Update(ctx context.Context, e *PromotionEnt) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET name = $2, description = $3, active = $4, priority = $5, exclusive = $6, by_code = $7,
+			category_uuids = $8, product_uuids = $9, user_types = $10, company_ids = $11, min_total = $12,
+			starts_at = $13, ends_at = $14, action = $15, value = $16, buy_quantity = $17, pay_quantity = $18,
+			gift_product_uuid = $19, gift_quantity = $20, updated_at = $21
+		WHERE id = $1
+	`, r.tableName)
+
+	e.UpdatedAt = time.Now()
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query,
+		e.ID, e.Name, e.Description, e.Active, e.Priority, e.Exclusive, e.ByCode, e.CategoryUUIDs, e.ProductUUIDs,
+		e.UserTypes, e.CompanyIDs, e.MinTotal, e.StartsAt, e.EndsAt, e.Action, e.Value, e.BuyQuantity, e.PayQuantity,
+		e.GiftProductUUID, e.GiftQuantity, e.UpdatedAt,
+	)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return affectedOne(res)
+}
+
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, r.tableName)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return affectedOne(res)
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int64) (*PromotionEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, promotionColumns, r.tableName)
+
+	var e PromotionEnt
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+// List — акции в порядке применения
+func (r *Repository) List(ctx context.Context, limit, offset int) ([]PromotionEnt, int, error) {
+	var total int
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &total, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, r.tableName)); err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s
+		ORDER BY priority DESC, id
+		LIMIT $1 OFFSET $2
+	`, promotionColumns, r.tableName)
+
+	var promotions []PromotionEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &promotions, query, limit, offset); err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	return promotions, total, nil
+}
+
+// Running — включённые акции без промокода, действующие в момент now
+func (r *Repository) Running(ctx context.Context, now time.Time) ([]PromotionEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s
+		WHERE active AND NOT by_code
+			AND (starts_at IS NULL OR starts_at <= $1)
+			AND (ends_at IS NULL OR ends_at > $1)
+		ORDER BY priority DESC, id
+	`, promotionColumns, r.tableName)
+
+	var promotions []PromotionEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &promotions, query, now); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return promotions, nil
+}
+
+// CreateCode добавляет промокод, если такой код уже есть — store.ErrConflict
+func (r *Repository) CreateCode(ctx context.Context, e *CodeEnt) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (promotion_id, code, usage_limit, per_user_limit, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING id
+	`, r.codesName)
+
+	now := time.Now()
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query,
+		e.PromotionID, e.Code, e.UsageLimit, e.PerUserLimit, e.Active, now,
+	).Scan(&e.ID)
+	if err != nil {
+		if store.IsUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) Codes(ctx context.Context, promotionID int64) ([]CodeEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE promotion_id = $1 ORDER BY id`, codeColumns, r.codesName)
+
+	var codes []CodeEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &codes, query, promotionID); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return codes, nil
+}
+
+func (r *Repository) DeleteCode(ctx context.Context, promotionID, id int64) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE promotion_id = $1 AND id = $2`, r.codesName)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, promotionID, id)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return affectedOne(res)
+}
+
+// GetCode ищет промокод без учёта регистра, forUpdate блокирует его до конца транзакции
+func (r *Repository) GetCode(ctx context.Context, code string, forUpdate bool) (*CodeEnt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE code = UPPER($1)`, codeColumns, r.codesName)
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var e CodeEnt
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, code); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+// UserUsages — сколько раз пользователь уже использовал промокод
+func (r *Repository) UserUsages(ctx context.Context, codeID, userID int64) (int, error) {
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE promo_code_id = $1 AND user_id = $2`, r.usagesName)
+
+	var n int
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &n, query, codeID, userID); err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	return n, nil
+}
+
+// AddUsage записывает использование промокода и увеличивает его счётчик
+func (r *Repository) AddUsage(ctx context.Context, codeID, userID, orderID int64) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (promo_code_id, user_id, order_id, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, r.usagesName)
+
+	now := time.Now()
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, codeID, userID, orderID, now); err != nil {
+		return store.ContextError(err)
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET used_count = used_count + 1, updated_at = $2 WHERE id = $1`, r.codesName)
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, query, codeID, now); err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// Categories — категория каждого товара вместе со всеми родительскими
+func (r *Repository) Categories(ctx context.Context, productUUIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT p.uuid AS product_uuid, c.uuid, c.parent_uuid
+			FROM products p
+			JOIN categories c ON c.uuid = p.category_uuid
+			WHERE p.uuid = ANY($1::uuid[])
+			UNION
+			SELECT chain.product_uuid, c.uuid, c.parent_uuid
+			FROM chain
+			JOIN categories c ON c.uuid = chain.parent_uuid
+		)
+		SELECT product_uuid, uuid FROM chain
+	`
+
+	var rows []struct {
+		ProductUUID  uuid.UUID `db:"product_uuid"`
+		CategoryUUID uuid.UUID `db:"uuid"`
+	}
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &rows, query, formatUUIDs(productUUIDs)); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	result := make(map[uuid.UUID][]uuid.UUID, len(productUUIDs))
+	for _, row := range rows {
+		result[row.ProductUUID] = append(result[row.ProductUUID], row.CategoryUUID)
+	}
+
+	return result, nil
+}
+
+// Products — товары с ценой типа priceType, без типа цен цена пустая. Товар из архива считается выключенным
+func (r *Repository) Products(ctx context.Context, productUUIDs []uuid.UUID, priceType *uuid.UUID) ([]ProductEnt, error) {
+	query := `
+		SELECT p.uuid, p.name, p.code, p.article, p.unit, CASE WHEN p.deleted_at IS NULL THEN p.active ELSE 'N' END AS active,
+			(SELECT pp.price FROM product_prices pp
+				WHERE pp.product_uuid = p.uuid AND pp.type_price_uuid = $2 AND pp.active = 'Y'
+				ORDER BY pp.updated_at DESC LIMIT 1) AS price
+		FROM products p
+		WHERE p.uuid = ANY($1::uuid[])
+	`
+
+	var products []ProductEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &products, query, formatUUIDs(productUUIDs), priceType); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return products, nil
+}
+
+func affectedOne(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,512 @@
+package promotion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"go-monolite/module/cart"
+	"go-monolite/module/company"
+	"go-monolite/module/user"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/validator"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	store       *store.Store
+	repo        *Repository
+	carts       *cart.Service
+	userRepo    *user.Repository
+	companyRepo *company.Repository
+}
+
+func NewService(store *store.Store, repo *Repository, carts *cart.Service, userRepo *user.Repository, companyRepo *company.Repository) *Service {
+	return &Service{
+		store:       store,
+		repo:        repo,
+		carts:       carts,
+		userRepo:    userRepo,
+		companyRepo: companyRepo,
+	}
+}
+
+func (s *Service) Create(ctx context.Context, req PromotionRequest) (*PromotionResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	e := newPromotionEnt(req)
+	if err := s.repo.Create(ctx, e); err != nil {
+		return nil, "произошла ошибка при создании акции", err
+	}
+
+	resp := e.ToResponse()
+	return &resp, "акция создана", nil
+}
+
+func (s *Service) Update(ctx context.Context, id int64, req PromotionRequest) (*PromotionResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	e := newPromotionEnt(req)
+	e.ID = id
+	if err := s.repo.Update(ctx, e); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "акция не найдена", err
+		}
+		return nil, "произошла ошибка при изменении акции", err
+	}
+
+	return s.Get(ctx, id)
+}
+
+func (s *Service) Delete(ctx context.Context, id int64) (string, error) {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "акция не найдена", err
+		}
+		return "произошла ошибка при удалении акции", err
+	}
+
+	return "акция удалена", nil
+}
+
+func (s *Service) Get(ctx context.Context, id int64) (*PromotionResponse, string, error) {
+	e, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "акция не найдена", err
+		}
+		return nil, "произошла ошибка при получении акции", err
+	}
+
+	resp := e.ToResponse()
+	return &resp, "", nil
+}
+
+func (s *Service) List(ctx context.Context, limit, offset int) (*ListResponse, string, error) {
+	if limit < 1 || limit > 100 || offset < 0 {
+		return nil, "", validator.ValidationError{
+			Err:    validator.ErrorValidation,
+			Fields: map[string]string{"limit": "Значение limit должно быть от 1 до 100, offset — не меньше 0"},
+		}
+	}
+
+	promotions, total, err := s.repo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, "произошла ошибка при получении акций", err
+	}
+
+	return &ListResponse{Items: helper.ToResponse(promotions), Total: total}, "", nil
+}
+
+func (s *Service) CreateCode(ctx context.Context, promotionID int64, req CodeRequest) (*CodeResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	if _, err := s.repo.GetByID(ctx, promotionID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "акция не найдена", err
+		}
+		return nil, "произошла ошибка при создании промокода", err
+	}
+
+	e := &CodeEnt{
+		PromotionID:  promotionID,
+		Code:         strings.ToUpper(req.Code),
+		UsageLimit:   req.UsageLimit,
+		PerUserLimit: req.PerUserLimit,
+		Active:       true,
+	}
+	if err := s.repo.CreateCode(ctx, e); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			return nil, "такой промокод уже есть", ErrCodeExists
+		}
+		return nil, "произошла ошибка при создании промокода", err
+	}
+
+	resp := e.ToResponse()
+	return &resp, "промокод создан", nil
+}
+
+func (s *Service) Codes(ctx context.Context, promotionID int64) ([]CodeResponse, string, error) {
+	codes, err := s.repo.Codes(ctx, promotionID)
+	if err != nil {
+		return nil, "произошла ошибка при получении промокодов", err
+	}
+
+	return helper.ToResponse(codes), "", nil
+}
+
+func (s *Service) DeleteCode(ctx context.Context, promotionID, codeID int64) (string, error) {
+	if err := s.repo.DeleteCode(ctx, promotionID, codeID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "промокод не найден", err
+		}
+		return "произошла ошибка при удалении промокода", err
+	}
+
+	return "промокод удалён", nil
+}
+
+// Evaluate — скидки на список товаров по ценам покупателя, гостю — по ценам по умолчанию
+func (s *Service) Evaluate(ctx context.Context, userID int64, req EvaluateRequest) (*EvaluateResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	lines, err := s.priceLines(ctx, userID, req.Items)
+	if err != nil {
+		return nil, priceMessage(err), err
+	}
+
+	customer, err := s.Customer(ctx, userID)
+	if err != nil {
+		return nil, "произошла ошибка при расчёте скидок", err
+	}
+
+	result, err := s.Apply(ctx, customer, lines, req.PromoCode)
+	if err != nil {
+		return nil, "произошла ошибка при расчёте скидок", err
+	}
+
+	gifts, err := s.Gifts(ctx, result.Gifts)
+	if err != nil {
+		return nil, "произошла ошибка при расчёте скидок", err
+	}
+
+	return &EvaluateResponse{
+		Items:       lineResponses(result.Lines),
+		Gifts:       gifts,
+		BaseTotal:   result.BaseTotal,
+		Discount:    result.Discount,
+		Total:       result.Total,
+		PromoCode:   result.PromoCode,
+		CodeApplied: result.CodeApplied,
+	}, "", nil
+}
+
+// Card — цена товара в карточке с учётом акций. Условие на сумму корзины проверяется по самому товару
+func (s *Service) Card(ctx context.Context, userID int64, productUUID uuid.UUID, quantity int) (*CardResponse, string, error) {
+	if quantity < 1 || quantity > 100000 {
+		return nil, "", validator.ValidationError{
+			Err:    validator.ErrorValidation,
+			Fields: map[string]string{"quantity": "Количество должно быть от 1 до 100000"},
+		}
+	}
+
+	lines, err := s.priceLines(ctx, userID, []EvaluateItem{{ProductUUID: productUUID, Quantity: quantity}})
+	if err != nil {
+		return nil, priceMessage(err), err
+	}
+
+	customer, err := s.Customer(ctx, userID)
+	if err != nil {
+		return nil, "произошла ошибка при расчёте скидок", err
+	}
+
+	result, err := s.Apply(ctx, customer, lines, "")
+	if err != nil {
+		return nil, "произошла ошибка при расчёте скидок", err
+	}
+
+	gifts, err := s.Gifts(ctx, result.Gifts)
+	if err != nil {
+		return nil, "произошла ошибка при расчёте скидок", err
+	}
+
+	line := lineResponses(result.Lines)[0]
+	return &CardResponse{
+		ProductUUID: productUUID,
+		Price:       line.Price,
+		FinalPrice:  roundMoney(line.Sum / float64(quantity)),
+		Discount:    roundMoney(line.Discount / float64(quantity)),
+		Promotions:  line.Promotions,
+		Gifts:       gifts,
+	}, "", nil
+}
+
+// Customer — тип и компания пользователя для условий акций, гость — нулевой userID
+func (s *Service) Customer(ctx context.Context, userID int64) (Customer, error) {
+	if userID == 0 {
+		return Customer{}, nil
+	}
+
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return Customer{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	customer := Customer{UserID: userID, UserType: string(u.UserType)}
+
+	membership, err := s.companyRepo.GetMembership(ctx, userID)
+	switch {
+	case err == nil:
+		customer.CompanyID = &membership.CompanyID
+	case !errors.Is(err, store.ErrNotFound):
+		return Customer{}, fmt.Errorf("failed to get company membership: %w", err)
+	}
+
+	return customer, nil
+}
+
+// Apply считает скидки для позиций с уже известными ценами, code — необязательный промокод.
+// Недействительный промокод — validator.ValidationError по полю promo_code
+func (s *Service) Apply(ctx context.Context, customer Customer, lines []Line, code string) (*Evaluation, error) {
+	now := time.Now()
+
+	promotions, err := s.repo.Running(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var byCode *PromotionEnt
+	if code != "" {
+		if _, byCode, err = s.checkCode(ctx, code, customer.UserID, false); err != nil {
+			return nil, err
+		}
+		if !containsPromotion(promotions, byCode.ID) {
+			promotions = append(promotions, *byCode)
+		}
+	}
+
+	productUUIDs := make([]uuid.UUID, 0, len(lines))
+	for _, line := range lines {
+		productUUIDs = append(productUUIDs, line.ProductUUID)
+	}
+	categories, err := s.repo.Categories(ctx, productUUIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lines {
+		lines[i].Categories = categories[lines[i].ProductUUID]
+	}
+
+	result := Evaluate(promotions, customer, lines, now)
+	if byCode != nil {
+		result.PromoCode = strings.ToUpper(code)
+		for _, id := range result.Applied {
+			if id == byCode.ID {
+				result.CodeApplied = true
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Redeem списывает использование промокода по оформленному заказу, вызывается в транзакции заказа.
+// Лимиты проверяются повторно под блокировкой промокода
+func (s *Service) Redeem(ctx context.Context, code string, userID, orderID int64) error {
+	return s.store.WithinTx(ctx, func(ctx context.Context) error {
+		e, _, err := s.checkCode(ctx, code, userID, true)
+		if err != nil {
+			return err
+		}
+		return s.repo.AddUsage(ctx, e.ID, userID, orderID)
+	})
+}
+
+// Gifts — подарки с данными товаров, неактивные и удалённые товары не дарятся.
+// Остаток подарков не проверяется
+func (s *Service) Gifts(ctx context.Context, gifts []Gift) ([]GiftResponse, error) {
+	result := make([]GiftResponse, 0, len(gifts))
+	if len(gifts) == 0 {
+		return result, nil
+	}
+
+	productUUIDs := make([]uuid.UUID, 0, len(gifts))
+	for _, g := range gifts {
+		productUUIDs = append(productUUIDs, g.ProductUUID)
+	}
+	products, err := s.repo.Products(ctx, productUUIDs, nil)
+	if err != nil {
+		return nil, err
+	}
+	byUUID := make(map[uuid.UUID]ProductEnt, len(products))
+	for _, p := range products {
+		byUUID[p.UUID] = p
+	}
+
+	for _, g := range gifts {
+		p, ok := byUUID[g.ProductUUID]
+		if !ok || p.Active != user.ActiveYes {
+			continue
+		}
+		result = append(result, GiftResponse{
+			PromotionID: g.PromotionID,
+			ProductUUID: p.UUID,
+			Name:        p.Name,
+			Code:        p.Code,
+			Article:     p.Article,
+			Unit:        p.Unit,
+			Quantity:    g.Quantity,
+		})
+	}
+
+	return result, nil
+}
+
+// checkCode проверяет промокод и его акцию, userID нужен для лимита на пользователя
+func (s *Service) checkCode(ctx context.Context, code string, userID int64, forUpdate bool) (*CodeEnt, *PromotionEnt, error) {
+	invalid := func(message string) error {
+		return validator.ValidationError{
+			Err:    validator.ErrorValidation,
+			Fields: map[string]string{"promo_code": message},
+		}
+	}
+
+	e, err := s.repo.GetCode(ctx, code, forUpdate)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil, invalid("Промокод не найден")
+		}
+		return nil, nil, err
+	}
+	if !e.Active || e.Exhausted() {
+		return nil, nil, invalid("Промокод больше не действует")
+	}
+
+	p, err := s.repo.GetByID(ctx, e.PromotionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !p.Running(time.Now()) {
+		return nil, nil, invalid("Акция по промокоду не действует")
+	}
+
+	if e.PerUserLimit != nil {
+		if userID == 0 {
+			return nil, nil, invalid("Войдите, чтобы использовать промокод")
+		}
+		used, err := s.repo.UserUsages(ctx, e.ID, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if used >= *e.PerUserLimit {
+			return nil, nil, invalid("Вы уже использовали этот промокод")
+		}
+	}
+
+	return e, p, nil
+}
+
+// priceLines — позиции по ценам типа цен покупателя, одинаковые товары складываются
+func (s *Service) priceLines(ctx context.Context, userID int64, items []EvaluateItem) ([]Line, error) {
+	priceType, err := s.carts.PriceType(ctx, cart.Owner{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		lines   []Line
+		indexes = map[uuid.UUID]int{}
+		uuids   []uuid.UUID
+	)
+	for _, item := range items {
+		if i, ok := indexes[item.ProductUUID]; ok {
+			lines[i].Quantity += item.Quantity
+			continue
+		}
+		indexes[item.ProductUUID] = len(lines)
+		lines = append(lines, Line{ProductUUID: item.ProductUUID, Quantity: item.Quantity})
+		uuids = append(uuids, item.ProductUUID)
+	}
+
+	products, err := s.repo.Products(ctx, uuids, priceType)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[uuid.UUID]*float64, len(products))
+	for _, p := range products {
+		if p.Active == user.ActiveYes {
+			prices[p.UUID] = p.Price
+		}
+	}
+
+	for i, line := range lines {
+		price, ok := prices[line.ProductUUID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrProductNotFound, line.ProductUUID)
+		}
+		if price == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNoPrice, line.ProductUUID)
+		}
+		lines[i].Price = *price
+	}
+
+	return lines, nil
+}
+
+func newPromotionEnt(req PromotionRequest) *PromotionEnt {
+	e := &PromotionEnt{
+		Name:            req.Name,
+		Description:     req.Description,
+		Active:          req.Active == nil || *req.Active,
+		Priority:        req.Priority,
+		Exclusive:       req.Exclusive,
+		ByCode:          req.ByCode,
+		CategoryUUIDs:   formatUUIDs(req.CategoryUUIDs),
+		ProductUUIDs:    formatUUIDs(req.ProductUUIDs),
+		UserTypes:       append([]string{}, req.UserTypes...),
+		CompanyIDs:      append([]int64{}, req.CompanyIDs...),
+		MinTotal:        req.MinTotal,
+		StartsAt:        req.StartsAt,
+		EndsAt:          req.EndsAt,
+		Action:          req.Action,
+		Value:           req.Value,
+		BuyQuantity:     req.BuyQuantity,
+		PayQuantity:     req.PayQuantity,
+		GiftProductUUID: req.GiftProductUUID,
+		GiftQuantity:    req.GiftQuantity,
+	}
+	if e.Action == ActionGift && e.GiftQuantity == nil {
+		one := 1
+		e.GiftQuantity = &one
+	}
+	return e
+}
+
+func lineResponses(lines []LineResult) []LineResponse {
+	result := make([]LineResponse, 0, len(lines))
+	for _, line := range lines {
+		promotions := make([]AppliedResponse, 0, len(line.Promotions))
+		for _, p := range line.Promotions {
+			promotions = append(promotions, AppliedResponse{PromotionID: p.PromotionID, Name: p.Name, Discount: p.Discount})
+		}
+		result = append(result, LineResponse{
+			ProductUUID: line.ProductUUID,
+			Quantity:    line.Quantity,
+			Price:       line.Price,
+			BaseSum:     line.BaseSum,
+			Discount:    line.Discount,
+			Sum:         line.Sum,
+			Promotions:  promotions,
+		})
+	}
+	return result
+}
+
+func containsPromotion(promotions []PromotionEnt, id int64) bool {
+	for _, p := range promotions {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func priceMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		return "товар не найден"
+	case errors.Is(err, ErrNoPrice):
+		return "у товара нет цены"
+	}
+	return "произошла ошибка при расчёте скидок"
+}
@@ -0,0 +1,41 @@
+package property
+
+import "go-monolite/module/audit"
+
+// propertyEntries — изменения свойств после выгрузки, архивное свойство из выгрузки считается восстановленным
+func propertyEntries(current map[string]PropertyEnt, deletes, inserts, updates []PropertyEnt) []audit.Entry {
+	entries := make([]audit.Entry, 0, len(deletes)+len(inserts)+len(updates))
+	for _, d := range deletes {
+		entries = append(entries, audit.Deleted(audit.EntityProperty, d.UUID.String(), d))
+	}
+	for _, i := range inserts {
+		entries = append(entries, audit.Created(audit.EntityProperty, i.UUID.String(), i))
+	}
+	for _, u := range updates {
+		if curr := current[u.UUID.String()]; curr.DeletedAt != nil {
+			entries = append(entries, audit.Restored(audit.EntityProperty, u.UUID.String(), u))
+		} else {
+			entries = append(entries, audit.Updated(audit.EntityProperty, u.UUID.String(), curr, u))
+		}
+	}
+	return entries
+}
+
+// propertyValueEntries — изменения значений свойств после выгрузки, значения пишутся под ключом
+func propertyValueEntries(current map[string]PropertyValueEnt, deletes, inserts, updates []PropertyValueEnt) []audit.Entry {
+	entries := make([]audit.Entry, 0, len(deletes)+len(inserts)+len(updates))
+	for _, d := range deletes {
+		entries = append(entries, audit.Deleted(audit.EntityPropertyValue, d.Key, d))
+	}
+	for _, i := range inserts {
+		entries = append(entries, audit.Created(audit.EntityPropertyValue, i.Key, i))
+	}
+	for _, u := range updates {
+		if curr := current[u.Key]; curr.DeletedAt != nil {
+			entries = append(entries, audit.Restored(audit.EntityPropertyValue, u.Key, u))
+		} else {
+			entries = append(entries, audit.Updated(audit.EntityPropertyValue, u.Key, curr, u))
+		}
+	}
+	return entries
+}
@@ -7,12 +7,13 @@ import (
 )
 
 type PropertyValueEnt struct {
-	ID           uint      `db:"id" json:"id"`
-	Key          string    `db:"key" json:"key"` // не UUID так как 1с предает true false для bool значений
-	Slug         string    `db:"slug" json:"slug"`
-	PropertyUUID uuid.UUID `db:"property_uuid" json:"property_uuid"`
-	Value        string    `db:"value" json:"value"`
-	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	ID           uint       `db:"id" json:"id"`
+	Key          string     `db:"key" json:"key"` // не UUID так как 1с предает true false для bool значений
+	Slug         string     `db:"slug" json:"slug"`
+	PropertyUUID uuid.UUID  `db:"property_uuid" json:"property_uuid"`
+	Value        string     `db:"value" json:"value"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt    *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
 }
 
 type PropertyEnt struct {
@@ -23,5 +24,6 @@ type PropertyEnt struct {
 	Name      string             `db:"name" json:"name"`
 	CreatedAt time.Time          `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time          `db:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time         `db:"deleted_at" json:"deleted_at,omitempty"`
 	Values    []PropertyValueEnt `json:"values"`
 }
@@ -1,7 +1,9 @@
 package property
 
 import (
+	"errors"
 	"go-monolite/internal/store"
+	"go-monolite/module/audit"
 	"go-monolite/pkg/logger"
 	"go-monolite/pkg/respond"
 	"go-monolite/pkg/validator"
@@ -21,12 +23,13 @@ type Handler struct {
 func NewHandler(store *store.Store) *Handler {
 	propertyRepo := NewPropertyRepository(store)
 	propertyValuesRepo := NewPropertyValuesRepository(store)
-	service := NewService(propertyRepo, propertyValuesRepo)
+	service := NewService(store, propertyRepo, propertyValuesRepo, audit.NewRecorder(store))
 	return &Handler{service: service}
 }
 
 func (h *Handler) Init(r chi.Router) {
 	r.Post("/upsert", h.Upsert)
+	r.Post("/restore/{uuid}", h.Restore)
 }
 
 // @Summary Upsert property list
@@ -61,3 +64,36 @@ func (h *Handler) Upsert(w http.ResponseWriter, r *http.Request) {
 
 	respond.SuccessHandler(w, r, http.StatusCreated, "", resp)
 }
+
+// @Summary Restore property
+// @Description Restore a property archived by the exchange together with the values archived with it
+// @Tags properties
+// @Produce json
+// @Param uuid path string true "Property UUID"
+// @Success 200 {object} respond.SuccessResponse{data=PropertyEnt}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /restore/{uuid} [post]
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	propertyUUID, err := validator.ParseUUID(chi.URLParam(r, "uuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	property, err := h.service.Restore(r.Context(), propertyUUID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, "Свойство в архиве не найдено")
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, "Произошла ошибка при восстановлении свойства")
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, "Произошла ошибка при восстановлении свойства")
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "Свойство восстановлено", property)
+}
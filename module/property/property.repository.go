@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 )
 
@@ -67,9 +68,10 @@ func (r *PropertyRepository) CreateBatch(ctx context.Context, props []PropertyEn
 	return nil
 }
 
+// GetList — все свойства вместе с архивными, по ним обмен решает, что добавить, обновить или вернуть из архива
 func (r *PropertyRepository) GetList(ctx context.Context) ([]PropertyEnt, error) {
 	query := fmt.Sprintf(`
-		SELECT id, uuid, slug, type, name, created_at, updated_at
+		SELECT id, uuid, slug, type, name, created_at, updated_at, deleted_at
 		FROM %s
 		ORDER BY created_at DESC
 	`, r.tableName)
@@ -90,15 +92,16 @@ func (r *PropertyRepository) GetList(ctx context.Context) ([]PropertyEnt, error)
 	return properties, nil
 }
 
+// GetByUUID — свойство не из архива
 func (r *PropertyRepository) GetByUUID(ctx context.Context, uuid string) (*PropertyEnt, error) {
 	query := fmt.Sprintf(`
-		SELECT id, uuid, slug, type, name, created_at, updated_at
+		SELECT id, uuid, slug, type, name, created_at, updated_at, deleted_at
 		FROM %s
-		WHERE uuid = $1
+		WHERE uuid = $1 AND deleted_at IS NULL
 	`, r.tableName)
 
 	var property PropertyEnt
-	err := r.store.Db.GetContext(ctx, &property, query, uuid)
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &property, query, uuid)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, store.ErrNotFound
@@ -109,6 +112,7 @@ func (r *PropertyRepository) GetByUUID(ctx context.Context, uuid string) (*Prope
 	return &property, nil
 }
 
+// UpdateBatch обновляет свойства, архивные свойства из выгрузки возвращаются из архива
 func (r *PropertyRepository) UpdateBatch(ctx context.Context, props []PropertyEnt) error {
 	if len(props) == 0 {
 		return nil
@@ -121,7 +125,8 @@ func (r *PropertyRepository) UpdateBatch(ctx context.Context, props []PropertyEn
 		slug = v.slug,
 		type = v.type,
 		name = v.name,
-		updated_at = v.updated_at
+		updated_at = v.updated_at,
+		deleted_at = NULL
 	FROM (VALUES
 	`, r.tableName))
 
@@ -162,29 +167,81 @@ WHERE p.uuid = v.uuid::uuid;
 	return nil
 }
 
+// DeleteBatch переносит свойства в архив вместе с их значениями, окончательно их удаляет задача очистки
 func (r *PropertyRepository) DeleteBatch(ctx context.Context, uuids []uuid.UUID) error {
 	if len(uuids) == 0 {
 		return nil
 	}
 
 	query := fmt.Sprintf(`
-	DELETE FROM %s
-		WHERE uuid = ANY($1)
+		WITH archived AS (
+			UPDATE %s SET deleted_at = $2
+			WHERE uuid = ANY($1) AND deleted_at IS NULL
+			RETURNING uuid
+		), archived_values AS (
+			UPDATE property_values SET deleted_at = $2
+			WHERE property_uuid IN (SELECT uuid FROM archived) AND deleted_at IS NULL
+		)
+		SELECT COUNT(*) FROM archived
 	`, r.tableName)
 
-	result, err := r.store.Db.ExecContext(ctx, query, pq.Array(uuids))
-	if err != nil {
+	var rows int
+	if err := r.store.Db.GetContext(ctx, &rows, query, pq.Array(uuids), time.Now()); err != nil {
 		return store.ContextError(err)
 	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return store.ContextError(err)
+	if rows != len(uuids) {
+		return fmt.Errorf("archived %d of %d properties: some uuids not found", rows, len(uuids))
 	}
 
-	if int(rows) != len(uuids) {
-		return fmt.Errorf("deleted %d of %d properties: some uuids not found", rows, len(uuids))
+	return nil
+}
+
+// Restore возвращает из архива свойство и значения, перенесённые в архив вместе с ним
+func (r *PropertyRepository) Restore(ctx context.Context, propertyUUID uuid.UUID) error {
+	query := fmt.Sprintf(`
+		WITH restored AS (
+			UPDATE %[1]s p SET deleted_at = NULL, updated_at = $2
+			FROM %[1]s old
+			WHERE p.uuid = $1 AND old.uuid = p.uuid AND p.deleted_at IS NOT NULL
+			RETURNING p.uuid, old.deleted_at
+		), restored_values AS (
+			UPDATE property_values v SET deleted_at = NULL, updated_at = $2
+			FROM restored
+			WHERE v.property_uuid = restored.uuid AND v.deleted_at = restored.deleted_at
+		)
+		SELECT COUNT(*) FROM restored
+	`, r.tableName)
+
+	var rows int
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &rows, query, propertyUUID, time.Now()); err != nil {
+		return store.ContextError(err)
+	}
+	if rows == 0 {
+		return store.ErrNotFound
 	}
 
 	return nil
 }
+
+// Purge окончательно удаляет свойства, которые лежат в архиве с момента before.
+// Свойство удаляется, когда у него не осталось значений и на него не ссылаются фильтры
+func (r *PropertyRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %s p
+		WHERE p.deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM property_values v WHERE v.property_uuid = p.uuid)
+			AND NOT EXISTS (SELECT 1 FROM filter f WHERE f.property_uuid = p.uuid)
+	`, r.tableName)
+
+	result, err := r.store.Db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+	return rows, nil
+}
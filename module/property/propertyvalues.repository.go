@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 )
 
@@ -53,9 +54,10 @@ func (r *PropertyValuesRepository) CreateBatch(ctx context.Context, values []Pro
 	return nil
 }
 
+// GetList — все значения свойств вместе с архивными, по ним обмен решает, что добавить, обновить или вернуть из архива
 func (r *PropertyValuesRepository) GetList(ctx context.Context) ([]PropertyValueEnt, error) {
 	query := fmt.Sprintf(`
-		SELECT id, key, slug, property_uuid, value
+		SELECT id, key, slug, property_uuid, value, deleted_at
 		FROM %s
 	`, r.tableName)
 
@@ -75,15 +77,16 @@ func (r *PropertyValuesRepository) GetList(ctx context.Context) ([]PropertyValue
 	return properties, nil
 }
 
+// GetByUUID — значения свойства не из архива
 func (r *PropertyValuesRepository) GetByUUID(ctx context.Context, propertyUUID uuid.UUID) ([]PropertyValueEnt, error) {
 	valuesQuery := fmt.Sprintf(`
-		SELECT id, key, slug, property_uuid, value
+		SELECT id, key, slug, property_uuid, value, deleted_at
 		FROM %s
-		WHERE property_uuid = $1
+		WHERE property_uuid = $1 AND deleted_at IS NULL
 	`, r.tableName)
 
 	var values []PropertyValueEnt
-	err := r.store.Db.SelectContext(ctx, &values, valuesQuery, propertyUUID)
+	err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &values, valuesQuery, propertyUUID)
 	if err != nil {
 		return nil, store.ContextError(err)
 	}
@@ -93,6 +96,7 @@ func (r *PropertyValuesRepository) GetByUUID(ctx context.Context, propertyUUID u
 	return values, nil
 }
 
+// UpdateBatch обновляет значения, архивные значения из выгрузки возвращаются из архива
 func (r *PropertyValuesRepository) UpdateBatch(ctx context.Context, values []PropertyValueEnt) error {
 	if len(values) == 0 {
 		return nil
@@ -106,7 +110,7 @@ func (r *PropertyValuesRepository) UpdateBatch(ctx context.Context, values []Pro
 	for _, v := range values {
 		query := fmt.Sprintf(`
 			UPDATE %s
-			SET slug = $1, value = $2, updated_at = $3
+			SET slug = $1, value = $2, updated_at = $3, deleted_at = NULL
 			WHERE key = $4 AND property_uuid = $5
 		`, r.tableName)
 
@@ -140,9 +144,10 @@ func (r *PropertyValuesRepository) UpdateBatch(ctx context.Context, values []Pro
 	return nil
 }
 
+// Delete переносит в архив значения свойства
 func (r *PropertyValuesRepository) Delete(ctx context.Context, uuid uuid.UUID) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE property_uuid = $1`, r.tableName)
-	result, err := r.store.Db.ExecContext(ctx, query, uuid)
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = $2 WHERE property_uuid = $1 AND deleted_at IS NULL`, r.tableName)
+	result, err := r.store.Db.ExecContext(ctx, query, uuid, time.Now())
 	if err != nil {
 		return store.ContextError(err)
 	}
@@ -157,17 +162,18 @@ func (r *PropertyValuesRepository) Delete(ctx context.Context, uuid uuid.UUID) e
 	return nil
 }
 
+// DeleteBatch переносит значения в архив, окончательно их удаляет задача очистки
 func (r *PropertyValuesRepository) DeleteBatch(ctx context.Context, keys []string) error {
 	if len(keys) == 0 {
 		return nil
 	}
 
 	query := fmt.Sprintf(`
-		DELETE FROM %s
-		WHERE uuid = ANY($1)
+		UPDATE %s SET deleted_at = $2
+		WHERE key = ANY($1) AND deleted_at IS NULL
 	`, r.tableName)
 
-	result, err := r.store.Db.ExecContext(ctx, query, pq.Array(keys))
+	result, err := r.store.Db.ExecContext(ctx, query, pq.Array(keys), time.Now())
 	if err != nil {
 		return store.ContextError(err)
 	}
@@ -178,8 +184,30 @@ func (r *PropertyValuesRepository) DeleteBatch(ctx context.Context, keys []strin
 	}
 
 	if int(rows) != len(keys) {
-		return fmt.Errorf("deleted %d of %d properties: some uuids not found", rows, len(keys))
+		return fmt.Errorf("archived %d of %d property values: some keys not found", rows, len(keys))
 	}
 
 	return nil
 }
+
+// Purge окончательно удаляет значения, которые лежат в архиве с момента before.
+// Значения, на которые ссылаются бренды товаров или фильтры, остаются
+func (r *PropertyValuesRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %s v
+		WHERE v.deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM products p WHERE p.brand_uuid = v.key)
+			AND NOT EXISTS (SELECT 1 FROM filter_values fv WHERE fv.property_values_id = v.id)
+	`, r.tableName)
+
+	result, err := r.store.Db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+	return rows, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"go-monolite/internal/store"
+	"go-monolite/module/audit"
 	"go-monolite/pkg/logger"
 
 	"github.com/google/uuid"
@@ -12,12 +13,14 @@ import (
 )
 
 type Service struct {
+	store              *store.Store
 	propertyRepo       *PropertyRepository
 	propertyValuesRepo *PropertyValuesRepository
+	audit              *audit.Recorder
 }
 
-func NewService(propertyRepo *PropertyRepository, propertyValuesRepo *PropertyValuesRepository) *Service {
-	return &Service{propertyRepo, propertyValuesRepo}
+func NewService(store *store.Store, propertyRepo *PropertyRepository, propertyValuesRepo *PropertyValuesRepository, recorder *audit.Recorder) *Service {
+	return &Service{store, propertyRepo, propertyValuesRepo, recorder}
 }
 
 func (s *Service) Upsert(ctx context.Context, dtos []PropertyDto) (*PropResponse, error) {
@@ -41,8 +44,38 @@ func (s *Service) Upsert(ctx context.Context, dtos []PropertyDto) (*PropResponse
 	}, nil
 }
 
+// Restore возвращает свойство из архива вместе со значениями, перенесёнными в архив с ним
+func (s *Service) Restore(ctx context.Context, propertyUUID uuid.UUID) (*PropertyEnt, error) {
+	var property *PropertyEnt
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.propertyRepo.Restore(ctx, propertyUUID); err != nil {
+			return err
+		}
+
+		var err error
+		if property, err = s.propertyRepo.GetByUUID(ctx, propertyUUID.String()); err != nil {
+			return err
+		}
+
+		property.Values, err = s.propertyValuesRepo.GetByUUID(ctx, propertyUUID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+		if property.Values == nil {
+			property.Values = []PropertyValueEnt{}
+		}
+
+		return s.audit.Record(ctx, audit.Restored(audit.EntityProperty, property.UUID.String(), property))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return property, nil
+}
+
 func (s *Service) upsertProperties(ctx context.Context, dtos []PropertyDto) (*PropertyResponse, error) {
-	deletes, inserts, updates, err := s.preparePropertyDiff(ctx, dtos)
+	current, deletes, inserts, updates, err := s.preparePropertyDiff(ctx, dtos)
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +84,10 @@ func (s *Service) upsertProperties(ctx context.Context, dtos []PropertyDto) (*Pr
 		return nil, err
 	}
 
+	if err := s.audit.Record(ctx, propertyEntries(current, deletes, inserts, updates)...); err != nil {
+		return nil, err
+	}
+
 	return &PropertyResponse{
 		Deletes: deletes,
 		Inserts: inserts,
@@ -59,7 +96,7 @@ func (s *Service) upsertProperties(ctx context.Context, dtos []PropertyDto) (*Pr
 }
 
 func (s *Service) upsertPropertyValues(ctx context.Context, dtos []PropertyDto) (*PropertyValuesResponse, error) {
-	deletes, inserts, updates, err := s.preparePropertyValuesDiff(ctx, dtos)
+	current, deletes, inserts, updates, err := s.preparePropertyValuesDiff(ctx, dtos)
 	if err != nil {
 		return nil, err
 	}
@@ -68,6 +105,10 @@ func (s *Service) upsertPropertyValues(ctx context.Context, dtos []PropertyDto)
 		return nil, err
 	}
 
+	if err := s.audit.Record(ctx, propertyValueEntries(current, deletes, inserts, updates)...); err != nil {
+		return nil, err
+	}
+
 	return &PropertyValuesResponse{
 		Deletes: deletes,
 		Inserts: inserts,
@@ -75,19 +116,20 @@ func (s *Service) upsertPropertyValues(ctx context.Context, dtos []PropertyDto)
 	}, nil
 }
 
-func (s *Service) preparePropertyDiff(ctx context.Context, dtos []PropertyDto) (deletes, inserts, updates []PropertyEnt, err error) {
+// preparePropertyDiff — изменения свойств и их текущее состояние по UUID
+func (s *Service) preparePropertyDiff(ctx context.Context, dtos []PropertyDto) (current map[string]PropertyEnt, deletes, inserts, updates []PropertyEnt, err error) {
 	existing, err := s.propertyRepo.GetList(ctx)
 	if err != nil && !errors.Is(err, store.ErrNotFound) {
-		return nil, nil, nil, fmt.Errorf("ошибка при выполнении propertyRepo.GetList: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("ошибка при выполнении propertyRepo.GetList: %w", err)
 	}
 
 	desired := toPropertySlice(dtos)
 
-	currentMap := toPropertyMap(existing)
+	current = toPropertyMap(existing)
 	desiredMap := toPropertyMap(desired)
 
-	deletes, inserts, updates = diffProperties(currentMap, desiredMap)
-	return deletes, inserts, updates, nil
+	deletes, inserts, updates = diffProperties(current, desiredMap)
+	return current, deletes, inserts, updates, nil
 }
 
 func (s *Service) applyPropertyChanges(ctx context.Context, deletes, inserts, updates []PropertyEnt) error {
@@ -132,7 +174,8 @@ func (s *Service) applyPropertyChanges(ctx context.Context, deletes, inserts, up
 	return g.Wait()
 }
 
-func (s *Service) preparePropertyValuesDiff(ctx context.Context, dtos []PropertyDto) (deletes, inserts, updates []PropertyValueEnt, err error) {
+// preparePropertyValuesDiff — изменения значений свойств и их текущее состояние по ключу
+func (s *Service) preparePropertyValuesDiff(ctx context.Context, dtos []PropertyDto) (current map[string]PropertyValueEnt, deletes, inserts, updates []PropertyValueEnt, err error) {
 	var allValueDtos []PropertyValueDto
 	for _, dto := range dtos {
 		allValueDtos = append(allValueDtos, dto.Values...)
@@ -140,18 +183,18 @@ func (s *Service) preparePropertyValuesDiff(ctx context.Context, dtos []Property
 
 	existing, err := s.propertyValuesRepo.GetList(ctx)
 	if err != nil && !errors.Is(err, store.ErrNotFound) {
-		return nil, nil, nil, fmt.Errorf("ошибка при выполнении propertyValuesRepo.GetList: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("ошибка при выполнении propertyValuesRepo.GetList: %w", err)
 
 	}
 
 	desired := toPropertyValueSlice(allValueDtos)
 
-	currentMap := toPropertyValueMap(existing)
+	current = toPropertyValueMap(existing)
 	desiredMap := toPropertyValueMap(desired)
 
-	// deletes нет, были удалены вместе с property cascade
-	deletes, inserts, updates = diffPropertyValues(currentMap, desiredMap)
-	return deletes, inserts, updates, nil
+	// значения свойств из deletes уже в архиве вместе со свойством и в deletes не попадут
+	deletes, inserts, updates = diffPropertyValues(current, desiredMap)
+	return current, deletes, inserts, updates, nil
 }
 
 func (s *Service) applyPropertyValueChanges(ctx context.Context, deletes, inserts, updates []PropertyValueEnt) error {
@@ -164,9 +207,9 @@ func (s *Service) applyPropertyValueChanges(ctx context.Context, deletes, insert
 				keys = append(keys, p.Key)
 			}
 			err := s.propertyValuesRepo.DeleteBatch(ctx, keys)
-			logger.DebugCtx(ctx, "inserts []PropertyValueEnt", "inserts", inserts)
+			logger.DebugCtx(ctx, "delete []keys", "keys", keys)
 			if err != nil {
-				return fmt.Errorf("ошибка при выполнении propertyValuesRepo.CreateBatch: %w", err)
+				return fmt.Errorf("ошибка при выполнении propertyValuesRepo.DeleteBatch: %w", err)
 			}
 			return nil
 		})
@@ -198,16 +241,17 @@ func (s *Service) applyPropertyValueChanges(ctx context.Context, deletes, insert
 }
 
 // TODO: так же передается поле "помечено на удаление", но решил пока игнорировать
+// Свойства, которых нет в выгрузке, уходят в архив, архивные свойства из выгрузки возвращаются обновлением
 func diffProperties(current, desired map[string]PropertyEnt) (deletes, inserts, updates []PropertyEnt) {
 	for key, curr := range current {
-		if _, ok := desired[key]; !ok {
+		if _, ok := desired[key]; !ok && curr.DeletedAt == nil {
 			deletes = append(deletes, curr)
 		}
 	}
 	for key, want := range desired {
 		curr, ok := current[key]
 		if ok {
-			if !isPropertyEqual(curr, want) {
+			if curr.DeletedAt != nil || !isPropertyEqual(curr, want) {
 				updates = append(updates, want)
 			}
 		} else {
@@ -219,14 +263,14 @@ func diffProperties(current, desired map[string]PropertyEnt) (deletes, inserts,
 
 func diffPropertyValues(current, desired map[string]PropertyValueEnt) (deletes, inserts, updates []PropertyValueEnt) {
 	for key, curr := range current {
-		if _, ok := desired[key]; !ok {
+		if _, ok := desired[key]; !ok && curr.DeletedAt == nil {
 			deletes = append(deletes, curr)
 		}
 	}
 	for key, want := range desired {
 		curr, ok := current[key]
 		if ok {
-			if !isPropertyValueEqual(curr, want) {
+			if curr.DeletedAt != nil || !isPropertyValueEqual(curr, want) {
 				updates = append(updates, want)
 			}
 		} else {
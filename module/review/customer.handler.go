@@ -0,0 +1,161 @@
+package review
+
+import (
+	"go-monolite/internal/store"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// CustomerHandler — отзывы покупателя, подключается к /user/me/reviews
+type CustomerHandler struct {
+	service  *Service
+	userAuth func(next http.Handler) http.Handler
+}
+
+// NewCustomerHandler — userAuth (auth.UserAuth) собирается один раз в роутере сервера
+func NewCustomerHandler(store *store.Store, userAuth func(next http.Handler) http.Handler) *CustomerHandler {
+	return &CustomerHandler{
+		service:  NewService(store, NewRepository(store)),
+		userAuth: userAuth,
+	}
+}
+
+func (h *CustomerHandler) Init(r chi.Router) {
+	r.Use(h.userAuth)
+
+	r.Get("/", h.GetList)
+	r.Post("/", h.Create)
+	r.Put("/{id}", h.Update)
+	r.Delete("/{id}", h.Delete)
+}
+
+// @Summary My reviews
+// @Description Get reviews of the authenticated user in all statuses, newest first
+// @Tags review
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} respond.SuccessResponse{data=[]ReviewResponse}
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /user/me/reviews [get]
+func (h *CustomerHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.UserReviews(r.Context(), identity.UserID)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Write review
+// @Description Write a review of an active product, one per product. The review is published after moderation
+// @Tags review
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param request body CreateRequest true "Review"
+// @Success 201 {object} respond.SuccessResponse{data=ReviewResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /user/me/reviews [post]
+func (h *CustomerHandler) Create(w http.ResponseWriter, r *http.Request) {
+	body := respond.ParseBody(w, r)
+
+	var request CreateRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.Create(r.Context(), identity.UserID, request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusCreated, "отзыв отправлен на модерацию", resp)
+}
+
+// @Summary Edit my review
+// @Description Edit a review of the authenticated user, the review goes back to moderation
+// @Tags review
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Review ID"
+// @Param request body ReviewRequest true "Review"
+// @Success 200 {object} respond.SuccessResponse{data=ReviewResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /user/me/reviews/{id} [put]
+func (h *CustomerHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	body := respond.ParseBody(w, r)
+
+	var request ReviewRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	resp, mess, err := h.service.Update(r.Context(), identity.UserID, id, request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "отзыв отправлен на модерацию", resp)
+}
+
+// @Summary Delete my review
+// @Description Delete a review of the authenticated user
+// @Tags review
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Review ID"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /user/me/reviews/{id} [delete]
+func (h *CustomerHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	identity, _ := auth.CurrentUser(r.Context())
+
+	mess, err := h.service.DeleteOwn(r.Context(), identity.UserID, id)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess)
+}
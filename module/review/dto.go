@@ -0,0 +1,112 @@
+package review
+
+import (
+	"go-monolite/pkg/validator"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewRequest — DTO для PUT /user/me/reviews/{id}
+type ReviewRequest struct {
+	Rating int      `json:"rating" validate:"required,min=1,max=5" example:"5"`
+	Text   *string  `json:"text,omitempty" validate:"omitempty,max=5000" example:"Удобная дрель, держит заряд весь день"`
+	Pros   *string  `json:"pros,omitempty" validate:"omitempty,max=2000" example:"Лёгкая"`
+	Cons   *string  `json:"cons,omitempty" validate:"omitempty,max=2000" example:"Нет кейса"`
+	Photos []string `json:"photos,omitempty" validate:"max=10,dive,url,max=2048"`
+}
+
+func (d *ReviewRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// CreateRequest — DTO для POST /user/me/reviews
+type CreateRequest struct {
+	ProductUUID uuid.UUID `json:"product_uuid" validate:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ReviewRequest
+}
+
+func (d *CreateRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// StatusRequest — DTO для POST /review/{id}/status
+type StatusRequest struct {
+	Status string  `json:"status" validate:"required,oneof=approved rejected" example:"approved"`
+	Reason *string `json:"reason,omitempty" validate:"required_if=Status rejected,omitempty,max=1000" example:"Отзыв не о товаре"`
+}
+
+func (d *StatusRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// ListRequest — фильтр отзывов для модерации
+type ListRequest struct {
+	Status      string `validate:"omitempty,oneof=pending approved rejected"`
+	ProductUUID *uuid.UUID
+	UserID      int64 `validate:"min=0"`
+	Limit       int   `validate:"min=1,max=100"`
+	Offset      int   `validate:"min=0"`
+}
+
+func (d *ListRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// ProductRequest — одобренные отзывы товара: new — сначала новые, high и low — по оценке
+type ProductRequest struct {
+	Sort   string `validate:"omitempty,oneof=new high low"`
+	Limit  int    `validate:"min=1,max=100"`
+	Offset int    `validate:"min=0"`
+}
+
+func (d *ProductRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+type ReviewResponse struct {
+	ID           int64      `json:"id" example:"1"`
+	ProductUUID  uuid.UUID  `json:"product_uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	UserID       int64      `json:"user_id" example:"1"`
+	Author       string     `json:"author" example:"Иван"`
+	Rating       int        `json:"rating" example:"5"`
+	Text         *string    `json:"text,omitempty"`
+	Pros         *string    `json:"pros,omitempty"`
+	Cons         *string    `json:"cons,omitempty"`
+	Photos       []string   `json:"photos"`
+	Status       string     `json:"status" example:"pending"`
+	RejectReason *string    `json:"reject_reason,omitempty"`
+	ModeratedBy  *int64     `json:"moderated_by,omitempty"`
+	ModeratedAt  *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type PublicReviewResponse struct {
+	ID        int64     `json:"id" example:"1"`
+	Author    string    `json:"author" example:"Иван"`
+	Rating    int       `json:"rating" example:"5"`
+	Text      *string   `json:"text,omitempty"`
+	Pros      *string   `json:"pros,omitempty"`
+	Cons      *string   `json:"cons,omitempty"`
+	Photos    []string  `json:"photos"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SummaryResponse — рейтинг товара и число одобренных отзывов с каждой оценкой
+type SummaryResponse struct {
+	Rating       *float64    `json:"rating,omitempty" example:"4.5"`
+	Count        int         `json:"count" example:"12"`
+	Distribution map[int]int `json:"distribution"`
+}
+
+type ProductReviewsResponse struct {
+	Summary SummaryResponse        `json:"summary"`
+	Items   []PublicReviewResponse `json:"items"`
+	Total   int                    `json:"total"`
+}
+
+type ListResponse struct {
+	Items []ReviewResponse `json:"items"`
+	Total int              `json:"total"`
+}
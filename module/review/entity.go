@@ -0,0 +1,103 @@
+package review
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// transitions — решения модератора: одобренный отзыв можно снять, отклонённый — одобрить после пересмотра
+var transitions = map[string][]string{
+	StatusPending:  {StatusApproved, StatusRejected},
+	StatusApproved: {StatusRejected},
+	StatusRejected: {StatusApproved},
+}
+
+// CanTransition — можно ли перевести отзыв из статуса from в to
+func CanTransition(from, to string) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+type ReviewEnt struct {
+	ID           int64          `db:"id"`
+	ProductUUID  uuid.UUID      `db:"product_uuid"`
+	UserID       int64          `db:"user_id"`
+	Rating       int            `db:"rating"`
+	Text         *string        `db:"text"`
+	Pros         *string        `db:"pros"`
+	Cons         *string        `db:"cons"`
+	Photos       pq.StringArray `db:"photos"`
+	Status       string         `db:"status"`
+	RejectReason *string        `db:"reject_reason"`
+	ModeratedBy  *int64         `db:"moderated_by"`
+	ModeratedAt  *time.Time     `db:"moderated_at"`
+	CreatedAt    time.Time      `db:"created_at"`
+	UpdatedAt    time.Time      `db:"updated_at"`
+
+	AuthorName *string `db:"author_name"` // имя автора из профиля
+}
+
+func (e ReviewEnt) ToResponse() ReviewResponse {
+	return ReviewResponse{
+		ID:           e.ID,
+		ProductUUID:  e.ProductUUID,
+		UserID:       e.UserID,
+		Author:       e.Author(),
+		Rating:       e.Rating,
+		Text:         e.Text,
+		Pros:         e.Pros,
+		Cons:         e.Cons,
+		Photos:       photos(e.Photos),
+		Status:       e.Status,
+		RejectReason: e.RejectReason,
+		ModeratedBy:  e.ModeratedBy,
+		ModeratedAt:  e.ModeratedAt,
+		CreatedAt:    e.CreatedAt,
+		UpdatedAt:    e.UpdatedAt,
+	}
+}
+
+// ToPublicResponse — отзыв на странице товара, без данных модерации и идентификатора автора
+func (e ReviewEnt) ToPublicResponse() PublicReviewResponse {
+	return PublicReviewResponse{
+		ID:        e.ID,
+		Author:    e.Author(),
+		Rating:    e.Rating,
+		Text:      e.Text,
+		Pros:      e.Pros,
+		Cons:      e.Cons,
+		Photos:    photos(e.Photos),
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+// Author — подпись отзыва, если покупатель не указал имя — «Покупатель»
+func (e ReviewEnt) Author() string {
+	if e.AuthorName != nil && *e.AuthorName != "" {
+		return *e.AuthorName
+	}
+	return "Покупатель"
+}
+
+// ProductEnt — товар с рейтингом из одобренных отзывов
+type ProductEnt struct {
+	UUID         uuid.UUID `db:"uuid"`
+	Active       string    `db:"active"`
+	Rating       *float64  `db:"rating"`
+	ReviewsCount int       `db:"reviews_count"`
+}
+
+func photos(values pq.StringArray) []string {
+	if values == nil {
+		return []string{}
+	}
+	return []string(values)
+}
@@ -0,0 +1,9 @@
+package review
+
+import "errors"
+
+var (
+	ErrProductNotFound   = errors.New("product not found")
+	ErrReviewExists      = errors.New("review already exists")
+	ErrInvalidTransition = errors.New("invalid status transition")
+)
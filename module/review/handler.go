@@ -0,0 +1,278 @@
+package review
+
+import (
+	"errors"
+	"go-monolite/internal/store"
+	"go-monolite/module/user"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const defaultListLimit = 50
+
+// Handler — отзывы на странице товара и модерация отзывов
+type Handler struct {
+	service     *Service
+	adminAuth   func(next http.Handler) http.Handler
+	requireRole func(next http.Handler) http.Handler
+}
+
+// NewHandler — authenticate и permissions собираются один раз в роутере сервера
+func NewHandler(store *store.Store, authenticate func(next http.Handler) http.Handler, permissions auth.PermissionChecker) *Handler {
+	return &Handler{
+		service:     NewService(store, NewRepository(store)),
+		adminAuth:   authenticate,
+		requireRole: auth.RequirePermission(permissions, user.PermissionReviewsModerate),
+	}
+}
+
+func (h *Handler) Init(r chi.Router) {
+	r.Get("/product/{productUuid}", h.ProductReviews)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.adminAuth, h.requireRole)
+
+		r.Get("/", h.GetList)
+		r.Get("/{id}", h.Get)
+		r.Post("/{id}/status", h.ChangeStatus)
+		r.Delete("/{id}", h.Delete)
+	})
+}
+
+// @Summary Product reviews
+// @Description Get approved reviews of an active product with the rating summary
+// @Tags review
+// @Produce json
+// @Param productUuid path string true "Product UUID"
+// @Param sort query string false "Sort order: new, high, low" default(new)
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} respond.SuccessResponse{data=ProductReviewsResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /product/{productUuid} [get]
+func (h *Handler) ProductReviews(w http.ResponseWriter, r *http.Request) {
+	productUUID, err := uuid.Parse(chi.URLParam(r, "productUuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный UUID товара")
+		return
+	}
+
+	request := ProductRequest{Sort: r.URL.Query().Get("sort")}
+	if request.Limit, err = queryInt(r, "limit", defaultListLimit); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный limit")
+		return
+	}
+	if request.Offset, err = queryInt(r, "offset", 0); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный offset")
+		return
+	}
+
+	resp, mess, err := h.service.ProductReviews(r.Context(), productUUID, request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary List reviews
+// @Description Get reviews for moderation, oldest first
+// @Tags review
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param status query string false "Status: pending, approved, rejected"
+// @Param product_uuid query string false "Product UUID"
+// @Param user_id query int false "Author ID"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} respond.SuccessResponse{data=ListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [get]
+func (h *Handler) GetList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := ListRequest{Status: query.Get("status")}
+
+	if value := query.Get("product_uuid"); value != "" {
+		productUUID, err := uuid.Parse(value)
+		if err != nil {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный product_uuid")
+			return
+		}
+		request.ProductUUID = &productUUID
+	}
+
+	userID, err := queryInt(r, "user_id", 0)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный user_id")
+		return
+	}
+	request.UserID = int64(userID)
+
+	if request.Limit, err = queryInt(r, "limit", defaultListLimit); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный limit")
+		return
+	}
+	if request.Offset, err = queryInt(r, "offset", 0); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный offset")
+		return
+	}
+
+	resp, mess, err := h.service.List(r.Context(), request)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Get review
+// @Description Get a review with moderation details
+// @Tags review
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Review ID"
+// @Success 200 {object} respond.SuccessResponse{data=ReviewResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	resp, mess, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Moderate review
+// @Description Approve or reject a review: pending → approved or rejected, an approved review can be rejected and a rejected one approved. Rejection requires a reason. Only approved reviews count towards the product rating
+// @Tags review
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Review ID"
+// @Param request body StatusRequest true "Moderation decision"
+// @Success 200 {object} respond.SuccessResponse{data=ReviewResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id}/status [post]
+func (h *Handler) ChangeStatus(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	body := respond.ParseBody(w, r)
+
+	var request StatusRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	var moderatedBy *int64
+	if identity, _ := auth.CurrentUser(r.Context()); identity.UserID != 0 {
+		moderatedBy = &identity.UserID
+	}
+
+	resp, mess, err := h.service.Moderate(r.Context(), id, request, moderatedBy)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "статус отзыва изменён", resp)
+}
+
+// @Summary Delete review
+// @Description Delete a review, the product rating is recalculated
+// @Tags review
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "Review ID"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id} [delete]
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	mess, err := h.service.Delete(r.Context(), id)
+	if err != nil {
+		respondError(w, r, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess)
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный идентификатор отзыва")
+		return 0, false
+	}
+	return id, true
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func respondError(w http.ResponseWriter, r *http.Request, err error, mess string) {
+	var validationErrors validator.ValidationError
+	if errors.As(err, &validationErrors) {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+		return
+	}
+
+	switch {
+	case errors.Is(err, store.ErrNotFound), errors.Is(err, ErrProductNotFound):
+		respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+	case errors.Is(err, ErrReviewExists), errors.Is(err, ErrInvalidTransition):
+		respond.ErrorHandler(w, r, http.StatusConflict, err, mess)
+	default:
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+	}
+}
@@ -0,0 +1,265 @@
+package review_test
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/module/product"
+	"go-monolite/module/review"
+	"go-monolite/module/user"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"go-monolite/pkg/token"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewIntegration(t *testing.T) {
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	access := testinit.NewAuth(store, config)
+	server := testinit.SetupTestServer(t, review.NewHandler(store, access.Authenticate, access.Users))
+	defer server.Close()
+	customer := testinit.SetupTestServer(t, review.NewCustomerHandler(store, access.UserAuth))
+	defer customer.Close()
+	products := testinit.SetupTestServer(t, product.NewHandler(store))
+	defer products.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	ctx := context.Background()
+	tokenManager := token.NewManager(config.Auth.JWTSecret, time.Minute)
+
+	categoryUUID := uuid.New()
+	drill, hammer, archived := uuid.New(), uuid.New(), uuid.New()
+	for _, query := range []string{
+		fmt.Sprintf(`INSERT INTO categories (uuid, slug, name) VALUES ('%s', 'tools', 'Инструмент')`, categoryUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Дрель', 9301, 'drill', '%s')`, drill, categoryUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid) VALUES ('%s', 'Молоток', 9302, 'hammer', '%s')`, hammer, categoryUUID),
+		fmt.Sprintf(`INSERT INTO products (uuid, name, code, slug, category_uuid, active) VALUES ('%s', 'Архивная дрель', 9303, 'archived', '%s', 'N')`, archived, categoryUUID),
+	} {
+		_, err := store.Db.Exec(query)
+		require.NoError(t, err)
+	}
+
+	users := user.NewRepository(store)
+	newCustomer := func(t *testing.T, phone string, name *string) string {
+		t.Helper()
+		tv := user.InitialTokenVersion
+		userID, err := users.Create(ctx, &user.UserEnt{
+			Phone:        &phone,
+			Name:         name,
+			UserType:     user.UserTypeIndividual,
+			Active:       user.ActiveYes,
+			TokenVersion: &tv,
+		})
+		require.NoError(t, err)
+		accessToken, _, err := tokenManager.Generate(userID, tv, "")
+		require.NoError(t, err)
+		return accessToken
+	}
+	ivan := "Иван"
+	first := newCustomer(t, "79990000401", &ivan)
+	second := newCustomer(t, "79990000402", nil)
+	admin := config.HTTPServer.BearerToken
+
+	send := func(t *testing.T, server *httptest.Server, method, path, body, accessToken string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	decode := func(t *testing.T, resp *http.Response, status int, v any) {
+		t.Helper()
+		require.Equal(t, status, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+		testinit.MarshalUnmarshal(t, response.Data, v)
+	}
+
+	productReviews := func(t *testing.T, query string) review.ProductReviewsResponse {
+		t.Helper()
+		var p review.ProductReviewsResponse
+		decode(t, send(t, server, http.MethodGet, "/product/"+drill.String()+query, "", ""), http.StatusOK, &p)
+		return p
+	}
+
+	moderate := func(t *testing.T, id int64, body string, status int) review.ReviewResponse {
+		t.Helper()
+		var r review.ReviewResponse
+		resp := send(t, server, http.MethodPost, fmt.Sprintf("/%d/status", id), body, admin)
+		if status != http.StatusOK {
+			assert.Equal(t, status, resp.StatusCode)
+			return r
+		}
+		decode(t, resp, http.StatusOK, &r)
+		return r
+	}
+
+	var firstReview, secondReview review.ReviewResponse
+
+	t.Run("Create", func(t *testing.T) {
+		body := fmt.Sprintf(`{"product_uuid": "%s", "rating": 5, "text": "Отличная дрель", "pros": "Лёгкая", "photos": ["https://cdn.example.com/1.jpg"]}`, drill)
+		decode(t, send(t, customer, http.MethodPost, "/", body, first), http.StatusCreated, &firstReview)
+		assert.Equal(t, review.StatusPending, firstReview.Status)
+		assert.Equal(t, "Иван", firstReview.Author)
+		assert.Equal(t, []string{"https://cdn.example.com/1.jpg"}, firstReview.Photos)
+
+		// один отзыв на товар от пользователя
+		resp := send(t, customer, http.MethodPost, "/", body, first)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = send(t, customer, http.MethodPost, "/", fmt.Sprintf(`{"product_uuid": "%s", "rating": 5}`, archived), first)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp = send(t, customer, http.MethodPost, "/", fmt.Sprintf(`{"product_uuid": "%s", "rating": 6}`, drill), second)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, customer, http.MethodPost, "/", fmt.Sprintf(`{"product_uuid": "%s", "rating": 4, "photos": ["not a url"]}`, drill), second)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, customer, http.MethodPost, "/", body, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		decode(t, send(t, customer, http.MethodPost, "/", fmt.Sprintf(`{"product_uuid": "%s", "rating": 4, "cons": "Шумная"}`, drill), second),
+			http.StatusCreated, &secondReview)
+		assert.Equal(t, "Покупатель", secondReview.Author)
+		assert.Empty(t, secondReview.Photos)
+
+		// до модерации отзывы не видны
+		p := productReviews(t, "")
+		assert.Empty(t, p.Items)
+		assert.Nil(t, p.Summary.Rating)
+		assert.Equal(t, 0, p.Summary.Count)
+	})
+
+	t.Run("Moderation Access", func(t *testing.T) {
+		resp := send(t, server, http.MethodGet, "/", "", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		resp = send(t, server, http.MethodGet, "/", "", first)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		var l review.ListResponse
+		decode(t, send(t, server, http.MethodGet, "/?status=pending", "", admin), http.StatusOK, &l)
+		assert.Equal(t, 2, l.Total)
+		require.Len(t, l.Items, 2)
+		assert.Equal(t, firstReview.ID, l.Items[0].ID)
+
+		resp = send(t, server, http.MethodGet, "/?status=unknown", "", admin)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Approve", func(t *testing.T) {
+		r := moderate(t, firstReview.ID, `{"status": "approved"}`, http.StatusOK)
+		assert.Equal(t, review.StatusApproved, r.Status)
+		assert.NotNil(t, r.ModeratedAt)
+		moderate(t, firstReview.ID, `{"status": "approved"}`, http.StatusConflict)
+		moderate(t, secondReview.ID, `{"status": "pending"}`, http.StatusBadRequest)
+		moderate(t, secondReview.ID, `{"status": "approved"}`, http.StatusOK)
+
+		p := productReviews(t, "")
+		require.Len(t, p.Items, 2)
+		require.NotNil(t, p.Summary.Rating)
+		assert.Equal(t, 4.5, *p.Summary.Rating)
+		assert.Equal(t, 2, p.Summary.Count)
+		assert.Equal(t, map[int]int{1: 0, 2: 0, 3: 0, 4: 1, 5: 1}, p.Summary.Distribution)
+
+		p = productReviews(t, "?sort=low")
+		assert.Equal(t, 4, p.Items[0].Rating)
+		p = productReviews(t, "?sort=high&limit=1")
+		require.Len(t, p.Items, 1)
+		assert.Equal(t, 5, p.Items[0].Rating)
+		assert.Equal(t, 2, p.Total)
+
+		resp := send(t, server, http.MethodGet, "/product/"+drill.String()+"?sort=random", "", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, server, http.MethodGet, "/product/"+archived.String(), "", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Product Rating", func(t *testing.T) {
+		var card product.ProductEnt
+		decode(t, send(t, products, http.MethodGet, "/"+drill.String(), "", ""), http.StatusOK, &card)
+		require.NotNil(t, card.Rating)
+		assert.Equal(t, 4.5, *card.Rating)
+		assert.Equal(t, 2, card.ReviewsCount)
+
+		// товары без отзывов — в конце, выключенные не показываются
+		var l product.ListResponse
+		decode(t, send(t, products, http.MethodGet, "/?sort=rating", "", ""), http.StatusOK, &l)
+		assert.Equal(t, 2, l.Total)
+		require.Len(t, l.Items, 2)
+		assert.Equal(t, drill, l.Items[0].UUID)
+		assert.Equal(t, hammer, l.Items[1].UUID)
+		assert.Nil(t, l.Items[1].Rating)
+
+		resp := send(t, products, http.MethodGet, "/?sort=price", "", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, products, http.MethodGet, "/"+archived.String(), "", "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Reject And Edit", func(t *testing.T) {
+		// отклонение требует причины
+		moderate(t, firstReview.ID, `{"status": "rejected"}`, http.StatusBadRequest)
+		r := moderate(t, firstReview.ID, `{"status": "rejected", "reason": "Ссылки на другие магазины"}`, http.StatusOK)
+		require.NotNil(t, r.RejectReason)
+
+		p := productReviews(t, "")
+		require.Len(t, p.Items, 1)
+		assert.Equal(t, 4.0, *p.Summary.Rating)
+
+		// после правки отзыв снова на модерации
+		var edited review.ReviewResponse
+		decode(t, send(t, customer, http.MethodPut, fmt.Sprintf("/%d", firstReview.ID), `{"rating": 3, "text": "Нормальная дрель"}`, first),
+			http.StatusOK, &edited)
+		assert.Equal(t, review.StatusPending, edited.Status)
+		assert.Equal(t, 3, edited.Rating)
+		assert.Nil(t, edited.RejectReason)
+		assert.Empty(t, edited.Photos)
+
+		// чужой отзыв не найти
+		resp := send(t, customer, http.MethodPut, fmt.Sprintf("/%d", firstReview.ID), `{"rating": 1}`, second)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp = send(t, customer, http.MethodDelete, fmt.Sprintf("/%d", firstReview.ID), "", second)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		var mine []review.ReviewResponse
+		decode(t, send(t, customer, http.MethodGet, "/", "", first), http.StatusOK, &mine)
+		require.Len(t, mine, 1)
+		assert.Equal(t, review.StatusPending, mine[0].Status)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		resp := send(t, customer, http.MethodDelete, fmt.Sprintf("/%d", secondReview.ID), "", second)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		p := productReviews(t, "")
+		assert.Empty(t, p.Items)
+		assert.Nil(t, p.Summary.Rating)
+
+		resp = send(t, server, http.MethodDelete, fmt.Sprintf("/%d", firstReview.ID), "", admin)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = send(t, server, http.MethodGet, fmt.Sprintf("/%d", firstReview.ID), "", admin)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		var card product.ProductEnt
+		decode(t, send(t, products, http.MethodGet, "/"+drill.String(), "", ""), http.StatusOK, &card)
+		assert.Equal(t, 0, card.ReviewsCount)
+	})
+}
@@ -0,0 +1,9 @@
+DELETE FROM permissions WHERE code = 'reviews:moderate';
+
+DROP TRIGGER IF EXISTS reviews_product_rating ON reviews;
+DROP FUNCTION IF EXISTS reviews_update_product_rating();
+
+DROP INDEX IF EXISTS products_rating_idx;
+ALTER TABLE products DROP COLUMN IF EXISTS reviews_count, DROP COLUMN IF EXISTS rating;
+
+DROP TABLE IF EXISTS reviews;
@@ -0,0 +1,63 @@
+-- отзыв покупателя о товаре, один на пользователя и товар. В рейтинге товара учитываются только одобренные
+CREATE TABLE IF NOT EXISTS reviews (
+  id BIGSERIAL PRIMARY KEY,
+  product_uuid UUID NOT NULL REFERENCES products(uuid) ON DELETE CASCADE,
+  user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+  text TEXT,
+  pros TEXT,
+  cons TEXT,
+  photos TEXT[] NOT NULL DEFAULT '{}', -- URL фотографий
+  status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+  reject_reason TEXT,
+  moderated_by INT REFERENCES users(id) ON DELETE SET NULL,
+  moderated_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (product_uuid, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS reviews_product_idx ON reviews (product_uuid, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS reviews_status_idx ON reviews (status, created_at);
+
+-- средняя оценка и число одобренных отзывов, пересчитываются триггером
+ALTER TABLE products
+  ADD COLUMN IF NOT EXISTS rating NUMERIC(3, 2),
+  ADD COLUMN IF NOT EXISTS reviews_count INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS products_rating_idx ON products (rating DESC NULLS LAST, reviews_count DESC);
+
+CREATE OR REPLACE FUNCTION reviews_update_product_rating() RETURNS trigger AS $$
+DECLARE
+  product UUID;
+BEGIN
+  FOREACH product IN ARRAY ARRAY[
+    CASE WHEN TG_OP <> 'INSERT' THEN OLD.product_uuid END,
+    CASE WHEN TG_OP <> 'DELETE' THEN NEW.product_uuid END
+  ] LOOP
+    CONTINUE WHEN product IS NULL;
+    UPDATE products p
+    SET rating = s.rating, reviews_count = s.count
+    FROM (
+      SELECT ROUND(AVG(rating), 2) AS rating, COUNT(*) AS count
+      FROM reviews
+      WHERE product_uuid = product AND status = 'approved'
+    ) s
+    WHERE p.uuid = product;
+  END LOOP;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS reviews_product_rating ON reviews;
+CREATE TRIGGER reviews_product_rating
+AFTER INSERT OR DELETE OR UPDATE OF status, rating, product_uuid ON reviews
+FOR EACH ROW EXECUTE FUNCTION reviews_update_product_rating();
+
+INSERT INTO permissions (code, name) VALUES ('reviews:moderate', 'Модерация отзывов')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.code IN ('admin', 'content_manager') AND p.code = 'reviews:moderate'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,251 @@
+package review
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-monolite/internal/store"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const reviewColumns = `r.id, r.product_uuid, r.user_id, r.rating, r.text, r.pros, r.cons, r.photos, r.status,
+	r.reject_reason, r.moderated_by, r.moderated_at, r.created_at, r.updated_at, u.name AS author_name`
+
+// productSorts — порядок одобренных отзывов на странице товара
+var productSorts = map[string]string{
+	"":     "r.created_at DESC, r.id DESC",
+	"new":  "r.created_at DESC, r.id DESC",
+	"high": "r.rating DESC, r.created_at DESC, r.id DESC",
+	"low":  "r.rating, r.created_at DESC, r.id DESC",
+}
+
+type Repository struct {
+	store     *store.Store
+	tableName string
+}
+
+func NewRepository(store *store.Store) *Repository {
+	return &Repository{
+		store:     store,
+		tableName: "reviews",
+	}
+}
+
+// Create добавляет отзыв, если пользователь уже оставил отзыв о товаре — store.ErrConflict
+func (r *Repository) Create(ctx context.Context, e *ReviewEnt) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (product_uuid, user_id, rating, text, pros, cons, photos, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING id
+	`, r.tableName)
+
+	now := time.Now()
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query,
+		e.ProductUUID, e.UserID, e.Rating, e.Text, e.Pros, e.Cons, e.Photos, e.Status, now,
+	).Scan(&e.ID)
+	if err != nil {
+		if store.IsUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// Update сохраняет текст и оценку отзыва вместе со статусом и данными модерации
+func (r *Repository) Update(ctx context.Context, e *ReviewEnt) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET rating = $2, text = $3, pros = $4, cons = $5, photos = $6, status = $7,
+			reject_reason = $8, moderated_by = $9, moderated_at = $10, updated_at = $11
+		WHERE id = $1
+	`, r.tableName)
+
+	e.UpdatedAt = time.Now()
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query,
+		e.ID, e.Rating, e.Text, e.Pros, e.Cons, e.Photos, e.Status,
+		e.RejectReason, e.ModeratedBy, e.ModeratedAt, e.UpdatedAt,
+	)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return affectedOne(res)
+}
+
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, r.tableName)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return affectedOne(res)
+}
+
+// GetByID — отзыв с именем автора, forUpdate блокирует его до конца транзакции
+func (r *Repository) GetByID(ctx context.Context, id int64, forUpdate bool) (*ReviewEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s r
+		LEFT JOIN users u ON u.id = r.user_id
+		WHERE r.id = $1
+	`, reviewColumns, r.tableName)
+	if forUpdate {
+		query += " FOR UPDATE OF r"
+	}
+
+	var e ReviewEnt
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+// List — отзывы для модерации, сначала старые, чтобы очередь разбиралась по порядку
+func (r *Repository) List(ctx context.Context, req ListRequest) ([]ReviewEnt, int, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if req.Status != "" {
+		args = append(args, req.Status)
+		where = append(where, fmt.Sprintf("r.status = $%d", len(args)))
+	}
+	if req.ProductUUID != nil {
+		args = append(args, *req.ProductUUID)
+		where = append(where, fmt.Sprintf("r.product_uuid = $%d", len(args)))
+	}
+	if req.UserID != 0 {
+		args = append(args, req.UserID)
+		where = append(where, fmt.Sprintf("r.user_id = $%d", len(args)))
+	}
+
+	filter := ""
+	if len(where) > 0 {
+		filter = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s r %s`, r.tableName, filter)
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &total, countQuery, args...); err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s r
+		LEFT JOIN users u ON u.id = r.user_id
+		%s
+		ORDER BY r.created_at, r.id
+		LIMIT $%d OFFSET $%d
+	`, reviewColumns, r.tableName, filter, len(args)+1, len(args)+2)
+
+	var reviews []ReviewEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &reviews, query, append(args, req.Limit, req.Offset)...); err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	return reviews, total, nil
+}
+
+// UserReviews — отзывы пользователя, сначала новые
+func (r *Repository) UserReviews(ctx context.Context, userID int64) ([]ReviewEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s r
+		LEFT JOIN users u ON u.id = r.user_id
+		WHERE r.user_id = $1
+		ORDER BY r.created_at DESC, r.id DESC
+	`, reviewColumns, r.tableName)
+
+	var reviews []ReviewEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &reviews, query, userID); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return reviews, nil
+}
+
+// ProductReviews — одобренные отзывы о товаре
+func (r *Repository) ProductReviews(ctx context.Context, productUUID uuid.UUID, req ProductRequest) ([]ReviewEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s r
+		LEFT JOIN users u ON u.id = r.user_id
+		WHERE r.product_uuid = $1 AND r.status = $2
+		ORDER BY %s
+		LIMIT $3 OFFSET $4
+	`, reviewColumns, r.tableName, productSorts[req.Sort])
+
+	var reviews []ReviewEnt
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &reviews, query, productUUID, StatusApproved, req.Limit, req.Offset); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return reviews, nil
+}
+
+// Distribution — число одобренных отзывов о товаре по оценкам
+func (r *Repository) Distribution(ctx context.Context, productUUID uuid.UUID) (map[int]int, error) {
+	query := fmt.Sprintf(`
+		SELECT rating, COUNT(*) AS count FROM %s
+		WHERE product_uuid = $1 AND status = $2
+		GROUP BY rating
+	`, r.tableName)
+
+	var rows []struct {
+		Rating int `db:"rating"`
+		Count  int `db:"count"`
+	}
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &rows, query, productUUID, StatusApproved); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	distribution := map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	for _, row := range rows {
+		distribution[row.Rating] = row.Count
+	}
+
+	return distribution, nil
+}
+
+// Product — товар с рейтингом, который пересчитывает триггер reviews_product_rating.
+// Товар из архива считается выключенным
+func (r *Repository) Product(ctx context.Context, productUUID uuid.UUID) (*ProductEnt, error) {
+	query := `
+		SELECT uuid, CASE WHEN deleted_at IS NULL THEN active ELSE 'N' END AS active, rating, reviews_count
+		FROM products WHERE uuid = $1
+	`
+
+	var e ProductEnt
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &e, query, productUUID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &e, nil
+}
+
+func affectedOne(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"go-monolite/internal/store"
+	"go-monolite/pkg/helper"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Service struct {
+	store *store.Store
+	repo  *Repository
+}
+
+func NewService(store *store.Store, repo *Repository) *Service {
+	return &Service{
+		store: store,
+		repo:  repo,
+	}
+}
+
+// ProductReviews — одобренные отзывы о включённом товаре и сводка по оценкам
+func (s *Service) ProductReviews(ctx context.Context, productUUID uuid.UUID, req ProductRequest) (*ProductReviewsResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	product, err := s.activeProduct(ctx, productUUID)
+	if err != nil {
+		return nil, reviewMessage(err, "произошла ошибка при получении отзывов"), err
+	}
+
+	reviews, err := s.repo.ProductReviews(ctx, productUUID, req)
+	if err != nil {
+		return nil, "произошла ошибка при получении отзывов", err
+	}
+	distribution, err := s.repo.Distribution(ctx, productUUID)
+	if err != nil {
+		return nil, "произошла ошибка при получении отзывов", err
+	}
+
+	items := make([]PublicReviewResponse, 0, len(reviews))
+	for _, e := range reviews {
+		items = append(items, e.ToPublicResponse())
+	}
+
+	return &ProductReviewsResponse{
+		Summary: SummaryResponse{Rating: product.Rating, Count: product.ReviewsCount, Distribution: distribution},
+		Items:   items,
+		Total:   product.ReviewsCount,
+	}, "", nil
+}
+
+// UserReviews — отзывы пользователя во всех статусах
+func (s *Service) UserReviews(ctx context.Context, userID int64) ([]ReviewResponse, string, error) {
+	reviews, err := s.repo.UserReviews(ctx, userID)
+	if err != nil {
+		return nil, "произошла ошибка при получении отзывов", err
+	}
+
+	return helper.ToResponse(reviews), "", nil
+}
+
+// Create — отзыв покупателя о товаре, публикуется после одобрения модератором
+func (s *Service) Create(ctx context.Context, userID int64, req CreateRequest) (*ReviewResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	e := &ReviewEnt{
+		ProductUUID: req.ProductUUID,
+		UserID:      userID,
+		Status:      StatusPending,
+	}
+	req.ReviewRequest.apply(e)
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.activeProduct(ctx, req.ProductUUID); err != nil {
+			return err
+		}
+		if err := s.repo.Create(ctx, e); err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				return ErrReviewExists
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, reviewMessage(err, "произошла ошибка при создании отзыва"), err
+	}
+
+	return s.get(ctx, e.ID)
+}
+
+// Update — правка своего отзыва, отзыв снова уходит на модерацию
+func (s *Service) Update(ctx context.Context, userID, id int64, req ReviewRequest) (*ReviewResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		e, err := s.userReview(ctx, userID, id, true)
+		if err != nil {
+			return err
+		}
+
+		req.apply(e)
+		e.Status = StatusPending
+		e.RejectReason = nil
+		e.ModeratedBy = nil
+		e.ModeratedAt = nil
+		return s.repo.Update(ctx, e)
+	})
+	if err != nil {
+		return nil, reviewMessage(err, "произошла ошибка при изменении отзыва"), err
+	}
+
+	return s.get(ctx, id)
+}
+
+// DeleteOwn — удаление своего отзыва покупателем
+func (s *Service) DeleteOwn(ctx context.Context, userID, id int64) (string, error) {
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.userReview(ctx, userID, id, true); err != nil {
+			return err
+		}
+		return s.repo.Delete(ctx, id)
+	})
+	if err != nil {
+		return reviewMessage(err, "произошла ошибка при удалении отзыва"), err
+	}
+
+	return "отзыв удалён", nil
+}
+
+func (s *Service) List(ctx context.Context, req ListRequest) (*ListResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	reviews, total, err := s.repo.List(ctx, req)
+	if err != nil {
+		return nil, "произошла ошибка при получении отзывов", err
+	}
+
+	return &ListResponse{Items: helper.ToResponse(reviews), Total: total}, "", nil
+}
+
+func (s *Service) Get(ctx context.Context, id int64) (*ReviewResponse, string, error) {
+	return s.get(ctx, id)
+}
+
+// Moderate — решение модератора по отзыву, отклонение требует причины
+func (s *Service) Moderate(ctx context.Context, id int64, req StatusRequest, moderatedBy *int64) (*ReviewResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		e, err := s.repo.GetByID(ctx, id, true)
+		if err != nil {
+			return err
+		}
+		if !CanTransition(e.Status, req.Status) {
+			return ErrInvalidTransition
+		}
+
+		now := time.Now()
+		e.Status = req.Status
+		e.RejectReason = nil
+		if req.Status == StatusRejected {
+			e.RejectReason = req.Reason
+		}
+		e.ModeratedBy = moderatedBy
+		e.ModeratedAt = &now
+		return s.repo.Update(ctx, e)
+	})
+	if err != nil {
+		return nil, reviewMessage(err, "произошла ошибка при модерации отзыва"), err
+	}
+
+	return s.get(ctx, id)
+}
+
+func (s *Service) Delete(ctx context.Context, id int64) (string, error) {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return reviewMessage(err, "произошла ошибка при удалении отзыва"), err
+	}
+
+	return "отзыв удалён", nil
+}
+
+func (s *Service) get(ctx context.Context, id int64) (*ReviewResponse, string, error) {
+	e, err := s.repo.GetByID(ctx, id, false)
+	if err != nil {
+		return nil, reviewMessage(err, "произошла ошибка при получении отзыва"), err
+	}
+
+	resp := e.ToResponse()
+	return &resp, "", nil
+}
+
+// userReview — отзыв пользователя, чужой отзыв не отличается от несуществующего
+func (s *Service) userReview(ctx context.Context, userID, id int64, forUpdate bool) (*ReviewEnt, error) {
+	e, err := s.repo.GetByID(ctx, id, forUpdate)
+	if err != nil {
+		return nil, err
+	}
+	if e.UserID != userID {
+		return nil, store.ErrNotFound
+	}
+	return e, nil
+}
+
+func (s *Service) activeProduct(ctx context.Context, productUUID uuid.UUID) (*ProductEnt, error) {
+	product, err := s.repo.Product(ctx, productUUID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	if product.Active != "Y" {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
+
+func (d ReviewRequest) apply(e *ReviewEnt) {
+	e.Rating = d.Rating
+	e.Text = d.Text
+	e.Pros = d.Pros
+	e.Cons = d.Cons
+	e.Photos = pq.StringArray(d.Photos)
+	if e.Photos == nil {
+		e.Photos = pq.StringArray{}
+	}
+}
+
+func reviewMessage(err error, fallback string) string {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return "отзыв не найден"
+	case errors.Is(err, ErrProductNotFound):
+		return "товар не найден"
+	case errors.Is(err, ErrReviewExists):
+		return "вы уже оставили отзыв об этом товаре"
+	case errors.Is(err, ErrInvalidTransition):
+		return "недопустимая смена статуса отзыва"
+	}
+	return fallback
+}
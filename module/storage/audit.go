@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"go-monolite/module/audit"
+	"maps"
+
+	"github.com/google/uuid"
+)
+
+// auditStock — остаток товара на одном складе в журнале изменений
+type auditStock struct {
+	Quantity int    `json:"quantity"`
+	Active   string `json:"active"`
+}
+
+// storageEntries — изменения складов после выгрузки, архивный склад из выгрузки считается восстановленным
+func storageEntries(current map[uuid.UUID]StorageEnt, deletes, inserts, updates []StorageEnt) []audit.Entry {
+	entries := make([]audit.Entry, 0, len(deletes)+len(inserts)+len(updates))
+	for _, d := range deletes {
+		entries = append(entries, audit.Deleted(audit.EntityStorage, d.UUID.String(), d))
+	}
+	for _, i := range inserts {
+		entries = append(entries, audit.Created(audit.EntityStorage, i.UUID.String(), i))
+	}
+	for _, u := range updates {
+		if curr := current[u.UUID]; curr.DeletedAt != nil {
+			entries = append(entries, audit.Restored(audit.EntityStorage, u.UUID.String(), u))
+		} else {
+			entries = append(entries, audit.Updated(audit.EntityStorage, u.UUID.String(), curr, u))
+		}
+	}
+	return entries
+}
+
+// productStorageEntries — изменения остатков по товарам: снимок товара — его остатки по UUID склада
+func productStorageEntries(current map[uuid.UUID]map[uuid.UUID]ProductStorageEnt, inserts, updates []ProductStorageEnt) []audit.Entry {
+	changed := make(map[uuid.UUID]map[uuid.UUID]auditStock)
+	for _, list := range [][]ProductStorageEnt{inserts, updates} {
+		for _, p := range list {
+			if changed[p.ProductUUID] == nil {
+				changed[p.ProductUUID] = make(map[uuid.UUID]auditStock)
+			}
+			changed[p.ProductUUID][p.StorageUUID] = auditStock{Quantity: p.Quantity, Active: p.Active}
+		}
+	}
+
+	entries := make([]audit.Entry, 0, len(changed))
+	for productUUID, stocks := range changed {
+		before := make(map[uuid.UUID]auditStock, len(current[productUUID]))
+		for storageUUID, p := range current[productUUID] {
+			before[storageUUID] = auditStock{Quantity: p.Quantity, Active: p.Active}
+		}
+		after := maps.Clone(before)
+		maps.Copy(after, stocks)
+
+		if len(before) == 0 {
+			entries = append(entries, audit.Created(audit.EntityProductStorage, productUUID.String(), after))
+		} else {
+			entries = append(entries, audit.Updated(audit.EntityProductStorage, productUUID.String(), before, after))
+		}
+	}
+	return entries
+}
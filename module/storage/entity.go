@@ -7,12 +7,13 @@ import (
 )
 
 type StorageEnt struct {
-	ID        uint      `db:"id"`
-	UUID      uuid.UUID `db:"uuid"`
-	Name      string    `db:"name"`
-	Active    string    `db:"active"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ID        uint       `db:"id" json:"id"`
+	UUID      uuid.UUID  `db:"uuid" json:"uuid"`
+	Name      string     `db:"name" json:"name"`
+	Active    string     `db:"active" json:"active"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
 }
 
 type ProductStorageEnt struct {
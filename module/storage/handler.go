@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"errors"
 	"go-monolite/internal/store"
+	"go-monolite/module/audit"
 	"go-monolite/pkg/helper"
 	"go-monolite/pkg/logger"
 	"go-monolite/pkg/respond"
@@ -18,13 +20,14 @@ type Handler struct {
 func NewHandler(store *store.Store) *Handler {
 	storageRepo := NewStorageRepository(store)
 	productStoragesRepo := NewProductStoragesRepository(store)
-	service := NewService(storageRepo, productStoragesRepo)
+	service := NewService(store, storageRepo, productStoragesRepo, audit.NewRecorder(store))
 	return &Handler{service: service}
 }
 
 func (h *Handler) Init(r chi.Router) {
 	r.Post("/upsert", h.Upsert)
 	r.Get("/storages", h.GetStorage)
+	r.Post("/restore/{uuid}", h.Restore)
 }
 
 // @Summary Upsert storages
@@ -86,3 +89,36 @@ func (h *Handler) GetStorage(w http.ResponseWriter, r *http.Request) {
 
 	respond.SuccessHandler(w, r, http.StatusCreated, "", resp)
 }
+
+// @Summary Restore storage
+// @Description Restore a storage archived by the exchange, product stock on it is kept while it is archived
+// @Tags storages
+// @Produce json
+// @Param uuid path string true "Storage UUID"
+// @Success 200 {object} respond.SuccessResponse{data=StorageResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /restore/{uuid} [post]
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	storageUUID, err := validator.ParseUUID(chi.URLParam(r, "uuid"))
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, err.Error())
+		return
+	}
+
+	resp, err := h.service.Restore(r.Context(), storageUUID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, "Склад в архиве не найден")
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, "Произошла ошибка при восстановлении склада")
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, "Произошла ошибка при восстановлении склада")
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "Склад восстановлен", resp)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"go-monolite/internal/store"
+	"go-monolite/module/audit"
 	"go-monolite/pkg/helper"
 	"go-monolite/pkg/logger"
 
@@ -13,12 +14,14 @@ import (
 )
 
 type Service struct {
+	store              *store.Store
 	storageRepo        *StorageRepository
 	productStorageRepo *ProductStoragesRepository
+	audit              *audit.Recorder
 }
 
-func NewService(storageRepo *StorageRepository, productStorageRepo *ProductStoragesRepository) *Service {
-	return &Service{storageRepo, productStorageRepo}
+func NewService(store *store.Store, storageRepo *StorageRepository, productStorageRepo *ProductStoragesRepository, recorder *audit.Recorder) *Service {
+	return &Service{store, storageRepo, productStorageRepo, recorder}
 }
 
 func (s *Service) GetStorage(ctx context.Context) ([]StorageResponse, string, error) {
@@ -33,6 +36,24 @@ func (s *Service) GetStorage(ctx context.Context) ([]StorageResponse, string, er
 	return helper.ToResponse(existing), "", nil
 }
 
+// Restore возвращает склад из архива
+func (s *Service) Restore(ctx context.Context, storageUUID uuid.UUID) (*StorageResponse, error) {
+	var storage *StorageEnt
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if storage, err = s.storageRepo.Restore(ctx, storageUUID.String()); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, audit.Restored(audit.EntityStorage, storage.UUID.String(), storage))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := storage.ToResponse()
+	return &resp, nil
+}
+
 func (s *Service) Upsert(ctx context.Context, request UpsertRequest) (*UpsertResponse, string, error) {
 	if err := request.Validate(); err != nil {
 		return nil, "", err
@@ -72,7 +93,7 @@ func (s *Service) Upsert(ctx context.Context, request UpsertRequest) (*UpsertRes
 }
 
 func (s *Service) upsertStorages(ctx context.Context, request UpsertRequest) (*StorageUpsertStatsResponse, error) {
-	deletes, inserts, updates, err := s.prepareStoragesDiff(ctx, request)
+	current, deletes, inserts, updates, err := s.prepareStoragesDiff(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +102,10 @@ func (s *Service) upsertStorages(ctx context.Context, request UpsertRequest) (*S
 		return nil, err
 	}
 
+	if err := s.audit.Record(ctx, storageEntries(current, deletes, inserts, updates)...); err != nil {
+		return nil, err
+	}
+
 	return &StorageUpsertStatsResponse{
 		CountDeleted:  len(deletes),
 		CountInserted: len(inserts),
@@ -89,7 +114,7 @@ func (s *Service) upsertStorages(ctx context.Context, request UpsertRequest) (*S
 }
 
 func (s *Service) upsertStoragesValue(ctx context.Context, requestData []ProductStorageDto) (*ProductStorageUpsertStatsResponse, error) {
-	inserts, updates, err := s.prepareStoragesValueDiff(ctx, requestData)
+	current, inserts, updates, err := s.prepareStoragesValueDiff(ctx, requestData)
 	if err != nil {
 		return nil, err
 	}
@@ -103,6 +128,10 @@ func (s *Service) upsertStoragesValue(ctx context.Context, requestData []Product
 		return nil, err
 	}
 
+	if err := s.audit.Record(ctx, productStorageEntries(current, inserts, updates)...); err != nil {
+		return nil, err
+	}
+
 	return &ProductStorageUpsertStatsResponse{
 		CountInserted: len(inserts),
 		CountUpdated:  len(updates),
@@ -114,12 +143,13 @@ func (s *Service) filterValidStorageUUIDs(ctx context.Context, inserts []Product
 		return inserts, nil
 	}
 
-	existing, err := s.storageRepo.GetList(ctx)
+	// остатки архивного склада сохраняются, чтобы вернуться вместе с ним
+	existing, err := s.storageRepo.GetListWithArchived(ctx)
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
 			return nil, fmt.Errorf("ошибка Storage не заполнен")
 		}
-		return nil, fmt.Errorf("ошибка при выполнении storageRepo.GetList: %w", err)
+		return nil, fmt.Errorf("ошибка при выполнении storageRepo.GetListWithArchived: %w", err)
 	}
 
 	valid := make(map[uuid.UUID]struct{}, len(existing))
@@ -139,25 +169,26 @@ func (s *Service) filterValidStorageUUIDs(ctx context.Context, inserts []Product
 	return filtered, nil
 }
 
-func (s *Service) prepareStoragesDiff(ctx context.Context, request UpsertRequest) (deletes, inserts, updates []StorageEnt, err error) {
-	existing, err := s.storageRepo.GetList(ctx)
+// prepareStoragesDiff — изменения складов и их текущее состояние по UUID
+func (s *Service) prepareStoragesDiff(ctx context.Context, request UpsertRequest) (current map[uuid.UUID]StorageEnt, deletes, inserts, updates []StorageEnt, err error) {
+	existing, err := s.storageRepo.GetListWithArchived(ctx)
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
 			if request.General == nil || request.General.Storages == nil {
-				return nil, nil, nil, fmt.Errorf("ошибка General должна быть заполнена")
+				return nil, nil, nil, nil, fmt.Errorf("ошибка General должна быть заполнена")
 			}
 		} else {
-			return nil, nil, nil, fmt.Errorf("ошибка при выполнении storageRepo.GetList: %w", err)
+			return nil, nil, nil, nil, fmt.Errorf("ошибка при выполнении storageRepo.GetListWithArchived: %w", err)
 		}
 	}
 
 	desired := toStorageSlice(request.General.Storages)
 
-	currentMap := toStoragesMap(existing)
+	current = toStoragesMap(existing)
 	desiredMap := toStoragesMap(desired)
 
-	deletes, inserts, updates = diffStorages(currentMap, desiredMap)
-	return deletes, inserts, updates, nil
+	deletes, inserts, updates = diffStorages(current, desiredMap)
+	return current, deletes, inserts, updates, nil
 }
 
 func (s *Service) applyStorageChanges(ctx context.Context, deletes, inserts, updates []StorageEnt) error {
@@ -236,20 +267,21 @@ func (s *Service) applyStorageValueChanges(ctx context.Context, inserts, updates
 	return g.Wait()
 }
 
-func (s *Service) prepareStoragesValueDiff(ctx context.Context, requestData []ProductStorageDto) (inserts, updates []ProductStorageEnt, err error) {
+// prepareStoragesValueDiff — изменения остатков товаров и текущие остатки по товару и складу
+func (s *Service) prepareStoragesValueDiff(ctx context.Context, requestData []ProductStorageDto) (current map[uuid.UUID]map[uuid.UUID]ProductStorageEnt, inserts, updates []ProductStorageEnt, err error) {
 	desiredMap := toProductStorageDataMap(requestData)
 
 	productUUIDs := helper.GetKeys(desiredMap)
 
 	existing, err := s.productStorageRepo.GetByProductUUIDs(ctx, productUUIDs)
 	if err != nil && !errors.Is(err, store.ErrNotFound) {
-		return nil, nil, fmt.Errorf("ошибка при выполнении productStorageRepo.GetByProductUUIDs: %w", err)
+		return nil, nil, nil, fmt.Errorf("ошибка при выполнении productStorageRepo.GetByProductUUIDs: %w", err)
 	}
 
-	currentMap := toProductStorageMap(existing)
+	current = toProductStorageMap(existing)
 
-	inserts, updates = diffProductStorages(currentMap, desiredMap)
-	return inserts, updates, nil
+	inserts, updates = diffProductStorages(current, desiredMap)
+	return current, inserts, updates, nil
 }
 
 func toStoragesMap(list []StorageEnt) map[uuid.UUID]StorageEnt {
@@ -286,9 +318,10 @@ func toProductStorageDataMap(list []ProductStorageDto) map[uuid.UUID]map[uuid.UU
 	return result
 }
 
+// diffStorages — склады, которых нет в выгрузке, уходят в архив, архивные склады из выгрузки возвращаются обновлением
 func diffStorages(current, desired map[uuid.UUID]StorageEnt) (deletes, inserts, updates []StorageEnt) {
 	for key, curr := range current {
-		if _, ok := desired[key]; !ok {
+		if _, ok := desired[key]; !ok && curr.DeletedAt == nil {
 			deletes = append(deletes, curr)
 		}
 	}
@@ -296,7 +329,7 @@ func diffStorages(current, desired map[uuid.UUID]StorageEnt) (deletes, inserts,
 	for key, want := range desired {
 		curr, ok := current[key]
 		if ok {
-			if !isStorageEqual(curr, want) {
+			if curr.DeletedAt != nil || !isStorageEqual(curr, want) {
 				updates = append(updates, want)
 			}
 		} else {
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"go-monolite/internal/store"
 	"time"
+
+	"github.com/jmoiron/sqlx"
 )
 
 type StorageRepository struct {
@@ -63,12 +65,23 @@ func (r *StorageRepository) Create(ctx context.Context, s *StorageEnt) (*uint, e
 	return &id, nil
 }
 
+// GetList — склады не из архива
 func (r *StorageRepository) GetList(ctx context.Context) ([]StorageEnt, error) {
+	return r.getList(ctx, "WHERE deleted_at IS NULL")
+}
+
+// GetListWithArchived — все склады вместе с архивными, по ним обмен решает, что добавить, обновить или вернуть из архива
+func (r *StorageRepository) GetListWithArchived(ctx context.Context) ([]StorageEnt, error) {
+	return r.getList(ctx, "")
+}
+
+func (r *StorageRepository) getList(ctx context.Context, filter string) ([]StorageEnt, error) {
 	query := fmt.Sprintf(`
-		SELECT id, uuid, name, active, created_at, updated_at
+		SELECT id, uuid, name, active, created_at, updated_at, deleted_at
 		FROM %s
+		%s
 		ORDER BY created_at DESC
-	`, r.tableName)
+	`, r.tableName, filter)
 
 	var storages []StorageEnt
 	var err error
@@ -91,10 +104,11 @@ func (r *StorageRepository) GetList(ctx context.Context) ([]StorageEnt, error) {
 	return storages, nil
 }
 
+// Update обновляет склад, архивный склад из выгрузки возвращается из архива
 func (r *StorageRepository) Update(ctx context.Context, s *StorageEnt) error {
 	query := fmt.Sprintf(`
 		UPDATE %s
-		SET name = $1, active = $2, updated_at = $3
+		SET name = $1, active = $2, updated_at = $3, deleted_at = NULL
 		WHERE uuid = $4
 	`, r.tableName)
 
@@ -132,15 +146,16 @@ func (r *StorageRepository) Update(ctx context.Context, s *StorageEnt) error {
 	return nil
 }
 
+// Delete переносит склад в архив, окончательно его удаляет задача очистки
 func (r *StorageRepository) Delete(ctx context.Context, uuid string) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE uuid = $1`, r.tableName)
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = $2 WHERE uuid = $1 AND deleted_at IS NULL`, r.tableName)
 
 	var result sql.Result
 	var err error
 	if tx := store.GetTx(ctx); tx != nil {
-		result, err = tx.ExecContext(ctx, query, uuid)
+		result, err = tx.ExecContext(ctx, query, uuid, time.Now())
 	} else {
-		result, err = r.store.Db.ExecContext(ctx, query, uuid)
+		result, err = r.store.Db.ExecContext(ctx, query, uuid, time.Now())
 	}
 	if err != nil {
 		return store.ContextError(err)
@@ -156,3 +171,44 @@ func (r *StorageRepository) Delete(ctx context.Context, uuid string) error {
 
 	return nil
 }
+
+// Restore возвращает склад из архива
+func (r *StorageRepository) Restore(ctx context.Context, uuid string) (*StorageEnt, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s SET deleted_at = NULL, updated_at = $2
+		WHERE uuid = $1 AND deleted_at IS NOT NULL
+		RETURNING id, uuid, name, active, created_at, updated_at, deleted_at
+	`, r.tableName)
+
+	var storage StorageEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &storage, query, uuid, time.Now())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &storage, nil
+}
+
+// Purge окончательно удаляет склады, которые лежат в архиве с момента before, вместе с остатками на них.
+// Склады, указанные в заказах, остаются, чтобы в истории заказов был пункт выдачи
+func (r *StorageRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %s s
+		WHERE s.deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.storage_uuid = s.uuid)
+	`, r.tableName)
+
+	result, err := r.store.Db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+	return rows, nil
+}
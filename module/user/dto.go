@@ -1,5 +1,7 @@
 package user
 
+import "go-monolite/pkg/validator"
+
 // UserResponse — DTO для GET /users/me
 type UserResponse struct {
 	ID         uint    `json:"id"`
@@ -14,15 +16,49 @@ type UserResponse struct {
 	Active     string  `json:"active"`
 	CreatedAt  string  `json:"created_at"`
 	UpdatedAt  string  `json:"updated_at"`
+
+	Roles []string `json:"roles,omitempty"`
 }
 
-// UpdateUserRequest — DTO для PUT /users/me
+// UpdateUserRequest — DTO для PATCH /users/me, меняются только переданные поля, ИНН меняется через компанию.
+// Для смены email или телефона нужен код, отправленный на новый контакт через POST /auth/sendCode с type=contact_change
 type UpdateUserRequest struct {
-	Email      string `json:"email,omitempty"`
-	Phone      string `json:"phone,omitempty"`
-	Name       string `json:"name,omitempty"`
-	LastName   string `json:"last_name,omitempty"`
-	SecondName string `json:"second_name,omitempty"`
-	CityID     int64  `json:"city_id,omitempty"`
-	INN        string `json:"inn,omitempty"`
+	Email      *string `json:"email,omitempty" validate:"omitempty,email,excluded_with=Phone"` // email и телефон меняются по одному
+	Phone      *string `json:"phone,omitempty" validate:"omitempty,phone"`
+	Code       string  `json:"code,omitempty" validate:"omitempty,numeric"`
+	Name       *string `json:"name,omitempty" validate:"omitempty,max=100"`
+	LastName   *string `json:"last_name,omitempty" validate:"omitempty,max=100"`
+	SecondName *string `json:"second_name,omitempty" validate:"omitempty,max=100"`
+	CityID     *int64  `json:"city_id,omitempty" validate:"omitempty,gt=0"`
+}
+
+func (d *UpdateUserRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// ListUsersRequest — фильтр GET /users, Query ищет по email, телефону, имени и фамилии
+type ListUsersRequest struct {
+	Query  string `validate:"max=255"`
+	Active string `validate:"omitempty,oneof=Y N"`
+	Limit  int    `validate:"min=1,max=100"`
+	Offset int    `validate:"min=0"`
+}
+
+func (d *ListUsersRequest) Validate() error {
+	return validator.Validate(d)
+}
+
+// UsersListResponse — DTO для GET /users
+type UsersListResponse struct {
+	Items []*UserResponse `json:"items"`
+	Total int             `json:"total"`
+}
+
+// SetRolesRequest — DTO для PUT /users/{id}/roles
+type SetRolesRequest struct {
+	Roles []string `json:"roles" validate:"required,min=1,dive,oneof=admin content_manager exchange customer"`
+}
+
+func (d *SetRolesRequest) Validate() error {
+	return validator.Validate(d)
 }
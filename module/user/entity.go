@@ -11,6 +11,35 @@ const (
 	UserTypeLegal      UserType = "legal"
 )
 
+const (
+	ActiveYes = "Y"
+	ActiveNo  = "N"
+
+	// начальная версия токенов, при смене версии все выданные access токены перестают действовать
+	InitialTokenVersion = "1"
+)
+
+// роли пользователей, права ролей хранятся в role_permissions
+const (
+	RoleAdmin          = "admin"
+	RoleContentManager = "content_manager"
+	RoleExchange       = "exchange"
+	RoleCustomer       = "customer"
+)
+
+const (
+	PermissionCatalogWrite        = "catalog:write"
+	PermissionExchangeWrite       = "exchange:write"
+	PermissionUsersManage         = "users:manage"
+	PermissionAPIKeysManage       = "apikeys:manage"
+	PermissionNotificationsManage = "notifications:manage"
+	PermissionOrdersManage        = "orders:manage"
+	PermissionCustomersExport     = "customers:export"
+	PermissionPromotionsManage    = "promotions:manage"
+	PermissionReviewsModerate     = "reviews:moderate"
+	PermissionAuditRead           = "audit:read"
+)
+
 type UserEnt struct {
 	ID           int64     `db:"id"`
 	Email        *string   `db:"email"`
@@ -23,8 +52,21 @@ type UserEnt struct {
 	INN          *string   `db:"inn"`
 	Active       string    `db:"active"`
 	PasswordHash string    `db:"password_hash"`
-	Checkword    *string   `db:"checkword"`
+	Checkword    *string   `db:"checkword"` // sha256 контрольного слова для восстановления пароля
 	TokenVersion *string   `db:"token_version"`
 	CreatedAt    time.Time `db:"created_at"`
 	UpdatedAt    time.Time `db:"updated_at"`
+
+	EmailVerifiedAt *time.Time `db:"email_verified_at"`
+	PhoneVerifiedAt *time.Time `db:"phone_verified_at"`
+
+	CheckwordExpiresAt *time.Time `db:"checkword_expires_at"`
+}
+
+// CurrentTokenVersion — версия, которую должны содержать действующие access токены пользователя
+func (u *UserEnt) CurrentTokenVersion() string {
+	if u.TokenVersion == nil || *u.TokenVersion == "" {
+		return InitialTokenVersion
+	}
+	return *u.TokenVersion
 }
@@ -0,0 +1,9 @@
+package user
+
+import "errors"
+
+var (
+	ErrContactCodeRequired = errors.New("contact verification code required")
+	ErrContactCodeInvalid  = errors.New("invalid contact verification code")
+	ErrCityNotFound        = errors.New("city not found")
+)
@@ -1,30 +1,383 @@
 package user
 
 import (
+	"context"
+	"errors"
+	"go-monolite/internal/config"
 	"go-monolite/internal/store"
+	"go-monolite/module/city"
+	"go-monolite/pkg/helper"
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/middleware/auth"
 	"go-monolite/pkg/respond"
+	"go-monolite/pkg/validator"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi"
 )
 
+const defaultListLimit = 50
+
 type Handler struct {
-	service *Service
+	service   *Service
+	userAuth  func(next http.Handler) http.Handler
+	adminAuth func(next http.Handler) http.Handler
 }
 
-func NewHandler(store *store.Store) *Handler {
-	repo := NewRepository(store)
-	service := NewService(repo)
-	return &Handler{service: service}
+// NewHandler — contacts проверяет коды при смене email/телефона (auth.NewContactVerifier),
+// userAuth и authenticate собираются один раз в роутере сервера
+func NewHandler(store *store.Store, cfg *config.Config, contacts ContactVerifier, userAuth, authenticate func(next http.Handler) http.Handler) *Handler {
+	cities := city.NewService(store, city.NewRepository(store), cfg.City.DefaultSlug)
+	return &Handler{
+		service:   NewService(store, NewRepository(store), NewRolesRepository(store), cities, contacts),
+		userAuth:  userAuth,
+		adminAuth: authenticate,
+	}
 }
 
 func (h *Handler) Init(r chi.Router) {
-	r.Get("/me", h.Me)
-	// r.Put("/update/{uuid}", h.Update)
-	// r.Get("/{id}", h.GetByUUID)        // для админа
-	// r.Delete("/delete/{id}", h.Delete) // для админа
+	r.Group(func(r chi.Router) {
+		r.Use(h.userAuth)
+
+		r.Get("/me", h.Me)
+		r.Patch("/me", h.UpdateMe)
+		r.Get("/me/city", h.MyCity)
+		r.Post("/me/deactivate", h.Deactivate)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.adminAuth, auth.RequirePermission(h.service, PermissionUsersManage))
+
+		r.Get("/", h.GetList)
+		r.Put("/{id}/roles", h.SetRoles)
+		r.Put("/{id}/block", h.Block)
+		r.Put("/{id}/unblock", h.Unblock)
+	})
 }
 
+// @Summary Current user
+// @Description Get the profile of the authenticated user
+// @Tags user
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} respond.SuccessResponse{data=UserResponse}
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /me [get]
 func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
-	respond.SuccessHandler(w, r, http.StatusCreated, "", "")
+	identity, ok := auth.CurrentUser(r.Context())
+	if !ok {
+		respond.ErrorHandler(w, r, http.StatusUnauthorized, auth.Unauthorized, auth.Unauthorized)
+		return
+	}
+
+	resp, mess, err := h.service.Me(r.Context(), identity.UserID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Update current user
+// @Description Update the profile of the authenticated user. Changing email or phone requires a code sent to the new contact via /auth/sendCode with type contact_change
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param request body UpdateUserRequest true "Profile fields to change"
+// @Success 200 {object} respond.SuccessResponse{data=UserResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 409 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /me [patch]
+func (h *Handler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.CurrentUser(r.Context())
+	if !ok {
+		respond.ErrorHandler(w, r, http.StatusUnauthorized, auth.Unauthorized, auth.Unauthorized)
+		return
+	}
+
+	body := respond.ParseBody(w, r)
+
+	var request UpdateUserRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	resp, mess, err := h.service.UpdateMe(r.Context(), identity.UserID, request)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		switch {
+		case errors.Is(err, ErrContactCodeRequired), errors.Is(err, ErrContactCodeInvalid), errors.Is(err, ErrCityNotFound):
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, mess)
+			return
+		case errors.Is(err, store.ErrConflict):
+			respond.ErrorHandler(w, r, http.StatusConflict, err, mess)
+			return
+		case errors.Is(err, store.ErrNotFound):
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, resp)
+}
+
+// @Summary Current user city
+// @Description Resolve the city of the authenticated user: the one from the profile, otherwise the nearest to the coordinates, otherwise the default one
+// @Tags user
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param lat query number false "Latitude"
+// @Param lon query number false "Longitude"
+// @Success 200 {object} respond.SuccessResponse{data=city.CityResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /me/city [get]
+func (h *Handler) MyCity(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.CurrentUser(r.Context())
+	if !ok {
+		respond.ErrorHandler(w, r, http.StatusUnauthorized, auth.Unauthorized, auth.Unauthorized)
+		return
+	}
+
+	var lat, lon *float64
+	if latStr, lonStr := r.URL.Query().Get("lat"), r.URL.Query().Get("lon"); latStr != "" || lonStr != "" {
+		latValue, latErr := strconv.ParseFloat(latStr, 64)
+		lonValue, lonErr := strconv.ParseFloat(lonStr, 64)
+		if err := errors.Join(latErr, lonErr); err != nil {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректные координаты")
+			return
+		}
+		lat, lon = &latValue, &lonValue
+	}
+
+	resp, mess, err := h.service.MyCity(r.Context(), identity.UserID, lat, lon)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Deactivate current user
+// @Description Deactivate the account of the authenticated user, issued tokens stop working
+// @Tags user
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /me/deactivate [post]
+func (h *Handler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.CurrentUser(r.Context())
+	if !ok {
+		respond.ErrorHandler(w, r, http.StatusUnauthorized, auth.Unauthorized, auth.Unauthorized)
+		return
+	}
+
+	mess, err := h.service.Deactivate(r.Context(), identity.UserID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, "")
+}
+
+// @Summary Get users
+// @Description Search users, requires the users:manage permission
+// @Tags user
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param q query string false "Search by email, phone, name or last name"
+// @Param active query string false "Filter by activity (Y or N)"
+// @Param limit query int false "Page size, 50 by default, at most 100"
+// @Param offset query int false "Offset"
+// @Success 200 {object} respond.SuccessResponse{data=UsersListResponse}
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router / [get]
+func (h *Handler) GetList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := ListUsersRequest{
+		Query:  query.Get("q"),
+		Active: query.Get("active"),
+	}
+
+	var err error
+	if request.Limit, err = queryInt(r, "limit", defaultListLimit); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный limit")
+		return
+	}
+	if request.Offset, err = queryInt(r, "offset", 0); err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный offset")
+		return
+	}
+
+	resp, mess, err := h.service.List(r.Context(), request)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, "", resp)
+}
+
+// @Summary Block user
+// @Description Block a user and revoke issued tokens, requires the users:manage permission
+// @Tags user
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "User ID"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id}/block [put]
+func (h *Handler) Block(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, h.service.Block)
+}
+
+// @Summary Unblock user
+// @Description Unblock a user, requires the users:manage permission
+// @Tags user
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "User ID"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id}/unblock [put]
+func (h *Handler) Unblock(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, h.service.Unblock)
+}
+
+func (h *Handler) setActive(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, id int64) (string, error)) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный идентификатор пользователя")
+		return
+	}
+
+	mess, err := action(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, "")
+}
+
+// @Summary Set user roles
+// @Description Replace the roles of a user, requires the users:manage permission
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Param id path int true "User ID"
+// @Param request body SetRolesRequest true "Roles"
+// @Success 200 {object} respond.SuccessResponse
+// @Failure 400 {object} respond.ErrorResponse
+// @Failure 401 {object} respond.ErrorResponse
+// @Failure 403 {object} respond.ErrorResponse
+// @Failure 404 {object} respond.ErrorResponse
+// @Failure 500 {object} respond.ErrorResponse
+// @Router /{id}/roles [put]
+func (h *Handler) SetRoles(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusBadRequest, err, "некорректный идентификатор пользователя")
+		return
+	}
+
+	body := respond.ParseBody(w, r)
+
+	var request SetRolesRequest
+
+	mess, err := helper.Unmarshal(body, &request)
+	if err != nil {
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	mess, err = h.service.SetRoles(r.Context(), id, request)
+	if err != nil {
+		if validationErrors, ok := err.(validator.ValidationError); ok {
+			respond.ErrorHandler(w, r, http.StatusBadRequest, validationErrors.Fields, validator.ErrorValidation)
+			return
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			respond.ErrorHandler(w, r, http.StatusNotFound, err, mess)
+			return
+		}
+		logger.ErrorCtx(r.Context(), err, mess)
+		respond.ErrorHandler(w, r, http.StatusInternalServerError, err, mess)
+		return
+	}
+
+	respond.SuccessHandler(w, r, http.StatusOK, mess, "")
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
 }
@@ -1,17 +1,254 @@
 package user_test
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go-monolite/module/auth"
+	"go-monolite/module/user"
+	"go-monolite/pkg/respond"
+	"go-monolite/pkg/testinit"
+	"go-monolite/pkg/token"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUserIntegration(t *testing.T) {
-	// store := testinit.SetupStoreTest(t)
-	// handler := category.NewHandler(store)
-	// server := testinit.SetupTestServer(t, handler)
-	// defer server.Close()
-
-	// t.Cleanup(func() {
-	// 	err := testinit.TruncateAllTables(store.Db)
-	// 	require.NoError(t, err)
-	// })
+	store := testinit.SetupStoreTest(t)
+	config := testinit.GetConfigs()
+	access := testinit.NewAuth(store, config)
+	handler := user.NewHandler(store, config, auth.NewContactVerifier(store, config, access.TokenManager), access.UserAuth, access.Authenticate)
+	server := testinit.SetupTestServer(t, handler)
+	defer server.Close()
+
+	t.Cleanup(func() {
+		err := testinit.TruncateAllTables(store.Db)
+		require.NoError(t, err)
+	})
+
+	ctx := context.Background()
+	repo := user.NewRepository(store)
+	tokenManager := token.NewManager(config.Auth.JWTSecret, time.Minute)
+
+	phone := "79990000010"
+	tv := user.InitialTokenVersion
+	userID, err := repo.Create(ctx, &user.UserEnt{
+		Phone:        &phone,
+		UserType:     user.UserTypeIndividual,
+		Active:       user.ActiveYes,
+		TokenVersion: &tv,
+	})
+	require.NoError(t, err)
+
+	send := func(t *testing.T, method, path, body, accessToken string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	getMe := func(t *testing.T, accessToken string) *http.Response {
+		t.Helper()
+		return send(t, http.MethodGet, "/me", "", accessToken)
+	}
+
+	accessToken, _, err := tokenManager.Generate(userID, tv, "default")
+	require.NoError(t, err)
+
+	decodeUser := func(t *testing.T, resp *http.Response) user.UserResponse {
+		t.Helper()
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+
+		var u user.UserResponse
+		testinit.MarshalUnmarshal(t, response.Data, &u)
+		return u
+	}
+
+	t.Run("Me Unauthorized", func(t *testing.T) {
+		resp := getMe(t, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = getMe(t, "invalid")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Me", func(t *testing.T) {
+		resp := getMe(t, accessToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+
+		var me user.UserResponse
+		testinit.MarshalUnmarshal(t, response.Data, &me)
+
+		assert.Equal(t, uint(userID), me.ID)
+		require.NotNil(t, me.Phone)
+		assert.Equal(t, phone, *me.Phone)
+	})
+
+	t.Run("Set Roles", func(t *testing.T) {
+		path := fmt.Sprintf("/%d/roles", userID)
+		body := fmt.Sprintf(`{"roles": ["%s"]}`, user.RoleContentManager)
+
+		// у пользователя без роли администратора нет права users:manage
+		resp := send(t, http.MethodPut, path, body, accessToken)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = send(t, http.MethodPut, path, `{"roles": ["unknown"]}`, config.HTTPServer.BearerToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, http.MethodPut, path, body, config.HTTPServer.BearerToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = getMe(t, accessToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+
+		var me user.UserResponse
+		testinit.MarshalUnmarshal(t, response.Data, &me)
+		assert.Equal(t, []string{user.RoleContentManager}, me.Roles)
+	})
+
+	t.Run("Update Me", func(t *testing.T) {
+		resp := send(t, http.MethodPatch, "/me", `{"name": "Иван", "last_name": "Петров"}`, accessToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		me := decodeUser(t, resp)
+		require.NotNil(t, me.Name)
+		assert.Equal(t, "Иван", *me.Name)
+		require.NotNil(t, me.Phone)
+		assert.Equal(t, phone, *me.Phone)
+
+		resp = send(t, http.MethodPatch, "/me", `{"city_id": 999999}`, accessToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, http.MethodPatch, "/me", `{"email": "not-an-email"}`, accessToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Update Me Phone", func(t *testing.T) {
+		newPhone := "79990000011"
+		body := fmt.Sprintf(`{"phone": "%s", "code": "123456"}`, newPhone)
+
+		// без кода смена телефона невозможна
+		resp := send(t, http.MethodPatch, "/me", fmt.Sprintf(`{"phone": "%s"}`, newPhone), accessToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, http.MethodPatch, "/me", body, accessToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		mac := hmac.New(sha256.New, []byte(config.Auth.CodeSecret))
+		mac.Write([]byte("123456"))
+		err := auth.NewAuthCodeRepository(store).SaveCode(ctx, "", newPhone, auth.CodeTypeContactChange,
+			hex.EncodeToString(mac.Sum(nil)), time.Now().Add(time.Minute))
+		require.NoError(t, err)
+
+		resp = send(t, http.MethodPatch, "/me", body, accessToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		me := decodeUser(t, resp)
+		require.NotNil(t, me.Phone)
+		assert.Equal(t, newPhone, *me.Phone)
+
+		u, err := repo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		assert.NotNil(t, u.PhoneVerifiedAt)
+
+		// код одноразовый
+		resp = send(t, http.MethodPatch, "/me", `{"phone": "79990000012", "code": "123456"}`, accessToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Get List", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "/?q=Петров", "", accessToken)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = send(t, http.MethodGet, "/?limit=1000", "", config.HTTPServer.BearerToken)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, http.MethodGet, "/?q=Петров&active=Y", "", config.HTTPServer.BearerToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response respond.Response
+		testinit.DecodeJSON(t, resp.Body, &response)
+
+		var list user.UsersListResponse
+		testinit.MarshalUnmarshal(t, response.Data, &list)
+		assert.Equal(t, 1, list.Total)
+		require.Len(t, list.Items, 1)
+		assert.Equal(t, uint(userID), list.Items[0].ID)
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		otherPhone := "79990000013"
+		otherID, err := repo.Create(ctx, &user.UserEnt{
+			Phone:        &otherPhone,
+			UserType:     user.UserTypeIndividual,
+			Active:       user.ActiveYes,
+			TokenVersion: &tv,
+		})
+		require.NoError(t, err)
+
+		otherToken, _, err := tokenManager.Generate(otherID, tv, "default")
+		require.NoError(t, err)
+
+		resp := send(t, http.MethodPut, fmt.Sprintf("/%d/block", otherID), "", config.HTTPServer.BearerToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = getMe(t, otherToken)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = send(t, http.MethodPut, fmt.Sprintf("/%d/unblock", otherID), "", config.HTTPServer.BearerToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		u, err := repo.GetByID(ctx, otherID)
+		require.NoError(t, err)
+		assert.Equal(t, user.ActiveYes, u.Active)
+
+		resp = send(t, http.MethodPut, "/999999/block", "", config.HTTPServer.BearerToken)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Me Revoked Token", func(t *testing.T) {
+		require.NoError(t, repo.IncrementTokenVersion(ctx, userID))
+
+		resp := getMe(t, accessToken)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Deactivate", func(t *testing.T) {
+		u, err := repo.GetByID(ctx, userID)
+		require.NoError(t, err)
+
+		token, _, err := tokenManager.Generate(userID, u.CurrentTokenVersion(), "default")
+		require.NoError(t, err)
+
+		resp := send(t, http.MethodPost, "/me/deactivate", "", token)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		u, err = repo.GetByID(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, user.ActiveNo, u.Active)
+
+		resp = getMe(t, token)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
 }
@@ -0,0 +1,57 @@
+CREATE TABLE IF NOT EXISTS roles (
+  id SERIAL PRIMARY KEY,
+  code VARCHAR(50) NOT NULL UNIQUE,
+  name VARCHAR(100) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+  id SERIAL PRIMARY KEY,
+  code VARCHAR(50) NOT NULL UNIQUE, -- ресурс:действие, например catalog:write
+  name VARCHAR(100) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  permission_id INT NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+  PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS user_roles_role_id_idx ON user_roles (role_id);
+
+INSERT INTO roles (code, name) VALUES
+  ('admin', 'Администратор'),
+  ('content_manager', 'Контент-менеджер'),
+  ('exchange', 'Интеграция обмена'),
+  ('customer', 'Покупатель')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO permissions (code, name) VALUES
+  ('catalog:write', 'Изменение каталога'),
+  ('exchange:write', 'Загрузка данных обмена'),
+  ('users:manage', 'Управление пользователями')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON (r.code, p.code) IN (
+  ('admin', 'catalog:write'),
+  ('admin', 'exchange:write'),
+  ('admin', 'users:manage'),
+  ('content_manager', 'catalog:write'),
+  ('exchange', 'catalog:write'),
+  ('exchange', 'exchange:write')
+)
+ON CONFLICT DO NOTHING;
+
+-- существующие пользователи — покупатели
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u, roles r WHERE r.code = 'customer'
+ON CONFLICT DO NOTHING;
@@ -2,9 +2,27 @@ package user
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"go-monolite/internal/store"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 )
 
+const userColumns = `id, email, phone, name, last_name, second_name, city_id, user_type, inn,
+	active, password_hash, checkword, checkword_expires_at, token_version, created_at, updated_at,
+	email_verified_at, phone_verified_at`
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// nextTokenVersion — следующая версия токенов пользователя
+const nextTokenVersion = `CASE
+		WHEN token_version ~ '^[0-9]+$' THEN (token_version::BIGINT + 1)::TEXT
+		ELSE '2'
+	END`
+
 type Repository struct {
 	store     *store.Store
 	tableName string
@@ -17,6 +35,355 @@ func NewRepository(store *store.Store) *Repository {
 	}
 }
 
-func (r *Repository) GetUser(ctx context.Context, id uint) error {
+func (r *Repository) GetByID(ctx context.Context, id int64) (*UserEnt, error) {
+	return r.getBy(ctx, "id", id)
+}
+
+func (r *Repository) GetByEmail(ctx context.Context, email string) (*UserEnt, error) {
+	return r.getBy(ctx, "email", email)
+}
+
+func (r *Repository) GetByPhone(ctx context.Context, phone string) (*UserEnt, error) {
+	return r.getBy(ctx, "phone", phone)
+}
+
+func (r *Repository) Create(ctx context.Context, u *UserEnt) (int64, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (
+			email, phone, name, last_name, second_name, city_id, user_type, inn,
+			active, password_hash, token_version, created_at, updated_at,
+			email_verified_at, phone_verified_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8,
+			$9, $10, $11, $12, $13,
+			$14, $15
+		)
+		RETURNING id
+	`, r.tableName)
+
+	now := time.Now()
+	u.CreatedAt = now
+	u.UpdatedAt = now
+
+	var id int64
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query,
+		u.Email,
+		u.Phone,
+		u.Name,
+		u.LastName,
+		u.SecondName,
+		u.CityID,
+		u.UserType,
+		u.INN,
+		u.Active,
+		u.PasswordHash,
+		u.TokenVersion,
+		u.CreatedAt,
+		u.UpdatedAt,
+		u.EmailVerifiedAt,
+		u.PhoneVerifiedAt,
+	).Scan(&id)
+	if err != nil {
+		if store.IsUniqueViolation(err) {
+			return 0, store.ErrConflict
+		}
+		return 0, store.ContextError(err)
+	}
+
+	u.ID = id
+	return id, nil
+}
+
+// Update сохраняет профиль пользователя, если email или телефон заняты — store.ErrConflict
+func (r *Repository) Update(ctx context.Context, u *UserEnt) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET email = $2,
+			phone = $3,
+			name = $4,
+			last_name = $5,
+			second_name = $6,
+			city_id = $7,
+			inn = $8,
+			email_verified_at = $9,
+			phone_verified_at = $10,
+			updated_at = $11
+		WHERE id = $1
+	`, r.tableName)
+
+	u.UpdatedAt = time.Now()
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query,
+		u.ID,
+		u.Email,
+		u.Phone,
+		u.Name,
+		u.LastName,
+		u.SecondName,
+		u.CityID,
+		u.INN,
+		u.EmailVerifiedAt,
+		u.PhoneVerifiedAt,
+		u.UpdatedAt,
+	)
+	if err != nil {
+		if store.IsUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		return store.ContextError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetCompany привязывает пользователя к компании по ИНН или отвязывает (inn == nil)
+func (r *Repository) SetCompany(ctx context.Context, id int64, inn *string, userType UserType) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET inn = $2, user_type = $3, updated_at = $4
+		WHERE id = $1
+	`, r.tableName)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, id, inn, userType, time.Now())
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetActive включает или выключает пользователя
+func (r *Repository) SetActive(ctx context.Context, id int64, active string) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET active = $2, updated_at = $3
+		WHERE id = $1
+	`, r.tableName)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, id, active, time.Now())
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+
 	return nil
 }
+
+// List возвращает страницу пользователей и общее количество подходящих под фильтр
+func (r *Repository) List(ctx context.Context, filter ListUsersRequest) ([]UserEnt, int, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if filter.Query != "" {
+		args = append(args, "%"+likeEscaper.Replace(filter.Query)+"%")
+		where = append(where, fmt.Sprintf(
+			"(email ILIKE $%[1]d OR phone ILIKE $%[1]d OR name ILIKE $%[1]d OR last_name ILIKE $%[1]d)", len(args)))
+	}
+	if filter.Active != "" {
+		args = append(args, filter.Active)
+		where = append(where, fmt.Sprintf("active = $%d", len(args)))
+	}
+
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s %s`, r.tableName, whereSQL)
+	if err := sqlx.GetContext(ctx, r.store.Conn(ctx), &total, countQuery, args...); err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		%s
+		ORDER BY id
+		LIMIT $%d OFFSET $%d
+	`, userColumns, r.tableName, whereSQL, len(args)+1, len(args)+2)
+
+	var users []UserEnt
+	err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &users, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, store.ContextError(err)
+	}
+
+	return users, total, nil
+}
+
+// IncrementTokenVersion меняет версию токенов, все ранее выданные access токены перестают действовать
+func (r *Repository) IncrementTokenVersion(ctx context.Context, id int64) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET token_version = %s, updated_at = $2
+		WHERE id = $1
+	`, r.tableName, nextTokenVersion)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, id, time.Now())
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// SetCheckword сохраняет хэш контрольного слова для восстановления пароля
+func (r *Repository) SetCheckword(ctx context.Context, id int64, checkwordHash string, expiresAt time.Time) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET checkword = $2, checkword_expires_at = $3, checkword_attempts = 0, updated_at = $4
+		WHERE id = $1
+	`, r.tableName)
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query, id, checkwordHash, expiresAt, time.Now())
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// ClearCheckword сбрасывает контрольное слово, повторно им воспользоваться нельзя
+func (r *Repository) ClearCheckword(ctx context.Context, id int64) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET checkword = NULL, checkword_expires_at = NULL, checkword_attempts = 0, updated_at = $2
+		WHERE id = $1
+	`, r.tableName)
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query, id, time.Now())
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// ReserveCheckwordAttempt списывает попытку ввода контрольного слова и возвращает номер попытки.
+// Если контрольного слова нет или попытки исчерпаны — store.ErrNotFound
+func (r *Repository) ReserveCheckwordAttempt(ctx context.Context, id int64, maxAttempts int) (int, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET checkword_attempts = checkword_attempts + 1
+		WHERE id = $1 AND checkword IS NOT NULL AND checkword_attempts < $2
+		RETURNING checkword_attempts
+	`, r.tableName)
+
+	var attempts int
+	err := r.store.Conn(ctx).QueryRowxContext(ctx, query, id, maxAttempts).Scan(&attempts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, store.ErrNotFound
+		}
+		return 0, store.ContextError(err)
+	}
+
+	return attempts, nil
+}
+
+// ResetPassword меняет пароль по действующему контрольному слову, сбрасывает его
+// и меняет версию токенов. Если контрольное слово уже использовано или истекло — store.ErrNotFound
+func (r *Repository) ResetPassword(ctx context.Context, id int64, checkwordHash, passwordHash string) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET password_hash = $3,
+			checkword = NULL,
+			checkword_expires_at = NULL,
+			checkword_attempts = 0,
+			token_version = %s,
+			updated_at = $4
+		WHERE id = $1 AND checkword = $2 AND checkword_expires_at >= $4
+	`, r.tableName, nextTokenVersion)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, query, id, checkwordHash, passwordHash, time.Now())
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return store.ContextError(err)
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+// MarkEmailVerified отмечает email подтверждённым, если он ещё не подтверждён
+func (r *Repository) MarkEmailVerified(ctx context.Context, id int64) error {
+	return r.markVerified(ctx, id, "email_verified_at")
+}
+
+// MarkPhoneVerified отмечает телефон подтверждённым, если он ещё не подтверждён
+func (r *Repository) MarkPhoneVerified(ctx context.Context, id int64) error {
+	return r.markVerified(ctx, id, "phone_verified_at")
+}
+
+func (r *Repository) markVerified(ctx context.Context, id int64, column string) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET %s = $2, updated_at = $2
+		WHERE id = $1 AND %s IS NULL
+	`, r.tableName, column, column)
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query, id, time.Now())
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) getBy(ctx context.Context, column string, value any) (*UserEnt, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE %s = $1
+	`, userColumns, r.tableName, column)
+
+	var user UserEnt
+	err := sqlx.GetContext(ctx, r.store.Conn(ctx), &user, query, value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, store.ContextError(err)
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,106 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"go-monolite/internal/store"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type RolesRepository struct {
+	store                    *store.Store
+	rolesTableName           string
+	rolePermissionsTableName string
+	userRolesTableName       string
+	permissionsTableName     string
+}
+
+func NewRolesRepository(store *store.Store) *RolesRepository {
+	return &RolesRepository{
+		store:                    store,
+		rolesTableName:           "roles",
+		rolePermissionsTableName: "role_permissions",
+		userRolesTableName:       "user_roles",
+		permissionsTableName:     "permissions",
+	}
+}
+
+// AssignRole добавляет пользователю роль, несуществующая роль пропускается
+func (r *RolesRepository) AssignRole(ctx context.Context, userID int64, roleCode string) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (user_id, role_id, created_at)
+		SELECT $1, id, $3 FROM %s WHERE code = $2
+		ON CONFLICT DO NOTHING
+	`, r.userRolesTableName, r.rolesTableName)
+
+	_, err := r.store.Conn(ctx).ExecContext(ctx, query, userID, roleCode, time.Now())
+	if err != nil {
+		return store.ContextError(err)
+	}
+
+	return nil
+}
+
+// SetRoles заменяет роли пользователя, возвращает количество назначенных ролей
+func (r *RolesRepository) SetRoles(ctx context.Context, userID int64, roleCodes []string) (int, error) {
+	deleteQuery := fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, r.userRolesTableName)
+	if _, err := r.store.Conn(ctx).ExecContext(ctx, deleteQuery, userID); err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	insertQuery := fmt.Sprintf(`
+		INSERT INTO %s (user_id, role_id, created_at)
+		SELECT $1, id, $3 FROM %s WHERE code = ANY($2)
+	`, r.userRolesTableName, r.rolesTableName)
+
+	res, err := r.store.Conn(ctx).ExecContext(ctx, insertQuery, userID, pq.Array(roleCodes), time.Now())
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, store.ContextError(err)
+	}
+
+	return int(affected), nil
+}
+
+func (r *RolesRepository) GetRoles(ctx context.Context, userID int64) ([]string, error) {
+	query := fmt.Sprintf(`
+		SELECT r.code
+		FROM %s ur
+		JOIN %s r ON r.id = ur.role_id
+		WHERE ur.user_id = $1
+		ORDER BY r.code
+	`, r.userRolesTableName, r.rolesTableName)
+
+	roles := []string{}
+	if err := sqlx.SelectContext(ctx, r.store.Conn(ctx), &roles, query, userID); err != nil {
+		return nil, store.ContextError(err)
+	}
+
+	return roles, nil
+}
+
+func (r *RolesRepository) HasPermission(ctx context.Context, userID int64, permission string) (bool, error) {
+	query := fmt.Sprintf(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM %s ur
+			JOIN %s rp ON rp.role_id = ur.role_id
+			JOIN %s p ON p.id = rp.permission_id
+			WHERE ur.user_id = $1 AND p.code = $2
+		)
+	`, r.userRolesTableName, r.rolePermissionsTableName, r.permissionsTableName)
+
+	var exists bool
+	if err := r.store.Conn(ctx).QueryRowxContext(ctx, query, userID, permission).Scan(&exists); err != nil {
+		return false, store.ContextError(err)
+	}
+
+	return exists, nil
+}
@@ -2,16 +2,311 @@ package user
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"go-monolite/internal/store"
+	"go-monolite/module/city"
+	"go-monolite/pkg/middleware/auth"
+	"time"
 )
 
+// ContactVerifier проверяет код, отправленный на новый email или телефон, реализуется модулем auth.
+// Неверный, истёкший или заблокированный код — ErrContactCodeInvalid
+type ContactVerifier interface {
+	VerifyContactCode(ctx context.Context, email, phone, code string) error
+}
+
 type Service struct {
-	repo *Repository
+	store     *store.Store
+	repo      *Repository
+	rolesRepo *RolesRepository
+	cities    *city.Service
+	contacts  ContactVerifier
+}
+
+// NewService — cities и contacts нужны только для работы с профилем (UpdateMe, MyCity),
+// сервису проверки токенов и прав их можно не передавать
+func NewService(store *store.Store, repo *Repository, rolesRepo *RolesRepository, cities *city.Service, contacts ContactVerifier) *Service {
+	return &Service{
+		store:     store,
+		repo:      repo,
+		rolesRepo: rolesRepo,
+		cities:    cities,
+		contacts:  contacts,
+	}
+}
+
+func (s *Service) Me(ctx context.Context, id int64) (*UserResponse, string, error) {
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "пользователь не найден", err
+		}
+		return nil, "произошла ошибка при получении пользователя", err
+	}
+
+	roles, err := s.rolesRepo.GetRoles(ctx, id)
+	if err != nil {
+		return nil, "произошла ошибка при получении пользователя", err
+	}
+
+	resp := toUserResponse(u)
+	resp.Roles = roles
+	return resp, "", nil
+}
+
+// UpdateMe меняет профиль пользователя. Новый email или телефон сохраняется подтверждённым
+// только после проверки кода, отправленного на него
+func (s *Service) UpdateMe(ctx context.Context, id int64, req UpdateUserRequest) (*UserResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "пользователь не найден", err
+		}
+		return nil, "произошла ошибка при получении пользователя", err
+	}
+
+	emailChanged := req.Email != nil && !equalString(u.Email, *req.Email)
+	phoneChanged := req.Phone != nil && !equalString(u.Phone, *req.Phone)
+	if emailChanged || phoneChanged {
+		if mess, err := s.verifyNewContact(ctx, id, req); err != nil {
+			return nil, mess, err
+		}
+
+		now := time.Now()
+		if emailChanged {
+			u.Email = req.Email
+			u.EmailVerifiedAt = &now
+		} else {
+			u.Phone = req.Phone
+			u.PhoneVerifiedAt = &now
+		}
+	}
+
+	if req.CityID != nil {
+		exists, err := s.cities.Exists(ctx, *req.CityID)
+		if err != nil {
+			return nil, "произошла ошибка при проверке города", err
+		}
+		if !exists {
+			return nil, "город не найден", ErrCityNotFound
+		}
+		u.CityID = req.CityID
+	}
+	if req.Name != nil {
+		u.Name = req.Name
+	}
+	if req.LastName != nil {
+		u.LastName = req.LastName
+	}
+	if req.SecondName != nil {
+		u.SecondName = req.SecondName
+	}
+
+	if err := s.repo.Update(ctx, u); err != nil {
+		switch {
+		case errors.Is(err, store.ErrConflict):
+			return nil, "email или телефон уже используется", err
+		case errors.Is(err, store.ErrNotFound):
+			return nil, "пользователь не найден", err
+		}
+		return nil, "произошла ошибка при изменении профиля", err
+	}
+
+	resp, mess, err := s.Me(ctx, id)
+	if err != nil {
+		return nil, mess, err
+	}
+
+	return resp, "профиль изменён", nil
+}
+
+// MyCity возвращает город пользователя: выбранный в профиле, иначе ближайший к координатам, иначе город по умолчанию
+func (s *Service) MyCity(ctx context.Context, id int64, lat, lon *float64) (*city.CityResponse, string, error) {
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, "пользователь не найден", err
+		}
+		return nil, "произошла ошибка при получении пользователя", err
+	}
+
+	return s.cities.ResolveDefault(ctx, u.CityID, lat, lon)
+}
+
+// Deactivate выключает аккаунт пользователя, выданные токены перестают действовать
+func (s *Service) Deactivate(ctx context.Context, id int64) (string, error) {
+	if err := s.setActive(ctx, id, ActiveNo); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "пользователь не найден", err
+		}
+		return "произошла ошибка при деактивации аккаунта", err
+	}
+
+	return "аккаунт деактивирован", nil
+}
+
+// List ищет пользователей для администратора
+func (s *Service) List(ctx context.Context, req ListUsersRequest) (*UsersListResponse, string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	users, total, err := s.repo.List(ctx, req)
+	if err != nil {
+		return nil, "произошла ошибка при получении пользователей", err
+	}
+
+	resp := &UsersListResponse{
+		Items: make([]*UserResponse, 0, len(users)),
+		Total: total,
+	}
+	for i := range users {
+		resp.Items = append(resp.Items, toUserResponse(&users[i]))
+	}
+
+	return resp, "", nil
+}
+
+// Block блокирует пользователя, выданные токены перестают действовать
+func (s *Service) Block(ctx context.Context, id int64) (string, error) {
+	if err := s.setActive(ctx, id, ActiveNo); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "пользователь не найден", err
+		}
+		return "произошла ошибка при блокировке пользователя", err
+	}
+
+	return "пользователь заблокирован", nil
+}
+
+// Unblock снимает блокировку, войти нужно заново
+func (s *Service) Unblock(ctx context.Context, id int64) (string, error) {
+	if err := s.setActive(ctx, id, ActiveYes); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "пользователь не найден", err
+		}
+		return "произошла ошибка при разблокировке пользователя", err
+	}
+
+	return "пользователь разблокирован", nil
+}
+
+// SetRoles заменяет роли пользователя
+func (s *Service) SetRoles(ctx context.Context, id int64, req SetRolesRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+
+	err := s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.repo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		_, err := s.rolesRepo.SetRoles(ctx, id, req.Roles)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "пользователь не найден", err
+		}
+		return "произошла ошибка при изменении ролей", err
+	}
+
+	return "роли пользователя изменены", nil
+}
+
+// HasPermission реализует auth.PermissionChecker
+func (s *Service) HasPermission(ctx context.Context, userID int64, permission string) (bool, error) {
+	return s.rolesRepo.HasPermission(ctx, userID, permission)
+}
+
+// VerifyTokenVersion реализует auth.TokenVerifier: токен действителен, пока пользователь активен
+// и версия в токене совпадает с текущей версией пользователя
+func (s *Service) VerifyTokenVersion(ctx context.Context, userID int64, tokenVersion string) error {
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return auth.ErrTokenRevoked
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if u.Active != ActiveYes || u.CurrentTokenVersion() != tokenVersion {
+		return auth.ErrTokenRevoked
+	}
+
+	return nil
+}
+
+// verifyNewContact проверяет, что новый контакт свободен и подтверждён кодом
+func (s *Service) verifyNewContact(ctx context.Context, id int64, req UpdateUserRequest) (string, error) {
+	if req.Code == "" || s.contacts == nil {
+		return "для смены email или телефона нужен код подтверждения", ErrContactCodeRequired
+	}
+
+	var (
+		email, phone string
+		owner        *UserEnt
+		err          error
+	)
+	if req.Email != nil {
+		email = *req.Email
+		owner, err = s.repo.GetByEmail(ctx, email)
+	} else {
+		phone = *req.Phone
+		owner, err = s.repo.GetByPhone(ctx, phone)
+	}
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return "произошла ошибка при проверке контакта", err
+	}
+	if owner != nil && owner.ID != id {
+		return "email или телефон уже используется", store.ErrConflict
+	}
+
+	if err := s.contacts.VerifyContactCode(ctx, email, phone, req.Code); err != nil {
+		if errors.Is(err, ErrContactCodeInvalid) {
+			return "неверный или истёкший код подтверждения", err
+		}
+		return "произошла ошибка при проверке кода", err
+	}
+
+	return "", nil
+}
+
+// setActive меняет активность пользователя, при выключении отзывает его токены
+func (s *Service) setActive(ctx context.Context, id int64, active string) error {
+	return s.store.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetActive(ctx, id, active); err != nil {
+			return err
+		}
+		if active == ActiveNo {
+			return s.repo.IncrementTokenVersion(ctx, id)
+		}
+		return nil
+	})
 }
 
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+func equalString(current *string, value string) bool {
+	return current != nil && *current == value
 }
 
-func (s *Service) Create(ctx context.Context) (string, error) {
-	return "категории успешно создались", nil
+func toUserResponse(u *UserEnt) *UserResponse {
+	return &UserResponse{
+		ID:         uint(u.ID),
+		Email:      u.Email,
+		Phone:      u.Phone,
+		Name:       u.Name,
+		LastName:   u.LastName,
+		SecondName: u.SecondName,
+		CityID:     u.CityID,
+		UserType:   string(u.UserType),
+		INN:        u.INN,
+		Active:     u.Active,
+		CreatedAt:  u.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  u.UpdatedAt.Format(time.RFC3339),
+	}
 }
@@ -1,12 +1,11 @@
 package helper
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
-	"math/rand"
-	"strconv"
+	"math/big"
 	"strings"
-	"time"
 
 	"os"
 )
@@ -41,18 +40,20 @@ func Unmarshal(data []byte, v any) (string, error) {
 	return "", nil
 }
 
-func GenerateCode(length int) string {
+// GenerateCode генерирует цифровой код криптографически стойким генератором
+func GenerateCode(length int) (string, error) {
 	if length <= 0 {
-		return ""
+		return "", fmt.Errorf("invalid code length: %d", length)
 	}
 
-	rand.Seed(time.Now().UnixNano())
-
-	code := ""
-	for i := 0; i < length; i++ {
-		digit := rand.Intn(10) // число от 0 до 9
-		code += strconv.Itoa(digit)
+	code := make([]byte, length)
+	for i := range code {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10)) // число от 0 до 9
+		if err != nil {
+			return "", fmt.Errorf("failed to generate code: %w", err)
+		}
+		code[i] = byte('0' + digit.Int64())
 	}
 
-	return code
+	return string(code), nil
 }
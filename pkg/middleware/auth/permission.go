@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"go-monolite/pkg/logger"
+)
+
+var Forbidden = "Forbidden"
+
+// PermissionChecker проверяет право пользователя по его ролям
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, userID int64, permission string) (bool, error)
+}
+
+// RequirePermission пропускает запрос, только если у пользователя из контекста есть право permission,
+// а у ключа интеграции — один из apiKeyScopes (без них ключи не допускаются).
+// Должен стоять после Authenticate или UserAuth
+func RequirePermission(checker PermissionChecker, permission string, apiKeyScopes ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := CurrentUser(r.Context())
+			if !ok {
+				http.Error(w, Unauthorized, http.StatusUnauthorized)
+				return
+			}
+
+			if identity.Superuser {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if identity.IsAPIKey() {
+				if !identity.HasScope(apiKeyScopes...) {
+					logger.Debug(Forbidden, "api_key_id", identity.APIKeyID, "scopes", apiKeyScopes)
+					http.Error(w, Forbidden, http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed, err := checker.HasPermission(r.Context(), identity.UserID, permission)
+			if err != nil {
+				logger.ErrorCtx(r.Context(), err, "failed to check permission", "permission", permission)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				logger.Debug(Forbidden, "user_id", identity.UserID, "permission", permission)
+				http.Error(w, Forbidden, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WriteMethods применяет middlewares только к изменяющим запросам, чтение остаётся открытым
+func WriteMethods(middlewares ...func(http.Handler) http.Handler) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		protected := next
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			protected = middlewares[i](protected)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+			default:
+				protected.ServeHTTP(w, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"go-monolite/pkg/logger"
+	"go-monolite/pkg/token"
+)
+
+var (
+	// ErrTokenRevoked — версия токена не совпадает с версией пользователя или пользователь недоступен
+	ErrTokenRevoked = errors.New("token revoked")
+	// ErrAPIKeyInvalid — ключ не найден, отозван, истёк или запрос пришёл не с разрешённого IP
+	ErrAPIKeyInvalid = errors.New("invalid api key")
+)
+
+type contextIdentityKey string
+
+const identityKey contextIdentityKey = "identity"
+
+// Identity — пользователь, от имени которого выполняется запрос
+type Identity struct {
+	UserID   int64
+	DeviceID string
+
+	// Superuser — запрос со статическим токеном администратора, проверка прав не выполняется
+	Superuser bool
+
+	// APIKeyID и Scopes заполняются для запросов с ключом интеграции, UserID при этом пустой
+	APIKeyID int64
+	Scopes   []string
+}
+
+func (i Identity) IsAPIKey() bool {
+	return i.APIKeyID != 0
+}
+
+func (i Identity) HasScope(scopes ...string) bool {
+	for _, have := range i.Scopes {
+		for _, want := range scopes {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TokenVerifier проверяет, что токен пользователя не отозван
+type TokenVerifier interface {
+	VerifyTokenVersion(ctx context.Context, userID int64, tokenVersion string) error
+}
+
+// APIKeyVerifier проверяет ключ интеграции и возвращает его идентификатор и scopes
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key, ip string) (id int64, scopes []string, err error)
+}
+
+// UserAuth проверяет JWT из заголовка Authorization и кладёт пользователя в контекст запроса
+func UserAuth(tokenManager *token.Manager, verifier TokenVerifier) func(next http.Handler) http.Handler {
+	return Authenticate("", tokenManager, verifier, nil)
+}
+
+// Authenticate работает как UserAuth, но дополнительно принимает статический токен
+// суперпользователя и ключи интеграций (Authorization: ApiKey ...).
+// Пустой superuserToken или nil apiKeys отключают соответствующий способ
+func Authenticate(superuserToken string, tokenManager *token.Manager, verifier TokenVerifier, apiKeys APIKeyVerifier) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := authorization(r, "ApiKey"); ok && apiKeys != nil {
+				id, scopes, err := apiKeys.VerifyAPIKey(r.Context(), key, clientIP(r))
+				if err != nil {
+					if errors.Is(err, ErrAPIKeyInvalid) {
+						logger.Debug(Unauthorized, "reason", err.Error())
+						http.Error(w, Unauthorized, http.StatusUnauthorized)
+						return
+					}
+					logger.ErrorCtx(r.Context(), err, "failed to verify api key")
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					return
+				}
+
+				ctx := WithIdentity(r.Context(), Identity{APIKeyID: id, Scopes: scopes})
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			tokenStr, ok := authorization(r, "Bearer")
+			if !ok {
+				logger.Debug(Unauthorized, "reason", "missing bearer token")
+				http.Error(w, Unauthorized, http.StatusUnauthorized)
+				return
+			}
+
+			if superuserToken != "" && subtle.ConstantTimeCompare([]byte(tokenStr), []byte(superuserToken)) == 1 {
+				ctx := WithIdentity(r.Context(), Identity{Superuser: true})
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			claims, err := tokenManager.Parse(tokenStr)
+			if err != nil {
+				logger.Debug(Unauthorized, "reason", err.Error())
+				http.Error(w, Unauthorized, http.StatusUnauthorized)
+				return
+			}
+
+			if err := verifier.VerifyTokenVersion(r.Context(), claims.UserID, claims.TokenVersion); err != nil {
+				if errors.Is(err, ErrTokenRevoked) {
+					logger.Debug(Unauthorized, "reason", err.Error(), "user_id", claims.UserID)
+					http.Error(w, Unauthorized, http.StatusUnauthorized)
+					return
+				}
+				logger.ErrorCtx(r.Context(), err, "failed to verify token version")
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			ctx := WithIdentity(r.Context(), Identity{
+				UserID:   claims.UserID,
+				DeviceID: claims.DeviceID,
+			})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Optional применяет authenticate только к запросам с заголовком Authorization,
+// остальные проходят без пользователя в контексте
+func Optional(authenticate func(http.Handler) http.Handler) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		protected := authenticate(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			protected.ServeHTTP(w, r)
+		})
+	}
+}
+
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey, identity)
+}
+
+// CurrentUser возвращает пользователя, прошедшего UserAuth
+func CurrentUser(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey).(Identity)
+	return identity, ok
+}
+
+func authorization(r *http.Request, scheme string) (string, bool) {
+	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || parts[0] != scheme || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,69 @@
+package locale
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	RU      = "ru"
+	EN      = "en"
+	Default = RU
+)
+
+// Supported — языки, для которых есть тексты уведомлений
+var Supported = []string{RU, EN}
+
+type contextKey struct{}
+
+// Handler определяет язык клиента по заголовку Accept-Language, без заголовка — язык по умолчанию
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithLocale(r.Context(), Parse(r.Header.Get("Accept-Language")))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(contextKey{}).(string); ok {
+		return locale
+	}
+	return Default
+}
+
+// IsSupported — язык из списка Supported
+func IsSupported(locale string) bool {
+	return slices.Contains(Supported, locale)
+}
+
+// Parse выбирает поддерживаемый язык с наибольшим весом из Accept-Language, например "en-US,en;q=0.9,ru;q=0.8"
+func Parse(header string) string {
+	best, bestWeight := Default, 0.0
+	for _, item := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if !IsSupported(lang) {
+			continue
+		}
+
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		if weight > bestWeight {
+			best, bestWeight = lang, weight
+		}
+	}
+	return best
+}
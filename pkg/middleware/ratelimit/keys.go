@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-monolite/pkg/middleware/auth"
+)
+
+// KeyFunc возвращает ключ ограничения, false — запрос не ограничивается
+type KeyFunc func(r *http.Request) (string, bool)
+
+func ByIP(r *http.Request) (string, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr, true
+	}
+	return "ip:" + host, true
+}
+
+// ByUser ограничивает только запросы авторизованного пользователя
+func ByUser(r *http.Request) (string, bool) {
+	identity, ok := auth.CurrentUser(r.Context())
+	if !ok || identity.UserID == 0 {
+		return "", false
+	}
+	return "user:" + strconv.FormatInt(identity.UserID, 10), true
+}
+
+// ByAPIKey ограничивает только запросы с ключом интеграции
+func ByAPIKey(r *http.Request) (string, bool) {
+	identity, ok := auth.CurrentUser(r.Context())
+	if !ok || !identity.IsAPIKey() {
+		return "", false
+	}
+	return "apikey:" + strconv.FormatInt(identity.APIKeyID, 10), true
+}
+
+// ByIdentity — по пользователю или ключу интеграции, для анонимных запросов по IP.
+// Суперпользователь не ограничивается
+func ByIdentity(r *http.Request) (string, bool) {
+	if identity, ok := auth.CurrentUser(r.Context()); ok && identity.Superuser {
+		return "", false
+	}
+	if key, ok := ByUser(r); ok {
+		return key, true
+	}
+	if key, ok := ByAPIKey(r); ok {
+		return key, true
+	}
+	return ByIP(r)
+}
+
+// ByRoute добавляет к ключу путь запроса, чтобы каждый маршрут группы считался отдельно
+func ByRoute(keyFunc KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		key, ok := keyFunc(r)
+		if !ok {
+			return "", false
+		}
+		return key + ":" + r.URL.Path, true
+	}
+}
+
+// ForPaths применяет keyFunc только к запросам, путь которых оканчивается на один из paths
+func ForPaths(keyFunc KeyFunc, paths ...string) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		for _, path := range paths {
+			if strings.HasSuffix(r.URL.Path, path) {
+				return keyFunc(r)
+			}
+		}
+		return "", false
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval — как часто удаляются устаревшие счётчики
+const sweepInterval = time.Minute
+
+type memoryCounter struct {
+	window      time.Duration // окно группы, к которой относится счётчик
+	windowStart time.Time
+	previous    int
+	current     int
+}
+
+// MemoryStore хранит счётчики в памяти процесса, подходит для одного экземпляра приложения.
+// Одно хранилище обслуживает группы с разными окнами, каждый счётчик помнит своё окно
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]*memoryCounter),
+		now:      time.Now,
+	}
+}
+
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	now := s.now()
+	windowStart := now.Truncate(limit.Window)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	c, ok := s.counters[key]
+	switch {
+	case !ok || c.window != limit.Window:
+		c = &memoryCounter{window: limit.Window, windowStart: windowStart}
+		s.counters[key] = c
+	case c.windowStart.Equal(windowStart):
+	case c.windowStart.Add(limit.Window).Equal(windowStart):
+		c.previous, c.current, c.windowStart = c.current, 0, windowStart
+	default:
+		c.previous, c.current, c.windowStart = 0, 0, windowStart
+	}
+
+	c.current++
+
+	return slidingWindow(now, windowStart, limit, c.previous, c.current), nil
+}
+
+// sweep удаляет счётчики, которые уже не влияют на ограничение своей группы
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, c := range s.counters {
+		if now.Sub(c.windowStart) > 2*c.window {
+			delete(s.counters, key)
+		}
+	}
+}
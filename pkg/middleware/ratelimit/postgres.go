@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const cleanupInterval = time.Minute
+
+// PostgresStore хранит счётчики в таблице rate_limits, ограничение общее для всех экземпляров приложения
+type PostgresStore struct {
+	db          *sqlx.DB
+	tableName   string
+	lastCleanup atomic.Int64
+}
+
+func NewPostgresStore(db *sqlx.DB) *PostgresStore {
+	return &PostgresStore{
+		db:        db,
+		tableName: "rate_limits",
+	}
+}
+
+func (s *PostgresStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+	windowStart := now.Truncate(limit.Window)
+
+	query := fmt.Sprintf(`
+		WITH previous AS (
+			SELECT count FROM %[1]s WHERE key = $1 AND window_start = $3
+		)
+		INSERT INTO %[1]s (key, window_start, count, expires_at)
+		VALUES ($1, $2, 1, $4)
+		ON CONFLICT (key, window_start) DO UPDATE SET count = %[1]s.count + 1
+		RETURNING count, COALESCE((SELECT count FROM previous), 0)
+	`, s.tableName)
+
+	var current, previous int
+	err := s.db.QueryRowxContext(ctx, query,
+		key,
+		windowStart,
+		windowStart.Add(-limit.Window),
+		windowStart.Add(2*limit.Window),
+	).Scan(&current, &previous)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to count request: %w", err)
+	}
+
+	s.cleanup(ctx, now)
+
+	return slidingWindow(now, windowStart, limit, previous, current), nil
+}
+
+// cleanup не чаще раза в минуту удаляет окна, которые уже не влияют на ограничение
+func (s *PostgresStore) cleanup(ctx context.Context, now time.Time) {
+	last := s.lastCleanup.Load()
+	if now.Sub(time.Unix(0, last)) < cleanupInterval || !s.lastCleanup.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE expires_at < $1`, s.tableName)
+	if _, err := s.db.ExecContext(ctx, query, now); err != nil {
+		s.lastCleanup.Store(last)
+	}
+}
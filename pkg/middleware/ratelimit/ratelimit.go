@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-monolite/pkg/logger"
+)
+
+var TooManyRequests = "Too Many Requests"
+
+// Limit — не больше Requests запросов за Window
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAt    time.Time
+	RetryAfter time.Duration
+}
+
+// Store считает запросы по ключу скользящим окном: текущее окно плюс доля предыдущего
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// New ограничивает запросы группы name по ключу из keyFunc. Ответ содержит заголовки X-RateLimit-*,
+// при превышении — 429 и Retry-After. Если хранилище недоступно, запрос пропускается
+func New(store Store, name string, limit Limit, keyFunc KeyFunc) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := keyFunc(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := store.Allow(r.Context(), name+":"+key, limit)
+			if err != nil {
+				logger.ErrorCtx(r.Context(), err, "rate limit store error", "group", name)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(res.ResetAt.Unix(), 10))
+
+			if !res.Allowed {
+				retryAfter := int(math.Ceil(res.RetryAfter.Seconds()))
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				logger.DebugCtx(r.Context(), TooManyRequests, "group", name, "key", key)
+				http.Error(w, TooManyRequests, http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// slidingWindow считает результат по счётчикам текущего и предыдущего окна,
+// текущий запрос уже учтён в current
+func slidingWindow(now, windowStart time.Time, limit Limit, previous, current int) Result {
+	window := limit.Window
+	elapsed := now.Sub(windowStart)
+	weight := float64(window-elapsed) / float64(window)
+	estimate := float64(previous)*weight + float64(current)
+
+	res := Result{
+		Allowed:   estimate <= float64(limit.Requests),
+		Limit:     limit.Requests,
+		Remaining: limit.Requests - int(math.Ceil(estimate)),
+		ResetAt:   windowStart.Add(window),
+	}
+	if res.Remaining < 0 {
+		res.Remaining = 0
+	}
+
+	if !res.Allowed {
+		// ждём, пока вклад предыдущего окна уменьшится настолько, чтобы поместился ещё один запрос
+		free := float64(limit.Requests - current)
+		if free <= 0 || previous == 0 {
+			res.RetryAfter = res.ResetAt.Sub(now)
+		} else {
+			needElapsed := time.Duration(float64(window) * (1 - free/float64(previous)))
+			res.RetryAfter = needElapsed - elapsed
+		}
+	}
+
+	return res
+}
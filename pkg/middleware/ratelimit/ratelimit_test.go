@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	windowStart := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	limit := Limit{Requests: 10, Window: time.Minute}
+
+	tests := []struct {
+		name      string
+		elapsed   time.Duration
+		previous  int
+		current   int
+		allowed   bool
+		remaining int
+		retry     time.Duration
+	}{
+		{name: "first request", current: 1, allowed: true, remaining: 9},
+		{name: "limit reached", current: 10, allowed: true, remaining: 0},
+		{name: "over limit without previous", elapsed: 20 * time.Second, current: 11, retry: 40 * time.Second},
+		{name: "half of previous counts", elapsed: 30 * time.Second, previous: 10, current: 5, allowed: true, remaining: 0},
+		{name: "wait for previous to fade", elapsed: 30 * time.Second, previous: 10, current: 6, retry: 6 * time.Second},
+		{name: "current alone over limit", elapsed: 30 * time.Second, previous: 10, current: 12, retry: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := slidingWindow(windowStart.Add(tt.elapsed), windowStart, limit, tt.previous, tt.current)
+
+			assert.Equal(t, tt.allowed, res.Allowed)
+			assert.Equal(t, limit.Requests, res.Limit)
+			assert.Equal(t, tt.remaining, res.Remaining)
+			assert.Equal(t, windowStart.Add(limit.Window), res.ResetAt)
+			assert.Equal(t, tt.retry, res.RetryAfter)
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	newStore := func() (*MemoryStore, func(time.Duration)) {
+		now := start
+		s := NewMemoryStore()
+		s.now = func() time.Time { return now }
+		return s, func(d time.Duration) { now = now.Add(d) }
+	}
+
+	allow := func(t *testing.T, s *MemoryStore, key string, limit Limit) bool {
+		t.Helper()
+		res, err := s.Allow(ctx, key, limit)
+		require.NoError(t, err)
+		return res.Allowed
+	}
+
+	t.Run("Limit Per Key", func(t *testing.T) {
+		s, _ := newStore()
+		limit := Limit{Requests: 2, Window: time.Minute}
+
+		assert.True(t, allow(t, s, "api:1.1.1.1", limit))
+		assert.True(t, allow(t, s, "api:1.1.1.1", limit))
+		assert.False(t, allow(t, s, "api:1.1.1.1", limit))
+		assert.True(t, allow(t, s, "api:2.2.2.2", limit))
+	})
+
+	t.Run("Previous Window Counts", func(t *testing.T) {
+		s, advance := newStore()
+		limit := Limit{Requests: 2, Window: time.Minute}
+
+		assert.True(t, allow(t, s, "api:ip", limit))
+		assert.True(t, allow(t, s, "api:ip", limit))
+
+		// середина следующего окна: половина прошлых запросов ещё учитывается
+		advance(90 * time.Second)
+		assert.True(t, allow(t, s, "api:ip", limit))
+		assert.False(t, allow(t, s, "api:ip", limit))
+
+		// через окно без запросов счётчик начинается заново
+		advance(2 * time.Minute)
+		assert.True(t, allow(t, s, "api:ip", limit))
+		assert.True(t, allow(t, s, "api:ip", limit))
+	})
+
+	t.Run("Groups With Different Windows", func(t *testing.T) {
+		s, advance := newStore()
+		code := Limit{Requests: 2, Window: time.Hour}
+		api := Limit{Requests: 600, Window: time.Minute}
+
+		assert.True(t, allow(t, s, "code:ip", code))
+		assert.True(t, allow(t, s, "code:ip", code))
+		assert.False(t, allow(t, s, "code:ip", code))
+
+		// очистка по запросу группы с коротким окном не сбрасывает часовой счётчик
+		advance(2 * time.Minute)
+		assert.True(t, allow(t, s, "api:ip", api))
+		advance(2 * time.Minute)
+		assert.True(t, allow(t, s, "api:ip", api))
+
+		assert.False(t, allow(t, s, "code:ip", code))
+		assert.Contains(t, s.counters, "code:ip")
+	})
+
+	t.Run("Sweep Expired", func(t *testing.T) {
+		s, advance := newStore()
+		limit := Limit{Requests: 2, Window: time.Minute}
+
+		assert.True(t, allow(t, s, "api:old", limit))
+		advance(3 * time.Minute)
+		assert.True(t, allow(t, s, "api:new", limit))
+
+		assert.NotContains(t, s.counters, "api:old")
+		assert.Contains(t, s.counters, "api:new")
+	})
+}
@@ -0,0 +1,41 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxLength — bcrypt учитывает только первые 72 байта пароля
+const MaxLength = 72
+
+var ErrTooLong = errors.New("password is too long")
+
+// dummyHash сравнивается, когда пользователь не найден, чтобы время ответа не выдавало
+// существование учётной записи
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+func Hash(password string) (string, error) {
+	if len(password) > MaxLength {
+		return "", ErrTooLong
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+// Compare проверяет пароль по хэшу, для пустого хэша выполняет сравнение с фиктивным
+// хэшем и всегда возвращает false
+func Compare(hash, password string) bool {
+	if hash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
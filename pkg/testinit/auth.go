@@ -0,0 +1,32 @@
+package testinit
+
+import (
+	"go-monolite/internal/config"
+	"go-monolite/internal/store"
+	"go-monolite/module/apikey"
+	"go-monolite/module/user"
+	middlewareAuth "go-monolite/pkg/middleware/auth"
+	"go-monolite/pkg/token"
+	"net/http"
+	"time"
+)
+
+// Auth — проверки доступа, собранные так же, как в роутере сервера
+type Auth struct {
+	TokenManager *token.Manager
+	Users        *user.Service
+	Authenticate func(next http.Handler) http.Handler
+	UserAuth     func(next http.Handler) http.Handler
+}
+
+func NewAuth(store *store.Store, cfg *config.Config) Auth {
+	tokenManager := token.NewManager(cfg.Auth.JWTSecret, time.Duration(cfg.Auth.AccessTokenTTL)*time.Second)
+	users := user.NewService(store, user.NewRepository(store), user.NewRolesRepository(store), nil, nil)
+	apiKeys := apikey.NewService(apikey.NewRepository(store))
+	return Auth{
+		TokenManager: tokenManager,
+		Users:        users,
+		Authenticate: middlewareAuth.Authenticate(cfg.HTTPServer.BearerToken, tokenManager, users, apiKeys),
+		UserAuth:     middlewareAuth.UserAuth(tokenManager, users),
+	}
+}
@@ -9,6 +9,15 @@ import (
 var (
 	customFieldPhone        = "phone"
 	customFieldAuthPassword = "auth_password"
+	customFieldINN          = "inn"
+	customFieldKPP          = "kpp"
+)
+
+// весовые коэффициенты контрольных цифр ИНН
+var (
+	innWeights10   = []int{2, 4, 10, 3, 5, 9, 4, 6, 8}
+	innWeights12_1 = []int{7, 2, 4, 10, 3, 5, 9, 4, 6, 8}
+	innWeights12_2 = []int{3, 7, 2, 4, 10, 3, 5, 9, 4, 6, 8}
 )
 
 func initCustomValidPatterns() error {
@@ -20,6 +29,14 @@ func initCustomValidPatterns() error {
 		return err
 	}
 
+	if err := validatorIns.RegisterValidation(customFieldINN, createValidINN); err != nil {
+		return err
+	}
+
+	if err := validatorIns.RegisterValidation(customFieldKPP, createValidKPP); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -40,3 +57,41 @@ func createValidAuthPasswordPattern(fl validator.FieldLevel) bool {
 
 	return hasUppercase && hasLowercase && hasDigit
 }
+
+func createValidINN(fl validator.FieldLevel) bool {
+	return ValidINN(fl.Field().String())
+}
+
+// createValidKPP — 4 цифры кода налогового органа, 2 символа причины постановки на учёт, 3 цифры номера
+func createValidKPP(fl validator.FieldLevel) bool {
+	return createValidPattern(fl, `^\d{4}[\dA-Z]{2}\d{3}$`)
+}
+
+// ValidINN проверяет контрольные цифры ИНН: 10 цифр у организаций, 12 — у ИП и физических лиц
+func ValidINN(inn string) bool {
+	digits := make([]int, 0, len(inn))
+	for _, r := range inn {
+		if r < '0' || r > '9' {
+			return false
+		}
+		digits = append(digits, int(r-'0'))
+	}
+
+	switch len(digits) {
+	case 10:
+		return innChecksum(digits, innWeights10) == digits[9]
+	case 12:
+		return innChecksum(digits, innWeights12_1) == digits[10] &&
+			innChecksum(digits, innWeights12_2) == digits[11]
+	default:
+		return false
+	}
+}
+
+func innChecksum(digits, weights []int) int {
+	sum := 0
+	for i, w := range weights {
+		sum += digits[i] * w
+	}
+	return sum % 11 % 10
+}
@@ -87,8 +87,10 @@ func getErrorsMap(validationErrors validator.ValidationErrors, tagMessages map[s
 
 func defaultErrorMessage(tag, field, param string) string {
 	switch tag {
-	case "required":
+	case "required", "required_if":
 		return fmt.Sprintf("Поле %s обязательно для заполнения", field)
+	case "excluded_unless":
+		return fmt.Sprintf("Поле %s не должно передаваться", field)
 	case "min":
 		return fmt.Sprintf("Поле %s должно быть не меньше %s", field, param)
 	case "max":
@@ -107,6 +109,10 @@ func defaultErrorMessage(tag, field, param string) string {
 		return fmt.Sprintf("Поле %s неправильно передано номер телефона", field)
 	case customFieldAuthPassword:
 		return fmt.Sprintf("Поле %s неправильно пароль", field)
+	case customFieldINN:
+		return fmt.Sprintf("Поле %s должно быть корректным ИНН", field)
+	case customFieldKPP:
+		return fmt.Sprintf("Поле %s должно быть корректным КПП", field)
 	default:
 		return fmt.Sprintf("Поле %s не прошло проверку: %s", field, tag)
 	}